@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"io"
+	"os"
+)
+
+// useColor reports whether diagnostics written to w should be colored. Color
+// is off with --no-color, when NO_COLOR is set, or when w isn't a terminal.
+func useColor(noColor bool, w io.Writer) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseColor(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	t.Run("not for files that aren't terminals", func(t *testing.T) {
+		assert.False(t, useColor(false, file))
+	})
+
+	t.Run("not for writers that aren't files", func(t *testing.T) {
+		assert.False(t, useColor(false, &bytes.Buffer{}))
+	})
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip("no terminal available")
+	}
+	defer tty.Close()
+
+	t.Run("for terminals", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "")
+		assert.True(t, useColor(false, tty))
+	})
+
+	t.Run("not with --no-color", func(t *testing.T) {
+		assert.False(t, useColor(true, tty))
+	})
+
+	t.Run("not when NO_COLOR is set", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		assert.False(t, useColor(false, tty))
+	})
+}
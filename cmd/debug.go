@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/studio-mcp/studio/internal/blueprint"
+	"github.com/studio-mcp/studio/internal/tool"
+)
+
+// trace is the breakdown of how a command template was parsed
+type trace struct {
+	Command string                `json:"command"`
+	Tool    string                `json:"tool,omitempty"`
+	Words   []blueprint.WordTrace `json:"words,omitempty"`
+	Error   string                `json:"error,omitempty"`
+}
+
+// traceTemplate creates the studio opts and commandArgs describe the way the
+// server does, and returns how each shell word of its template was read
+func traceTemplate(opts options, commandArgs []string) trace {
+	s, command, err := loadStudio(opts, commandArgs)
+	result := trace{Command: command}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Tool = tool.Options{NamePrefix: opts.namePrefix}.ToolName(s.Blueprint)
+	result.Words = s.Blueprint.Trace()
+	return result
+}
+
+// writeTrace reports result to w as text, colored when color is set, or as
+// JSON when asJSON is set
+func writeTrace(w io.Writer, result trace, asJSON bool, color bool) error {
+	if asJSON {
+		return writeJSON(w, result)
+	}
+
+	if result.Error != "" {
+		writeInvalid(w, result.Command, []string{result.Error}, color)
+		return nil
+	}
+
+	fmt.Fprintf(w, "command: %s\n", result.Command)
+	fmt.Fprintf(w, "tool: %s\n", result.Tool)
+	for i, word := range result.Words {
+		fmt.Fprintf(w, "%s %s\n", tool.Paint(color, tool.Cyan, fmt.Sprintf("word %d:", i+1)), word.Word)
+		if err := writeTokens(w, word.Tokens, "  ", color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTokens writes a line for each token, with the schema property of
+// fields and the words of groups indented below it
+func writeTokens(w io.Writer, tokens []blueprint.TokenTrace, indent string, color bool) error {
+	for _, token := range tokens {
+		switch token.Type {
+		case blueprint.TraceField:
+			field := token.Field
+			required := "optional"
+			if field.Required {
+				required = "required"
+			}
+			fmt.Fprintf(w, "%s%s %s (%s)\n", indent, tool.Paint(color, tool.Green, "field"), field.Name, required)
+			if field.Property != field.Name {
+				fmt.Fprintf(w, "%s  property: %s\n", indent, field.Property)
+			}
+			if field.Description != "" {
+				fmt.Fprintf(w, "%s  description: %s\n", indent, field.Description)
+			}
+			if field.Schema != nil {
+				schema, err := json.Marshal(field.Schema)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "%s  schema: %s\n", indent, schema)
+			}
+		case blueprint.TraceGroup:
+			fmt.Fprintf(w, "%sgroup %s\n", indent, token.Token)
+			for _, word := range token.Words {
+				fmt.Fprintf(w, "%s  word: %s\n", indent, word.Word)
+				if err := writeTokens(w, word.Tokens, indent+"    ", color); err != nil {
+					return err
+				}
+			}
+		default:
+			fmt.Fprintf(w, "%stext %q\n", indent, token.Token)
+		}
+	}
+	return nil
+}
+
+// debugCmd prints how a command template was parsed without starting the server
+var debugCmd = &cobra.Command{
+	Use:   "studio debug [--json] [studio flags] [--] <command> --example \"{{req # required arg}}\"",
+	Short: "Show how a command template is parsed into tokens and schema properties",
+	Long: `debug parses a command template the same way studio does and prints every
+shell word, the text, field and group tokens it was read as, and the schema
+property each field became. Use it to find out why a field didn't get the type
+or description you expected.
+
+  --json - Print the breakdown as JSON.
+  --no-color - Don't color the breakdown. Color is also off when NO_COLOR is set or stdout isn't a terminal.
+  --command-file <filename> - Read the command template from a file instead of the arguments.
+  -- - End of flag parsing. Everything after this is the command template.
+
+Every other flag of studio, like --open and --close or --tool-file, applies the
+way it does to the server, except --version, --list-tools and --check.
+
+Example:
+  studio debug gh issue list --label="[labels...:csv # labels to filter by]"`,
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, commandArgs, err := parseSubcommandArgs("debug", args)
+		if err != nil {
+			if err.Error() == "help requested" {
+				return cmd.Help()
+			}
+			return err
+		}
+
+		result := traceTemplate(opts, commandArgs)
+
+		out := cmd.OutOrStdout()
+		if err := writeTrace(out, result, opts.jsonOutput, useColor(opts.noColor, out)); err != nil {
+			return err
+		}
+		if result.Error != "" {
+			cmd.SilenceErrors = true
+			return fmt.Errorf("invalid command template")
+		}
+		return nil
+	},
+}
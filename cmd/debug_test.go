@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugCmd(t *testing.T) {
+	run := func(t *testing.T, args ...string) (string, error) {
+		var out bytes.Buffer
+		debugCmd.SetOut(&out)
+		debugCmd.SetErr(&out)
+		debugCmd.SetArgs(args)
+		t.Cleanup(func() {
+			debugCmd.SetOut(nil)
+			debugCmd.SetErr(nil)
+		})
+		err := debugCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("prints every token and the property of each field", func(t *testing.T) {
+		out, err := run(t, "gh", "--repo={{repo # owner/name}}", "{?--limit {{max-count}}?}")
+
+		require.NoError(t, err)
+		assert.Equal(t, `command: gh --repo={{repo}} [--limit {{max-count}}]
+tool: gh
+word 1: gh
+  text "gh"
+word 2: --repo={{repo}}
+  text "--repo="
+  field repo (required)
+    description: owner/name
+    schema: {"type":"string","description":"owner/name"}
+word 3: {?--limit {{max-count}}?}
+  group {?--limit {{max-count}}?}
+    word: --limit
+      text "--limit"
+    word: {{max-count}}
+      field max-count (optional)
+        property: max_count
+        schema: {"type":"string"}
+`, out)
+	})
+
+	t.Run("fails on an invalid template", func(t *testing.T) {
+		out, err := run(t, "ls", "{?--all")
+
+		assert.Error(t, err)
+		assert.Contains(t, out, "invalid: ls {?--all\n  error: failed to create blueprint: cannot create blueprint: unterminated optional group")
+	})
+
+	t.Run("reads the template with the server's flags", func(t *testing.T) {
+		out, err := run(t, "--open", "<<", "--close", ">>", "--name-prefix", "repo1_", "git", "<<ref>>")
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "tool: repo1_git\n")
+		assert.Contains(t, out, "field ref (required)")
+	})
+
+	t.Run("refuses flags that run the command", func(t *testing.T) {
+		_, err := run(t, "--check", "git", "{{ref}}")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--check does not apply to debug")
+	})
+
+	t.Run("prints JSON", func(t *testing.T) {
+		out, err := run(t, "--json", "say", "[voice=siri]")
+
+		require.NoError(t, err)
+		var result trace
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "say", result.Tool)
+		require.Len(t, result.Words, 2)
+		field := result.Words[1].Tokens[0].Field
+		require.NotNil(t, field)
+		assert.Equal(t, "voice", field.Name)
+		assert.False(t, field.Required)
+		assert.Equal(t, "string", field.Schema.Type)
+	})
+}
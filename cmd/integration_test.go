@@ -38,9 +38,8 @@ type InitializeParams struct {
 	ClientInfo      map[string]interface{} `json:"clientInfo"`
 }
 
-// sendMCPRequest spawns the Go binary and sends an MCP request over stdio
-func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, timeout time.Duration) MCPResponse {
-	// Build the project first
+// buildStudio builds the Go binary to bin/studio and returns its path
+func buildStudio(t *testing.T) string {
 	projectRoot, err := filepath.Abs("..")
 	require.NoError(t, err)
 
@@ -56,6 +55,14 @@ func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, time
 	err = buildCmd.Run()
 	require.NoError(t, err, "Failed to build project")
 
+	return binaryPath
+}
+
+// sendMCPRequest spawns the Go binary and sends an MCP request over stdio
+func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, timeout time.Duration) MCPResponse {
+	// Build the project first
+	binaryPath := buildStudio(t)
+
 	// Prepare the command
 	args := append([]string{}, commandArgs...)
 	cmd := exec.Command(binaryPath, args...)
@@ -79,8 +86,8 @@ func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, time
 		cmd.Wait()
 	}()
 
-	// For tools/ methods, we need to initialize first
-	needsInit := strings.HasPrefix(request.Method, "tools/")
+	// Everything but initialize and ping needs an initialized session
+	needsInit := request.Method != "initialize" && request.Method != "ping"
 
 	if needsInit {
 		// Send initialize request first
@@ -145,6 +152,7 @@ func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, time
 	// Collect all responses and find the one matching our request ID
 	var targetResponse MCPResponse
 	found := false
+	var errMsg string
 
 	for {
 		select {
@@ -152,7 +160,7 @@ func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, time
 			if !ok {
 				// Channel closed, we're done
 				if !found {
-					t.Fatalf("Did not receive response for request ID %s", request.ID)
+					t.Fatalf("Did not receive response for request ID %s: %s", request.ID, errMsg)
 				}
 				return targetResponse
 			}
@@ -167,8 +175,10 @@ func sendMCPRequest(t *testing.T, commandArgs []string, request MCPRequest, time
 				found = true
 			}
 
-		case errMsg := <-errorData:
-			t.Fatalf("Process error: %s", errMsg)
+		case errMsg = <-errorData:
+			// stderr closes when the process exits, which can happen before the
+			// last responses are read, so only report it if no response arrives
+			errorData = nil
 		case <-ctx.Done():
 			t.Fatalf("Request timed out after %v", timeout)
 		}
@@ -224,6 +234,73 @@ func TestStudioMCPServerIntegration(t *testing.T) {
 			require.True(t, ok, "serverInfo should be an object")
 			assert.Equal(t, "studio", serverInfo["name"])
 		})
+
+		t.Run("reports the configured server name", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "1",
+				Method:  "initialize",
+				Params: InitializeParams{
+					ProtocolVersion: "2024-11-05",
+					Capabilities:    map[string]interface{}{},
+					ClientInfo: map[string]interface{}{
+						"name":    "test-client",
+						"version": "1.0.0",
+					},
+				},
+			}
+
+			response := sendMCPRequest(t, []string{"--server-name", "speaker", "echo", "hello"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok, "Result should be an object")
+
+			serverInfo, ok := result["serverInfo"].(map[string]interface{})
+			require.True(t, ok, "serverInfo should be an object")
+			assert.Equal(t, "speaker", serverInfo["name"])
+		})
+	})
+
+	t.Run("Capabilities", func(t *testing.T) {
+		initialize := MCPRequest{
+			JSONRPC: "2.0",
+			ID:      "1",
+			Method:  "initialize",
+			Params: InitializeParams{
+				ProtocolVersion: "2024-11-05",
+				Capabilities:    map[string]interface{}{},
+				ClientInfo: map[string]interface{}{
+					"name":    "test-client",
+					"version": "1.0.0",
+				},
+			},
+		}
+
+		capabilities := func(t *testing.T, args []string) map[string]interface{} {
+			response := sendMCPRequest(t, args, initialize, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok, "Result should be an object")
+			capabilities, ok := result["capabilities"].(map[string]interface{})
+			require.True(t, ok, "capabilities should be an object")
+			return capabilities
+		}
+
+		t.Run("advertises only tools by default", func(t *testing.T) {
+			assert.Equal(t, map[string]interface{}{"tools": map[string]interface{}{}}, capabilities(t, []string{"echo", "hello"}))
+		})
+
+		t.Run("advertises resources with --resources", func(t *testing.T) {
+			result := capabilities(t, []string{"--resources", "echo", "hello"})
+			assert.Contains(t, result, "resources")
+			assert.NotContains(t, result, "prompts")
+		})
+
+		t.Run("advertises prompts with --prompts", func(t *testing.T) {
+			result := capabilities(t, []string{"--prompts", "echo", "hello"})
+			assert.Contains(t, result, "prompts")
+			assert.NotContains(t, result, "resources")
+		})
 	})
 
 	t.Run("ToolsFunctionality", func(t *testing.T) {
@@ -694,7 +771,43 @@ func TestStudioMCPServerIntegration(t *testing.T) {
 
 			assert.Equal(t, "2.0", response.JSONRPC)
 			assert.Equal(t, "13", response.ID)
-			assert.NotNil(t, response.Error)
+			require.NotNil(t, response.Error)
+
+			rpcError, ok := response.Error.(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, float64(-32602), rpcError["code"])
+			assert.Contains(t, rpcError["message"], "unknown tool")
+			assert.Equal(t, map[string]interface{}{"method": "tools/call"}, rpcError["data"])
+		})
+
+		t.Run("reports standard JSON-RPC error codes", func(t *testing.T) {
+			tests := []struct {
+				name         string
+				method       string
+				params       interface{}
+				expectedCode float64
+			}{
+				{name: "unknown method", method: "bogus/method", expectedCode: -32601},
+				{name: "params that don't unmarshal", method: "tools/call", params: "not an object", expectedCode: -32602},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					request := MCPRequest{
+						JSONRPC: "2.0",
+						ID:      "22",
+						Method:  tt.method,
+						Params:  tt.params,
+					}
+
+					response := sendMCPRequest(t, []string{"echo", "hello"}, request, timeout)
+					require.NotNil(t, response.Error)
+
+					rpcError, ok := response.Error.(map[string]interface{})
+					require.True(t, ok)
+					assert.Equal(t, tt.expectedCode, rpcError["code"])
+				})
+			}
 		})
 	})
 
@@ -756,6 +869,425 @@ func TestStudioMCPServerIntegration(t *testing.T) {
 			assert.NotContains(t, properties, "args")
 		})
 	})
+
+	t.Run("Resources", func(t *testing.T) {
+		t.Run("lists the last output resource when enabled", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "17",
+				Method:  "resources/list",
+			}
+
+			response := sendMCPRequest(t, []string{"--resources", "echo", "{{text}}"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			resources, ok := result["resources"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, resources, 1)
+
+			resource, ok := resources[0].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, "studio://last-output", resource["uri"])
+			assert.Equal(t, "text/plain", resource["mimeType"])
+		})
+
+		t.Run("lists no resources by default", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "18",
+				Method:  "resources/list",
+			}
+
+			response := sendMCPRequest(t, []string{"echo", "{{text}}"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			resources, ok := result["resources"].([]interface{})
+			require.True(t, ok)
+			assert.Empty(t, resources)
+		})
+	})
+
+	t.Run("Prompts", func(t *testing.T) {
+		t.Run("lists the tool prompt when enabled", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "19",
+				Method:  "prompts/list",
+			}
+
+			response := sendMCPRequest(t, []string{"--prompts", "echo", "{{text # what to say}}"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			prompts, ok := result["prompts"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, prompts, 1)
+
+			prompt, ok := prompts[0].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, "echo", prompt["name"])
+			assert.Len(t, prompt["arguments"], 1)
+		})
+
+		t.Run("gets the tool prompt", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "20",
+				Method:  "prompts/get",
+				Params: map[string]interface{}{
+					"name":      "echo",
+					"arguments": map[string]interface{}{"text": "hello"},
+				},
+			}
+
+			response := sendMCPRequest(t, []string{"--prompts", "echo", "{{text # what to say}}"}, request, timeout)
+			require.Nil(t, response.Error)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			messages, ok := result["messages"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, messages, 1)
+
+			message, ok := messages[0].(map[string]interface{})
+			require.True(t, ok)
+			content, ok := message["content"].(map[string]interface{})
+			require.True(t, ok)
+			assert.Contains(t, content["text"], "- text (string, required): what to say")
+			assert.Contains(t, content["text"], "- text: hello")
+		})
+
+		t.Run("lists no prompts by default", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "21",
+				Method:  "prompts/list",
+			}
+
+			response := sendMCPRequest(t, []string{"echo", "{{text}}"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			prompts, ok := result["prompts"].([]interface{})
+			require.True(t, ok)
+			assert.Empty(t, prompts)
+		})
+	})
+
+	t.Run("FileLists", func(t *testing.T) {
+		t.Run("expands an array field from a file", func(t *testing.T) {
+			listFile := filepath.Join(t.TempDir(), "list.txt")
+			err := os.WriteFile(listFile, []byte("one\ntwo\nthree\n"), 0644)
+			require.NoError(t, err)
+
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "23",
+				Method:  "tools/call",
+				Params: map[string]interface{}{
+					"name": "echo",
+					"arguments": map[string]interface{}{
+						"words": map[string]interface{}{"file": listFile},
+					},
+				},
+			}
+
+			response := sendMCPRequest(t, []string{"--file-lists", "echo", "[words...]"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok, "unexpected response: %v", response.Error)
+
+			content, ok := result["content"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, content, 1)
+
+			textContent, ok := content[0].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, "one two three", textContent["text"])
+		})
+	})
+
+	t.Run("FileFields", func(t *testing.T) {
+		root := t.TempDir()
+		err := os.WriteFile(filepath.Join(root, "note.txt"), []byte("from a file"), 0644)
+		require.NoError(t, err)
+
+		call := func(t *testing.T, path string) (string, bool) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "24",
+				Method:  "tools/call",
+				Params: map[string]interface{}{
+					"name":      "echo",
+					"arguments": map[string]interface{}{"note": path},
+				},
+			}
+
+			response := sendMCPRequest(t, []string{"--file-root", root, "echo", "{{note:@file}}"}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok, "unexpected response: %v", response.Error)
+
+			content, ok := result["content"].([]interface{})
+			require.True(t, ok)
+			textContent, ok := content[0].(map[string]interface{})
+			require.True(t, ok)
+
+			isError, _ := result["isError"].(bool)
+			return textContent["text"].(string), isError
+		}
+
+		t.Run("passes the contents of the file", func(t *testing.T) {
+			text, isError := call(t, "note.txt")
+			assert.False(t, isError)
+			assert.Equal(t, "from a file", text)
+		})
+
+		t.Run("reports a missing file as a tool error", func(t *testing.T) {
+			text, isError := call(t, "missing.txt")
+			assert.True(t, isError)
+			assert.Contains(t, text, "failed to read file")
+		})
+
+		t.Run("refuses files outside of the root", func(t *testing.T) {
+			text, isError := call(t, "../outside.txt")
+			assert.True(t, isError)
+			assert.Contains(t, text, "is outside of")
+		})
+	})
+
+	t.Run("AllArgs", func(t *testing.T) {
+		schemaFile := filepath.Join(t.TempDir(), "schema.json")
+		err := os.WriteFile(schemaFile, []byte(`{"type": "object", "properties": {"query": {"type": "string"}, "limit": {"type": "integer"}}, "required": ["query"]}`), 0644)
+		require.NoError(t, err)
+		args := []string{"--input-schema", schemaFile, "echo", "{{@all:json}}"}
+
+		t.Run("advertises the given schema", func(t *testing.T) {
+			request := MCPRequest{JSONRPC: "2.0", ID: "25", Method: "tools/list"}
+			response := sendMCPRequest(t, args, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok, "unexpected response: %v", response.Error)
+			tools, ok := result["tools"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, tools, 1)
+			inputSchema := tools[0].(map[string]interface{})["inputSchema"].(map[string]interface{})
+			assert.Contains(t, inputSchema["properties"], "query")
+			assert.Contains(t, inputSchema["properties"], "limit")
+			assert.Equal(t, []interface{}{"query"}, inputSchema["required"])
+		})
+
+		t.Run("passes the full arguments as JSON", func(t *testing.T) {
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "26",
+				Method:  "tools/call",
+				Params: map[string]interface{}{
+					"name":      "echo",
+					"arguments": map[string]interface{}{"query": "cats & dogs", "limit": 3},
+				},
+			}
+			response := sendMCPRequest(t, args, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok, "unexpected response: %v", response.Error)
+			content, ok := result["content"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, content, 1)
+			textContent, ok := content[0].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, `{"limit":3,"query":"cats & dogs"}`, textContent["text"])
+		})
+	})
+
+	t.Run("ListTools", func(t *testing.T) {
+		template := []string{"--file-lists", "git", "log", "{{ref # commit to start from}}", "[paths...]"}
+
+		runListTools := func(t *testing.T, flags ...string) []byte {
+			cmd := exec.Command(buildStudio(t), append(flags, template...)...)
+			output, err := cmd.Output()
+			require.NoError(t, err)
+			return output
+		}
+
+		t.Run("prints what tools/list returns", func(t *testing.T) {
+			request := MCPRequest{JSONRPC: "2.0", ID: "25", Method: "tools/list"}
+			response := sendMCPRequest(t, template, request, timeout)
+			expected, err := json.Marshal(response.Result)
+			require.NoError(t, err)
+
+			output := runListTools(t, "--list-tools")
+
+			assert.JSONEq(t, string(expected), string(output))
+			assert.Contains(t, string(output), "\n  \"tools\": [", "output should be indented")
+		})
+
+		t.Run("prints compact JSON", func(t *testing.T) {
+			output := runListTools(t, "--list-tools", "--compact")
+
+			assert.Equal(t, 1, strings.Count(string(output), "\n"))
+			assert.True(t, json.Valid(output))
+		})
+
+		t.Run("includes field examples", func(t *testing.T) {
+			cmd := exec.Command(buildStudio(t), "--list-tools", "git", "checkout", "{{branch # git branch || main || release/1.0}}")
+			output, err := cmd.Output()
+			require.NoError(t, err)
+
+			var result struct {
+				Tools []struct {
+					InputSchema struct {
+						Properties map[string]struct {
+							Description string   `json:"description"`
+							Examples    []string `json:"examples"`
+						} `json:"properties"`
+					} `json:"inputSchema"`
+				} `json:"tools"`
+			}
+			require.NoError(t, json.Unmarshal(output, &result))
+			require.Len(t, result.Tools, 1)
+
+			branch := result.Tools[0].InputSchema.Properties["branch"]
+			assert.Equal(t, "git branch", branch.Description)
+			assert.Equal(t, []string{"main", "release/1.0"}, branch.Examples)
+		})
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		template := []string{"sh", "-c", `echo "$0"; echo warning >&2; exit "$1"`, "{{text}}", "[code=0]"}
+
+		t.Run("prints the result", func(t *testing.T) {
+			cmd := exec.Command(buildStudio(t), append([]string{"run", "--echo-command", "--args", `{"text": "hi"}`}, template...)...)
+			output, err := cmd.Output()
+			require.NoError(t, err)
+
+			assert.Equal(t, "hi\n\nwarning\n_meta: {\"command\":[\"sh\",\"-c\",\"echo \\\"$0\\\"; echo warning >&2; exit \\\"$1\\\"\",\"hi\",\"0\"]}\n", string(output))
+		})
+
+		t.Run("prints what tools/call returns", func(t *testing.T) {
+			request := MCPRequest{JSONRPC: "2.0", ID: "26", Method: "tools/call", Params: map[string]interface{}{
+				"name":      "sh",
+				"arguments": map[string]interface{}{"text": "hi"},
+			}}
+			response := sendMCPRequest(t, template, request, timeout)
+			expected, err := json.Marshal(response.Result)
+			require.NoError(t, err)
+
+			cmd := exec.Command(buildStudio(t), append([]string{"run", "--args", `{"text": "hi"}`, "--json"}, template...)...)
+			output, err := cmd.Output()
+			require.NoError(t, err)
+
+			assert.JSONEq(t, string(expected), string(output))
+		})
+
+		t.Run("exits non-zero when the call fails", func(t *testing.T) {
+			cmd := exec.Command(buildStudio(t), append([]string{"run", "--args", `{"text": "oops", "code": "3"}`}, template...)...)
+			output, err := cmd.Output()
+			require.Error(t, err)
+
+			assert.Equal(t, "oops\n\nwarning\n", string(output))
+			assert.Contains(t, string(err.(*exec.ExitError).Stderr), "the tool call returned an error")
+		})
+	})
+
+	t.Run("ControlCharacters", func(t *testing.T) {
+		// Responses are read one line at a time and must each parse as JSON,
+		// so output with raw newlines or control characters has to be escaped
+		script := `printf 'one\ntwo\tthree\000four\r\033[31mred\033[0m\342\200\250end\n'; printf 'err\000\n' >&2`
+		request := MCPRequest{
+			JSONRPC: "2.0",
+			ID:      "26",
+			Method:  "tools/call",
+			Params: map[string]interface{}{
+				"name":      "sh",
+				"arguments": map[string]interface{}{"script": script},
+			},
+		}
+
+		response := sendMCPRequest(t, []string{"sh", "-c", "{{script}}"}, request, timeout)
+
+		result, ok := response.Result.(map[string]interface{})
+		require.True(t, ok)
+
+		content, ok := result["content"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, content, 1)
+
+		textContent, ok := content[0].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "one\ntwo\tthree\x00four\r\x1b[31mred\x1b[0m\u2028end\n\nerr\x00", textContent["text"])
+	})
+
+	t.Run("CommandFile", func(t *testing.T) {
+		t.Run("executes a template read from a file", func(t *testing.T) {
+			commandFile := filepath.Join(t.TempDir(), "echo.txt")
+			err := os.WriteFile(commandFile, []byte(`echo "Hello, {{name # who to greet}}!"`+"\n"), 0644)
+			require.NoError(t, err)
+
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "16",
+				Method:  "tools/call",
+				Params: map[string]interface{}{
+					"name": "echo",
+					"arguments": map[string]interface{}{
+						"name": "Studio",
+					},
+				},
+			}
+
+			response := sendMCPRequest(t, []string{"--command-file", commandFile}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			content, ok := result["content"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, content, 1)
+
+			textContent, ok := content[0].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, "Hello, Studio!", textContent["text"])
+		})
+	})
+
+	t.Run("ToolFile", func(t *testing.T) {
+		t.Run("describes fields from the tool file", func(t *testing.T) {
+			toolFile := filepath.Join(t.TempDir(), "echo.json")
+			err := os.WriteFile(toolFile, []byte(`{
+				"command": ["echo", "Hello, [name]!"],
+				"fields": {"name": {"description": "who to greet", "required": true}}
+			}`), 0644)
+			require.NoError(t, err)
+
+			request := MCPRequest{
+				JSONRPC: "2.0",
+				ID:      "17",
+				Method:  "tools/list",
+			}
+
+			response := sendMCPRequest(t, []string{"--tool-file", toolFile}, request, timeout)
+
+			result, ok := response.Result.(map[string]interface{})
+			require.True(t, ok)
+
+			tools, ok := result["tools"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, tools, 1)
+
+			schema := tools[0].(map[string]interface{})["inputSchema"].(map[string]interface{})
+			name := schema["properties"].(map[string]interface{})["name"].(map[string]interface{})
+			assert.Equal(t, "who to greet", name["description"])
+			assert.Equal(t, []interface{}{"name"}, schema["required"])
+		})
+	})
 }
 
 // TestArgumentParsingRegression tests the specific issue where flags in command
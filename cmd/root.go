@@ -22,11 +22,22 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/studio-mcp/studio/internal/blueprint"
 	"github.com/studio-mcp/studio/internal/studio"
+	"github.com/studio-mcp/studio/internal/tool"
 
 	"github.com/spf13/cobra"
 )
@@ -38,8 +49,88 @@ var (
 	Date    string
 )
 
+// options holds the studio flags parsed from the command line
+type options struct {
+	debug       bool
+	noColor     bool
+	version     bool
+	listTools   bool
+	compact     bool
+	callArgs    map[string]any
+	jsonOutput  bool
+	logFile     string
+	auditLog    string
+	serverName  string
+	namePrefix  string
+	framing     string
+	commandFile string
+	toolFile    string
+	delimiters  blueprint.Delimiters
+	resources   bool
+	prompts     bool
+	shell       bool
+	mimeType    string
+	outputType  string
+	encode      string
+	quiet       bool
+	mergeOutput bool
+
+	maxConcurrency int
+	rateLimit      tool.Rate
+	limits         tool.Limits
+	echoCommand    bool
+	outputSize     bool
+	reportDuration bool
+	runAsUser      string
+	glossary       string
+	inputSchema    string
+	outputSchema   string
+	messages       string
+	fileRoot       string
+	trimArgs       bool
+	noPathChecks   bool
+	noEnumValues   bool
+	requireDescs   bool
+	keepDashes     bool
+	maxArgLength   int
+	maxArgs        int
+	arrayDelimiter string
+	successCodes   []int
+	errorIfMatch   *regexp.Regexp
+	successIfMatch *regexp.Regexp
+	redact         []*regexp.Regexp
+	fileLists      bool
+	strictArgs     bool
+	errorSummary   bool
+
+	inactivityTimeout time.Duration
+	killGrace         time.Duration
+	detach            time.Duration
+	contentMIMEType   string
+	cacheTTL          time.Duration
+	selector          tool.Selector
+	splitOn           string
+	outputTemplate    tool.OutputTemplate
+	pageSize          int
+	summaryLines      int
+	maxOutputLines    int
+	outputFile        string
+	removeOutputFile  bool
+
+	check     bool
+	checkArgs []string
+
+	env      []string
+	metaEnv  map[string]string
+	metaArgs map[string]string
+
+	clearEnv       bool
+	envPassthrough []string
+	execPrefix     []string
+}
+
 // parseArgs parses arguments manually, stopping flag parsing at first non-flag
-func parseArgs(args []string) (debugFlag bool, versionFlag bool, logFile string, commandArgs []string, err error) {
+func parseArgs(args []string) (opts options, commandArgs []string, err error) {
 	i := 0
 
 	// Parse studio flags until we hit a non-flag or --
@@ -57,48 +148,848 @@ func parseArgs(args []string) (debugFlag bool, versionFlag bool, logFile string,
 			break
 		}
 
-		switch arg {
+		// Flags that take a value accept it as --flag value or --flag=value
+		flag, inlineValue, hasInlineValue := strings.Cut(arg, "=")
+		usedValue := false
+		value := func(valueName string) (string, error) {
+			usedValue = true
+			if hasInlineValue {
+				return inlineValue, nil
+			}
+			i++
+			return flagValue(args, i, flag, valueName)
+		}
+		// anyValue is value for flags whose value may itself start with a dash
+		anyValue := func(valueName string) (string, error) {
+			usedValue = true
+			if hasInlineValue {
+				return inlineValue, nil
+			}
+			i++
+			if i >= len(args) {
+				return "", fmt.Errorf("%s requires a %s argument", flag, valueName)
+			}
+			return args[i], nil
+		}
+
+		switch flag {
 		case "--debug":
-			debugFlag = true
+			opts.debug = true
+		case "--no-color":
+			opts.noColor = true
 		case "--version":
-			versionFlag = true
+			opts.version = true
+		case "--list-tools":
+			opts.listTools = true
+		case "--compact":
+			opts.compact = true
+		case "--json":
+			opts.jsonOutput = true
+		case "--shell":
+			opts.shell = true
+		case "--quiet":
+			opts.quiet = true
+		case "--merge-output":
+			opts.mergeOutput = true
+		case "--check":
+			opts.check = true
+		case "--require-descriptions":
+			opts.requireDescs = true
+		case "--echo-command":
+			opts.echoCommand = true
+		case "--output-size":
+			opts.outputSize = true
+		case "--report-duration":
+			opts.reportDuration = true
+		case "--no-enum-values":
+			opts.noEnumValues = true
+		case "--file-lists":
+			opts.fileLists = true
+		case "--strict-args":
+			opts.strictArgs = true
+		case "--error-summary":
+			opts.errorSummary = true
+		case "--trim-args":
+			opts.trimArgs = true
+		case "--no-path-checks":
+			opts.noPathChecks = true
+		case "--keep-dashes":
+			opts.keepDashes = true
+		case "--resources":
+			opts.resources = true
+		case "--prompts":
+			opts.prompts = true
 		case "--log":
-			// Check if we have a next argument for the filename
-			if i+1 >= len(args) {
-				return false, false, "", nil, fmt.Errorf("--log requires a filename argument")
+			opts.logFile, err = value("filename")
+		case "--audit-log":
+			opts.auditLog, err = value("filename")
+		case "--server-name":
+			opts.serverName, err = value("name")
+			if err == nil && strings.TrimSpace(opts.serverName) == "" {
+				err = fmt.Errorf("--server-name cannot be empty")
 			}
-			i++
-			// Check if the next argument is another flag
-			if strings.HasPrefix(args[i], "-") {
-				return false, false, "", nil, fmt.Errorf("--log requires a filename argument")
+		case "--name-prefix":
+			opts.namePrefix, err = value("prefix")
+			if err == nil && opts.namePrefix == "" {
+				err = fmt.Errorf("--name-prefix cannot be empty")
+			}
+		case "--framing":
+			opts.framing, err = value("framing")
+			if err == nil && !studio.IsFraming(opts.framing) {
+				err = fmt.Errorf("--framing must be one of: %s", strings.Join(studio.Framings, ", "))
+			}
+		case "--args":
+			var call string
+			call, err = value("arguments")
+			if err == nil {
+				opts.callArgs, err = parseCallArgs(call)
+			}
+		case "--mime-type":
+			opts.mimeType, err = value("MIME type")
+		case "--content-mime-type":
+			opts.contentMIMEType, err = value("MIME type")
+			if err == nil && strings.TrimSpace(opts.contentMIMEType) == "" {
+				err = fmt.Errorf("--content-mime-type cannot be empty")
+			}
+		case "--output-type":
+			opts.outputType, err = value("type")
+			if err == nil && !tool.IsOutputType(opts.outputType) {
+				err = fmt.Errorf("--output-type must be one of: %s", strings.Join(tool.OutputTypes, ", "))
+			}
+		case "--encode":
+			opts.encode, err = value("encoding")
+			if err == nil && !tool.IsEncoding(opts.encode) {
+				err = fmt.Errorf("--encode must be one of: %s", strings.Join(tool.Encodings, ", "))
+			}
+		case "--max-concurrency":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.maxConcurrency, err = positiveInt(flag, n)
+			}
+		case "--max-arg-length":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.maxArgLength, err = positiveInt(flag, n)
+			}
+		case "--page-size":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.pageSize, err = positiveInt(flag, n)
+			}
+		case "--max-output-lines":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.maxOutputLines, err = positiveInt(flag, n)
+			}
+		case "--summary-lines":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.summaryLines, err = positiveInt(flag, n)
+			}
+		case "--max-args":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.maxArgs, err = positiveInt(flag, n)
+			}
+		case "--rate-limit":
+			var rate string
+			rate, err = value("rate")
+			if err == nil {
+				opts.rateLimit, err = tool.ParseRate(rate)
+				if err != nil {
+					err = fmt.Errorf("%s: %w", flag, err)
+				}
+			}
+		case "--inactivity-timeout":
+			var d string
+			d, err = value("duration")
+			if err == nil {
+				opts.inactivityTimeout, err = positiveDuration(flag, d)
+			}
+		case "--kill-grace":
+			var d string
+			d, err = value("duration")
+			if err == nil {
+				opts.killGrace, err = positiveDuration(flag, d)
+			}
+		case "--detach":
+			var d string
+			d, err = value("duration")
+			if err == nil {
+				opts.detach, err = positiveDuration(flag, d)
+			}
+		case "--cache-ttl":
+			var d string
+			d, err = value("duration")
+			if err == nil {
+				opts.cacheTTL, err = positiveDuration(flag, d)
+			}
+		case "--max-cpu-seconds":
+			var n string
+			n, err = value("number")
+			if err == nil {
+				opts.limits.CPUSeconds, err = positiveInt(flag, n)
+			}
+		case "--max-memory":
+			var size string
+			size, err = value("size")
+			if err == nil {
+				opts.limits.MemoryBytes, err = byteSize(flag, size)
+			}
+		case "--select":
+			var expr string
+			expr, err = value("path")
+			if err == nil {
+				opts.selector, err = tool.ParseSelector(expr)
+			}
+		case "--array-delim":
+			var d string
+			d, err = value("delimiter")
+			if err == nil {
+				opts.arrayDelimiter, err = delimiter(flag, d)
+			}
+		case "--split-on":
+			var d string
+			d, err = value("delimiter")
+			if err == nil {
+				opts.splitOn, err = delimiter(flag, d)
+			}
+		case "--output-template":
+			var template string
+			template, err = value("template")
+			if err == nil {
+				opts.outputTemplate, err = outputTemplate(flag, template)
+			}
+		case "--output-file":
+			opts.outputFile, err = value("path")
+		case "--remove-output-file":
+			opts.removeOutputFile = true
+		case "--check-args":
+			var checkArgs string
+			checkArgs, err = anyValue("list of arguments")
+			if err == nil {
+				opts.check = true
+				opts.checkArgs, err = blueprint.ParseShellWords(checkArgs)
+			}
+			if err == nil && len(opts.checkArgs) == 0 {
+				err = fmt.Errorf("--check-args cannot be empty")
+			}
+		case "--run-as-user":
+			opts.runAsUser, err = value("user")
+			if err == nil && strings.TrimSpace(opts.runAsUser) == "" {
+				err = fmt.Errorf("--run-as-user cannot be empty")
+			}
+		case "--exec-prefix":
+			var prefix string
+			prefix, err = value("command")
+			if err == nil {
+				opts.execPrefix, err = blueprint.ParseShellWords(prefix)
+			}
+			if err == nil && len(opts.execPrefix) == 0 {
+				err = fmt.Errorf("--exec-prefix cannot be empty")
+			}
+		case "--glossary":
+			opts.glossary, err = value("filename")
+		case "--input-schema":
+			opts.inputSchema, err = value("filename")
+		case "--output-schema":
+			opts.outputSchema, err = value("filename")
+		case "--messages":
+			opts.messages, err = value("filename")
+		case "--file-root":
+			opts.fileRoot, err = value("directory")
+		case "--success-codes":
+			var codes string
+			codes, err = value("list of exit codes")
+			if err == nil {
+				opts.successCodes, err = exitCodes(flag, codes)
+			}
+		case "--error-if-match", "--success-if-match":
+			var pattern string
+			pattern, err = value("pattern")
+			if err == nil {
+				var re *regexp.Regexp
+				re, err = outputPattern(flag, pattern)
+				if flag == "--error-if-match" {
+					opts.errorIfMatch = re
+				} else {
+					opts.successIfMatch = re
+				}
+			}
+		case "--redact":
+			var pattern string
+			pattern, err = value("pattern")
+			if err == nil {
+				var re *regexp.Regexp
+				re, err = outputPattern(flag, pattern)
+				if err == nil && re.MatchString("") {
+					err = fmt.Errorf("%s pattern %q must not match empty text", flag, pattern)
+				}
+				opts.redact = append(opts.redact, re)
+			}
+		case "--set-env":
+			var assignment string
+			assignment, err = value("NAME=template")
+			if err == nil {
+				opts.env = append(opts.env, assignment)
+			}
+		case "--clear-env":
+			opts.clearEnv = true
+		case "--env-passthrough":
+			var names string
+			names, err = value("list of variables")
+			if err == nil {
+				var passthrough []string
+				passthrough, err = envNames(flag, names)
+				opts.envPassthrough = append(opts.envPassthrough, passthrough...)
+				opts.clearEnv = true
+			}
+		case "--meta-env":
+			var mapping string
+			mapping, err = value("key=VAR")
+			if err == nil {
+				opts.metaEnv, err = metaMapping(flag, mapping, opts.metaEnv)
+			}
+		case "--meta-arg":
+			var mapping string
+			mapping, err = value("key=field")
+			if err == nil {
+				opts.metaArgs, err = metaMapping(flag, mapping, opts.metaArgs)
+			}
+		case "--command-file":
+			opts.commandFile, err = value("filename")
+		case "--tool-file":
+			opts.toolFile, err = value("filename")
+		case "--open":
+			var markers string
+			markers, err = value("markers")
+			if err == nil {
+				opts.delimiters.Open, opts.delimiters.OptionalOpen, err = templateMarkers(flag, markers)
+			}
+		case "--close":
+			var markers string
+			markers, err = value("markers")
+			if err == nil {
+				opts.delimiters.Close, opts.delimiters.OptionalClose, err = templateMarkers(flag, markers)
 			}
-			logFile = args[i]
 		case "-h", "--help":
 			// Let cobra handle help
-			return false, false, "", nil, fmt.Errorf("help requested")
+			return options{}, nil, fmt.Errorf("help requested")
 		default:
-			return false, false, "", nil, fmt.Errorf("unknown flag: %s", arg)
+			return options{}, nil, fmt.Errorf("unknown flag: %s", arg)
+		}
+
+		if err == nil && hasInlineValue && !usedValue {
+			err = fmt.Errorf("%s does not take a value", flag)
+		}
+
+		if err != nil {
+			return options{}, nil, err
 		}
 
 		i++
 	}
 
+	if opts.compact && !opts.listTools {
+		return options{}, nil, fmt.Errorf("--compact only applies to --list-tools")
+	}
+	// Detached commands are never stopped, and must start on every call
+	if opts.detach > 0 && opts.inactivityTimeout > 0 {
+		return options{}, nil, fmt.Errorf("--detach cannot be combined with --inactivity-timeout")
+	}
+	if opts.detach > 0 && opts.cacheTTL > 0 {
+		return options{}, nil, fmt.Errorf("--detach cannot be combined with --cache-ttl")
+	}
+	if err := opts.delimiters.Validate(); err != nil {
+		return options{}, nil, fmt.Errorf("--open and --close: %w", err)
+	}
+
+	// Encoded output is always text, so it can't also be split or typed
+	if opts.encode != "" && opts.outputType != "" {
+		return options{}, nil, fmt.Errorf("--encode cannot be combined with --output-type")
+	}
+	if opts.encode != "" && opts.splitOn != "" {
+		return options{}, nil, fmt.Errorf("--encode cannot be combined with --split-on")
+	}
+
+	// The template formats a single block of text
+	if !opts.outputTemplate.IsZero() {
+		switch {
+		case opts.encode != "":
+			return options{}, nil, fmt.Errorf("--output-template cannot be combined with --encode")
+		case opts.splitOn != "":
+			return options{}, nil, fmt.Errorf("--output-template cannot be combined with --split-on")
+		case opts.outputType == tool.OutputImage || opts.outputType == tool.OutputBinary:
+			return options{}, nil, fmt.Errorf("--output-template only applies to text output, not --output-type %s", opts.outputType)
+		}
+	}
+
+	// The summary folds a single block of text
+	if opts.summaryLines > 0 {
+		switch {
+		case opts.encode != "":
+			return options{}, nil, fmt.Errorf("--summary-lines cannot be combined with --encode")
+		case opts.splitOn != "":
+			return options{}, nil, fmt.Errorf("--summary-lines cannot be combined with --split-on")
+		case opts.outputType == tool.OutputImage || opts.outputType == tool.OutputBinary:
+			return options{}, nil, fmt.Errorf("--summary-lines only applies to text output, not --output-type %s", opts.outputType)
+		}
+	}
+
+	// Truncation counts lines of text
+	if opts.maxOutputLines > 0 && (opts.outputType == tool.OutputImage || opts.outputType == tool.OutputBinary) {
+		return options{}, nil, fmt.Errorf("--max-output-lines only applies to text output, not --output-type %s", opts.outputType)
+	}
+
+	// Typed output is returned whole, as the text the command wrote
+	if opts.contentMIMEType != "" {
+		switch {
+		case opts.encode != "":
+			return options{}, nil, fmt.Errorf("--content-mime-type cannot be combined with --encode")
+		case opts.outputType == tool.OutputImage || opts.outputType == tool.OutputBinary:
+			return options{}, nil, fmt.Errorf("--content-mime-type only applies to text output, not --output-type %s", opts.outputType)
+		case !opts.outputTemplate.IsZero():
+			return options{}, nil, fmt.Errorf("--content-mime-type cannot be combined with --output-template")
+		case opts.summaryLines > 0:
+			return options{}, nil, fmt.Errorf("--content-mime-type cannot be combined with --summary-lines")
+		case opts.pageSize > 0:
+			return options{}, nil, fmt.Errorf("--content-mime-type cannot be combined with --page-size")
+		case opts.maxOutputLines > 0:
+			return options{}, nil, fmt.Errorf("--content-mime-type cannot be combined with --max-output-lines")
+		}
+	}
+
+	if opts.removeOutputFile && opts.outputFile == "" {
+		return options{}, nil, fmt.Errorf("--remove-output-file needs --output-file")
+	}
+
 	// Everything from i onwards goes to blueprint parsing
 	commandArgs = args[i:]
 
-	return debugFlag, versionFlag, logFile, commandArgs, nil
+	return opts, commandArgs, nil
+}
+
+// flagValue returns the argument at position i as the value for flag
+func flagValue(args []string, i int, flag string, valueName string) (string, error) {
+	// Check if we have a next argument and that it isn't another flag
+	if i >= len(args) || strings.HasPrefix(args[i], "-") {
+		return "", fmt.Errorf("%s requires a %s argument", flag, valueName)
+	}
+	return args[i], nil
+}
+
+// positiveInt parses the value of flag as a number greater than zero
+func positiveInt(flag string, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive number, got %q", flag, value)
+	}
+	return n, nil
+}
+
+// byteSize parses the value of flag as a number of bytes greater than zero,
+// optionally followed by K, M or G for kibibytes, mebibytes or gibibytes
+func byteSize(flag string, value string) (int64, error) {
+	number, unit := strings.ToUpper(value), int64(1)
+	if len(number) > 0 {
+		switch number[len(number)-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		}
+		if unit > 1 {
+			number = number[:len(number)-1]
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 1 || n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("%s must be a positive size like 512M or 2G, got %q", flag, value)
+	}
+	return n * unit, nil
+}
+
+// positiveDuration parses the value of flag as a duration like 30s, greater than zero
+func positiveDuration(flag string, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration like 30s, got %q", flag, value)
+	}
+	return d, nil
+}
+
+// exitCodes parses the value of flag as a comma separated list of exit codes
+func exitCodes(flag string, value string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(value, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || code < 0 || code > 255 {
+			return nil, fmt.Errorf("%s must be a comma separated list of exit codes, got %q", flag, value)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// envNames parses the value of flag as a comma separated list of environment
+// variable names
+func envNames(flag string, value string) ([]string, error) {
+	var names []string
+	for _, field := range strings.Split(value, ",") {
+		name := strings.TrimSpace(field)
+		if name == "" || strings.Contains(name, "=") {
+			return nil, fmt.Errorf("%s must be a comma separated list of variable names like HOME,PATH, got %q", flag, value)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// templateMarkers parses the value of flag as the marker of required fields,
+// optionally followed by the marker of optional fields
+func templateMarkers(flag string, value string) (required string, optional string, err error) {
+	markers := strings.Fields(value)
+	if len(markers) == 0 || len(markers) > 2 {
+		return "", "", fmt.Errorf("%s must be a marker for required fields, optionally followed by one for optional fields, like '<< (', got %q", flag, value)
+	}
+	if len(markers) == 2 {
+		optional = markers[1]
+	}
+	return markers[0], optional, nil
+}
+
+// delimiter parses the value of flag as a delimiter, understanding escapes
+// like \0, \n and \t
+func delimiter(flag string, value string) (string, error) {
+	escaped := strings.NewReplacer(`"`, `\"`, `\0`, `\x00`).Replace(value)
+	d, err := strconv.Unquote(`"` + escaped + `"`)
+	if err != nil || d == "" {
+		return "", fmt.Errorf("%s must be a delimiter like '\\0' or ---, got %q", flag, value)
+	}
+	return d, nil
+}
+
+// outputPattern compiles the regular expression of flag, which output is matched against
+func outputPattern(flag string, value string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a regular expression: %w", flag, err)
+	}
+	return re, nil
+}
+
+// outputTemplate parses the value of flag as an output template, understanding
+// escapes like \n and \t
+func outputTemplate(flag string, value string) (tool.OutputTemplate, error) {
+	text, err := strconv.Unquote(`"` + strings.ReplaceAll(value, `"`, `\"`) + `"`)
+	if err != nil {
+		return tool.OutputTemplate{}, fmt.Errorf("%s has an invalid escape in %q", flag, value)
+	}
+	template, err := tool.ParseOutputTemplate(text)
+	if err != nil {
+		return tool.OutputTemplate{}, fmt.Errorf("%s: %w", flag, err)
+	}
+	return template, nil
+}
+
+// metaMapping adds the value of flag, a _meta key mapped to a name like
+// requestId=REQUEST_ID, to mappings
+func metaMapping(flag string, value string, mappings map[string]string) (map[string]string, error) {
+	key, name, found := strings.Cut(value, "=")
+	key, name = strings.TrimSpace(key), strings.TrimSpace(name)
+	if !found || key == "" || name == "" {
+		return nil, fmt.Errorf("%s must map a _meta key to a name like requestId=REQUEST_ID, got %q", flag, value)
+	}
+	if mappings == nil {
+		mappings = map[string]string{}
+	}
+	mappings[key] = name
+	return mappings, nil
+}
+
+// listTools prints the result of tools/list for s, indented unless compact is set
+func listTools(cmd *cobra.Command, s *studio.Studio, compact bool) error {
+	result, err := s.ListTools(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	if !compact {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(result)
+}
+
+// readCommandFile reads a command template from a file and splits it into
+// shell words, returning the line each word starts on as well
+func readCommandFile(filename string) ([]string, []int, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read command file: %w", err)
+	}
+
+	words, lines, err := blueprint.ParseShellWordLines(string(content))
+	if err != nil {
+		var wordsErr *blueprint.ShellWordsError
+		if errors.As(err, &wordsErr) {
+			return nil, nil, fmt.Errorf("failed to parse command file %s:%d: %w", filename, wordsErr.Line, err)
+		}
+		return nil, nil, fmt.Errorf("failed to parse command file %s: %w", filename, err)
+	}
+	if len(words) == 0 {
+		return nil, nil, fmt.Errorf("command file %s is empty", filename)
+	}
+	return words, lines, nil
+}
+
+// commandTemplate is the command studio serves, from the arguments, a command
+// file or a tool file
+type commandTemplate struct {
+	args   []string
+	fields map[string]blueprint.FieldDefinition
+	lines  []int // Line of the command file each of args starts on
+}
+
+// loadTemplate reads the command template of opts from its command file or
+// tool file, and takes commandArgs as the template when there is neither
+func loadTemplate(opts options, commandArgs []string) (commandTemplate, error) {
+	switch {
+	case opts.commandFile != "":
+		args, lines, err := readCommandFile(opts.commandFile)
+		if err != nil {
+			return commandTemplate{}, err
+		}
+		return commandTemplate{args: args, lines: lines}, nil
+	case opts.toolFile != "":
+		toolFile, err := blueprint.LoadToolFile(opts.toolFile)
+		if err != nil {
+			return commandTemplate{}, err
+		}
+		return commandTemplate{args: toolFile.Command, fields: toolFile.Fields}, nil
+	}
+	return commandTemplate{args: commandArgs}, nil
+}
+
+// checkCommandArgs checks that opts and commandArgs give exactly one command
+// template, returning usage as the error when they give none
+func checkCommandArgs(opts options, commandArgs []string, usage string) error {
+	if opts.commandFile != "" {
+		if len(commandArgs) > 0 {
+			return fmt.Errorf("--command-file cannot be combined with command arguments")
+		}
+		if opts.toolFile != "" {
+			return fmt.Errorf("--command-file cannot be combined with --tool-file")
+		}
+		return nil
+	}
+
+	if opts.toolFile != "" {
+		if len(commandArgs) > 0 {
+			return fmt.Errorf("--tool-file cannot be combined with command arguments")
+		}
+		return nil
+	}
+
+	if len(commandArgs) == 0 {
+		return errors.New(usage)
+	}
+	return nil
+}
+
+// newStudio creates the studio opts describe for template, coloring debug logs
+// when color is set. An error in a word of a command file names its line.
+func newStudio(opts options, template commandTemplate, color bool) (*studio.Studio, error) {
+	s, err := studio.New(template.args, studio.Options{
+		DebugMode:  opts.debug,
+		LogFile:    opts.logFile,
+		AuditLog:   opts.auditLog,
+		Color:      color,
+		ServerName: opts.serverName,
+		NamePrefix: opts.namePrefix,
+		Framing:    opts.framing,
+		Version:    Version,
+		Commit:     Commit,
+		Resources:  opts.resources,
+		Prompts:    opts.prompts,
+		Shell:      opts.shell,
+		MIMEType:   opts.mimeType,
+		OutputType: opts.outputType,
+		Encode:     opts.encode,
+		Quiet:      opts.quiet,
+
+		MaxConcurrency: opts.maxConcurrency,
+		RateLimit:      opts.rateLimit,
+		EchoCommand:    opts.echoCommand,
+		OutputSize:     opts.outputSize,
+		ReportDuration: opts.reportDuration,
+		RunAsUser:      opts.runAsUser,
+		MergeOutput:    opts.mergeOutput,
+		Fields:         template.fields,
+		Delimiters:     opts.delimiters,
+		Glossary:       opts.glossary,
+		InputSchema:    opts.inputSchema,
+		OutputSchema:   opts.outputSchema,
+		Messages:       opts.messages,
+		FileRoot:       opts.fileRoot,
+		TrimArgs:       opts.trimArgs,
+		NoPathChecks:   opts.noPathChecks,
+		KeepDashes:     opts.keepDashes,
+		MaxArgLength:   opts.maxArgLength,
+		MaxArgs:        opts.maxArgs,
+		ArrayDelimiter: opts.arrayDelimiter,
+		SuccessCodes:   opts.successCodes,
+		ErrorIfMatch:   opts.errorIfMatch,
+		SuccessIfMatch: opts.successIfMatch,
+		SkipEnumValues: opts.noEnumValues,
+		FileLists:      opts.fileLists,
+		StrictArgs:     opts.strictArgs,
+		ErrorSummary:   opts.errorSummary,
+
+		InactivityTimeout: opts.inactivityTimeout,
+		KillGrace:         opts.killGrace,
+		Detach:            opts.detach,
+		Limits:            opts.limits,
+		ContentMIMEType:   opts.contentMIMEType,
+		CacheTTL:          opts.cacheTTL,
+		Select:            opts.selector,
+		SplitOn:           opts.splitOn,
+		OutputTemplate:    opts.outputTemplate,
+		PageSize:          opts.pageSize,
+		SummaryLines:      opts.summaryLines,
+		MaxOutputLines:    opts.maxOutputLines,
+		Redact:            opts.redact,
+		OutputFile:        opts.outputFile,
+		RemoveOutputFile:  opts.removeOutputFile,
+
+		CheckArgs: opts.checkArgs,
+
+		Env:      opts.env,
+		MetaEnv:  opts.metaEnv,
+		MetaArgs: opts.metaArgs,
+
+		ClearEnv:       opts.clearEnv,
+		EnvPassthrough: opts.envPassthrough,
+		ExecPrefix:     opts.execPrefix,
+
+		RequireDescriptions: opts.requireDescs,
+	})
+	if err != nil && template.lines != nil {
+		var wordErr *blueprint.WordError
+		if errors.As(err, &wordErr) {
+			return nil, fmt.Errorf("%s:%d: %w", opts.commandFile, template.lines[wordErr.Index], err)
+		}
+	}
+	return s, err
 }
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "studio [--debug] [--log filename] [--] <command> --example \"{{req # required arg}}\" \"[args... # array of args]\"",
+	Use:   "studio [--list-tools [--compact]] [--check] [--check-args args] [--debug] [--no-color] [--log filename] [--audit-log filename] [--server-name name] [--name-prefix prefix] [--framing framing] [--command-file filename] [--tool-file filename] [--open markers --close markers] [--resources] [--prompts] [--shell] [--mime-type type] [--content-mime-type type] [--output-type type] [--encode encoding] [--quiet] [--merge-output] [--run-as-user user] [--exec-prefix command] [--max-concurrency n] [--max-cpu-seconds n] [--max-memory size] [--rate-limit rate] [--max-arg-length bytes] [--max-args n] [--array-delim delimiter] [--inactivity-timeout duration] [--kill-grace duration] [--detach duration] [--cache-ttl duration] [--select path] [--split-on delimiter] [--output-template template] [--page-size bytes] [--summary-lines n] [--max-output-lines n] [--output-file path [--remove-output-file]] [--echo-command] [--output-size] [--report-duration] [--glossary filename] [--input-schema filename] [--output-schema filename] [--messages filename] [--no-enum-values] [--require-descriptions] [--success-codes codes] [--error-if-match regex] [--success-if-match regex] [--error-summary] [--redact regex] [--file-lists] [--strict-args] [--file-root dir] [--trim-args] [--no-path-checks] [--keep-dashes] [--set-env NAME=template] [--clear-env] [--env-passthrough names] [--meta-env key=VAR] [--meta-arg key=field] [--] <command> --example \"{{req # required arg}}\" \"[args... # array of args]\"",
 	Short: "A tool for running a single command MCP server",
 	Long: `studio is a tool for running a single command MCP server.
 
   -h, --help - Show this help message and exit.
   --version - Show version information and exit.
+  --list-tools - Print the JSON tools/list would return and exit. Add --compact for a single line.
+  --check - Run the command with --version at startup and exit if it fails, before serving any calls.
+  --check-args <args> - Arguments the check runs the command with instead of --version, like --help. Implies --check.
+                        Quoted the way a shell would, like 'help "go doc"'.
   --debug - Print debug logs to stderr to diagnose MCP server issues.
+  --no-color - Don't color debug logs. Color is also off when NO_COLOR is set or stderr isn't a terminal.
   --log <filename> - Write debug logs to the specified file instead of stderr.
+  --audit-log <filename> - Append a JSON line to this file for every command run, with its argv, exit code and duration.
+  --server-name <name> - Name reported to the client in serverInfo. Defaults to studio.
+  --name-prefix <prefix> - Put prefix in front of the tool name, like repo1_ for repo1_git.
+  --framing <framing> - How messages are delimited on stdin and stdout: newline (the default) or
+                        content-length, for clients that frame messages with Content-Length headers like LSP.
+  --command-file <filename> - Read the command template from a file instead of the arguments.
+  --tool-file <filename> - Read the command and a description, type and required flag per field from a JSON file.
+  --open <markers> --close <markers> - Mark fields with these instead of {{ }}, and optionally [ ], like --open '<< ('
+                                       --close '>> )'. Braces and brackets in the template are then passed as is.
+  --resources - Expose the last command output as the MCP resource studio://last-output.
+  --prompts - Expose an MCP prompt that explains how to call the tool and its fields.
+  --shell - Run the command with sh -c so it can use pipes and redirection.
+            Template values are single quoted so they are always passed as literal words.
+  --mime-type <type> - MIME type for binary (non UTF-8) output. Sniffed from the output by default.
+  --content-mime-type <type> - Return text output as a text resource of this MIME type, like application/json.
+  --output-type <type> - How to return output: auto (default), text, image or binary.
+                         auto returns text, images as image content, and other binary as a blob.
+  --encode <encoding> - Return stdout as base64 or hex text, whatever it holds. Encoding happens after --select.
+  --quiet - Leave stderr out of results when the command succeeds. Failures always include stderr.
+  --merge-output - Capture stdout and stderr as one stream, in the order the command wrote them.
+  --run-as-user <user> - Run commands as this user, given as a name, uid or uid:gid. Needs studio to run as root.
+  --exec-prefix <command> - Run every command through this wrapper, like "nice -n 10", split into words like a shell would.
+  --max-concurrency <n> - Run at most n commands at once. Extra calls wait for a free slot.
+  --max-cpu-seconds <n> - Stop a command once it has used n seconds of CPU time. Linux only.
+  --max-memory <size> - Limit the memory a command can allocate, like 512M or 2G. Linux only.
+  --max-arg-length <bytes> - Reject values longer than this many bytes. Fields can set their own with name:maxlen=N.
+  --max-args <n> - Reject array fields with more than n values, like [args...].
+  --array-delim <delimiter> - Let array fields also take one string of values split on delimiter, like ','.
+  --rate-limit <rate> - Reject calls beyond a rate like 10/min without running the command.
+  --inactivity-timeout <duration> - Stop a command that writes no output for this long, like 30s.
+  --kill-grace <duration> - When stopping a command, send SIGTERM and wait this long, like 5s, before killing it.
+                            Commands are killed right away by default.
+  --detach <duration> - Leave the command running in the background, like a server, and return its pid and
+                        what it wrote in this long, like 2s. Studio never stops it.
+  --cache-ttl <duration> - Return the output of an identical successful call made within this long, like 5m,
+                           without running the command again. Kept in memory by this process only.
+  --select <path> - Return only the values at a jq-like path in JSON output, like .items[].name.
+                    The full output is returned with a warning when it isn't JSON or nothing matches.
+  --split-on <delimiter> - Return text output as a content block per chunk, like '\0' for find -print0.
+                           Escapes like \0, \n and \t are understood.
+  --output-template <template> - Format text output, like '$ {{command}}\n{{output}}'. {{output}}, {{command}} and
+                                {{exitCode}} are filled in once, so output is never read as a template.
+  --page-size <bytes> - Return at most this many bytes of text output. The rest is kept to read a page at a time
+                        from the resource studio://output/{id}/{offset}.
+  --summary-lines <n> - Return only the first and last n lines of longer text output, with a count of the lines left out.
+  --max-output-lines <n> - Return only the first n lines of longer text output, followed by [... truncated].
+  --output-file <path> - Return the file the command writes its result to after its output, like '{{out}}'.
+                         {{name}} is the value of a string field. Text is returned as text, anything else as base64.
+                         A path named by the client needs --file-root, and must be inside it.
+  --remove-output-file - Delete the output file after every call, even a failed one, unless it was there before the call.
+  --echo-command - Include the exact command that ran in each result's _meta.command.
+  --output-size - Include how many bytes the command wrote in _meta.stdoutBytes and _meta.stderrBytes, and
+                  whether --page-size or --max-output-lines cut the content in _meta.truncated.
+  --report-duration - Include how long the command ran in _meta.duration, like 1.23s.
+  --glossary <filename> - JSON file mapping field names to descriptions for fields without one.
+  --input-schema <filename> - JSON schema file of the tool's arguments, used instead of the one inferred from fields.
+  --output-schema <filename> - JSON schema file of the command's JSON output, returned as structured content and checked on every call.
+                              Every field must be a top-level property. Needed by {{@all:json}}, which passes
+                              every argument as one JSON object.
+  --no-enum-values - Leave the allowed values of enum properties out of their descriptions.
+  --require-descriptions - Refuse to start when a required field has no description, listing every one that's missing.
+  --success-codes <codes> - Comma separated exit codes that count as success, like 0,1 for grep.
+                            Defaults to 0. Results include the exit code in _meta.exitCode.
+  --error-if-match <regex> - Flag the result as an error when stdout or stderr matches, even on a zero exit.
+  --success-if-match <regex> - Count the result as a success when stdout or stderr matches, even on a failing exit.
+                               --error-if-match wins when both match.
+  --error-summary - Lead the result of a failed command with the first line of its stderr, before the full output.
+  --messages <filename> - JSON file that rewords studio's own errors in results, like the inactivity timeout, by key.
+  --redact <regex> - Replace every match in stdout and stderr with *** before returning output, like 'ghp_\w+'.
+                     Repeatable.
+  --file-root <dir> - Only let name:@file fields read files inside this directory, and keep --output-file in it.
+                      Relative paths of file fields are read from it.
+  --trim-args - Trim leading and trailing whitespace from every value, as if each field were name:trim.
+  --no-path-checks - Don't check that name:path, name:dir and name:path? values exist (or don't) before running.
+  --keep-dashes - Keep dashes in argument names, like dry-run, instead of converting them to underscores.
+  --file-lists - Let array fields take {"file": path} to read their values from a file, one per line.
+  --strict-args - Reject calls with arguments that aren't fields, naming them, instead of ignoring them.
+  --set-env <NAME=template> - Set an environment variable of the command from fields, like TOKEN={{token}}.
+                             Values stay out of the command line. Repeatable.
+  --clear-env - Start commands from an empty environment instead of studio's. --set-env and --meta-env still apply.
+  --env-passthrough <names> - Comma separated variables, like HOME,PATH, to keep from studio's environment.
+                              Everything else is left out, as with --clear-env. Repeatable.
+  --meta-env <key=VAR> - Pass the _meta key of a call to the command as the environment variable VAR. Repeatable.
+  --meta-arg <key=field> - Use the _meta key of a call for an optional field the call left out. Repeatable.
   -- - End of flag parsing. Everything after this is treated as command arguments.
 
 the command starts at the first non-flag argument:
@@ -112,14 +1003,24 @@ arguments can be templated as their own shellword or as part of a shellword:
   "{{req # required arg}}" - tell the LLM about a required arg named 'req'.
   "[args... # array of args]" - tell the LLM about an optional array of args named 'args'.
   "[opt # optional string]" - a optional string arg named 'opt' (not in example).
+  "{?--limit {{limit}}?}" - an optional group, left out unless 'limit' is given.
+  "{{body:@file}}" - the LLM gives a file path and the contents of the file are passed instead.
+  "{{branch:trim}}" - leading and trailing whitespace is trimmed from the value.
+  "{{text:maxlen=1000}}" - a value of at most 1000 bytes.
+  "{{input:path}}" - a path that must exist. Use :dir for a directory and :path? for a path that must not exist.
   "https://en.wikipedia.org/wiki/{{wiki_page_name}}" - an example partially templated words.
 
+Check a template without starting the server with studio validate <command> ...
+See how a template is parsed into fields with studio debug <command> ...
+Call the tool once without a client with studio run --args '{"name": "value"}' <command> ...
+To wrap a command that is itself named validate, debug or run, use studio -- validate ...
+
 Example:
   studio say -v siri "{{speech # a concise phrase to say outloud to the user}}"`,
 	DisableFlagParsing: true, // Disable cobra's flag parsing so we can do custom parsing
 	Args: func(cmd *cobra.Command, args []string) error {
 		// Custom argument parsing
-		_, versionFlag, _, commandArgs, err := parseArgs(args)
+		opts, commandArgs, err := parseArgs(args)
 		if err != nil {
 			if err.Error() == "help requested" {
 				return nil // Let cobra handle help
@@ -128,18 +1029,21 @@ Example:
 		}
 
 		// If version flag is set, don't validate command args
-		if versionFlag {
+		if opts.version {
 			return nil
 		}
 
-		if len(commandArgs) == 0 {
-			return fmt.Errorf("usage: studio <command> --example \"{{req # required arg}}\" \"[args... # array of args]\"")
+		if opts.jsonOutput {
+			return fmt.Errorf("--json only applies to studio validate, debug and run")
 		}
-		return nil
+		if opts.callArgs != nil {
+			return fmt.Errorf("--args only applies to studio run")
+		}
+		return checkCommandArgs(opts, commandArgs, "usage: studio <command> --example \"{{req # required arg}}\" \"[args... # array of args]\"")
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Parse arguments manually
-		debugFlag, versionFlag, logFile, commandArgs, err := parseArgs(args)
+		opts, commandArgs, err := parseArgs(args)
 		if err != nil {
 			if err.Error() == "help requested" {
 				return cmd.Help()
@@ -150,16 +1054,17 @@ Example:
 		// Debug logging - log the raw arguments received
 		// Write to log file if specified, otherwise stderr
 		var logWriter *os.File
-		if logFile != "" {
-			logWriter, _ = os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if opts.logFile != "" {
+			logWriter, _ = os.OpenFile(opts.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		}
 
+		color := useColor(opts.noColor, os.Stderr)
 		writeDebug := func(format string, args ...interface{}) {
-			msg := fmt.Sprintf("[Studio MCP Root] "+format+"\n", args...)
+			msg := fmt.Sprintf(format+"\n", args...)
 			if logWriter != nil {
-				logWriter.WriteString(msg)
+				logWriter.WriteString("[Studio MCP Root] " + msg)
 			} else {
-				fmt.Fprint(os.Stderr, msg)
+				fmt.Fprint(os.Stderr, tool.Paint(color, tool.Cyan, "[Studio MCP Root]")+" "+msg)
 			}
 		}
 
@@ -167,8 +1072,20 @@ Example:
 		for i, arg := range args {
 			writeDebug("  raw[%d]: %q", i, arg)
 		}
-		writeDebug("Parsed command args: %d arguments", len(commandArgs))
-		for i, arg := range commandArgs {
+		// Read the command template from a command file or tool file if one was given
+		var template commandTemplate
+		if !opts.version {
+			template, err = loadTemplate(opts, commandArgs)
+			if err != nil {
+				if logWriter != nil {
+					logWriter.Close()
+				}
+				return err
+			}
+		}
+
+		writeDebug("Parsed command args: %d arguments", len(template.args))
+		for i, arg := range template.args {
 			writeDebug("  cmd[%d]: %q", i, arg)
 		}
 
@@ -177,21 +1094,44 @@ Example:
 		}
 
 		// Handle version flag
-		if versionFlag {
+		if opts.version {
 			cmd.Printf("studio %s\n", Version)
 			cmd.Printf("commit: %s\n", Commit)
 			cmd.Printf("built: %s\n", Date)
 			return nil
 		}
 
-		// Create a new Studio instance with the command args
-		s, err := studio.New(commandArgs, debugFlag, logFile, Version)
+		if !opts.limits.IsZero() && !tool.LimitsSupported {
+			cmd.PrintErrln("Warning: --max-cpu-seconds and --max-memory only work on Linux, running commands without limits")
+		}
+
+		// Create a new Studio instance with the command template
+		s, err := newStudio(opts, template, color)
 		if err != nil {
 			return err
 		}
 
+		for _, name := range s.Blueprint.UnusedFields(template.fields) {
+			cmd.PrintErrf("Warning: %s describes %s, which is not a field of the command\n", opts.toolFile, name)
+		}
+
+		if opts.check {
+			if err := s.Check(); err != nil {
+				return err
+			}
+		}
+
+		if opts.listTools {
+			return listTools(cmd, s, opts.compact)
+		}
+
+		// Shut down cleanly on SIGINT or SIGTERM. A second signal exits immediately.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		defer context.AfterFunc(ctx, stop)()
+
 		// Start the MCP server
-		return s.Serve()
+		return s.ServeWithContext(ctx)
 	},
 }
 
@@ -202,7 +1142,10 @@ func Execute(version, commit, date string) {
 	Commit = commit
 	Date = date
 
-	err := rootCmd.Execute()
+	cmd, args := commandFor(os.Args[1:])
+	cmd.SetArgs(args)
+
+	err := cmd.Execute()
 	if err != nil {
 		os.Exit(1)
 	}
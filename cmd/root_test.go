@@ -1,20 +1,95 @@
 package cmd
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+	"github.com/studio-mcp/studio/internal/tool"
 )
 
 func TestParseArgs(t *testing.T) {
 	tests := []struct {
-		name            string
-		args            []string
-		expectedDebug   bool
-		expectedVersion bool
-		expectedLogFile string
-		expectedCommand []string
-		expectedError   string
+		name                string
+		args                []string
+		expectedDebug       bool
+		expectedNoColor     bool
+		expectedVersion     bool
+		expectedListTools   bool
+		expectedCompact     bool
+		expectedCallArgs    map[string]any
+		expectedJSONOutput  bool
+		expectedLogFile     string
+		expectedAuditLog    string
+		expectedServerName  string
+		expectedNamePrefix  string
+		expectedFraming     string
+		expectedCommandFile string
+		expectedToolFile    string
+		expectedDelimiters  blueprint.Delimiters
+		expectedResources   bool
+		expectedPrompts     bool
+		expectedShell       bool
+		expectedMIMEType    string
+		expectedContentType string
+		expectedOutputType  string
+		expectedEncode      string
+		expectedQuiet       bool
+		expectedMergeOutput bool
+		expectedConcurrency int
+		expectedRateLimit   tool.Rate
+		expectedEchoCommand bool
+		expectedOutputSize  bool
+		expectedDuration    bool
+		expectedRunAsUser   string
+		expectedGlossary    string
+		expectedInputSchema string
+		expectedOutSchema   string
+		expectedMessages    string
+		expectedFileRoot    string
+		expectedTrimArgs    bool
+		expectedNoPaths     bool
+		expectedNoEnums     bool
+		expectedDescs       bool
+		expectedKeepDashes  bool
+		expectedMaxArgLen   int
+		expectedPageSize    int
+		expectedSummary     int
+		expectedMaxLines    int
+		expectedMaxArgs     int
+		expectedArrayDelim  string
+		expectedCodes       []int
+		expectedErrorMatch  string
+		expectedSuccessRe   string
+		expectedRedact      []string
+		expectedFileLists   bool
+		expectedStrictArgs  bool
+		expectedErrSummary  bool
+		expectedInactivity  time.Duration
+		expectedLimits      tool.Limits
+		expectedKillGrace   time.Duration
+		expectedDetach      time.Duration
+		expectedCacheTTL    time.Duration
+		expectedSelect      string
+		expectedSplitOn     string
+		expectedTemplate    string
+		expectedOutputFile  string
+		expectedRemoveFile  bool
+		expectedCheck       bool
+		expectedCheckArgs   []string
+		expectedEnv         []string
+		expectedMetaEnv     map[string]string
+		expectedMetaArgs    map[string]string
+		expectedClearEnv    bool
+		expectedPassthrough []string
+		expectedExecPrefix  []string
+		expectedCommand     []string
+		expectedError       string
 	}{
 		{
 			name:            "no flags, simple command",
@@ -32,6 +107,137 @@ func TestParseArgs(t *testing.T) {
 			expectedLogFile: "",
 			expectedCommand: []string{"echo", "hello"},
 		},
+		{
+			name:              "list tools flag",
+			args:              []string{"--list-tools", "echo", "{{text}}"},
+			expectedListTools: true,
+			expectedCommand:   []string{"echo", "{{text}}"},
+		},
+		{
+			name:              "list tools compact",
+			args:              []string{"--compact", "--list-tools", "echo"},
+			expectedListTools: true,
+			expectedCompact:   true,
+			expectedCommand:   []string{"echo"},
+		},
+		{
+			name:          "compact without list tools",
+			args:          []string{"--compact", "echo"},
+			expectedError: "--compact only applies to --list-tools",
+		},
+		{
+			name:             "args flag",
+			args:             []string{"--args", `{"text": "hi"}`, "echo", "{{text}}"},
+			expectedCallArgs: map[string]any{"text": "hi"},
+			expectedCommand:  []string{"echo", "{{text}}"},
+		},
+		{
+			name:               "args printed as json",
+			args:               []string{"--json", "--args={}", "echo"},
+			expectedCallArgs:   map[string]any{},
+			expectedJSONOutput: true,
+			expectedCommand:    []string{"echo"},
+		},
+		{
+			name:          "args that aren't an object",
+			args:          []string{"--args", `["hi"]`, "echo", "{{text}}"},
+			expectedError: `--args must be a JSON object of arguments, like '{"text": "hi"}'`,
+		},
+		{
+			name:          "null args",
+			args:          []string{"--args", "null", "echo"},
+			expectedError: `--args must be a JSON object of arguments`,
+		},
+		{
+			name:            "check flag",
+			args:            []string{"--check", "git", "status"},
+			expectedCheck:   true,
+			expectedCommand: []string{"git", "status"},
+		},
+		{
+			name:              "check args",
+			args:              []string{"--check-args", "help status", "git", "status"},
+			expectedCheck:     true,
+			expectedCheckArgs: []string{"help", "status"},
+			expectedCommand:   []string{"git", "status"},
+		},
+		{
+			name:              "check args starting with a dash",
+			args:              []string{"--check-args", "--help", "git", "status"},
+			expectedCheck:     true,
+			expectedCheckArgs: []string{"--help"},
+			expectedCommand:   []string{"git", "status"},
+		},
+		{
+			name:              "quoted check args",
+			args:              []string{"--check-args", `help "go doc"`, "go", "doc"},
+			expectedCheck:     true,
+			expectedCheckArgs: []string{"help", "go doc"},
+			expectedCommand:   []string{"go", "doc"},
+		},
+		{
+			name:          "missing check args",
+			args:          []string{"--check-args"},
+			expectedError: "--check-args requires a list of arguments argument",
+		},
+		{
+			name:          "empty check args",
+			args:          []string{"--check-args=", "git"},
+			expectedError: "--check-args cannot be empty",
+		},
+		{
+			name:            "set env flags",
+			args:            []string{"--set-env", "GH_TOKEN={{token}}", "--set-env=GH_HOST=[host]", "gh", "repo", "view"},
+			expectedEnv:     []string{"GH_TOKEN={{token}}", "GH_HOST=[host]"},
+			expectedCommand: []string{"gh", "repo", "view"},
+		},
+		{
+			name:            "meta env flags",
+			args:            []string{"--meta-env", "requestId=REQUEST_ID", "--meta-env=traceparent=TRACEPARENT", "make"},
+			expectedMetaEnv: map[string]string{"requestId": "REQUEST_ID", "traceparent": "TRACEPARENT"},
+			expectedCommand: []string{"make"},
+		},
+		{
+			name:             "clear env flag",
+			args:             []string{"--clear-env", "make"},
+			expectedClearEnv: true,
+			expectedCommand:  []string{"make"},
+		},
+		{
+			name:                "env passthrough flags",
+			args:                []string{"--env-passthrough", "HOME, PATH", "--env-passthrough=LANG", "make"},
+			expectedClearEnv:    true,
+			expectedPassthrough: []string{"HOME", "PATH", "LANG"},
+			expectedCommand:     []string{"make"},
+		},
+		{
+			name:          "env passthrough with an empty name",
+			args:          []string{"--env-passthrough", "HOME,,PATH", "make"},
+			expectedError: `--env-passthrough must be a comma separated list of variable names like HOME,PATH, got "HOME,,PATH"`,
+		},
+		{
+			name:             "meta arg flag",
+			args:             []string{"--meta-arg", "requestId=trace_id", "curl", "[trace_id]"},
+			expectedMetaArgs: map[string]string{"requestId": "trace_id"},
+			expectedCommand:  []string{"curl", "[trace_id]"},
+		},
+		{
+			name:          "meta env without a variable",
+			args:          []string{"--meta-env", "requestId", "make"},
+			expectedError: "--meta-env must map a _meta key to a name like requestId=REQUEST_ID",
+		},
+		{
+			name:          "meta arg with an empty key",
+			args:          []string{"--meta-arg==trace_id", "make"},
+			expectedError: "--meta-arg must map a _meta key to a name",
+		},
+		{
+			name:            "no color flag",
+			args:            []string{"--no-color", "--debug", "echo", "hello"},
+			expectedDebug:   true,
+			expectedNoColor: true,
+			expectedCommand: []string{"echo", "hello"},
+		},
 		{
 			name:            "version flag only",
 			args:            []string{"--version"},
@@ -138,6 +344,702 @@ func TestParseArgs(t *testing.T) {
 			expectedLogFile: "",
 			expectedCommand: []string{"curl", "-X", "POST", "-H", "Content-Type: application/json", "{{url}}"},
 		},
+		{
+			name:                "command file flag",
+			args:                []string{"--command-file", "tool.txt"},
+			expectedCommandFile: "tool.txt",
+			expectedCommand:     []string{},
+		},
+		{
+			name:             "tool file flag",
+			args:             []string{"--tool-file", "tool.json"},
+			expectedToolFile: "tool.json",
+			expectedCommand:  []string{},
+		},
+		{
+			name:          "command file flag without filename",
+			args:          []string{"--command-file"},
+			expectedError: "--command-file requires a filename argument",
+		},
+		{
+			name:              "resources flag",
+			args:              []string{"--resources", "echo", "{{text}}"},
+			expectedResources: true,
+			expectedCommand:   []string{"echo", "{{text}}"},
+		},
+		{
+			name:            "prompts flag",
+			args:            []string{"--prompts", "echo", "{{text}}"},
+			expectedPrompts: true,
+			expectedCommand: []string{"echo", "{{text}}"},
+		},
+		{
+			name:            "shell flag",
+			args:            []string{"--shell", "ls", "|", "wc", "-l"},
+			expectedShell:   true,
+			expectedCommand: []string{"ls", "|", "wc", "-l"},
+		},
+		{
+			name:             "mime type flag",
+			args:             []string{"--mime-type", "application/gzip", "gzip", "-c", "{{file}}"},
+			expectedMIMEType: "application/gzip",
+			expectedCommand:  []string{"gzip", "-c", "{{file}}"},
+		},
+		{
+			name:               "output type flag with equals",
+			args:               []string{"--output-type=image", "qrencode", "-o", "-", "{{text}}"},
+			expectedOutputType: "image",
+			expectedCommand:    []string{"qrencode", "-o", "-", "{{text}}"},
+		},
+		{
+			name:               "output type flag with separate value",
+			args:               []string{"--output-type", "binary", "cat", "{{file}}"},
+			expectedOutputType: "binary",
+			expectedCommand:    []string{"cat", "{{file}}"},
+		},
+		{
+			name:          "unknown output type",
+			args:          []string{"--output-type=audio", "say"},
+			expectedError: "--output-type must be one of: auto, text, image, binary",
+		},
+		{
+			name:            "encode flag",
+			args:            []string{"--encode", "hex", "cat", "{{file}}"},
+			expectedEncode:  "hex",
+			expectedCommand: []string{"cat", "{{file}}"},
+		},
+		{
+			name:          "unknown encoding",
+			args:          []string{"--encode=base32", "cat", "{{file}}"},
+			expectedError: "--encode must be one of: base64, hex",
+		},
+		{
+			name:          "encode with output type",
+			args:          []string{"--encode=base64", "--output-type=binary", "cat", "{{file}}"},
+			expectedError: "--encode cannot be combined with --output-type",
+		},
+		{
+			name:          "encode with split on",
+			args:          []string{"--encode=base64", "--split-on=\\n", "cat", "{{file}}"},
+			expectedError: "--encode cannot be combined with --split-on",
+		},
+		{
+			name:            "log flag with equals",
+			args:            []string{"--log=debug.log", "echo"},
+			expectedLogFile: "debug.log",
+			expectedCommand: []string{"echo"},
+		},
+		{
+			name:          "boolean flag with a value",
+			args:          []string{"--debug=true", "echo"},
+			expectedError: "--debug does not take a value",
+		},
+		{
+			name:            "quiet flag",
+			args:            []string{"--quiet", "npm", "install"},
+			expectedQuiet:   true,
+			expectedCommand: []string{"npm", "install"},
+		},
+		{
+			name:                "merge output flag",
+			args:                []string{"--merge-output", "make", "test"},
+			expectedMergeOutput: true,
+			expectedCommand:     []string{"make", "test"},
+		},
+		{
+			name:                "max concurrency flag",
+			args:                []string{"--max-concurrency", "4", "echo"},
+			expectedConcurrency: 4,
+			expectedCommand:     []string{"echo"},
+		},
+		{
+			name:          "max concurrency must be positive",
+			args:          []string{"--max-concurrency=0", "echo"},
+			expectedError: "--max-concurrency must be a positive number",
+		},
+		{
+			name:          "max concurrency must be a number",
+			args:          []string{"--max-concurrency", "lots", "echo"},
+			expectedError: "--max-concurrency must be a positive number",
+		},
+		{
+			name:                "echo command flag",
+			args:                []string{"--echo-command", "echo", "{{text}}"},
+			expectedEchoCommand: true,
+			expectedCommand:     []string{"echo", "{{text}}"},
+		},
+		{
+			name:              "run as user flag",
+			args:              []string{"--run-as-user", "nobody", "ls"},
+			expectedRunAsUser: "nobody",
+			expectedCommand:   []string{"ls"},
+		},
+		{
+			name:          "empty run as user",
+			args:          []string{"--run-as-user=", "ls"},
+			expectedError: "--run-as-user cannot be empty",
+		},
+		{
+			name:               "exec prefix flag",
+			args:               []string{"--exec-prefix", "nice -n 10 'taskset' -c 0", "make", "{{target}}"},
+			expectedExecPrefix: []string{"nice", "-n", "10", "taskset", "-c", "0"},
+			expectedCommand:    []string{"make", "{{target}}"},
+		},
+		{
+			name:          "empty exec prefix",
+			args:          []string{"--exec-prefix= ", "make"},
+			expectedError: "--exec-prefix cannot be empty",
+		},
+		{
+			name:               "output size flag",
+			args:               []string{"--output-size", "cat", "{{file}}"},
+			expectedOutputSize: true,
+			expectedCommand:    []string{"cat", "{{file}}"},
+		},
+		{
+			name:             "report duration flag",
+			args:             []string{"--report-duration", "make", "{{target}}"},
+			expectedDuration: true,
+			expectedCommand:  []string{"make", "{{target}}"},
+		},
+		{
+			name:             "glossary flag",
+			args:             []string{"--glossary", "glossary.json", "gh", "repo", "view", "{{repo}}"},
+			expectedGlossary: "glossary.json",
+			expectedCommand:  []string{"gh", "repo", "view", "{{repo}}"},
+		},
+		{
+			name:                "input schema flag",
+			args:                []string{"--input-schema", "schema.json", "handler", "{{@all:json}}"},
+			expectedInputSchema: "schema.json",
+			expectedCommand:     []string{"handler", "{{@all:json}}"},
+		},
+		{
+			name:              "output schema flag",
+			args:              []string{"--output-schema", "output.json", "gh", "api", "{{path}}"},
+			expectedOutSchema: "output.json",
+			expectedCommand:   []string{"gh", "api", "{{path}}"},
+		},
+		{
+			name:             "messages flag",
+			args:             []string{"--messages", "de.json", "make", "{{target}}"},
+			expectedMessages: "de.json",
+			expectedCommand:  []string{"make", "{{target}}"},
+		},
+		{
+			name:            "success codes flag",
+			args:            []string{"--success-codes", "0,1", "grep", "{{pattern}}"},
+			expectedCodes:   []int{0, 1},
+			expectedCommand: []string{"grep", "{{pattern}}"},
+		},
+		{
+			name:               "error if match flag",
+			args:               []string{"--error-if-match", "(?i)^error:", "terraform", "plan"},
+			expectedErrorMatch: "(?i)^error:",
+			expectedCommand:    []string{"terraform", "plan"},
+		},
+		{
+			name:              "success if match flag",
+			args:              []string{"--success-if-match=up to date", "git", "pull"},
+			expectedSuccessRe: "up to date",
+			expectedCommand:   []string{"git", "pull"},
+		},
+		{
+			name:            "redact flag repeats",
+			args:            []string{"--redact", `ghp_\w+`, `--redact=token=\S+`, "gh", "auth", "status"},
+			expectedRedact:  []string{`ghp_\w+`, `token=\S+`},
+			expectedCommand: []string{"gh", "auth", "status"},
+		},
+		{
+			name:          "redact must be a regular expression",
+			args:          []string{"--redact", "key(", "gh"},
+			expectedError: "--redact must be a regular expression",
+		},
+		{
+			name:          "redact must not match empty text",
+			args:          []string{"--redact", "x*", "gh"},
+			expectedError: `--redact pattern "x*" must not match empty text`,
+		},
+		{
+			name:          "error if match must be a regular expression",
+			args:          []string{"--error-if-match", "error(", "terraform"},
+			expectedError: "--error-if-match must be a regular expression: error parsing regexp: missing closing )",
+		},
+		{
+			name:          "success if match must be a regular expression",
+			args:          []string{"--success-if-match", "[a", "git"},
+			expectedError: "--success-if-match must be a regular expression",
+		},
+		{
+			name:            "success codes flag with equals",
+			args:            []string{"--success-codes=1", "grep", "{{pattern}}"},
+			expectedCodes:   []int{1},
+			expectedCommand: []string{"grep", "{{pattern}}"},
+		},
+		{
+			name:          "success codes must be exit codes",
+			args:          []string{"--success-codes", "0,oops", "grep"},
+			expectedError: "--success-codes must be a comma separated list of exit codes",
+		},
+		{
+			name:          "success codes must be in range",
+			args:          []string{"--success-codes", "256", "grep"},
+			expectedError: "--success-codes must be a comma separated list of exit codes",
+		},
+		{
+			name:              "file lists flag",
+			args:              []string{"--file-lists", "wc", "[files...]"},
+			expectedFileLists: true,
+			expectedCommand:   []string{"wc", "[files...]"},
+		},
+		{
+			name:               "strict args flag",
+			args:               []string{"--strict-args", "echo", "{{text}}"},
+			expectedStrictArgs: true,
+			expectedCommand:    []string{"echo", "{{text}}"},
+		},
+		{
+			name:               "error summary flag",
+			args:               []string{"--error-summary", "git", "[args...]"},
+			expectedErrSummary: true,
+			expectedCommand:    []string{"git", "[args...]"},
+		},
+		{
+			name:               "template delimiters",
+			args:               []string{"--open", "<< (", "--close", ">> )", "jq", "{a: <<key>>}", "(file)"},
+			expectedDelimiters: blueprint.Delimiters{Open: "<<", Close: ">>", OptionalOpen: "(", OptionalClose: ")"},
+			expectedCommand:    []string{"jq", "{a: <<key>>}", "(file)"},
+		},
+		{
+			name:               "template delimiters for required fields only",
+			args:               []string{"--open=<%", "--close=%>", "erb", "<%name%>"},
+			expectedDelimiters: blueprint.Delimiters{Open: "<%", Close: "%>"},
+			expectedCommand:    []string{"erb", "<%name%>"},
+		},
+		{
+			name:          "open without close",
+			args:          []string{"--open", "<<", "jq", "<<key>>"},
+			expectedError: "--open and --close: template delimiters need both an open and a close marker for required fields",
+		},
+		{
+			name:          "close without the optional marker",
+			args:          []string{"--open", "<< (", "--close", ">>", "jq", "<<key>>"},
+			expectedError: "template delimiters need both an open and a close marker for optional fields",
+		},
+		{
+			name:          "delimiters must be distinct",
+			args:          []string{"--open", "%", "--close", "%", "jq", "%key%"},
+			expectedError: `template delimiters must be distinct, got "%" twice`,
+		},
+		{
+			name:          "too many markers",
+			args:          []string{"--open", "<< ( |", "--close", ">> ) |", "jq"},
+			expectedError: "--open must be a marker for required fields, optionally followed by one for optional fields",
+		},
+		{
+			name:             "trim args flag",
+			args:             []string{"--trim-args", "git", "checkout", "{{branch}}"},
+			expectedTrimArgs: true,
+			expectedCommand:  []string{"git", "checkout", "{{branch}}"},
+		},
+		{
+			name:                "no enum values flag",
+			args:                []string{"--no-enum-values", "--input-schema", "schema.json", "./dispatch", "{{@all:json}}"},
+			expectedNoEnums:     true,
+			expectedInputSchema: "schema.json",
+			expectedCommand:     []string{"./dispatch", "{{@all:json}}"},
+		},
+		{
+			name:            "require descriptions flag",
+			args:            []string{"--require-descriptions", "gh", "{{repo # owner/name}}"},
+			expectedDescs:   true,
+			expectedCommand: []string{"gh", "{{repo # owner/name}}"},
+		},
+		{
+			name:            "no path checks flag",
+			args:            []string{"--no-path-checks", "cat", "{{input:path}}"},
+			expectedNoPaths: true,
+			expectedCommand: []string{"cat", "{{input:path}}"},
+		},
+		{
+			name:               "keep dashes flag",
+			args:               []string{"--keep-dashes", "terraform", "plan", "[--dry-run]"},
+			expectedKeepDashes: true,
+			expectedCommand:    []string{"terraform", "plan", "[--dry-run]"},
+		},
+		{
+			name:          "file lists flag takes no value",
+			args:          []string{"--file-lists=yes", "wc", "[files...]"},
+			expectedError: "--file-lists does not take a value",
+		},
+		{
+			name:               "inactivity timeout flag",
+			args:               []string{"--inactivity-timeout", "30s", "make", "build"},
+			expectedInactivity: 30 * time.Second,
+			expectedCommand:    []string{"make", "build"},
+		},
+		{
+			name:            "resource limit flags",
+			args:            []string{"--max-cpu-seconds", "30", "--max-memory=512M", "make", "build"},
+			expectedLimits:  tool.Limits{CPUSeconds: 30, MemoryBytes: 512 << 20},
+			expectedCommand: []string{"make", "build"},
+		},
+		{
+			name:            "memory in lowercase units",
+			args:            []string{"--max-memory", "2g", "make"},
+			expectedLimits:  tool.Limits{MemoryBytes: 2 << 30},
+			expectedCommand: []string{"make"},
+		},
+		{
+			name:            "memory in bytes",
+			args:            []string{"--max-memory", "1048576", "make"},
+			expectedLimits:  tool.Limits{MemoryBytes: 1 << 20},
+			expectedCommand: []string{"make"},
+		},
+		{
+			name:          "cpu seconds must be positive",
+			args:          []string{"--max-cpu-seconds", "0", "make"},
+			expectedError: "--max-cpu-seconds must be a positive number",
+		},
+		{
+			name:          "memory must be a size",
+			args:          []string{"--max-memory", "lots", "make"},
+			expectedError: `--max-memory must be a positive size like 512M or 2G, got "lots"`,
+		},
+		{
+			name:          "memory can't overflow",
+			args:          []string{"--max-memory", "99999999999G", "make"},
+			expectedError: "--max-memory must be a positive size",
+		},
+		{
+			name:              "kill grace flag",
+			args:              []string{"--kill-grace", "5s", "make", "build"},
+			expectedKillGrace: 5 * time.Second,
+			expectedCommand:   []string{"make", "build"},
+		},
+		{
+			name:          "kill grace must be positive",
+			args:          []string{"--kill-grace=0s", "make"},
+			expectedError: "--kill-grace must be a positive duration like 30s",
+		},
+		{
+			name:            "detach flag",
+			args:            []string{"--detach", "2s", "python3", "-m", "http.server", "{{port}}"},
+			expectedDetach:  2 * time.Second,
+			expectedCommand: []string{"python3", "-m", "http.server", "{{port}}"},
+		},
+		{
+			name:          "detach must be positive",
+			args:          []string{"--detach", "0s", "make"},
+			expectedError: "--detach must be a positive duration like 30s",
+		},
+		{
+			name:          "detached commands are never stopped for inactivity",
+			args:          []string{"--detach", "2s", "--inactivity-timeout", "30s", "make"},
+			expectedError: "--detach cannot be combined with --inactivity-timeout",
+		},
+		{
+			name:          "detached commands aren't cached",
+			args:          []string{"--detach", "2s", "--cache-ttl", "5m", "make"},
+			expectedError: "--detach cannot be combined with --cache-ttl",
+		},
+		{
+			name:             "cache ttl flag",
+			args:             []string{"--cache-ttl", "5m", "cat", "{{file}}"},
+			expectedCacheTTL: 5 * time.Minute,
+			expectedCommand:  []string{"cat", "{{file}}"},
+		},
+		{
+			name:          "cache ttl must be positive",
+			args:          []string{"--cache-ttl=0s", "cat"},
+			expectedError: "--cache-ttl must be a positive duration like 30s",
+		},
+		{
+			name:            "select flag",
+			args:            []string{"--select", ".items[].name", "kubectl", "get", "pods", "-o", "json"},
+			expectedSelect:  ".items[].name",
+			expectedCommand: []string{"kubectl", "get", "pods", "-o", "json"},
+		},
+		{
+			name:             "output template flag",
+			args:             []string{"--output-template", `$ {{command}}\n{{output}}`, "git", "status"},
+			expectedTemplate: "$ {{command}}\n{{output}}",
+			expectedCommand:  []string{"git", "status"},
+		},
+		{
+			name:          "output template needs output",
+			args:          []string{"--output-template", "{{command}}", "git", "status"},
+			expectedError: "--output-template: output template must include {{output}}",
+		},
+		{
+			name:          "output template with unknown placeholder",
+			args:          []string{"--output-template", "{{stdout}}", "git", "status"},
+			expectedError: "unknown placeholder {{stdout}}",
+		},
+		{
+			name:          "output template with split on",
+			args:          []string{"--output-template", "{{output}}", "--split-on", `\0`, "find", "."},
+			expectedError: "--output-template cannot be combined with --split-on",
+		},
+		{
+			name:          "output template with encode",
+			args:          []string{"--encode", "hex", "--output-template", "{{output}}", "cat"},
+			expectedError: "--output-template cannot be combined with --encode",
+		},
+		{
+			name:          "output template with binary output",
+			args:          []string{"--output-type", "image", "--output-template", "{{output}}", "convert"},
+			expectedError: "--output-template only applies to text output, not --output-type image",
+		},
+		{
+			name:               "output file flags",
+			args:               []string{"--output-file", "{{out}}", "--remove-output-file", "ffmpeg", "-i", "{{input}}", "{{out}}"},
+			expectedOutputFile: "{{out}}",
+			expectedRemoveFile: true,
+			expectedCommand:    []string{"ffmpeg", "-i", "{{input}}", "{{out}}"},
+		},
+		{
+			name:          "remove output file without output file",
+			args:          []string{"--remove-output-file", "ffmpeg", "-i", "{{input}}", "out.mp4"},
+			expectedError: "--remove-output-file needs --output-file",
+		},
+		{
+			name:              "max arg length flag",
+			args:              []string{"--max-arg-length", "4096", "say", "{{text}}"},
+			expectedMaxArgLen: 4096,
+			expectedCommand:   []string{"say", "{{text}}"},
+		},
+		{
+			name:          "max arg length must be positive",
+			args:          []string{"--max-arg-length=0", "say"},
+			expectedError: "--max-arg-length must be a positive number",
+		},
+		{
+			name:             "page size flag",
+			args:             []string{"--page-size", "8192", "journalctl"},
+			expectedPageSize: 8192,
+			expectedCommand:  []string{"journalctl"},
+		},
+		{
+			name:          "page size must be positive",
+			args:          []string{"--page-size=0", "journalctl"},
+			expectedError: "--page-size must be a positive number",
+		},
+		{
+			name:            "summary lines flag",
+			args:            []string{"--summary-lines", "20", "make", "test"},
+			expectedSummary: 20,
+			expectedCommand: []string{"make", "test"},
+		},
+		{
+			name:          "zero summary lines",
+			args:          []string{"--summary-lines=0", "make"},
+			expectedError: "--summary-lines must be a positive number",
+		},
+		{
+			name:          "summary lines with encode",
+			args:          []string{"--summary-lines", "20", "--encode", "base64", "cat", "{{file}}"},
+			expectedError: "--summary-lines cannot be combined with --encode",
+		},
+		{
+			name:          "summary lines with split on",
+			args:          []string{"--summary-lines", "20", "--split-on=---", "cat", "{{file}}"},
+			expectedError: "--summary-lines cannot be combined with --split-on",
+		},
+		{
+			name:          "summary lines with binary output",
+			args:          []string{"--summary-lines", "20", "--output-type", "binary", "cat", "{{file}}"},
+			expectedError: "--summary-lines only applies to text output, not --output-type binary",
+		},
+		{
+			name:             "max output lines flag",
+			args:             []string{"--max-output-lines", "100", "--page-size", "4096", "journalctl"},
+			expectedMaxLines: 100,
+			expectedPageSize: 4096,
+			expectedCommand:  []string{"journalctl"},
+		},
+		{
+			name:          "zero max output lines",
+			args:          []string{"--max-output-lines=0", "journalctl"},
+			expectedError: "--max-output-lines must be a positive number",
+		},
+		{
+			name:             "max output lines with split on",
+			args:             []string{"--max-output-lines", "100", "--split-on=---", "cat", "{{file}}"},
+			expectedMaxLines: 100,
+			expectedSplitOn:  "---",
+			expectedCommand:  []string{"cat", "{{file}}"},
+		},
+		{
+			name:             "max output lines with summary lines",
+			args:             []string{"--max-output-lines", "100", "--summary-lines", "5", "cat", "{{file}}"},
+			expectedMaxLines: 100,
+			expectedSummary:  5,
+			expectedCommand:  []string{"cat", "{{file}}"},
+		},
+		{
+			name:          "max output lines with binary output",
+			args:          []string{"--max-output-lines", "100", "--output-type", "binary", "cat", "{{file}}"},
+			expectedError: "--max-output-lines only applies to text output, not --output-type binary",
+		},
+		{
+			name:                "content mime type flag",
+			args:                []string{"--content-mime-type", "application/json", "gh", "api", "{{path}}"},
+			expectedContentType: "application/json",
+			expectedCommand:     []string{"gh", "api", "{{path}}"},
+		},
+		{
+			name:          "empty content mime type",
+			args:          []string{"--content-mime-type=", "gh"},
+			expectedError: "--content-mime-type cannot be empty",
+		},
+		{
+			name:          "content mime type with encode",
+			args:          []string{"--content-mime-type", "application/json", "--encode", "hex", "cat", "{{file}}"},
+			expectedError: "--content-mime-type cannot be combined with --encode",
+		},
+		{
+			name:          "content mime type with image output",
+			args:          []string{"--content-mime-type", "application/json", "--output-type", "image", "cat", "{{file}}"},
+			expectedError: "--content-mime-type only applies to text output, not --output-type image",
+		},
+		{
+			name:          "content mime type with summary lines",
+			args:          []string{"--content-mime-type", "application/json", "--summary-lines", "5", "cat", "{{file}}"},
+			expectedError: "--content-mime-type cannot be combined with --summary-lines",
+		},
+		{
+			name:          "content mime type with page size",
+			args:          []string{"--content-mime-type", "application/json", "--page-size", "1000", "cat", "{{file}}"},
+			expectedError: "--content-mime-type cannot be combined with --page-size",
+		},
+		{
+			name:            "max args flag",
+			args:            []string{"--max-args", "100", "rm", "[files...]"},
+			expectedMaxArgs: 100,
+			expectedCommand: []string{"rm", "[files...]"},
+		},
+		{
+			name:          "max args must be positive",
+			args:          []string{"--max-args=0", "rm"},
+			expectedError: "--max-args must be a positive number",
+		},
+		{
+			name:               "array delimiter flag",
+			args:               []string{"--array-delim", ",", "gh", "issue", "list", "[labels...]"},
+			expectedArrayDelim: ",",
+			expectedCommand:    []string{"gh", "issue", "list", "[labels...]"},
+		},
+		{
+			name:          "empty array delimiter",
+			args:          []string{"--array-delim=", "rm"},
+			expectedError: "--array-delim must be a delimiter",
+		},
+		{
+			name:              "rate limit flag",
+			args:              []string{"--rate-limit", "10/min", "curl", "{{url}}"},
+			expectedRateLimit: tool.Rate{Calls: 10, Period: time.Minute},
+			expectedCommand:   []string{"curl", "{{url}}"},
+		},
+		{
+			name:          "rate limit without a period",
+			args:          []string{"--rate-limit", "10", "curl"},
+			expectedError: "--rate-limit: rate must look like 10/min, got \"10\"",
+		},
+		{
+			name:            "split on null bytes",
+			args:            []string{"--split-on", `\0`, "find", ".", "-print0"},
+			expectedSplitOn: "\x00",
+			expectedCommand: []string{"find", ".", "-print0"},
+		},
+		{
+			name:            "split on a custom delimiter with escapes",
+			args:            []string{"--split-on=\\n---\\n", "cat"},
+			expectedSplitOn: "\n---\n",
+			expectedCommand: []string{"cat"},
+		},
+		{
+			name:            "split on a quote",
+			args:            []string{"--split-on", `"`, "cat"},
+			expectedSplitOn: `"`,
+			expectedCommand: []string{"cat"},
+		},
+		{
+			name:          "split on an empty delimiter",
+			args:          []string{"--split-on=", "cat"},
+			expectedError: "--split-on must be a delimiter",
+		},
+		{
+			name:          "select flag with an invalid path",
+			args:          []string{"--select", "items", "kubectl"},
+			expectedError: `selector "items" must start with .`,
+		},
+		{
+			name:               "inactivity timeout flag with equals",
+			args:               []string{"--inactivity-timeout=1m30s", "make"},
+			expectedInactivity: 90 * time.Second,
+			expectedCommand:    []string{"make"},
+		},
+		{
+			name:          "inactivity timeout needs a unit",
+			args:          []string{"--inactivity-timeout", "30", "make"},
+			expectedError: "--inactivity-timeout must be a positive duration like 30s",
+		},
+		{
+			name:          "inactivity timeout must be positive",
+			args:          []string{"--inactivity-timeout", "0s", "make"},
+			expectedError: "--inactivity-timeout must be a positive duration like 30s",
+		},
+		{
+			name:             "audit log flag",
+			args:             []string{"--audit-log", "audit.jsonl", "make"},
+			expectedAuditLog: "audit.jsonl",
+			expectedCommand:  []string{"make"},
+		},
+		{
+			name:               "server name flag",
+			args:               []string{"--server-name", "speaker", "say", "{{speech}}"},
+			expectedServerName: "speaker",
+			expectedCommand:    []string{"say", "{{speech}}"},
+		},
+		{
+			name:          "server name cannot be empty",
+			args:          []string{"--server-name=", "say", "{{speech}}"},
+			expectedError: "--server-name cannot be empty",
+		},
+		{
+			name:               "name prefix flag",
+			args:               []string{"--name-prefix", "repo1_", "git", "[args...]"},
+			expectedNamePrefix: "repo1_",
+			expectedCommand:    []string{"git", "[args...]"},
+		},
+		{
+			name:          "name prefix cannot be empty",
+			args:          []string{"--name-prefix=", "git", "[args...]"},
+			expectedError: "--name-prefix cannot be empty",
+		},
+		{
+			name:            "framing flag",
+			args:            []string{"--framing", "content-length", "git", "[args...]"},
+			expectedFraming: "content-length",
+			expectedCommand: []string{"git", "[args...]"},
+		},
+		{
+			name:          "unknown framing",
+			args:          []string{"--framing=lsp", "git", "[args...]"},
+			expectedError: "--framing must be one of: newline, content-length",
+		},
+		{
+			name:             "file root flag",
+			args:             []string{"--file-root", "/srv/notes", "cat", "{{note:@file}}"},
+			expectedFileRoot: "/srv/notes",
+			expectedCommand:  []string{"cat", "{{note:@file}}"},
+		},
+		{
+			name:          "file root flag without directory",
+			args:          []string{"--file-root"},
+			expectedError: "--file-root requires a directory argument",
+		},
 		{
 			name:          "unknown studio flag",
 			args:          []string{"--unknown", "echo", "hello"},
@@ -157,7 +1059,7 @@ func TestParseArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			debug, version, logFile, command, err := parseArgs(tt.args)
+			opts, command, err := parseArgs(tt.args)
 
 			if tt.expectedError != "" {
 				assert.Error(t, err)
@@ -166,9 +1068,89 @@ func TestParseArgs(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedDebug, debug)
-			assert.Equal(t, tt.expectedVersion, version)
-			assert.Equal(t, tt.expectedLogFile, logFile)
+			assert.Equal(t, tt.expectedDebug, opts.debug)
+			assert.Equal(t, tt.expectedNoColor, opts.noColor)
+			assert.Equal(t, tt.expectedVersion, opts.version)
+			assert.Equal(t, tt.expectedListTools, opts.listTools)
+			assert.Equal(t, tt.expectedCallArgs, opts.callArgs)
+			assert.Equal(t, tt.expectedJSONOutput, opts.jsonOutput)
+			assert.Equal(t, tt.expectedCompact, opts.compact)
+			assert.Equal(t, tt.expectedCheck, opts.check)
+			assert.Equal(t, tt.expectedCheckArgs, opts.checkArgs)
+			assert.Equal(t, tt.expectedEnv, opts.env)
+			assert.Equal(t, tt.expectedMetaEnv, opts.metaEnv)
+			assert.Equal(t, tt.expectedMetaArgs, opts.metaArgs)
+			assert.Equal(t, tt.expectedClearEnv, opts.clearEnv)
+			assert.Equal(t, tt.expectedPassthrough, opts.envPassthrough)
+			assert.Equal(t, tt.expectedExecPrefix, opts.execPrefix)
+			assert.Equal(t, tt.expectedLogFile, opts.logFile)
+			assert.Equal(t, tt.expectedAuditLog, opts.auditLog)
+			assert.Equal(t, tt.expectedServerName, opts.serverName)
+			assert.Equal(t, tt.expectedNamePrefix, opts.namePrefix)
+			assert.Equal(t, tt.expectedFraming, opts.framing)
+			assert.Equal(t, tt.expectedCommandFile, opts.commandFile)
+			assert.Equal(t, tt.expectedToolFile, opts.toolFile)
+			assert.Equal(t, tt.expectedDelimiters, opts.delimiters)
+			assert.Equal(t, tt.expectedResources, opts.resources)
+			assert.Equal(t, tt.expectedPrompts, opts.prompts)
+			assert.Equal(t, tt.expectedShell, opts.shell)
+			assert.Equal(t, tt.expectedMIMEType, opts.mimeType)
+			assert.Equal(t, tt.expectedContentType, opts.contentMIMEType)
+			assert.Equal(t, tt.expectedOutputType, opts.outputType)
+			assert.Equal(t, tt.expectedEncode, opts.encode)
+			assert.Equal(t, tt.expectedQuiet, opts.quiet)
+			assert.Equal(t, tt.expectedMergeOutput, opts.mergeOutput)
+			assert.Equal(t, tt.expectedConcurrency, opts.maxConcurrency)
+			assert.Equal(t, tt.expectedRateLimit, opts.rateLimit)
+			assert.Equal(t, tt.expectedEchoCommand, opts.echoCommand)
+			assert.Equal(t, tt.expectedOutputSize, opts.outputSize)
+			assert.Equal(t, tt.expectedDuration, opts.reportDuration)
+			assert.Equal(t, tt.expectedRunAsUser, opts.runAsUser)
+			assert.Equal(t, tt.expectedGlossary, opts.glossary)
+			assert.Equal(t, tt.expectedInputSchema, opts.inputSchema)
+			assert.Equal(t, tt.expectedOutSchema, opts.outputSchema)
+			assert.Equal(t, tt.expectedMessages, opts.messages)
+			assert.Equal(t, tt.expectedFileRoot, opts.fileRoot)
+			assert.Equal(t, tt.expectedTrimArgs, opts.trimArgs)
+			assert.Equal(t, tt.expectedNoPaths, opts.noPathChecks)
+			assert.Equal(t, tt.expectedNoEnums, opts.noEnumValues)
+			assert.Equal(t, tt.expectedDescs, opts.requireDescs)
+			assert.Equal(t, tt.expectedKeepDashes, opts.keepDashes)
+			assert.Equal(t, tt.expectedMaxArgLen, opts.maxArgLength)
+			assert.Equal(t, tt.expectedPageSize, opts.pageSize)
+			assert.Equal(t, tt.expectedSummary, opts.summaryLines)
+			assert.Equal(t, tt.expectedMaxLines, opts.maxOutputLines)
+			assert.Equal(t, tt.expectedMaxArgs, opts.maxArgs)
+			assert.Equal(t, tt.expectedArrayDelim, opts.arrayDelimiter)
+			assert.Equal(t, tt.expectedCodes, opts.successCodes)
+			if tt.expectedErrorMatch == "" {
+				assert.Nil(t, opts.errorIfMatch)
+			} else if assert.NotNil(t, opts.errorIfMatch) {
+				assert.Equal(t, tt.expectedErrorMatch, opts.errorIfMatch.String())
+			}
+			var redact []string
+			for _, re := range opts.redact {
+				redact = append(redact, re.String())
+			}
+			assert.Equal(t, tt.expectedRedact, redact)
+			if tt.expectedSuccessRe == "" {
+				assert.Nil(t, opts.successIfMatch)
+			} else if assert.NotNil(t, opts.successIfMatch) {
+				assert.Equal(t, tt.expectedSuccessRe, opts.successIfMatch.String())
+			}
+			assert.Equal(t, tt.expectedFileLists, opts.fileLists)
+			assert.Equal(t, tt.expectedStrictArgs, opts.strictArgs)
+			assert.Equal(t, tt.expectedErrSummary, opts.errorSummary)
+			assert.Equal(t, tt.expectedInactivity, opts.inactivityTimeout)
+			assert.Equal(t, tt.expectedLimits, opts.limits)
+			assert.Equal(t, tt.expectedKillGrace, opts.killGrace)
+			assert.Equal(t, tt.expectedDetach, opts.detach)
+			assert.Equal(t, tt.expectedCacheTTL, opts.cacheTTL)
+			assert.Equal(t, tt.expectedSelect, opts.selector.String())
+			assert.Equal(t, tt.expectedTemplate, opts.outputTemplate.String())
+			assert.Equal(t, tt.expectedOutputFile, opts.outputFile)
+			assert.Equal(t, tt.expectedRemoveFile, opts.removeOutputFile)
+			assert.Equal(t, tt.expectedSplitOn, opts.splitOn)
 			assert.Equal(t, tt.expectedCommand, command)
 		})
 	}
@@ -176,22 +1158,65 @@ func TestParseArgs(t *testing.T) {
 
 func TestVersionFlagParsing(t *testing.T) {
 	t.Run("identifies version flag correctly", func(t *testing.T) {
-		debug, version, logFile, command, err := parseArgs([]string{"--version"})
+		opts, command, err := parseArgs([]string{"--version"})
 		assert.NoError(t, err)
-		assert.False(t, debug)
-		assert.True(t, version)
-		assert.Empty(t, logFile)
+		assert.False(t, opts.debug)
+		assert.True(t, opts.version)
+		assert.Empty(t, opts.logFile)
 		assert.Empty(t, command)
 	})
+
+	t.Run("prints the version and commit", func(t *testing.T) {
+		version, commit, date := Version, Commit, Date
+		Version, Commit, Date = "1.2.3", "abc1234", "2025-07-01"
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"--version"})
+		t.Cleanup(func() {
+			Version, Commit, Date = version, commit, date
+			rootCmd.SetOut(nil)
+			rootCmd.SetArgs(nil)
+		})
+
+		require.NoError(t, rootCmd.Execute())
+		assert.Equal(t, "studio 1.2.3\ncommit: abc1234\nbuilt: 2025-07-01\n", out.String())
+	})
+}
+
+func TestRootArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{name: "command", args: []string{"echo", "{{text}}"}},
+		{name: "version without a command", args: []string{"--version"}},
+		{name: "no command", args: []string{"--debug"}, expectedError: "usage: studio <command>"},
+		{name: "json", args: []string{"--json", "echo"}, expectedError: "--json only applies to studio validate, debug and run"},
+		{name: "args", args: []string{"--args", "{}", "echo"}, expectedError: "--args only applies to studio run"},
+		{name: "command file and tool file", args: []string{"--command-file", "curl.txt", "--tool-file", "curl.json"}, expectedError: "--command-file cannot be combined with --tool-file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rootCmd.Args(rootCmd, tt.args)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
 }
 
 func TestEmptyArgs(t *testing.T) {
 	t.Run("handles empty args", func(t *testing.T) {
-		debug, version, logFile, command, err := parseArgs([]string{})
+		opts, command, err := parseArgs([]string{})
 		assert.NoError(t, err)
-		assert.False(t, debug)
-		assert.False(t, version)
-		assert.Empty(t, logFile)
+		assert.False(t, opts.debug)
+		assert.False(t, opts.version)
+		assert.Empty(t, opts.logFile)
 		assert.Empty(t, command)
 	})
 }
@@ -201,24 +1226,98 @@ func TestSayCommandWithVoiceFlag(t *testing.T) {
 	t.Run("say command with -v flag should not be parsed as studio flag", func(t *testing.T) {
 		args := []string{"say", "-v", "siri", "{{speech#A very concise message to say out loud to the user}}"}
 
-		debug, version, logFile, command, err := parseArgs(args)
+		opts, command, err := parseArgs(args)
 
 		assert.NoError(t, err)
-		assert.False(t, debug)
-		assert.False(t, version)
-		assert.Empty(t, logFile)
+		assert.False(t, opts.debug)
+		assert.False(t, opts.version)
+		assert.Empty(t, opts.logFile)
 		assert.Equal(t, args, command)
 	})
 
 	t.Run("debug flag followed by say command with -v flag", func(t *testing.T) {
 		args := []string{"--debug", "say", "-v", "siri", "{{speech#message}}"}
 
-		debug, version, logFile, command, err := parseArgs(args)
+		opts, command, err := parseArgs(args)
 
 		assert.NoError(t, err)
-		assert.True(t, debug)
-		assert.False(t, version)
-		assert.Empty(t, logFile)
+		assert.True(t, opts.debug)
+		assert.False(t, opts.version)
+		assert.Empty(t, opts.logFile)
 		assert.Equal(t, []string{"say", "-v", "siri", "{{speech#message}}"}, command)
 	})
 }
+
+func TestReadCommandFile(t *testing.T) {
+	t.Run("splits a quoted template into shell words", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "curl.txt")
+		template := `curl -X POST -H "Content-Type: application/json" '{{url # The URL to request}}'` + "\n"
+		require.NoError(t, os.WriteFile(filename, []byte(template), 0644))
+
+		args, _, err := readCommandFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"curl", "-X", "POST", "-H", "Content-Type: application/json", "{{url # The URL to request}}"}, args)
+	})
+
+	t.Run("reads multi-line templates", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "grep.txt")
+		template := "grep\n  -n\n  \"{{pattern # what to search for}}\"\n  [paths...]\n"
+		require.NoError(t, os.WriteFile(filename, []byte(template), 0644))
+
+		args, _, err := readCommandFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"grep", "-n", "{{pattern # what to search for}}", "[paths...]"}, args)
+	})
+
+	t.Run("honors backslash escapes and line continuations", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "ls.txt")
+		template := "ls \\\n  -la \\\n  my\\ dir \"{{path # \\\"quoted\\\" path}}\"\n"
+		require.NoError(t, os.WriteFile(filename, []byte(template), 0644))
+
+		args, _, err := readCommandFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ls", "-la", "my dir", `{{path # "quoted" path}}`}, args)
+	})
+
+	t.Run("reports unbalanced quotes", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "broken.txt")
+		require.NoError(t, os.WriteFile(filename, []byte(`echo "{{text}}`), 0644))
+
+		_, _, err := readCommandFile(filename)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unterminated double quote")
+	})
+
+	t.Run("returns the line of each word", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "grep.txt")
+		require.NoError(t, os.WriteFile(filename, []byte("grep -n\n  \"{{pattern}}\"\n  [paths...]\n"), 0644))
+
+		_, lines, err := readCommandFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 1, 2, 3}, lines)
+	})
+
+	t.Run("names the line of an unbalanced quote", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "broken.txt")
+		require.NoError(t, os.WriteFile(filename, []byte("echo\n  '{{text}}\n  [more]\n"), 0644))
+
+		_, _, err := readCommandFile(filename)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "broken.txt:2: unterminated single quote")
+	})
+
+	t.Run("rejects empty files", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "empty.txt")
+		require.NoError(t, os.WriteFile(filename, []byte("  \n"), 0644))
+
+		_, _, err := readCommandFile(filename)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is empty")
+	})
+
+	t.Run("reports missing files", func(t *testing.T) {
+		_, _, err := readCommandFile(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read command file")
+	})
+}
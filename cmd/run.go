@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/cobra"
+	"github.com/studio-mcp/studio/internal/studio"
+	"github.com/studio-mcp/studio/internal/tool"
+)
+
+// parseCallArgs parses the JSON object of arguments given to --args
+func parseCallArgs(call string) (map[string]any, error) {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(call), &args); err != nil || args == nil {
+		return nil, fmt.Errorf(`--args must be a JSON object of arguments, like '{"text": "hi"}'`)
+	}
+	return args, nil
+}
+
+// callTool calls the tool of s once with args and prints the result, as JSON
+// when asJSON is set. A result flagged as an error is returned as an error
+// after it is printed.
+func callTool(cmd *cobra.Command, s *studio.Studio, args map[string]any, asJSON bool) error {
+	result, err := s.CallTool(cmd.Context(), args)
+	if err != nil {
+		return fmt.Errorf("failed to call tool: %w", err)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printCallResult(cmd.OutOrStdout(), result)
+	}
+
+	if result.IsError {
+		return fmt.Errorf("the tool call returned an error")
+	}
+	return nil
+}
+
+// printCallResult prints the content of a tool result for reading. Content
+// that isn't text is described on a line of its own, and _meta comes last.
+func printCallResult(w io.Writer, result *mcp.CallToolResult) {
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case *mcp.TextContent:
+			fmt.Fprintln(w, strings.TrimSuffix(c.Text, "\n"))
+		case *mcp.ImageContent:
+			fmt.Fprintf(w, "[%s image, %d bytes]\n", c.MIMEType, len(c.Data))
+		case *mcp.EmbeddedResource:
+			if c.Resource.Blob == nil {
+				fmt.Fprintln(w, strings.TrimSuffix(c.Resource.Text, "\n"))
+			} else {
+				fmt.Fprintf(w, "[%s resource %s, %d bytes]\n", c.Resource.MIMEType, c.Resource.URI, len(c.Resource.Blob))
+			}
+		default:
+			fmt.Fprintf(w, "[%T content]\n", content)
+		}
+	}
+
+	if len(result.Meta) > 0 {
+		fmt.Fprint(w, "_meta: ")
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(result.Meta)
+	}
+}
+
+// runCmd calls the tool once without an MCP client
+var runCmd = &cobra.Command{
+	Use:   "studio run [--args arguments] [--json] [studio flags] [--] <command> --example \"{{req # required arg}}\"",
+	Short: "Call the tool once with a JSON object of arguments, print the result and exit",
+	Long: `run calls the tool of a command template once, the way an MCP client would,
+through the same tools/call handler and with the same flags applied. It prints
+the result and exits non-zero when the result is an error.
+
+  --args <arguments> - The JSON object of arguments to call the tool with, like '{"text": "hi"}'. Defaults to {}.
+  --json - Print the JSON tools/call returns instead of the content.
+  --no-color - Don't color debug logs. Color is also off when NO_COLOR is set or stderr isn't a terminal.
+  -- - End of flag parsing. Everything after this is the command template.
+
+Every other flag of studio applies the way it does to the server, and --check
+runs the check before the call. --version and --list-tools don't apply.
+
+Example:
+  studio run --args '{"text": "hi"}' echo "{{text}}"`,
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, commandArgs, err := parseSubcommandArgs("run", args)
+		if err != nil {
+			if err.Error() == "help requested" {
+				return cmd.Help()
+			}
+			return err
+		}
+
+		if !opts.limits.IsZero() && !tool.LimitsSupported {
+			cmd.PrintErrln("Warning: --max-cpu-seconds and --max-memory only work on Linux, running commands without limits")
+		}
+
+		template, err := loadTemplate(opts, commandArgs)
+		if err != nil {
+			return err
+		}
+		s, err := newStudio(opts, template, useColor(opts.noColor, os.Stderr))
+		if err != nil {
+			return err
+		}
+
+		if opts.check {
+			if err := s.Check(); err != nil {
+				return err
+			}
+		}
+
+		callArgs := opts.callArgs
+		if callArgs == nil {
+			callArgs = map[string]any{}
+		}
+		return callTool(cmd, s, callArgs, opts.jsonOutput)
+	},
+}
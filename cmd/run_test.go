@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCmd(t *testing.T) {
+	run := func(t *testing.T, args ...string) (string, error) {
+		var out bytes.Buffer
+		runCmd.SetOut(&out)
+		runCmd.SetErr(&out)
+		runCmd.SetArgs(args)
+		t.Cleanup(func() {
+			runCmd.SetOut(nil)
+			runCmd.SetErr(nil)
+		})
+		err := runCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("calls the tool with args and prints the result", func(t *testing.T) {
+		out, err := run(t, "--args", `{"text": "hi"}`, "echo", "{{text}}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hi\n", out)
+	})
+
+	t.Run("calls the tool without args", func(t *testing.T) {
+		out, err := run(t, "echo", "[text]")
+
+		require.NoError(t, err)
+		assert.Equal(t, "\n", out)
+	})
+
+	t.Run("prints JSON", func(t *testing.T) {
+		out, err := run(t, "--json", "--args", `{"text": "hi"}`, "echo", "{{text}}")
+
+		require.NoError(t, err)
+		var result map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, []any{map[string]any{"type": "text", "text": "hi"}}, result["content"])
+	})
+
+	t.Run("applies the flags of the server", func(t *testing.T) {
+		out, err := run(t, "--open", "<<", "--close", ">>", "--args", `{"text": "hi"}`, "echo", "<<text>>", "{literal}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hi {literal}\n", out)
+	})
+
+	t.Run("fails when the result is an error", func(t *testing.T) {
+		_, err := run(t, "--args", `{"code": "3"}`, "sh", "-c", `exit "$0"`, "{{code}}")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the tool call returned an error")
+	})
+
+	t.Run("refuses flags that don't call the tool", func(t *testing.T) {
+		_, err := run(t, "--list-tools", "echo")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--list-tools does not apply to run")
+	})
+}
@@ -0,0 +1,107 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignalHandling checks that stopping the server also stops the commands it is running
+func TestSignalHandling(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGTERM, syscall.SIGINT} {
+		t.Run("stops running commands on "+sig.String(), func(t *testing.T) {
+			binaryPath := buildStudio(t)
+			pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+			cmd := exec.Command(binaryPath, "sh", "-c", "echo $$ > "+pidFile+"; sleep 30; echo done")
+			stdin, err := cmd.StdinPipe()
+			require.NoError(t, err)
+			stdout, err := cmd.StdoutPipe()
+			require.NoError(t, err)
+			require.NoError(t, cmd.Start())
+			defer cmd.Process.Kill()
+
+			requests := []MCPRequest{
+				{JSONRPC: "2.0", ID: "init", Method: "initialize", Params: InitializeParams{
+					ProtocolVersion: "2024-11-05",
+					Capabilities:    map[string]interface{}{},
+					ClientInfo:      map[string]interface{}{"name": "test-client", "version": "1.0.0"},
+				}},
+				{JSONRPC: "2.0", ID: "call", Method: "tools/call", Params: map[string]interface{}{
+					"name":      "sh",
+					"arguments": map[string]interface{}{},
+				}},
+			}
+			for _, request := range requests {
+				requestJSON, err := json.Marshal(request)
+				require.NoError(t, err)
+				_, err = stdin.Write(append(requestJSON, '\n'))
+				require.NoError(t, err)
+			}
+
+			// Wait for the command to start
+			var pid int
+			require.Eventually(t, func() bool {
+				content, err := os.ReadFile(pidFile)
+				if err != nil {
+					return false
+				}
+				pid, err = strconv.Atoi(strings.TrimSpace(string(content)))
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond, "command never started")
+
+			// Collect responses until the server closes stdout
+			collected := make(chan []MCPResponse, 1)
+			go func() {
+				var responses []MCPResponse
+				scanner := bufio.NewScanner(stdout)
+				for scanner.Scan() {
+					var response MCPResponse
+					if json.Unmarshal(scanner.Bytes(), &response) == nil {
+						responses = append(responses, response)
+					}
+				}
+				collected <- responses
+			}()
+
+			require.NoError(t, cmd.Process.Signal(sig))
+
+			var responses []MCPResponse
+			select {
+			case responses = <-collected:
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not exit after signal")
+			}
+			assert.NoError(t, cmd.Wait(), "server should exit cleanly")
+
+			// The in-flight call still gets a response
+			var callResponse *MCPResponse
+			for i := range responses {
+				if responses[i].ID == "call" {
+					callResponse = &responses[i]
+				}
+			}
+			require.NotNil(t, callResponse, "in-flight call should get a response")
+			result, ok := callResponse.Result.(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, true, result["isError"])
+
+			// The child was stopped and reaped
+			err = syscall.Kill(pid, 0)
+			assert.True(t, errors.Is(err, syscall.ESRCH), "child process %d should be gone, got %v", pid, err)
+		})
+	}
+}
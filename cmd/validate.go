@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/spf13/cobra"
+	"github.com/studio-mcp/studio/internal/blueprint"
+	"github.com/studio-mcp/studio/internal/studio"
+	"github.com/studio-mcp/studio/internal/tool"
+)
+
+// validation is the result of checking a command template
+type validation struct {
+	Valid   bool     `json:"valid"`
+	Command string   `json:"command,omitempty"`
+	Tool    string   `json:"tool,omitempty"`
+	Fields  []string `json:"fields,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// parseSubcommandArgs parses the arguments of a subcommand like validate, which
+// are the flags of the server and --json followed by the command template
+func parseSubcommandArgs(subcommand string, args []string) (opts options, commandArgs []string, err error) {
+	opts, commandArgs, err = parseArgs(args)
+	if err != nil {
+		return options{}, nil, err
+	}
+
+	// Subcommands never answer a client, and only run runs the command
+	switch {
+	case opts.version:
+		return options{}, nil, fmt.Errorf("--version does not apply to %s", subcommand)
+	case opts.listTools:
+		return options{}, nil, fmt.Errorf("--list-tools does not apply to %s", subcommand)
+	case opts.check && subcommand != "run":
+		return options{}, nil, fmt.Errorf("--check does not apply to %s", subcommand)
+	case opts.callArgs != nil && subcommand != "run":
+		return options{}, nil, fmt.Errorf("--args only applies to run")
+	}
+
+	usage := fmt.Sprintf("usage: studio %s [--json] [studio flags] [--] <command> ...", subcommand)
+	if err := checkCommandArgs(opts, commandArgs, usage); err != nil {
+		return options{}, nil, err
+	}
+	return opts, commandArgs, nil
+}
+
+// loadStudio creates the studio opts and commandArgs describe the same way the
+// server does, without serving it. command is the template as far as it could
+// be read, to report an error against.
+func loadStudio(opts options, commandArgs []string) (s *studio.Studio, command string, err error) {
+	template, err := loadTemplate(opts, commandArgs)
+	if err != nil {
+		return nil, cmp.Or(opts.commandFile, opts.toolFile), err
+	}
+
+	s, err = newStudio(opts, template, useColor(opts.noColor, os.Stderr))
+	if err != nil {
+		return nil, strings.Join(template.args, " "), err
+	}
+	return s, s.Blueprint.GetCommandFormat(), nil
+}
+
+// validateTemplate checks that opts and commandArgs make a studio the server
+// could start
+func validateTemplate(opts options, commandArgs []string) validation {
+	s, command, err := loadStudio(opts, commandArgs)
+	result := validation{Command: command}
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	result.Tool = tool.Options{NamePrefix: opts.namePrefix}.ToolName(s.Blueprint)
+	if !tool.ValidToolName(result.Tool) {
+		result.Errors = append(result.Errors, fmt.Sprintf("tool name %q must be 1 to 64 letters, numbers, underscores or dashes; use the command name with PATH instead of a path", result.Tool))
+	}
+
+	// Properties keep the order of their fields, or of a loaded schema's names
+	schema := s.Blueprint.GetInputSchema().(*jsonschema.Schema)
+	if names, ok := schema.Extra[blueprint.PropertyOrdering].([]string); ok {
+		result.Fields = names
+	} else {
+		result.Fields = slices.Sorted(maps.Keys(schema.Properties))
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// writeValidation reports result to w as text, colored when color is set, or
+// as JSON when asJSON is set
+func writeValidation(w io.Writer, result validation, asJSON bool, color bool) error {
+	if asJSON {
+		return writeJSON(w, result)
+	}
+
+	if !result.Valid {
+		writeInvalid(w, result.Command, result.Errors, color)
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s %s\n", tool.Paint(color, tool.Green, "ok:"), result.Command)
+	fmt.Fprintf(w, "  tool: %s\n", result.Tool)
+	if len(result.Fields) > 0 {
+		fmt.Fprintf(w, "  fields: %s\n", strings.Join(result.Fields, ", "))
+	}
+	return nil
+}
+
+// writeJSON writes v to w as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeInvalid reports that command is invalid to w, with a line for each of
+// errors, colored when color is set
+func writeInvalid(w io.Writer, command string, errors []string, color bool) {
+	fmt.Fprintf(w, "%s %s\n", tool.Paint(color, tool.Red, "invalid:"), command)
+	for _, message := range errors {
+		fmt.Fprintf(w, "  %s %s\n", tool.Paint(color, tool.Red, "error:"), message)
+	}
+}
+
+// validateCmd checks a command template without starting the server
+var validateCmd = &cobra.Command{
+	Use:   "studio validate [--json] [studio flags] [--] <command> --example \"{{req # required arg}}\"",
+	Short: "Check a command template and report errors without starting the server",
+	Long: `validate takes the flags of studio and a command template, and creates the
+server they describe without running the command or serving it. It reports any
+problems, exiting non-zero when studio would refuse to start.
+
+  --json - Print the result as JSON.
+  --no-color - Don't color the result. Color is also off when NO_COLOR is set or stdout isn't a terminal.
+  --require-descriptions - Report every required field without a description as an error.
+  --command-file <filename> - Read the command template from a file. Errors in the template name its line.
+  -- - End of flag parsing. Everything after this is the command template.
+
+Every other flag of studio, like --open and --close, --tool-file, --set-env or
+--input-schema, is checked the way studio checks it, except the ones that run
+the command or exit early: --version, --list-tools and --check.
+
+Example:
+  studio validate say -v siri "{{speech # a concise phrase to say outloud to the user}}"`,
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, commandArgs, err := parseSubcommandArgs("validate", args)
+		if err != nil {
+			if err.Error() == "help requested" {
+				return cmd.Help()
+			}
+			return err
+		}
+
+		result := validateTemplate(opts, commandArgs)
+
+		out := cmd.OutOrStdout()
+		if err := writeValidation(out, result, opts.jsonOutput, useColor(opts.noColor, out)); err != nil {
+			return err
+		}
+		if !result.Valid {
+			cmd.SilenceErrors = true
+			return fmt.Errorf("invalid command template")
+		}
+		return nil
+	},
+}
+
+// commandFor picks the command that handles args. validate, debug and run are
+// only subcommands as the first argument, so they never take over a wrapped
+// command like terraform validate.
+func commandFor(args []string) (*cobra.Command, []string) {
+	if len(args) > 0 && args[0] == "validate" {
+		return validateCmd, args[1:]
+	}
+	if len(args) > 0 && args[0] == "debug" {
+		return debugCmd, args[1:]
+	}
+	if len(args) > 0 && args[0] == "run" {
+		return runCmd, args[1:]
+	}
+	return rootCmd, args
+}
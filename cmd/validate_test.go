@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		{"runs the server by default", []string{"echo", "{{text}}"}, "studio", []string{"echo", "{{text}}"}},
+		{"validate as the first argument", []string{"validate", "echo", "{{text}}"}, "validate", []string{"echo", "{{text}}"}},
+		{"validate after a flag is part of the command", []string{"--quiet", "terraform", "validate"}, "studio", []string{"--quiet", "terraform", "validate"}},
+		{"validate after -- is the wrapped command", []string{"--", "validate"}, "studio", []string{"--", "validate"}},
+		{"debug as the first argument", []string{"debug", "echo", "{{text}}"}, "debug", []string{"echo", "{{text}}"}},
+		{"debug after a flag is part of the command", []string{"--quiet", "debug"}, "studio", []string{"--quiet", "debug"}},
+		{"run as the first argument", []string{"run", "--args", "{}", "date"}, "run", []string{"--args", "{}", "date"}},
+		{"run after a flag is part of the command", []string{"--quiet", "go", "run", "."}, "studio", []string{"--quiet", "go", "run", "."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args := commandFor(tt.args)
+			switch tt.expectedCmd {
+			case "validate":
+				assert.Equal(t, validateCmd, cmd)
+			case "debug":
+				assert.Equal(t, debugCmd, cmd)
+			case "run":
+				assert.Equal(t, runCmd, cmd)
+			default:
+				assert.Equal(t, rootCmd, cmd)
+			}
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestParseSubcommandArgs(t *testing.T) {
+	tests := []struct {
+		name                string
+		args                []string
+		expectedJSON        bool
+		expectedNoColor     bool
+		expectedCommandFile string
+		expectedDescs       bool
+		expectedCommand     []string
+		expectedError       string
+	}{
+		{name: "command", args: []string{"echo", "{{text}}"}, expectedCommand: []string{"echo", "{{text}}"}},
+		{name: "json", args: []string{"--json", "echo"}, expectedJSON: true, expectedCommand: []string{"echo"}},
+		{name: "no color", args: []string{"--no-color", "echo"}, expectedNoColor: true, expectedCommand: []string{"echo"}},
+		{name: "command file", args: []string{"--command-file", "curl.txt"}, expectedCommandFile: "curl.txt", expectedCommand: []string{}},
+		{name: "command file with equals", args: []string{"--command-file=curl.txt", "--json"}, expectedJSON: true, expectedCommandFile: "curl.txt", expectedCommand: []string{}},
+		{name: "flags after the command are the command's", args: []string{"ls", "--json"}, expectedCommand: []string{"ls", "--json"}},
+		{name: "double dash", args: []string{"--", "--json"}, expectedCommand: []string{"--json"}},
+		{name: "server flags", args: []string{"--shell", "--open", "<<", "--close", ">>", "echo"}, expectedCommand: []string{"echo"}},
+		{name: "no command", args: []string{"--json"}, expectedError: "usage: studio validate"},
+		{name: "command file and command", args: []string{"--command-file", "curl.txt", "echo"}, expectedError: "--command-file cannot be combined with command arguments"},
+		{name: "tool file and command", args: []string{"--tool-file", "gh.json", "echo"}, expectedError: "--tool-file cannot be combined with command arguments"},
+		{name: "command file without a filename", args: []string{"--command-file"}, expectedError: "--command-file requires a filename argument"},
+		{name: "json with a value", args: []string{"--json=yes", "echo"}, expectedError: "--json does not take a value"},
+		{name: "require descriptions", args: []string{"--require-descriptions", "echo"}, expectedDescs: true, expectedCommand: []string{"echo"}},
+		{name: "require descriptions with a value", args: []string{"--require-descriptions=yes", "echo"}, expectedError: "--require-descriptions does not take a value"},
+		{name: "check", args: []string{"--check", "echo"}, expectedError: "--check does not apply to validate"},
+		{name: "list tools", args: []string{"--list-tools", "echo"}, expectedError: "--list-tools does not apply to validate"},
+		{name: "args", args: []string{"--args", "{}", "echo"}, expectedError: "--args only applies to run"},
+		{name: "unknown flag", args: []string{"--bogus", "echo"}, expectedError: "unknown flag: --bogus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, commandArgs, err := parseSubcommandArgs("validate", tt.args)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedJSON, opts.jsonOutput)
+			assert.Equal(t, tt.expectedNoColor, opts.noColor)
+			assert.Equal(t, tt.expectedCommandFile, opts.commandFile)
+			assert.Equal(t, tt.expectedDescs, opts.requireDescs)
+			assert.Equal(t, tt.expectedCommand, commandArgs)
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedTool   string
+		expectedFields []string
+		expectedError  string
+	}{
+		{
+			name:           "valid template",
+			args:           []string{"git-log", "{{ref}}", "[paths...]", "[--oneline]"},
+			expectedTool:   "git_log",
+			expectedFields: []string{"ref", "paths", "oneline"},
+		},
+		{
+			name:           "command without fields",
+			args:           []string{"date"},
+			expectedTool:   "date",
+			expectedFields: []string{},
+		},
+		{
+			name:          "empty command",
+			args:          []string{" "},
+			expectedError: "empty command provided",
+		},
+		{
+			name:          "unterminated group",
+			args:          []string{"ls", "{?--limit", "{{limit}}"},
+			expectedError: "unterminated optional group",
+		},
+		{
+			name:           "described fields",
+			args:           []string{"--require-descriptions", "gh", "{{repo # owner/name}}", "[limit]"},
+			expectedTool:   "gh",
+			expectedFields: []string{"repo", "limit"},
+		},
+		{
+			name:          "undescribed required fields",
+			args:          []string{"--require-descriptions", "gh", "{{repo}}", "{{title}}", "[limit]"},
+			expectedError: "required fields repo, title have no description",
+		},
+		{
+			name:          "command given as a path",
+			args:          []string{"/bin/ls", "[path]"},
+			expectedTool:  "/bin/ls",
+			expectedError: `tool name "/bin/ls" must be 1 to 64 letters`,
+		},
+		{
+			name:           "custom markers",
+			args:           []string{"--open", "<<", "--close", ">>", "echo", "<<text>>", "{literal}"},
+			expectedTool:   "echo",
+			expectedFields: []string{"text"},
+		},
+		{
+			name:           "name prefix",
+			args:           []string{"--name-prefix", "repo1_", "git", "[args...]"},
+			expectedTool:   "repo1_git",
+			expectedFields: []string{"args"},
+		},
+		{
+			name:          "invalid name prefix",
+			args:          []string{"--name-prefix", "my repo ", "git"},
+			expectedError: `tool name "my repo git" must be 1 to 64 letters`,
+		},
+		{
+			name:          "environment without a name",
+			args:          []string{"--set-env", "{{token}}", "gh", "{{repo}}"},
+			expectedError: "environment variable must look like NAME={{field}}",
+		},
+		{
+			name:          "detached pipeline",
+			args:          []string{"--detach", "2s", "tail", "-f", "log", "|", "grep", "{{pattern}}"},
+			expectedError: "--detach can't start a pipeline",
+		},
+		{
+			name:          "output file named by the client without a file root",
+			args:          []string{"--output-file", "{{out}}", "convert", "{{in}}", "{{out}}"},
+			expectedError: "needs --file-root",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, commandArgs, err := parseSubcommandArgs("validate", tt.args)
+			require.NoError(t, err)
+
+			result := validateTemplate(opts, commandArgs)
+
+			assert.Equal(t, tt.expectedTool, result.Tool)
+			if tt.expectedError != "" {
+				assert.False(t, result.Valid)
+				require.Len(t, result.Errors, 1)
+				assert.Contains(t, result.Errors[0], tt.expectedError)
+				return
+			}
+			assert.True(t, result.Valid)
+			assert.Empty(t, result.Errors)
+			assert.Equal(t, tt.expectedFields, result.Fields)
+		})
+	}
+
+	t.Run("input schema", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "schema.json")
+		require.NoError(t, os.WriteFile(filename, []byte(`{"type":"object","properties":{"b":{"type":"string"},"a":{"type":"string"}}}`), 0644))
+
+		result := validateTemplate(options{inputSchema: filename}, []string{"echo", "{{a}}", "[b]"})
+
+		assert.True(t, result.Valid, "errors: %v", result.Errors)
+		assert.Equal(t, []string{"a", "b"}, result.Fields)
+	})
+
+	t.Run("input schema missing a field", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "schema.json")
+		require.NoError(t, os.WriteFile(filename, []byte(`{"type":"object","properties":{"a":{"type":"string"}}}`), 0644))
+
+		result := validateTemplate(options{inputSchema: filename}, []string{"echo", "{{a}}", "[b]"})
+
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], filename)
+	})
+
+	t.Run("tool file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "gh.json")
+		require.NoError(t, os.WriteFile(filename, []byte(`{"command":["gh","issue","view","{{number}}"],"fields":{"number":{"description":"issue number"}}}`), 0644))
+
+		result := validateTemplate(options{toolFile: filename}, nil)
+
+		assert.True(t, result.Valid, "errors: %v", result.Errors)
+		assert.Equal(t, "gh issue view {{number}}", result.Command)
+		assert.Equal(t, []string{"number"}, result.Fields)
+	})
+}
+
+func TestValidateCmd(t *testing.T) {
+	run := func(t *testing.T, args ...string) (string, error) {
+		var out bytes.Buffer
+		validateCmd.SetOut(&out)
+		validateCmd.SetErr(&out)
+		validateCmd.SetArgs(args)
+		t.Cleanup(func() {
+			validateCmd.SetOut(nil)
+			validateCmd.SetErr(nil)
+		})
+		err := validateCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("reports a valid template", func(t *testing.T) {
+		out, err := run(t, "echo", "{{text # what to say}}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok: echo {{text}}\n  tool: echo\n  fields: text\n", out)
+	})
+
+	t.Run("fails on an invalid template", func(t *testing.T) {
+		out, err := run(t, "ls", "{?--all")
+
+		assert.Error(t, err)
+		assert.Contains(t, out, "invalid: ls {?--all\n  error: failed to create blueprint: cannot create blueprint: unterminated optional group")
+	})
+
+	t.Run("reports JSON", func(t *testing.T) {
+		out, err := run(t, "--json", "/bin/ls")
+
+		assert.Error(t, err)
+		var result validation
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.False(t, result.Valid)
+		assert.Equal(t, "/bin/ls", result.Tool)
+		assert.Len(t, result.Errors, 1)
+	})
+
+	t.Run("colors the result for a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		result := validation{Command: "ls {?--all", Errors: []string{"unterminated"}}
+
+		require.NoError(t, writeValidation(&out, result, false, true))
+		assert.Equal(t, "\x1b[31minvalid:\x1b[0m ls {?--all\n  \x1b[31merror:\x1b[0m unterminated\n", out.String())
+	})
+
+	t.Run("reads a command file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "curl.txt")
+		require.NoError(t, os.WriteFile(filename, []byte("curl\n  \"{{url # The URL to request}}\"\n"), 0644))
+
+		out, err := run(t, "--command-file", filename)
+
+		require.NoError(t, err)
+		assert.Contains(t, out, "ok: curl {{url}}")
+	})
+
+	t.Run("names the line of the command file an error is on", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "ls.txt")
+		require.NoError(t, os.WriteFile(filename, []byte("ls\n  -la\n  {?--sort\n  [path]\n"), 0644))
+
+		out, err := run(t, "--command-file", filename)
+
+		assert.Error(t, err)
+		assert.Contains(t, out, "error: "+filename+":3: failed to create blueprint: cannot create blueprint: unterminated optional group")
+	})
+
+	t.Run("reports a command file that can't be read", func(t *testing.T) {
+		out, err := run(t, "--command-file", filepath.Join(t.TempDir(), "missing.txt"))
+
+		assert.Error(t, err)
+		assert.Contains(t, out, "error: failed to read command file")
+	})
+}
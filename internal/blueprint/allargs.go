@@ -0,0 +1,120 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+const (
+	// allArgsName is the field that passes every argument of the call to the
+	// command as one JSON object, written {{@all:json}}
+	allArgsName = "@all"
+	// allArgsSuffix is the only modifier the @all field takes
+	allArgsSuffix = ":json"
+)
+
+// LoadInputSchema reads the JSON schema of the tool's arguments from a file,
+// for templates like {{@all:json}} whose arguments can't be inferred
+func LoadInputSchema(filename string) (*jsonschema.Schema, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input schema: %w", err)
+	}
+
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse input schema %s: %w", filename, err)
+	}
+	// Tool arguments are always an object
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("input schema %s must have type object", filename)
+	}
+	if _, err := schema.Resolve(nil); err != nil {
+		return nil, fmt.Errorf("invalid input schema %s: %w", filename, err)
+	}
+	return &schema, nil
+}
+
+// CheckInputSchema checks that every field of the template has a top-level
+// property in InputSchema, since field values are looked up by property name
+func (bp *Blueprint) CheckInputSchema() error {
+	if bp.InputSchema == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, fieldToken := range bp.fields() {
+		name := bp.propertyName(fieldToken.Name)
+		if _, ok := bp.InputSchema.Properties[name]; !ok && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("input schema has no property for the field %s", missing[0])
+	default:
+		return fmt.Errorf("input schema has no properties for the fields %s", strings.Join(missing, ", "))
+	}
+}
+
+// TakesAllArgs reports whether the template passes every argument to the
+// command as JSON with {{@all:json}}
+func (bp *Blueprint) TakesAllArgs() bool {
+	words := slices.Clone(bp.ShellWords)
+	for _, envVar := range bp.Env {
+		words = append(words, envVar.Tokens)
+	}
+
+	for _, tokens := range words {
+		for _, token := range tokens {
+			switch t := token.(type) {
+			case FieldToken:
+				if t.AllArgs {
+					return true
+				}
+			case GroupToken:
+				for _, fieldToken := range t.Fields() {
+					if fieldToken.AllArgs {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// addAllArgs returns params with args, the arguments as the client sent them,
+// encoded as the JSON value of the {{@all:json}} field
+func (bp *Blueprint) addAllArgs(params, args map[string]interface{}) (map[string]interface{}, error) {
+	if !bp.TakesAllArgs() {
+		return params, nil
+	}
+
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	// Keep <, > and & as they are rather than escaped for HTML
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(args); err != nil {
+		return nil, fmt.Errorf("failed to encode arguments as JSON: %w", err)
+	}
+
+	// Copy so the caller's params are untouched
+	result := make(map[string]interface{}, len(params)+1)
+	maps.Copy(result, params)
+	result[allArgsName] = strings.TrimSuffix(encoded.String(), "\n")
+	return result, nil
+}
@@ -0,0 +1,214 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_AllArgs(t *testing.T) {
+	t.Run("parses the @all:json field", func(t *testing.T) {
+		bp, err := FromArgs([]string{"handler", "--input", "{{@all:json}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "@all", Required: true, AllArgs: true}}, bp.ShellWords[2])
+		assert.Equal(t, "handler --input {{@all:json}}", bp.GetCommandFormat())
+		assert.True(t, bp.TakesAllArgs())
+	})
+
+	t.Run("leaves the field out of the generated schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"handler", "{{action}}", "{{@all:json}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Len(t, schema.Properties, 1)
+		assert.Contains(t, schema.Properties, "action")
+		assert.Equal(t, []string{"action"}, schema.Required)
+	})
+
+	t.Run("advertises the given schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"handler", "{{@all:json}}"})
+		require.NoError(t, err)
+
+		given := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"query": {Type: "string"}}}
+		bp.InputSchema = given
+		assert.Same(t, given, bp.GetInputSchema())
+	})
+
+	t.Run("templates without it don't take all arguments", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{text}}"})
+		require.NoError(t, err)
+		assert.False(t, bp.TakesAllArgs())
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "passes every argument as JSON",
+			args:     []string{"handler", "{{@all:json}}"},
+			params:   map[string]interface{}{"query": "a 'b' $(c)", "limit": float64(10), "tags": []interface{}{"x", "y"}, "nested": map[string]interface{}{"on": true}},
+			expected: []string{"handler", `{"limit":10,"nested":{"on":true},"query":"a 'b' $(c)","tags":["x","y"]}`},
+		},
+		{
+			name:     "HTML characters are not escaped",
+			args:     []string{"handler", "{{@all:json}}"},
+			params:   map[string]interface{}{"q": "<a> & <b>"},
+			expected: []string{"handler", `{"q":"<a> & <b>"}`},
+		},
+		{
+			name:     "no arguments is an empty object",
+			args:     []string{"handler", "{{@all:json}}"},
+			params:   nil,
+			expected: []string{"handler", "{}"},
+		},
+		{
+			name:     "joined with text",
+			args:     []string{"handler", "--input={{@all:json}}"},
+			params:   map[string]interface{}{"a": "b"},
+			expected: []string{"handler", `--input={"a":"b"}`},
+		},
+		{
+			name:     "alongside other fields",
+			args:     []string{"handler", "{{action}}", "[@all:json]"},
+			params:   map[string]interface{}{"action": "list", "page": float64(2)},
+			expected: []string{"handler", "list", `{"action":"list","page":2}`},
+		},
+		{
+			name:     "arguments as sent, without defaults",
+			args:     []string{"handler", "{{format=json}}", "{{@all:json}}"},
+			params:   map[string]interface{}{"id": "7"},
+			expected: []string{"handler", "json", `{"id":"7"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("shell mode quotes the JSON", func(t *testing.T) {
+		bp, err := FromArgs([]string{"handler", "{{@all:json}}"})
+		require.NoError(t, err)
+
+		script, err := bp.BuildShellCommand(map[string]interface{}{"q": "it's"})
+		require.NoError(t, err)
+		assert.Equal(t, `handler '{"q":"it'\''s"}'`, script)
+	})
+
+	t.Run("environment variables", func(t *testing.T) {
+		bp, err := FromArgs([]string{"handler"})
+		require.NoError(t, err)
+		require.NoError(t, bp.AddEnv("ARGS={{@all:json}}"))
+
+		env, err := bp.BuildEnv(map[string]interface{}{"q": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`ARGS={"q":"x"}`}, env)
+	})
+}
+
+func TestLoadInputSchema(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("reads an object schema", func(t *testing.T) {
+		path := write("schema.json", `{"type": "object", "properties": {"query": {"type": "string", "description": "What to search for"}}, "required": ["query"]}`)
+
+		schema, err := LoadInputSchema(path)
+		require.NoError(t, err)
+		assert.Equal(t, "object", schema.Type)
+		assert.Equal(t, "What to search for", schema.Properties["query"].Description)
+		assert.Equal(t, []string{"query"}, schema.Required)
+	})
+
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{"not JSON", `{"type": `, "failed to parse input schema"},
+		{"not an object", `{"type": "string"}`, "must have type object"},
+		{"no type", `{"properties": {}}`, "must have type object"},
+		{"invalid schema", `{"type": "object", "properties": {"a": {"$ref": "#/missing"}}}`, "invalid input schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadInputSchema(write(tt.name+".json", tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadInputSchema(filepath.Join(dir, "missing.json"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read input schema")
+	})
+}
+
+func TestBlueprint_CheckInputSchema(t *testing.T) {
+	schema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"repo":    {Type: "string"},
+			"dry_run": {Type: "boolean"},
+			"filter": {
+				Type:       "object",
+				Properties: map[string]*jsonschema.Schema{"state": {Type: "string"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		keepDashes    bool
+		expectedError string
+	}{
+		{name: "every field has a property", args: []string{"gh", "{{repo}}", "[--dry-run]", "{{@all:json}}"}},
+		{name: "fields repeat", args: []string{"gh", "{{repo}}", "{{repo}}"}},
+		{name: "missing field", args: []string{"gh", "{{repo}}", "[limit]"}, expectedError: "input schema has no property for the field limit"},
+		{name: "missing fields", args: []string{"gh", "[limit]", "{?--state {{state}}?}"}, expectedError: "input schema has no properties for the fields limit, state"},
+		{name: "nested properties don't count", args: []string{"gh", "{{state}}"}, expectedError: "no property for the field state"},
+		{name: "kept dashes", args: []string{"gh", "[--dry-run]"}, keepDashes: true, expectedError: "no property for the field dry-run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+			bp.InputSchema = schema
+			bp.KeepDashes = tt.keepDashes
+
+			err = bp.CheckInputSchema()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+
+	t.Run("no input schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "{{repo}}"})
+		require.NoError(t, err)
+		assert.NoError(t, bp.CheckInputSchema())
+	})
+}
@@ -0,0 +1,30 @@
+package blueprint
+
+import "strings"
+
+// upperSuffix and lowerSuffix mark a field whose value is changed to upper or
+// lower case before it is passed, as in {{env:upper}}
+const (
+	upperSuffix = ":upper"
+	lowerSuffix = ":lower"
+)
+
+// caseFields changes the values of fields that set a case to that case
+func (bp *Blueprint) caseFields(params map[string]interface{}) {
+	for _, fieldToken := range bp.fields() {
+		if fieldToken.Case == "" {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists {
+			continue
+		}
+
+		if fieldToken.Case == upperSuffix {
+			params[key] = mapStrings(params[key], strings.ToUpper)
+		} else {
+			params[key] = mapStrings(params[key], strings.ToLower)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_CaseFields(t *testing.T) {
+	t.Run("parses case fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"deploy", "{{env:upper # target environment}}", "[tags...:lower]", "{{region:trim:upper}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "env", Description: "target environment", Required: true, Case: ":upper"}}, bp.ShellWords[1])
+		assert.Equal(t, []Token{FieldToken{Name: "tags", IsArray: true, Case: ":lower"}}, bp.ShellWords[2])
+		assert.Equal(t, []Token{FieldToken{Name: "region", Required: true, Trim: true, Case: ":upper"}}, bp.ShellWords[3])
+		assert.Equal(t, "deploy {{env:upper}} [tags...:lower] {{region:trim:upper}}", bp.GetCommandFormat())
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "changes the value to upper case",
+			args:     []string{"deploy", "{{env:upper}}"},
+			params:   map[string]interface{}{"env": "prod"},
+			expected: []string{"deploy", "PROD"},
+		},
+		{
+			name:     "changes each value of an array to lower case",
+			args:     []string{"tag", "[labels...:lower]"},
+			params:   map[string]interface{}{"labels": []interface{}{"Bug", "HELP-Wanted"}},
+			expected: []string{"tag", "bug", "help-wanted"},
+		},
+		{
+			name:     "changes fields inside words",
+			args:     []string{"deploy", "--env={{env:upper}}"},
+			params:   map[string]interface{}{"env": "staging"},
+			expected: []string{"deploy", "--env=STAGING"},
+		},
+		{
+			name:     "composes with trim",
+			args:     []string{"deploy", "{{env:trim:upper}}"},
+			params:   map[string]interface{}{"env": " prod\n"},
+			expected: []string{"deploy", "PROD"},
+		},
+		{
+			name:     "leaves other fields alone",
+			args:     []string{"echo", "{{a:upper}}", "{{b}}"},
+			params:   map[string]interface{}{"a": "a", "b": "b"},
+			expected: []string{"echo", "A", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("keeps the caller's params", func(t *testing.T) {
+		bp, err := FromArgs([]string{"deploy", "{{env:upper}}"})
+		require.NoError(t, err)
+
+		params := map[string]interface{}{"env": "prod"}
+		_, err = bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, "prod", params["env"])
+	})
+
+	t.Run("leaves the schema alone", func(t *testing.T) {
+		bp, err := FromArgs([]string{"deploy", "{{env:upper # target environment}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "string", schema.Properties["env"].Type)
+		assert.Equal(t, "target environment", schema.Properties["env"].Description)
+		assert.Empty(t, schema.Properties["env"].Pattern)
+		assert.Nil(t, schema.Properties["env"].Enum)
+	})
+}
@@ -0,0 +1,86 @@
+package blueprint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultFilePrefix starts a modifier that reads a field's default from a
+// file, as in {{token:file=$TOKEN_FILE}} for secrets mounted as files
+const defaultFilePrefix = ":file="
+
+// defaultFilePath returns the path of the file a field defaults to, reading
+// it from the environment when written as $VAR or ${VAR}. It reports false
+// when the field has no default file or the variable is unset.
+func (t FieldToken) defaultFilePath() (string, bool) {
+	if t.DefaultFile == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(t.DefaultFile, "$") {
+		return t.DefaultFile, true
+	}
+	name := strings.TrimPrefix(t.DefaultFile, "$")
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+		name = name[1 : len(name)-1]
+	}
+	path, ok := os.LookupEnv(name)
+	return path, ok && path != ""
+}
+
+// readDefaultFile returns the contents of the file a field defaults to,
+// without the trailing newline most secret files end with
+func (t FieldToken) readDefaultFile() (string, bool, error) {
+	path, ok := t.defaultFilePath()
+	if !ok {
+		return "", false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("parameter '%s' was not given and its default file can't be read: %w", t.Name, err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+	return value, true, nil
+}
+
+// applyDefaultFiles fills in the contents of default files for fields that
+// were not provided. A default file that can't be read is an error rather
+// than a missing value, since it's usually a bad mount.
+func (bp *Blueprint) applyDefaultFiles(params map[string]interface{}) error {
+	for _, fieldToken := range bp.fields() {
+		if value, exists := findParamValue(params, fieldToken.Name); exists && bp.hasValue(value) {
+			continue
+		}
+
+		value, ok, err := fieldToken.readDefaultFile()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if fieldToken.IsArray {
+			params[bp.propertyName(fieldToken.Name)] = []interface{}{value}
+		} else {
+			params[bp.propertyName(fieldToken.Name)] = value
+		}
+	}
+	return nil
+}
+
+// SecretValues returns the values the command gets from default files for a
+// call with params. They are secrets the client never saw, so they are kept
+// out of logs.
+func (bp *Blueprint) SecretValues(params map[string]interface{}) []string {
+	var secrets []string
+	for _, fieldToken := range bp.fields() {
+		if value, exists := findParamValue(params, fieldToken.Name); exists && bp.hasValue(value) {
+			continue
+		}
+		if value, ok, err := fieldToken.readDefaultFile(); err == nil && ok && value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,115 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_DefaultFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cret\n"), 0600))
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		params        map[string]interface{}
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "reads the file named by the variable",
+			args:     []string{"curl", "-H", "Authorization: Bearer {{token:file=$TOKEN_FILE}}", "{{url}}"},
+			env:      map[string]string{"TOKEN_FILE": secret},
+			params:   map[string]interface{}{"url": "https://example.com"},
+			expected: []string{"curl", "-H", "Authorization: Bearer s3cret", "https://example.com"},
+		},
+		{
+			name:     "braced variable",
+			args:     []string{"login", "[token:file=${TOKEN_FILE}]"},
+			env:      map[string]string{"TOKEN_FILE": secret},
+			params:   map[string]interface{}{},
+			expected: []string{"login", "s3cret"},
+		},
+		{
+			name:     "literal path",
+			args:     []string{"login", "[token:file=" + secret + "]"},
+			params:   map[string]interface{}{},
+			expected: []string{"login", "s3cret"},
+		},
+		{
+			name:     "a value from the client wins",
+			args:     []string{"login", "{{token:file=$TOKEN_FILE}}"},
+			env:      map[string]string{"TOKEN_FILE": secret},
+			params:   map[string]interface{}{"token": "mine"},
+			expected: []string{"login", "mine"},
+		},
+		{
+			name:     "unset variable leaves the field out",
+			args:     []string{"login", "{{token:file=$STUDIO_TEST_UNSET_FILE}}"},
+			params:   map[string]interface{}{},
+			expected: []string{"login"},
+		},
+		{
+			name:          "missing file",
+			args:          []string{"login", "{{token:file=$TOKEN_FILE}}"},
+			env:           map[string]string{"TOKEN_FILE": missing},
+			params:        map[string]interface{}{},
+			expectedError: "parameter 'token' was not given and its default file can't be read",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("keeps the file out of the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"login", "{{token:file=$TOKEN_FILE # API token}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Empty(t, schema.Required)
+		assert.Equal(t, "API token", schema.Properties["token"].Description)
+		assert.Nil(t, schema.Properties["token"].Default)
+	})
+
+	t.Run("works in environment variables", func(t *testing.T) {
+		t.Setenv("TOKEN_FILE", secret)
+		bp, err := FromArgs([]string{"gh", "api", "{{endpoint}}"})
+		require.NoError(t, err)
+		require.NoError(t, bp.AddEnv("GH_TOKEN={{token:file=$TOKEN_FILE}}"))
+
+		env, err := bp.BuildEnv(map[string]interface{}{"endpoint": "user"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GH_TOKEN=s3cret"}, env)
+	})
+
+	t.Run("lists the values read as secrets", func(t *testing.T) {
+		t.Setenv("TOKEN_FILE", secret)
+		bp, err := FromArgs([]string{"login", "{{user}}", "{{token:file=$TOKEN_FILE}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"s3cret"}, bp.SecretValues(map[string]interface{}{"user": "me"}))
+		assert.Empty(t, bp.SecretValues(map[string]interface{}{"user": "me", "token": "mine"}))
+	})
+}
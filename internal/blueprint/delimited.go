@@ -0,0 +1,38 @@
+package blueprint
+
+import "strings"
+
+// csvSuffix marks an array field that also takes one string of comma separated
+// values, as in {{tags:csv}}
+const csvSuffix = ":csv"
+
+// delimiter returns the separator that splits a string given to an array
+// field into values, or "" when the field doesn't split strings. Fields
+// written name:csv split on commas, and ArrayDelimiter applies to every other
+// array field.
+func (bp *Blueprint) delimiter(fieldToken FieldToken) string {
+	if fieldToken.Delimiter != "" {
+		return fieldToken.Delimiter
+	}
+	if fieldToken.IsArray && !fieldToken.KeyValue && !fieldToken.ScalarOrArray {
+		return bp.ArrayDelimiter
+	}
+	return ""
+}
+
+// takesString reports whether an array field also takes a single string
+func (bp *Blueprint) takesString(fieldToken FieldToken) bool {
+	return fieldToken.ScalarOrArray || bp.delimiter(fieldToken) != ""
+}
+
+// splitValues splits a delimited string into values, trimming the space around
+// each one and leaving out empty values, so "a, b," is ["a", "b"]
+func splitValues(value string, delimiter string) []interface{} {
+	values := []interface{}{}
+	for _, item := range strings.Split(value, delimiter) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,117 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_Delimited(t *testing.T) {
+	t.Run("parses csv fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "issue", "list", "{{labels:csv # labels to match}}", "[repos...:csv]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "labels", Description: "labels to match", Required: true, IsArray: true, Delimiter: ","}}, bp.ShellWords[3])
+		assert.Equal(t, []Token{FieldToken{Name: "repos", IsArray: true, Delimiter: ","}}, bp.ShellWords[4])
+		assert.Equal(t, "gh issue list {{labels:csv}} [repos:csv]", bp.GetCommandFormat())
+	})
+
+	t.Run("advertises a list or a delimited string", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "issue", "list", "{{labels:csv # labels to match}}", "[repos:csv]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		labels := schema.Properties["labels"]
+		assert.Equal(t, "labels to match", labels.Description)
+		require.Len(t, labels.OneOf, 2)
+		assert.Equal(t, "string", labels.OneOf[0].Type)
+		assert.Equal(t, `Values separated by ","`, labels.OneOf[0].Description)
+		assert.Equal(t, "array", labels.OneOf[1].Type)
+		assert.Equal(t, 1, *labels.OneOf[1].MinItems)
+		assert.Equal(t, "A list of values", schema.Properties["repos"].Description)
+		assert.Equal(t, []string{"labels"}, schema.Required)
+	})
+
+	tests := []struct {
+		name          string
+		args          []string
+		delimiter     string
+		params        map[string]interface{}
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "splits a comma separated string",
+			args:     []string{"tag", "{{labels:csv}}"},
+			params:   map[string]interface{}{"labels": "bug, help wanted ,ui"},
+			expected: []string{"tag", "bug", "help wanted", "ui"},
+		},
+		{
+			name:     "keeps taking arrays",
+			args:     []string{"tag", "{{labels:csv}}"},
+			params:   map[string]interface{}{"labels": []interface{}{"bug", "a,b"}},
+			expected: []string{"tag", "bug", "a,b"},
+		},
+		{
+			name:     "leaves out empty values",
+			args:     []string{"tag", "[labels:csv]"},
+			params:   map[string]interface{}{"labels": ",bug,,"},
+			expected: []string{"tag", "bug"},
+		},
+		{
+			name:          "empty string for a required field",
+			args:          []string{"tag", "{{labels:csv}}"},
+			params:        map[string]interface{}{"labels": " , "},
+			expectedError: "parameter 'labels' needs at least 1 value(s)",
+		},
+		{
+			name:      "splits strings for every array with a delimiter",
+			args:      []string{"ls", "[paths...]", "[flags:string|array]"},
+			delimiter: ";",
+			params:    map[string]interface{}{"paths": "a b;c", "flags": "-l;-a"},
+			expected:  []string{"ls", "a b", "c", "-l;-a"},
+		},
+		{
+			name:      "keeps taking arrays with a delimiter",
+			args:      []string{"ls", "[paths...]"},
+			delimiter: ",",
+			params:    map[string]interface{}{"paths": []interface{}{"a,b", "c"}},
+			expected:  []string{"ls", "a,b", "c"},
+		},
+		{
+			name:          "item counts apply after splitting",
+			args:          []string{"cp", "{{paths...(2-)}}"},
+			delimiter:     ",",
+			params:        map[string]interface{}{"paths": "a.txt"},
+			expectedError: "parameter 'paths' needs at least 2 value(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+			bp.ArrayDelimiter = tt.delimiter
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("only changes the schema of arrays with a delimiter", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ls", "[paths...]", "{{dir}}", "[--all]"})
+		require.NoError(t, err)
+		bp.ArrayDelimiter = ","
+
+		schema := bp.GenerateInputSchema()
+		require.Len(t, schema.Properties["paths"].OneOf, 2)
+		assert.Equal(t, "string", schema.Properties["dir"].Type)
+		assert.Equal(t, "boolean", schema.Properties["all"].Type)
+	})
+}
@@ -0,0 +1,144 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Delimiters are the markers around the fields of a template, for commands
+// whose own syntax is full of braces or brackets. Markers left empty keep
+// their defaults, {{ }} for required fields and [ ] for optional ones.
+type Delimiters struct {
+	Open          string
+	Close         string
+	OptionalOpen  string
+	OptionalClose string
+}
+
+// withDefaults returns d with the markers left empty set to their defaults
+func (d Delimiters) withDefaults() Delimiters {
+	if d.Open == "" && d.Close == "" {
+		d.Open, d.Close = "{{", "}}"
+	}
+	if d.OptionalOpen == "" && d.OptionalClose == "" {
+		d.OptionalOpen, d.OptionalClose = "[", "]"
+	}
+	return d
+}
+
+// isDefault reports whether d leaves every marker at its default
+func (d Delimiters) isDefault() bool {
+	return d.withDefaults() == Delimiters{}.withDefaults()
+}
+
+// Validate checks that every marker is set with its pair, has no whitespace
+// and differs from the others
+func (d Delimiters) Validate() error {
+	if (d.Open == "") != (d.Close == "") {
+		return fmt.Errorf("template delimiters need both an open and a close marker for required fields")
+	}
+	if (d.OptionalOpen == "") != (d.OptionalClose == "") {
+		return fmt.Errorf("template delimiters need both an open and a close marker for optional fields")
+	}
+
+	d = d.withDefaults()
+	markers := []string{d.Open, d.Close, d.OptionalOpen, d.OptionalClose}
+	for i, marker := range markers {
+		if strings.ContainsAny(marker, " \t\n") {
+			return fmt.Errorf("template delimiter %q must not contain whitespace", marker)
+		}
+		for _, other := range markers[i+1:] {
+			if marker == other {
+				return fmt.Errorf("template delimiters must be distinct, got %q twice", marker)
+			}
+		}
+	}
+	return nil
+}
+
+// Translate rewrites args written with d into the default {{ }} and [ ]
+// markers so FromArgs can parse them. Braces and brackets of the defaults
+// that d replaces are escaped, so they reach the command verbatim.
+func (d Delimiters) Translate(args []string) ([]string, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	if d.isDefault() {
+		return args, nil
+	}
+
+	d = d.withDefaults()
+	var escapes []string
+	if d.Open != "{{" {
+		escapes = append(escapes, "{", `\{`, "}", `\}`)
+	}
+	if d.OptionalOpen != "[" {
+		escapes = append(escapes, "[", `\[`, "]", `\]`)
+	}
+	escaper := strings.NewReplacer(escapes...)
+
+	translated := make([]string, len(args))
+	for i, arg := range args {
+		translated[i] = d.translateWord(arg, escaper)
+	}
+	return translated, nil
+}
+
+// translateWord rewrites the fields of one shell word, escaping the text
+// around them. Optional group markers at the ends of the word are kept.
+func (d Delimiters) translateWord(word string, escaper *strings.Replacer) string {
+	var prefix, suffix string
+	if strings.HasPrefix(word, groupStart) {
+		prefix, word = groupStart, word[len(groupStart):]
+	}
+	if strings.HasSuffix(word, groupEnd) {
+		word, suffix = word[:len(word)-len(groupEnd)], groupEnd
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	text := 0
+	for i := 0; i < len(word); {
+		open, close, start, end := d.Open, d.Close, "{{", "}}"
+		if !strings.HasPrefix(word[i:], open) {
+			open, close, start, end = d.OptionalOpen, d.OptionalClose, "[", "]"
+		}
+		if !strings.HasPrefix(word[i:], open) {
+			i++
+			continue
+		}
+
+		length := closingMarker(word[i+len(open):], open, close)
+		if length == -1 {
+			i++
+			continue
+		}
+		b.WriteString(escaper.Replace(word[text:i]))
+		b.WriteString(start + word[i+len(open):i+len(open)+length] + end)
+		i += len(open) + length + len(close)
+		text = i
+	}
+	b.WriteString(escaper.Replace(word[text:]))
+	b.WriteString(suffix)
+	return b.String()
+}
+
+// closingMarker returns the index in text of the close marker that matches
+// an open marker just before it, counting nested pairs, or -1 if there is none
+func closingMarker(text, open, close string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], close):
+			if depth == 0 {
+				return i
+			}
+			depth--
+			i += len(close) - 1
+		case strings.HasPrefix(text[i:], open):
+			depth++
+			i += len(open) - 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,135 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelimiters_Translate(t *testing.T) {
+	custom := Delimiters{Open: "<<", Close: ">>", OptionalOpen: "(", OptionalClose: ")"}
+
+	tests := []struct {
+		name       string
+		delimiters Delimiters
+		args       []string
+		expected   []string
+	}{
+		{
+			name:       "default delimiters are left as is",
+			delimiters: Delimiters{},
+			args:       []string{"echo", "{{text}}", "[flag]", `\[x\]`},
+			expected:   []string{"echo", "{{text}}", "[flag]", `\[x\]`},
+		},
+		{
+			name:       "custom fields become default fields",
+			delimiters: custom,
+			args:       []string{"echo", "<<text # what to say>>", "(--loud)"},
+			expected:   []string{"echo", "{{text # what to say}}", "[--loud]"},
+		},
+		{
+			name:       "braces and brackets around fields are escaped",
+			delimiters: custom,
+			args:       []string{"jq", "{name: .<<key>>} | .[0]"},
+			expected:   []string{"jq", `\{name: .{{key}}\} | .\[0\]`},
+		},
+		{
+			name:       "only the replaced defaults are escaped",
+			delimiters: Delimiters{Open: "<<", Close: ">>"},
+			args:       []string{"awk", "{print $<<column>>}", "[file]"},
+			expected:   []string{"awk", `\{print ${{column}}\}`, "[file]"},
+		},
+		{
+			name:       "nested markers stay inside the field",
+			delimiters: custom,
+			args:       []string{"ls", "(path # a directory (optional))"},
+			expected:   []string{"ls", "[path # a directory (optional)]"},
+		},
+		{
+			name:       "unclosed markers are text",
+			delimiters: custom,
+			args:       []string{"echo", "<<text", "(x"},
+			expected:   []string{"echo", "<<text", "(x"},
+		},
+		{
+			name:       "group markers are kept",
+			delimiters: custom,
+			args:       []string{"pandoc", "{?-o", "<<output?>>?}"},
+			expected:   []string{"pandoc", "{?-o", "{{output?}}?}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translated, err := tt.delimiters.Translate(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, translated)
+		})
+	}
+}
+
+func TestDelimiters_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		delimiters  Delimiters
+		expectedErr string
+	}{
+		{name: "defaults", delimiters: Delimiters{}},
+		{name: "custom", delimiters: Delimiters{Open: "<<", Close: ">>", OptionalOpen: "(", OptionalClose: ")"}},
+		{name: "required only", delimiters: Delimiters{Open: "<%", Close: "%>"}},
+		{
+			name:        "missing close",
+			delimiters:  Delimiters{Open: "<<"},
+			expectedErr: "both an open and a close marker for required fields",
+		},
+		{
+			name:        "missing optional open",
+			delimiters:  Delimiters{OptionalClose: ")"},
+			expectedErr: "both an open and a close marker for optional fields",
+		},
+		{
+			name:        "same open and close",
+			delimiters:  Delimiters{Open: "%%", Close: "%%"},
+			expectedErr: `must be distinct, got "%%" twice`,
+		},
+		{
+			name:        "same as a default",
+			delimiters:  Delimiters{Open: "[", Close: ">>"},
+			expectedErr: `must be distinct, got "[" twice`,
+		},
+		{
+			name:        "whitespace",
+			delimiters:  Delimiters{Open: "< <", Close: ">>"},
+			expectedErr: "must not contain whitespace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.delimiters.Validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestDelimiters_EndToEnd(t *testing.T) {
+	d := Delimiters{Open: "<<", Close: ">>", OptionalOpen: "(", OptionalClose: ")"}
+	args, err := d.Translate([]string{"jq", "(--raw-output)", "{<<key # property to pick>>: .[<<index>>]}", "<<file>>"})
+	require.NoError(t, err)
+
+	bp, err := FromArgs(args)
+	require.NoError(t, err)
+
+	schema := bp.GenerateInputSchema()
+	assert.Equal(t, []string{"key", "index", "file"}, schema.Required)
+	assert.Equal(t, "property to pick", schema.Properties["key"].Description)
+
+	built, err := bp.BuildCommandArgs(map[string]interface{}{"key": "name", "index": "0", "file": "data.json", "raw_output": true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jq", "--raw-output", "{name: .[0]}", "data.json"}, built)
+}
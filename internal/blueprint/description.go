@@ -0,0 +1,84 @@
+package blueprint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// cleanDescription tidies a description that spans several lines, like one
+// written in a command file. Line endings become \n and the indentation the
+// lines after the first share is removed, so markdown in the description
+// reaches clients as written rather than as the template was indented. Single
+// line descriptions are returned as they are.
+func cleanDescription(description string) string {
+	description = strings.ReplaceAll(description, "\r\n", "\n")
+	lines := strings.Split(description, "\n")
+	if len(lines) == 1 {
+		return description
+	}
+
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || width < indent {
+			indent = width
+		}
+	}
+
+	if indent <= 0 {
+		return strings.Join(lines, "\n")
+	}
+	for i, line := range lines[1:] {
+		if len(line) < indent {
+			// Blank lines can be shorter than the indentation
+			lines[i+1] = strings.TrimLeft(line, " \t")
+			continue
+		}
+		lines[i+1] = line[indent:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckDescriptions reports every required field that has no description,
+// since LLMs have to guess what an undescribed field wants. With an
+// InputSchema its required properties are checked instead.
+func (bp *Blueprint) CheckDescriptions() error {
+	var missing []string
+	if bp.InputSchema != nil {
+		for _, name := range bp.InputSchema.Required {
+			if prop := bp.InputSchema.Properties[name]; prop == nil || strings.TrimSpace(prop.Description) == "" {
+				missing = append(missing, name)
+			}
+		}
+	} else {
+		var required []string
+		described := map[string]bool{}
+		for _, fieldToken := range bp.fields() {
+			name := bp.propertyName(fieldToken.Name)
+			if fieldToken.Required && !slices.Contains(required, name) {
+				required = append(required, name)
+			}
+			if strings.TrimSpace(fieldToken.Description) != "" {
+				described[name] = true
+			}
+		}
+		for _, name := range required {
+			if !described[name] {
+				missing = append(missing, name)
+			}
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("required field %s has no description", missing[0])
+	default:
+		return fmt.Errorf("required fields %s have no description", strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,125 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		expected    string
+	}{
+		{"single line", "The **branch** to check out", "The **branch** to check out"},
+		{"keeps newlines", "First line\nSecond line", "First line\nSecond line"},
+		{"removes shared indentation", "Search query.\n    Supports `AND`:\n    - one\n      - nested", "Search query.\nSupports `AND`:\n- one\n  - nested"},
+		{"keeps blank lines", "Intro\n\n    Details", "Intro\n\nDetails"},
+		{"normalizes CRLF", "One\r\nTwo", "One\nTwo"},
+		{"unindented lines", "One\n  - a\nTwo", "One\n  - a\nTwo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cleanDescription(tt.description))
+		})
+	}
+}
+
+func TestBlueprint_MarkdownDescriptions(t *testing.T) {
+	description := "Search query.\n\n- Use `AND` and `OR`\n- **Quotes** match a phrase\n\n## More\nSee [the docs](https://example.com/search)."
+
+	t.Run("keeps newlines and markdown in required fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"search", "{{query # " + description + "}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, description, bp.GenerateInputSchema().Properties["query"].Description)
+	})
+
+	t.Run("keeps markdown links in optional fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"search", "[limit # Most results, see [limits](https://example.com/limits)]", "[--all]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "Most results, see [limits](https://example.com/limits)", schema.Properties["limit"].Description)
+		assert.Contains(t, schema.Properties, "all")
+		assert.Equal(t, "search [limit] [--all]", bp.GetCommandFormat())
+	})
+
+	t.Run("reads indented descriptions from a command file", func(t *testing.T) {
+		words, err := ParseShellWords("search\n  '{{query # Search query.\n    - Use `AND` and `OR`\n    - **Quotes** match a phrase}}'\n")
+		require.NoError(t, err)
+		bp, err := FromArgs(words)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Search query.\n- Use `AND` and `OR`\n- **Quotes** match a phrase", bp.GenerateInputSchema().Properties["query"].Description)
+	})
+
+	t.Run("survives JSON encoding", func(t *testing.T) {
+		bp, err := FromArgs([]string{"search", "{{query # " + description + "}}"})
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(bp.GenerateInputSchema())
+		require.NoError(t, err)
+		var decoded struct {
+			Properties map[string]struct {
+				Description string `json:"description"`
+			} `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, description, decoded.Properties["query"].Description)
+	})
+}
+
+func TestBlueprint_CheckDescriptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{name: "described", args: []string{"gh", "{{repo # owner/name}}", "[limit]"}},
+		{name: "optional fields may be undescribed", args: []string{"gh", "[limit]", "[--web]", "{?--state {{state}}?}"}},
+		{name: "a default makes a field optional", args: []string{"gh", "{{state=open}}"}},
+		{name: "described anywhere", args: []string{"gh", "{{repo}}", "--repo={{repo # owner/name}}"}},
+		{name: "one undescribed", args: []string{"gh", "{{repo}}"}, expectedError: "required field repo has no description"},
+		{
+			name:          "every undescribed field is reported",
+			args:          []string{"gh", "{{repo}}", "{{title # issue title}}", "{{body-file:path}}"},
+			expectedError: "required fields repo, body_file have no description",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			err = bp.CheckDescriptions()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+
+	t.Run("checks the input schema instead", func(t *testing.T) {
+		bp, err := FromArgs([]string{"handler", "{{@all:json}}"})
+		require.NoError(t, err)
+		bp.InputSchema = &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"action": {Type: "string", Description: "What to do"},
+				"query":  {Type: "string"},
+				"limit":  {Type: "integer"},
+			},
+			Required: []string{"action", "query"},
+		}
+
+		assert.EqualError(t, bp.CheckDescriptions(), "required field query has no description")
+	})
+}
@@ -0,0 +1,81 @@
+package blueprint
+
+import (
+	"fmt"
+	"maps"
+)
+
+// eachSuffix marks an array field that runs the command once for each of its
+// values, as in {{files...:each}}
+const eachSuffix = ":each"
+
+// checkEachFields returns an error when a name:each field isn't an array or
+// more than one field is written name:each
+func (bp *Blueprint) checkEachFields() error {
+	var each string
+	for _, fieldToken := range bp.fields() {
+		if !fieldToken.Each {
+			continue
+		}
+		if !fieldToken.IsArray {
+			return fmt.Errorf("field %s runs once for each value and must be an array, like {{%s...:each}}", fieldToken.Name, fieldToken.Name)
+		}
+		if each != "" && each != fieldToken.Name {
+			return fmt.Errorf("fields %s and %s both run once for each value, only one field can", each, fieldToken.Name)
+		}
+		each = fieldToken.Name
+	}
+	return nil
+}
+
+// RunsEach reports whether a field runs the command once for each of its values
+func (bp *Blueprint) RunsEach() bool {
+	for _, fieldToken := range bp.fields() {
+		if fieldToken.Each {
+			return true
+		}
+	}
+	return false
+}
+
+// EachValues returns the key of the name:each argument in params and the values
+// the command runs once for each. The key is "" when there is no such field or
+// it has no values, and the command runs once as usual. The arguments are
+// checked as a whole first, so a bad call fails before anything runs.
+func (bp *Blueprint) EachValues(params map[string]interface{}) (string, []interface{}, error) {
+	var each *FieldToken
+	for _, fieldToken := range bp.fields() {
+		if fieldToken.Each {
+			each = &fieldToken
+			break
+		}
+	}
+	if each == nil {
+		return "", nil, nil
+	}
+
+	if _, err := bp.prepareParams(params); err != nil {
+		return "", nil, err
+	}
+
+	params = maps.Clone(params)
+	bp.wrapScalars(params)
+	key, exists := findParamKey(params, each.Name)
+	if !exists {
+		return "", nil, nil
+	}
+
+	var values []interface{}
+	switch v := params[key].(type) {
+	case []interface{}:
+		values = v
+	case []string:
+		for _, value := range v {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return "", nil, nil
+	}
+	return key, values, nil
+}
@@ -0,0 +1,132 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_Each(t *testing.T) {
+	t.Run("parses each fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"wc", "-l", "{{files...:each # files to count}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "files", Description: "files to count", Required: true, IsArray: true, Each: true}}, bp.ShellWords[2])
+		assert.Equal(t, "wc -l {{files...:each}}", bp.GetCommandFormat())
+		assert.Equal(t, "{{files:each}}", bp.ShellWords[2][0].String())
+	})
+
+	t.Run("works with csv fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ping", "-c1", "{{hosts:csv:each}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "hosts", Required: true, IsArray: true, Delimiter: ",", Each: true}}, bp.ShellWords[2])
+		assert.Equal(t, "ping -c1 {{hosts:csv:each}}", bp.GetCommandFormat())
+	})
+
+	errors := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{
+			name: "not an array",
+			args: []string{"wc", "{{file:each}}"},
+			err:  "field file runs once for each value and must be an array, like {{file...:each}}",
+		},
+		{
+			name: "two each fields",
+			args: []string{"diff", "{{left...:each}}", "{{right...:each}}"},
+			err:  "fields left and right both run once for each value, only one field can",
+		},
+	}
+
+	for _, tt := range errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromArgs(tt.args)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		params   map[string]interface{}
+		key      string
+		values   []interface{}
+		expected string
+	}{
+		{
+			name:   "array",
+			args:   []string{"wc", "-l", "{{files...:each}}"},
+			params: map[string]interface{}{"files": []interface{}{"a.txt", "b.txt"}},
+			key:    "files",
+			values: []interface{}{"a.txt", "b.txt"},
+		},
+		{
+			name:   "string slice",
+			args:   []string{"wc", "-l", "{{files...:each}}"},
+			params: map[string]interface{}{"files": []string{"a.txt", "b.txt"}},
+			key:    "files",
+			values: []interface{}{"a.txt", "b.txt"},
+		},
+		{
+			name:   "csv string",
+			args:   []string{"ping", "{{hosts:csv:each}}"},
+			params: map[string]interface{}{"hosts": "a.example, b.example"},
+			key:    "hosts",
+			values: []interface{}{"a.example", "b.example"},
+		},
+		{
+			name:   "dashed name",
+			args:   []string{"kubectl", "get", "{{pod-names...:each}}"},
+			params: map[string]interface{}{"pod_names": []interface{}{"web"}},
+			key:    "pod_names",
+			values: []interface{}{"web"},
+		},
+		{
+			name:   "no each field",
+			args:   []string{"wc", "-l", "{{files...}}"},
+			params: map[string]interface{}{"files": []interface{}{"a.txt", "b.txt"}},
+		},
+		{
+			name:   "left out",
+			args:   []string{"wc", "-l", "[files...:each]"},
+			params: map[string]interface{}{},
+		},
+		{
+			name:   "empty",
+			args:   []string{"wc", "-l", "[files...:each]"},
+			params: map[string]interface{}{"files": []interface{}{}},
+		},
+		{
+			name:     "missing required field",
+			args:     []string{"wc", "-l", "{{files...:each}}", "{{mode}}"},
+			params:   map[string]interface{}{"files": []interface{}{"a.txt"}},
+			expected: "missing required parameter: mode",
+		},
+		{
+			name:     "too many values",
+			args:     []string{"wc", "-l", "{{files...(-2):each}}"},
+			params:   map[string]interface{}{"files": []interface{}{"a", "b", "c"}},
+			expected: "parameter 'files' takes at most 2 value(s), got 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			key, values, err := bp.EachValues(tt.params)
+			if tt.expected != "" {
+				assert.EqualError(t, err, tt.expected)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.key, key)
+			assert.Equal(t, tt.values, values)
+		})
+	}
+}
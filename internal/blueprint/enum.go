@@ -0,0 +1,89 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// DescribeEnums adds the allowed values of every property with an enum to its
+// description, like "target (one of: dev, prod)", so clients that ignore enum
+// still show them. Arrays use the enum of their items. Descriptions that
+// already name every value as a whole word are left alone.
+func DescribeEnums(schema *jsonschema.Schema) {
+	for _, property := range schema.Properties {
+		enum := property.Enum
+		if len(enum) == 0 && property.Items != nil {
+			enum = property.Items.Enum
+		}
+		if len(enum) > 0 {
+			property.Description = enumDescription(property.Description, enum)
+		}
+	}
+}
+
+// enumDescription returns description followed by the values of enum, unless
+// it already mentions all of them
+func enumDescription(description string, enum []any) string {
+	values := make([]string, len(enum))
+	mentioned := true
+	for i, value := range enum {
+		values[i] = enumValue(value)
+		if !mentions(description, values[i]) {
+			mentioned = false
+		}
+	}
+	if mentioned {
+		return description
+	}
+
+	list := strings.Join(values, ", ")
+	if description == "" {
+		return "One of: " + list
+	}
+	return fmt.Sprintf("%s (one of: %s)", description, list)
+}
+
+// mentions reports whether description contains value as a whole word, so
+// "development" doesn't count as naming dev
+func mentions(description string, value string) bool {
+	if value == "" {
+		return true
+	}
+	for offset := 0; offset < len(description); {
+		i := strings.Index(description[offset:], value)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(value)
+		before, _ := utf8.DecodeLastRuneInString(description[:start])
+		after, _ := utf8.DecodeRuneInString(description[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// enumValue formats a value of an enum, strings as they are and anything else
+// as JSON
+func enumValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	formatted, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(formatted)
+}
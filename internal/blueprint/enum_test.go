@@ -0,0 +1,83 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnumDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		enum        []any
+		expected    string
+	}{
+		{
+			name:        "appends the values",
+			description: "target",
+			enum:        []any{"dev", "prod"},
+			expected:    "target (one of: dev, prod)",
+		},
+		{
+			name:     "without a description",
+			enum:     []any{"dev", "prod"},
+			expected: "One of: dev, prod",
+		},
+		{
+			name:        "already mentions every value",
+			description: "Either dev or prod",
+			enum:        []any{"dev", "prod"},
+			expected:    "Either dev or prod",
+		},
+		{
+			name:        "mentions the values only inside other words",
+			description: "development or production",
+			enum:        []any{"dev", "prod"},
+			expected:    "development or production (one of: dev, prod)",
+		},
+		{
+			name:        "mentions values next to punctuation",
+			description: "Deploy to us-east-1 (or eu-west-1).",
+			enum:        []any{"us-east-1", "eu-west-1"},
+			expected:    "Deploy to us-east-1 (or eu-west-1).",
+		},
+		{
+			name:        "mentions only some values",
+			description: "Usually dev",
+			enum:        []any{"dev", "prod"},
+			expected:    "Usually dev (one of: dev, prod)",
+		},
+		{
+			name:        "values that aren't strings",
+			description: "Retries",
+			enum:        []any{1, 2.5, true, nil},
+			expected:    "Retries (one of: 1, 2.5, true, null)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, enumDescription(tt.description, tt.enum))
+		})
+	}
+}
+
+func TestDescribeEnums(t *testing.T) {
+	schema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"env":    {Type: "string", Description: "target", Enum: []any{"dev", "prod"}},
+			"labels": {Type: "array", Items: &jsonschema.Schema{Type: "string", Enum: []any{"bug", "ui"}}},
+			"query":  {Type: "string", Description: "search terms"},
+		},
+	}
+
+	DescribeEnums(schema)
+
+	assert.Equal(t, "target (one of: dev, prod)", schema.Properties["env"].Description)
+	assert.Equal(t, "One of: bug, ui", schema.Properties["labels"].Description)
+	assert.Equal(t, "search terms", schema.Properties["query"].Description)
+	assert.Equal(t, []any{"dev", "prod"}, schema.Properties["env"].Enum)
+}
@@ -0,0 +1,57 @@
+package blueprint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EnvVar is an environment variable of the command whose value is a template,
+// like TOKEN={{token}}
+type EnvVar struct {
+	Name   string
+	Tokens []Token
+}
+
+// envNamePattern matches the names sh accepts for environment variables
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// AddEnv adds an environment variable written as NAME=template. Its fields
+// are part of the schema like any other, but their values reach the command
+// through the environment, which keeps them out of the process list.
+func (bp *Blueprint) AddEnv(assignment string) error {
+	name, value, found := strings.Cut(assignment, "=")
+	if !found || !envNamePattern.MatchString(name) {
+		return fmt.Errorf("environment variable must look like NAME={{field}}, got %q", assignment)
+	}
+
+	tokens := tokenizeShellWord(value)
+	if err := checkTokens(tokens); err != nil {
+		return fmt.Errorf("environment variable %s: %w", name, err)
+	}
+	debug("  env %s %q -> %d tokens", name, value, len(tokens))
+	bp.Env = append(bp.Env, EnvVar{Name: name, Tokens: tokens})
+	return nil
+}
+
+// BuildEnv returns NAME=value for every environment variable. Variables made
+// only of optional fields without a value are left out.
+func (bp *Blueprint) BuildEnv(params map[string]interface{}) ([]string, error) {
+	if len(bp.Env) == 0 {
+		return nil, nil
+	}
+
+	params, err := bp.prepareParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var env []string
+	for _, envVar := range bp.Env {
+		shouldInclude, words := bp.renderShellWord(envVar.Tokens, params, func(value string) string { return value })
+		if shouldInclude {
+			env = append(env, envVar.Name+"="+strings.Join(words, " "))
+		}
+	}
+	return env, nil
+}
@@ -0,0 +1,96 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_Env(t *testing.T) {
+	t.Run("adds env fields to the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "repo", "view", "{{repo}}"})
+		require.NoError(t, err)
+		require.NoError(t, bp.AddEnv("GH_TOKEN={{token # GitHub token}}"))
+		require.NoError(t, bp.AddEnv("GH_HOST=[host]"))
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "GitHub token", schema.Properties["token"].Description)
+		assert.Contains(t, schema.Properties, "host")
+		assert.Equal(t, []string{"repo", "token"}, schema.Required)
+		assert.Equal(t, []string{"repo", "token", "host"}, schema.Extra[PropertyOrdering])
+	})
+
+	t.Run("rejects invalid names", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh"})
+		require.NoError(t, err)
+
+		for _, assignment := range []string{"{{token}}", "=value", "1TOKEN={{token}}", "MY-TOKEN={{token}}"} {
+			err := bp.AddEnv(assignment)
+			require.Error(t, err, assignment)
+			assert.Contains(t, err.Error(), "environment variable must look like NAME={{field}}")
+		}
+	})
+
+	tests := []struct {
+		name          string
+		env           []string
+		params        map[string]interface{}
+		expectedEnv   []string
+		expectedError string
+	}{
+		{
+			name:        "renders fields",
+			env:         []string{"GH_TOKEN={{token}}", "AUTH=Bearer {{token}}"},
+			params:      map[string]interface{}{"repo": "cli/cli", "token": "secret"},
+			expectedEnv: []string{"GH_TOKEN=secret", "AUTH=Bearer secret"},
+		},
+		{
+			name:        "leaves out optional fields without a value",
+			env:         []string{"GH_HOST=[host]"},
+			params:      map[string]interface{}{"repo": "cli/cli"},
+			expectedEnv: nil,
+		},
+		{
+			name:        "uses defaults",
+			env:         []string{"GH_HOST=[host=github.com]"},
+			params:      map[string]interface{}{"repo": "cli/cli"},
+			expectedEnv: []string{"GH_HOST=github.com"},
+		},
+		{
+			name:        "keeps literal values",
+			env:         []string{"NO_COLOR=1"},
+			params:      map[string]interface{}{"repo": "cli/cli"},
+			expectedEnv: []string{"NO_COLOR=1"},
+		},
+		{
+			name:          "requires required fields",
+			env:           []string{"GH_TOKEN={{token}}"},
+			params:        map[string]interface{}{"repo": "cli/cli"},
+			expectedError: "missing required parameter: token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs([]string{"gh", "repo", "view", "{{repo}}"})
+			require.NoError(t, err)
+			for _, assignment := range tt.env {
+				require.NoError(t, bp.AddEnv(assignment))
+			}
+
+			env, err := bp.BuildEnv(tt.params)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedEnv, env)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"gh", "repo", "view", "cli/cli"}, args, "env values stay out of argv")
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package blueprint
+
+import "strings"
+
+// exampleSeparator separates a description from example values, as in
+// {{branch # git branch || main || release/1.0}}
+const exampleSeparator = "||"
+
+// cutExamples splits example values off the end of a field description
+func cutExamples(description string) (string, []string) {
+	parts := strings.Split(description, exampleSeparator)
+	var examples []string
+	for _, example := range parts[1:] {
+		if example = strings.TrimSpace(example); example != "" {
+			examples = append(examples, example)
+		}
+	}
+	return strings.TrimSpace(parts[0]), examples
+}
+
+// schemaExamples returns a field's examples as schema examples, or nil when
+// it has none
+func schemaExamples(fieldToken FieldToken) []any {
+	var examples []any
+	for _, example := range fieldToken.Examples {
+		examples = append(examples, example)
+	}
+	return examples
+}
@@ -0,0 +1,92 @@
+package blueprint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileSuffix marks a field whose value is a path to a file, as in {{body:@file}}.
+// The contents of the file are passed to the command instead of the path.
+const fileSuffix = ":@file"
+
+// fileDescription tells the client that a file field takes a path
+func fileDescription(description string) string {
+	if description == "" {
+		return "Path to a file whose contents are passed to the command"
+	}
+	return description + " (path to a file whose contents are passed to the command)"
+}
+
+// readFileFields replaces the path given to each file field by the contents
+// of the file
+func (bp *Blueprint) readFileFields(params map[string]interface{}) error {
+	for _, fieldToken := range bp.fields() {
+		if !fieldToken.ReadsFile {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists || !bp.hasValue(params[key]) {
+			continue
+		}
+
+		path, ok := params[key].(string)
+		if !ok {
+			return fmt.Errorf("parameter '%s' must be a file path, got %T", key, params[key])
+		}
+
+		content, err := bp.readFile(path)
+		if err != nil {
+			return fmt.Errorf("parameter '%s': %w", key, err)
+		}
+		params[key] = content
+	}
+
+	return nil
+}
+
+// readFile reads a file given to a file field. When FileRoot is set, relative
+// paths are read from it and paths that lead outside of it are refused.
+func (bp *Blueprint) readFile(path string) (string, error) {
+	if bp.FileRoot != "" {
+		resolved, err := resolveInRoot(bp.FileRoot, path)
+		if err != nil {
+			return "", err
+		}
+		path = resolved
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(content), nil
+}
+
+// resolveInRoot resolves path inside root, following symlinks, and fails if
+// the result is outside of root
+func resolveInRoot(root string, path string) (string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid file root: %w", err)
+	}
+	if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = realRoot
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	if realPath, err := filepath.EvalSymlinks(path); err == nil {
+		path = realPath
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %s is outside of %s", path, root)
+	}
+	return path, nil
+}
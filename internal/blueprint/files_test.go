@@ -0,0 +1,99 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_FileFields(t *testing.T) {
+	dir := t.TempDir()
+	bodyFile := filepath.Join(dir, "body.md")
+	require.NoError(t, os.WriteFile(bodyFile, []byte("# Release notes\n\nLots of changes.\n"), 0644))
+
+	t.Run("parses file fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "pr", "create", "--body", "{{body:@file # the PR description}}", "[notes:@file]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "body", Description: "the PR description", Required: true, ReadsFile: true}}, bp.ShellWords[4])
+		assert.Equal(t, []Token{FieldToken{Name: "notes", ReadsFile: true}}, bp.ShellWords[5])
+		assert.Equal(t, "gh pr create --body {{body:@file}} [notes:@file]", bp.GetCommandFormat())
+	})
+
+	t.Run("describes file fields in the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "{{body:@file # the PR description}}", "[notes:@file]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "string", schema.Properties["body"].Type)
+		assert.Equal(t, "the PR description (path to a file whose contents are passed to the command)", schema.Properties["body"].Description)
+		assert.Equal(t, "Path to a file whose contents are passed to the command", schema.Properties["notes"].Description)
+	})
+
+	t.Run("passes the contents of the file", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "pr", "create", "--body", "{{body:@file}}", "--title", "{{title}}"})
+		require.NoError(t, err)
+
+		params := map[string]interface{}{"body": bodyFile, "title": "Release"}
+		args, err := bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gh", "pr", "create", "--body", "# Release notes\n\nLots of changes.\n", "--title", "Release"}, args)
+		assert.Equal(t, bodyFile, params["body"], "params should be untouched")
+	})
+
+	t.Run("leaves out optional file fields without a value", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "[notes:@file]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cat"}, args)
+	})
+
+	t.Run("reports a missing file", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "{{body:@file}}"})
+		require.NoError(t, err)
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"body": filepath.Join(dir, "missing.md")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parameter 'body': failed to read file")
+	})
+
+	t.Run("reads relative paths from the file root", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "{{body:@file}}"})
+		require.NoError(t, err)
+		bp.FileRoot = dir
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"body": "body.md"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cat", "# Release notes\n\nLots of changes.\n"}, args)
+	})
+
+	t.Run("refuses files outside of the file root", func(t *testing.T) {
+		root := filepath.Join(dir, "root")
+		require.NoError(t, os.Mkdir(root, 0755))
+		require.NoError(t, os.Symlink(bodyFile, filepath.Join(root, "link.md")))
+
+		bp, err := FromArgs([]string{"cat", "{{body:@file}}"})
+		require.NoError(t, err)
+		bp.FileRoot = root
+
+		for _, path := range []string{"../body.md", bodyFile, "link.md"} {
+			_, err := bp.BuildCommandArgs(map[string]interface{}{"body": path})
+			require.Error(t, err, path)
+			assert.Contains(t, err.Error(), "is outside of", path)
+		}
+	})
+
+	t.Run("leaves other fields alone", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{path}}"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"path": bodyFile})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo", bodyFile}, args)
+	})
+}
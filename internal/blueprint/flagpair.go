@@ -0,0 +1,72 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// flagPairPrefix starts a modifier for a boolean field that passes one flag
+// when true and another when false, as in {{color:bool(--color|--no-color)}}
+const flagPairPrefix = ":bool("
+
+// cutFlagPair cuts a :bool(--on|--off) modifier from the end of a field name
+func cutFlagPair(name string) (string, string, bool) {
+	i := strings.LastIndex(name, flagPairPrefix)
+	if i == -1 || !strings.HasSuffix(name, ")") {
+		return name, "", false
+	}
+	trueFlag, falseFlag, found := strings.Cut(name[i+len(flagPairPrefix):len(name)-1], "|")
+	if !found || strings.TrimSpace(trueFlag+falseFlag) == "" {
+		return name, "", false
+	}
+	return name[:i], name[i:], true
+}
+
+// parseFlagPair returns the flags of a :bool(--on|--off) modifier. Either
+// flag can be left empty to pass nothing for that value.
+func parseFlagPair(modifier string) (string, string) {
+	flags := strings.TrimSuffix(strings.TrimPrefix(modifier, flagPairPrefix), ")")
+	trueFlag, falseFlag, _ := strings.Cut(flags, "|")
+	return strings.TrimSpace(trueFlag), strings.TrimSpace(falseFlag)
+}
+
+// flagPairDescription is the description given to flag pairs without one
+func flagPairDescription(fieldToken FieldToken) string {
+	switch {
+	case fieldToken.FalseFlag == "":
+		return fmt.Sprintf("Pass %s when true", fieldToken.TrueFlag)
+	case fieldToken.TrueFlag == "":
+		return fmt.Sprintf("Pass %s when false", fieldToken.FalseFlag)
+	default:
+		return fmt.Sprintf("Pass %s when true or %s when false", fieldToken.TrueFlag, fieldToken.FalseFlag)
+	}
+}
+
+// renderFlagPair returns the flag for the value of a flag pair field, and
+// nothing when the field was left out
+func (bp *Blueprint) renderFlagPair(fieldToken FieldToken, params map[string]interface{}) (bool, []string) {
+	value, exists := findParamValue(params, fieldToken.Name)
+	enabled, ok := value.(bool)
+	if !exists || !ok {
+		return false, nil
+	}
+
+	flag := fieldToken.FalseFlag
+	if enabled {
+		flag = fieldToken.TrueFlag
+	}
+	if flag == "" {
+		return false, nil
+	}
+	return true, []string{flag}
+}
+
+// isFlagPair reports whether the field was written with :bool(--on|--off)
+func (t FieldToken) isFlagPair() bool {
+	return t.TrueFlag != "" || t.FalseFlag != ""
+}
+
+// flagPairModifier returns the :bool(--on|--off) modifier as it was written
+func (t FieldToken) flagPairModifier() string {
+	return flagPairPrefix + t.TrueFlag + "|" + t.FalseFlag + ")"
+}
@@ -0,0 +1,87 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_FlagPairs(t *testing.T) {
+	t.Run("parses flag pairs", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ls", "{{color:bool(--color|--no-color)}}", "[all:bool(-a|) # show hidden files]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "color", TrueFlag: "--color", FalseFlag: "--no-color"}}, bp.ShellWords[1])
+		assert.Equal(t, []Token{FieldToken{Name: "all", Description: "show hidden files", TrueFlag: "-a"}}, bp.ShellWords[2])
+		assert.Equal(t, "ls [color:bool(--color|--no-color)] [all:bool(-a|)]", bp.GetCommandFormat())
+	})
+
+	t.Run("describes flag pairs as optional booleans", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ls", "{{color:bool(--color|--no-color)}}", "[quiet:bool(|--verbose)]", "[all:bool(-a|) # show hidden files]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "boolean", schema.Properties["color"].Type)
+		assert.Equal(t, "Pass --color when true or --no-color when false", schema.Properties["color"].Description)
+		assert.Equal(t, "Pass --verbose when false", schema.Properties["quiet"].Description)
+		assert.Equal(t, "show hidden files", schema.Properties["all"].Description)
+		assert.Empty(t, schema.Required)
+	})
+
+	t.Run("leaves fields without flags alone", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{text:bool(|)}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "text:bool(|)", Required: true}}, bp.ShellWords[1])
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "true",
+			args:     []string{"git", "log", "{{color:bool(--color|--no-color)}}"},
+			params:   map[string]interface{}{"color": true},
+			expected: []string{"git", "log", "--color"},
+		},
+		{
+			name:     "false",
+			args:     []string{"git", "log", "{{color:bool(--color|--no-color)}}"},
+			params:   map[string]interface{}{"color": false},
+			expected: []string{"git", "log", "--no-color"},
+		},
+		{
+			name:     "absent",
+			args:     []string{"git", "log", "{{color:bool(--color|--no-color)}}"},
+			params:   map[string]interface{}{},
+			expected: []string{"git", "log"},
+		},
+		{
+			name:     "false without a flag",
+			args:     []string{"ls", "[all:bool(-a|)]"},
+			params:   map[string]interface{}{"all": false},
+			expected: []string{"ls"},
+		},
+		{
+			name:     "true without a flag",
+			args:     []string{"rsync", "[quiet:bool(|--verbose)]"},
+			params:   map[string]interface{}{"quiet": true},
+			expected: []string{"rsync"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
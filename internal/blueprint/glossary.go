@@ -0,0 +1,55 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Glossary maps field names to the description used when a template leaves it out
+type Glossary map[string]string
+
+// LoadGlossary reads a glossary from a JSON file of field names and descriptions
+//
+//	{"repo": "The GitHub repository in owner/name form"}
+func LoadGlossary(filename string) (Glossary, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+
+	var entries map[string]string
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary %s: %w", filename, err)
+	}
+
+	// Field names are matched the same way as tool arguments
+	glossary := make(Glossary, len(entries))
+	for name, description := range entries {
+		glossary[normalizeFieldName(name)] = description
+	}
+	return glossary, nil
+}
+
+// ApplyGlossary fills in descriptions from the glossary for fields that don't
+// have one. Descriptions written in the template always win.
+func (bp *Blueprint) ApplyGlossary(glossary Glossary) {
+	bp.updateFields(func(fieldToken FieldToken) FieldToken {
+		if !needsDescription(fieldToken) {
+			return fieldToken
+		}
+		if description, ok := glossary[normalizeFieldName(fieldToken.Name)]; ok {
+			debug("Using glossary description for %s", fieldToken.Name)
+			fieldToken.Description = description
+		}
+		return fieldToken
+	})
+}
+
+// needsDescription reports whether the field has no description of its own
+func needsDescription(fieldToken FieldToken) bool {
+	if fieldToken.OriginalFlag != "" {
+		return fieldToken.Description == flagDescription(fieldToken.OriginalFlag)
+	}
+	return fieldToken.Description == ""
+}
@@ -0,0 +1,76 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_Glossary(t *testing.T) {
+	writeGlossary := func(t *testing.T, content string) string {
+		filename := filepath.Join(t.TempDir(), "glossary.json")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	t.Run("loads field descriptions from JSON", func(t *testing.T) {
+		glossary, err := LoadGlossary(writeGlossary(t, `{"repo": "owner/name of the repository", "dry-run": "only print changes"}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, Glossary{"repo": "owner/name of the repository", "dry_run": "only print changes"}, glossary)
+	})
+
+	t.Run("reports invalid JSON", func(t *testing.T) {
+		_, err := LoadGlossary(writeGlossary(t, `repo: owner/name`))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse glossary")
+	})
+
+	t.Run("reports missing files", func(t *testing.T) {
+		_, err := LoadGlossary(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read glossary")
+	})
+
+	t.Run("fills in missing descriptions", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "pr", "list", "--repo", "{{repo}}", "[--draft]", "{?--limit {{limit}}?}"})
+		require.NoError(t, err)
+
+		bp.ApplyGlossary(Glossary{
+			"repo":  "owner/name of the repository",
+			"draft": "only draft pull requests",
+			"limit": "maximum number of results",
+		})
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "owner/name of the repository", schema.Properties["repo"].Description)
+		assert.Equal(t, "only draft pull requests", schema.Properties["draft"].Description)
+		assert.Equal(t, "maximum number of results", schema.Properties["limit"].Description)
+	})
+
+	t.Run("template descriptions win over the glossary", func(t *testing.T) {
+		bp, err := FromArgs([]string{"gh", "repo", "view", "{{repo # the repo to show}}", "[--web # open in a browser]"})
+		require.NoError(t, err)
+
+		bp.ApplyGlossary(Glossary{"repo": "owner/name of the repository", "web": "from the glossary"})
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "the repo to show", schema.Properties["repo"].Description)
+		assert.Equal(t, "open in a browser", schema.Properties["web"].Description)
+	})
+
+	t.Run("matches dashed field names", func(t *testing.T) {
+		bp, err := FromArgs([]string{"deploy", "[dry-run]"})
+		require.NoError(t, err)
+
+		glossary, err := LoadGlossary(writeGlossary(t, `{"dry-run": "only print changes"}`))
+		require.NoError(t, err)
+		bp.ApplyGlossary(glossary)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "only print changes", schema.Properties["dry_run"].Description)
+	})
+}
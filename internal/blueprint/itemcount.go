@@ -0,0 +1,63 @@
+package blueprint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// itemCountPattern matches how many values an array field takes, written after
+// the ... as (2-) for at least 2, (-5) for at most 5, (2-5) for 2 to 5 or (3)
+// for exactly 3
+var itemCountPattern = regexp.MustCompile(`\.\.\.\((\d*)(-?)(\d*)\)$`)
+
+// cutItemCount cuts an item count like (2-) from the end of an array field
+// name, keeping the ...
+func cutItemCount(name string) (string, int, int, bool) {
+	match := itemCountPattern.FindStringSubmatchIndex(name)
+	if match == nil {
+		return name, 0, 0, false
+	}
+	low, dash, high := name[match[2]:match[3]], name[match[4]:match[5]], name[match[6]:match[7]]
+	if low == "" && high == "" {
+		return name, 0, 0, false
+	}
+	if dash == "" {
+		// (3) takes exactly 3
+		high = low
+	}
+
+	minItems, _ := strconv.Atoi(low)
+	maxItems, _ := strconv.Atoi(high)
+	return name[:match[0]+len("...")], minItems, maxItems, true
+}
+
+// itemCount returns the item count of an array field as written in a template,
+// or "" when it takes any number of values
+func (t FieldToken) itemCount() string {
+	switch {
+	case t.MinItems == 0 && t.MaxItems == 0:
+		return ""
+	case t.MinItems == t.MaxItems:
+		return fmt.Sprintf("(%d)", t.MinItems)
+	case t.MaxItems == 0:
+		return fmt.Sprintf("(%d-)", t.MinItems)
+	case t.MinItems == 0:
+		return fmt.Sprintf("(-%d)", t.MaxItems)
+	}
+	return fmt.Sprintf("(%d-%d)", t.MinItems, t.MaxItems)
+}
+
+// checkItemCounts returns an error for the first array field whose item count
+// can't be met
+func (bp *Blueprint) checkItemCounts() error {
+	for _, fieldToken := range bp.fields() {
+		if fieldToken.MaxItems == 0 {
+			continue
+		}
+		if fieldToken.MinItems > fieldToken.MaxItems {
+			return fmt.Errorf("field %s needs at least %d values but takes at most %d", fieldToken.Name, fieldToken.MinItems, fieldToken.MaxItems)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_ItemCount(t *testing.T) {
+	t.Run("parses item counts", func(t *testing.T) {
+		tests := []struct {
+			field       string
+			expectedMin int
+			expectedMax int
+			display     string
+		}{
+			{"[paths...(2-)]", 2, 0, "[paths...(2-)]"},
+			{"[paths...(-5)]", 0, 5, "[paths...(-5)]"},
+			{"[paths...(2-5)]", 2, 5, "[paths...(2-5)]"},
+			{"[paths...(3)]", 3, 3, "[paths...(3)]"},
+			{"{{paths...(2-) # files to copy}}", 2, 0, "{{paths...(2-)}}"},
+			{"[paths...(2-):path]", 2, 0, "[paths...(2-):path]"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.field, func(t *testing.T) {
+				bp, err := FromArgs([]string{"cp", tt.field})
+				require.NoError(t, err)
+
+				field := bp.ShellWords[1][0].(FieldToken)
+				assert.Equal(t, "paths", field.Name)
+				assert.True(t, field.IsArray)
+				assert.Equal(t, tt.expectedMin, field.MinItems)
+				assert.Equal(t, tt.expectedMax, field.MaxItems)
+				assert.Equal(t, "cp "+tt.display, bp.GetCommandFormat())
+			})
+		}
+	})
+
+	t.Run("refuses a minimum above the maximum", func(t *testing.T) {
+		_, err := FromArgs([]string{"cp", "[paths...(5-2)]"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field paths needs at least 5 values but takes at most 2")
+	})
+
+	t.Run("advertises minItems and maxItems", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cp", "{{paths...(2-)}}", "[tags...(-3)]", "[pair...(2)]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		paths := schema.Properties["paths"]
+		require.NotNil(t, paths.MinItems)
+		assert.Equal(t, 2, *paths.MinItems)
+		assert.Nil(t, paths.MaxItems)
+		assert.Equal(t, []string{"paths"}, schema.Required)
+
+		tags := schema.Properties["tags"]
+		assert.Nil(t, tags.MinItems)
+		require.NotNil(t, tags.MaxItems)
+		assert.Equal(t, 3, *tags.MaxItems)
+
+		pair := schema.Properties["pair"]
+		assert.Equal(t, 2, *pair.MinItems)
+		assert.Equal(t, 2, *pair.MaxItems)
+	})
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:          "below the minimum",
+			params:        map[string]interface{}{"paths": []interface{}{"a.txt"}},
+			expectedError: "parameter 'paths' needs at least 2 value(s)",
+		},
+		{
+			name:     "at the minimum",
+			params:   map[string]interface{}{"paths": []interface{}{"a.txt", "b.txt"}},
+			expected: []string{"cp", "a.txt", "b.txt"},
+		},
+		{
+			name:     "at the maximum",
+			params:   map[string]interface{}{"paths": []interface{}{"a.txt", "b.txt", "c.txt", "dir"}},
+			expected: []string{"cp", "a.txt", "b.txt", "c.txt", "dir"},
+		},
+		{
+			name:          "above the maximum",
+			params:        map[string]interface{}{"paths": []interface{}{"a", "b", "c", "d", "e"}},
+			expectedError: "parameter 'paths' takes at most 4 value(s), got 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs([]string{"cp", "{{paths...(2-4)}}"})
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package blueprint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// keyValueSuffix marks a field that takes an object with a key and a value
+// and passes them as one key=value argument, as in --label {{label:kv}}
+const keyValueSuffix = ":kv"
+
+// keyValueSchema returns the schema of the object a key=value field takes
+func keyValueSchema(description string) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        "object",
+		Description: description,
+		Properties: map[string]*jsonschema.Schema{
+			"key":   {Type: "string", Description: "Key, written before the ="},
+			"value": {Type: "string", Description: "Value, written after the ="},
+		},
+		Required: []string{"key", "value"},
+	}
+}
+
+// joinPairs joins the objects given to key=value fields into key=value
+// strings, so they are checked and rendered like any other value
+func (bp *Blueprint) joinPairs(params map[string]interface{}) error {
+	for _, fieldToken := range bp.fields() {
+		if !fieldToken.KeyValue {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists {
+			continue
+		}
+
+		var joined interface{}
+		switch v := params[key].(type) {
+		case string:
+			// Strings are taken as already joined, like values from _meta
+			continue
+		case []interface{}:
+			pairs := make([]interface{}, len(v))
+			for i, item := range v {
+				pair, err := joinPair(key, item)
+				if err != nil {
+					return err
+				}
+				pairs[i] = pair
+			}
+			joined = pairs
+		default:
+			pair, err := joinPair(key, v)
+			if err != nil {
+				return err
+			}
+			joined = pair
+		}
+		params[key] = joined
+	}
+	return nil
+}
+
+// joinPair joins an object with a key and a value into key=value. Strings are
+// returned as they are.
+func joinPair(name string, value interface{}) (string, error) {
+	if pair, ok := value.(string); ok {
+		return pair, nil
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("parameter '%s' must be an object with a key and a value, got %T", name, value)
+	}
+	key, keyOK := object["key"].(string)
+	val, valueOK := object["value"].(string)
+	if !keyOK || !valueOK {
+		return "", fmt.Errorf("parameter '%s' needs a string key and value", name)
+	}
+	if key == "" {
+		return "", fmt.Errorf("parameter '%s' needs a key", name)
+	}
+	// Everything after the first = belongs to the value, so the key can't have one
+	if strings.Contains(key, "=") {
+		return "", fmt.Errorf("key of parameter '%s' cannot contain =", name)
+	}
+	return key + "=" + val, nil
+}
@@ -0,0 +1,121 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_KeyValue(t *testing.T) {
+	t.Run("parses key=value fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "run", "--label", "{{label:kv # container label}}", "[envs...:kv]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "label", Description: "container label", Required: true, KeyValue: true}}, bp.ShellWords[3])
+		assert.Equal(t, []Token{FieldToken{Name: "envs", IsArray: true, KeyValue: true}}, bp.ShellWords[4])
+		assert.Equal(t, "docker run --label {{label:kv}} [envs...:kv]", bp.GetCommandFormat())
+	})
+
+	t.Run("advertises an object with a key and a value", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "run", "--label", "{{label:kv # container label}}", "[envs...:kv]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		label := schema.Properties["label"]
+		assert.Equal(t, "object", label.Type)
+		assert.Equal(t, "container label", label.Description)
+		assert.Equal(t, "string", label.Properties["key"].Type)
+		assert.Equal(t, "string", label.Properties["value"].Type)
+		assert.Equal(t, []string{"key", "value"}, label.Required)
+		assert.Equal(t, []string{"label"}, schema.Required)
+
+		envs := schema.Properties["envs"]
+		assert.Equal(t, "array", envs.Type)
+		assert.Equal(t, "object", envs.Items.Type)
+		assert.Equal(t, []string{"key", "value"}, envs.Items.Required)
+	})
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "key and value",
+			params:   map[string]interface{}{"label": map[string]interface{}{"key": "env", "value": "prod"}},
+			expected: []string{"docker", "run", "--label", "env=prod", "nginx"},
+		},
+		{
+			name:     "special characters in the value",
+			params:   map[string]interface{}{"label": map[string]interface{}{"key": "note", "value": "a=b; $(rm -rf /) 'quoted' \"double\"\n"}},
+			expected: []string{"docker", "run", "--label", "note=a=b; $(rm -rf /) 'quoted' \"double\"\n", "nginx"},
+		},
+		{
+			name:     "empty value",
+			params:   map[string]interface{}{"label": map[string]interface{}{"key": "env", "value": ""}},
+			expected: []string{"docker", "run", "--label", "env=", "nginx"},
+		},
+		{
+			name:     "array of pairs",
+			params:   map[string]interface{}{"label": map[string]interface{}{"key": "env", "value": "prod"}, "envs": []interface{}{map[string]interface{}{"key": "A", "value": "1"}, map[string]interface{}{"key": "B", "value": "2 3"}}},
+			expected: []string{"docker", "run", "--label", "env=prod", "A=1", "B=2 3", "nginx"},
+		},
+		{
+			name:          "missing key",
+			params:        map[string]interface{}{"label": map[string]interface{}{"key": "", "value": "prod"}},
+			expectedError: "parameter 'label' needs a key",
+		},
+		{
+			name:          "key with =",
+			params:        map[string]interface{}{"label": map[string]interface{}{"key": "a=b", "value": "prod"}},
+			expectedError: "key of parameter 'label' cannot contain =",
+		},
+		{
+			name:          "value that isn't a string",
+			params:        map[string]interface{}{"label": map[string]interface{}{"key": "env"}},
+			expectedError: "parameter 'label' needs a string key and value",
+		},
+		{
+			name:          "not an object",
+			params:        map[string]interface{}{"label": 42},
+			expectedError: "parameter 'label' must be an object with a key and a value, got int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs([]string{"docker", "run", "--label", "{{label:kv}}", "[envs...:kv]", "nginx"})
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("quotes the pair as one word in shell mode", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "run", "--label", "{{label:kv}}"})
+		require.NoError(t, err)
+
+		script, err := bp.BuildShellCommand(map[string]interface{}{"label": map[string]interface{}{"key": "note", "value": "it's here"}})
+		require.NoError(t, err)
+		assert.Equal(t, `docker run --label 'note=it'\''s here'`, script)
+	})
+
+	t.Run("leaves the caller's params untouched", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "run", "--label", "{{label:kv}}"})
+		require.NoError(t, err)
+
+		pair := map[string]interface{}{"key": "env", "value": "prod"}
+		params := map[string]interface{}{"label": pair}
+		_, err = bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, pair, params["label"])
+	})
+}
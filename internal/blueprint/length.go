@@ -0,0 +1,57 @@
+package blueprint
+
+import (
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// maxLengthPrefix starts a modifier limiting the length of a value in bytes,
+// as in {{text:maxlen=1000}}
+const maxLengthPrefix = ":maxlen="
+
+// maxLength returns the schema maxLength for a field, or nil when it has no
+// limit. Schemas count characters, so this only tells the client roughly how
+// long a value may be; checkLengths enforces the limit in bytes.
+func (bp *Blueprint) maxLength(fieldToken FieldToken) *int {
+	limit := fieldToken.MaxLength
+	if limit == 0 {
+		limit = bp.MaxArgLength
+	}
+	if limit == 0 {
+		return nil
+	}
+	return jsonschema.Ptr(limit)
+}
+
+// checkLengths returns an error for the first value longer than its field
+// allows. Lengths are counted in bytes since that is what the command line
+// limit counts.
+func (bp *Blueprint) checkLengths(params map[string]interface{}) error {
+	for _, fieldToken := range bp.fields() {
+		limit := fieldToken.MaxLength
+		if limit == 0 {
+			limit = bp.MaxArgLength
+		}
+		if limit == 0 || fieldToken.OriginalFlag != "" || fieldToken.isFlagPair() {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists {
+			continue
+		}
+
+		values := []string{bp.valueToString(params[key])}
+		if fieldToken.IsArray {
+			values = formatArray(params[key])
+		}
+
+		for _, value := range values {
+			if len(value) > limit {
+				return fmt.Errorf("parameter '%s' is %d bytes, longer than the limit of %d bytes", key, len(value), limit)
+			}
+		}
+	}
+	return nil
+}
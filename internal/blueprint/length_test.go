@@ -0,0 +1,116 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_MaxLength(t *testing.T) {
+	t.Run("parses length limits", func(t *testing.T) {
+		bp, err := FromArgs([]string{"say", "{{text:maxlen=100 # what to say}}", "[voices...:maxlen=10]", "[rate=200:maxlen=3]", "[note:maxlen=x]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "text", Description: "what to say", Required: true, MaxLength: 100}}, bp.ShellWords[1])
+		assert.Equal(t, []Token{FieldToken{Name: "voices", IsArray: true, MaxLength: 10}}, bp.ShellWords[2])
+		assert.Equal(t, []Token{FieldToken{Name: "rate", Default: "200", MaxLength: 3}}, bp.ShellWords[3])
+		assert.Equal(t, []Token{FieldToken{Name: "note:maxlen", Default: "x"}}, bp.ShellWords[4], "a limit that isn't a number is not a modifier")
+		assert.Equal(t, "say {{text:maxlen=100}} [voices...:maxlen=10] [rate=200:maxlen=3] [note:maxlen=x]", bp.GetCommandFormat())
+	})
+
+	t.Run("adds maxLength to the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"say", "{{text:maxlen=100}}", "[voices...:maxlen=10]", "[other]", "[--fast]"})
+		require.NoError(t, err)
+		bp.MaxArgLength = 50
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, jsonschema.Ptr(100), schema.Properties["text"].MaxLength)
+		assert.Equal(t, jsonschema.Ptr(10), schema.Properties["voices"].Items.MaxLength)
+		assert.Equal(t, jsonschema.Ptr(50), schema.Properties["other"].MaxLength)
+		assert.Nil(t, schema.Properties["fast"].MaxLength)
+	})
+
+	tests := []struct {
+		name          string
+		args          []string
+		maxArgLength  int
+		params        map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:   "value at the limit",
+			args:   []string{"echo", "{{text:maxlen=5}}"},
+			params: map[string]interface{}{"text": "hello"},
+		},
+		{
+			name:          "value over the limit",
+			args:          []string{"echo", "{{text:maxlen=5}}"},
+			params:        map[string]interface{}{"text": "hello!"},
+			expectedError: "parameter 'text' is 6 bytes, longer than the limit of 5 bytes",
+		},
+		{
+			name:          "counts bytes rather than characters",
+			args:          []string{"echo", "{{text:maxlen=5}}"},
+			params:        map[string]interface{}{"text": "héllo"},
+			expectedError: "parameter 'text' is 6 bytes",
+		},
+		{
+			name:          "checks each value of an array",
+			args:          []string{"echo", "[words...:maxlen=3]"},
+			params:        map[string]interface{}{"words": []interface{}{"one", "three"}},
+			expectedError: "parameter 'words' is 5 bytes",
+		},
+		{
+			name:          "applies the global limit",
+			args:          []string{"echo", "{{text}}", "[--verbose]"},
+			maxArgLength:  3,
+			params:        map[string]interface{}{"text": "four", "verbose": true},
+			expectedError: "parameter 'text' is 4 bytes, longer than the limit of 3 bytes",
+		},
+		{
+			name:         "a field limit overrides the global limit",
+			args:         []string{"echo", "{{text:maxlen=10}}"},
+			maxArgLength: 3,
+			params:       map[string]interface{}{"text": "four"},
+		},
+		{
+			name:          "names the field as the client sent it",
+			args:          []string{"echo", "{{long-text:maxlen=1}}"},
+			params:        map[string]interface{}{"long_text": "ab"},
+			expectedError: "parameter 'long_text' is 2 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+			bp.MaxArgLength = tt.maxArgLength
+
+			_, err = bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("checks the contents of file fields", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "body.md")
+		require.NoError(t, os.WriteFile(file, []byte(strings.Repeat("x", 20)), 0644))
+
+		bp, err := FromArgs([]string{"cat", "{{body:@file:maxlen=10}}"})
+		require.NoError(t, err)
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"body": file})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parameter 'body' is 20 bytes")
+	})
+}
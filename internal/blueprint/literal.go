@@ -0,0 +1,33 @@
+package blueprint
+
+import "strings"
+
+// literalUnescaper turns the escapes \[, \], \{ and \} back into the bracket
+// they protect, so templates can pass brackets and braces through verbatim
+var literalUnescaper = strings.NewReplacer(`\[`, "[", `\]`, "]", `\{`, "{", `\}`, "}")
+
+// unescapeLiteral removes the backslash from escaped brackets and braces
+func unescapeLiteral(text string) string {
+	return literalUnescaper.Replace(text)
+}
+
+// isEscaped reports whether the character at i is preceded by a backslash
+func isEscaped(text string, i int) bool {
+	return i > 0 && text[i-1] == '\\'
+}
+
+// indexUnescaped returns the index of the first occurrence of substr in text
+// that is not escaped with a backslash, or -1 if there is none
+func indexUnescaped(text, substr string) int {
+	offset := 0
+	for {
+		i := strings.Index(text[offset:], substr)
+		if i == -1 {
+			return -1
+		}
+		if !isEscaped(text, offset+i) {
+			return offset + i
+		}
+		offset += i + 1
+	}
+}
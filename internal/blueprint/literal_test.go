@@ -0,0 +1,83 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_LiteralEscapes(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		params         map[string]any
+		expectedArgs   []string
+		expectedFields []string
+	}{
+		{
+			name:           "escaped bracket is not an optional field",
+			args:           []string{"grep", "-E", `\[0-9]+`, "{{file}}"},
+			params:         map[string]any{"file": "log.txt"},
+			expectedArgs:   []string{"grep", "-E", "[0-9]+", "log.txt"},
+			expectedFields: []string{"file"},
+		},
+		{
+			name:           "escaped braces are not a required field",
+			args:           []string{"echo", `\{{name}}`, "{{greeting}}"},
+			params:         map[string]any{"greeting": "hi"},
+			expectedArgs:   []string{"echo", "{{name}}", "hi"},
+			expectedFields: []string{"greeting"},
+		},
+		{
+			name:           "escaped brackets mixed with a field",
+			args:           []string{"echo", `\[{{level}}\]`},
+			params:         map[string]any{"level": "info"},
+			expectedArgs:   []string{"echo", "[info]"},
+			expectedFields: []string{"level"},
+		},
+		{
+			name:         "double backslash keeps a backslash",
+			args:         []string{"grep", `\\[error\\]`},
+			params:       map[string]any{},
+			expectedArgs: []string{"grep", `\[error\]`},
+		},
+		{
+			name:         "other backslashes are untouched",
+			args:         []string{"grep", `\d+\s`},
+			params:       map[string]any{},
+			expectedArgs: []string{"grep", `\d+\s`},
+		},
+		{
+			name:           "escaped brackets inside an optional description",
+			args:           []string{"ls", `[path # like \[a-z\]*]`},
+			params:         map[string]any{"path": "src"},
+			expectedArgs:   []string{"ls", "src"},
+			expectedFields: []string{"path"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			var names []string
+			for _, field := range bp.fields() {
+				names = append(names, field.Name)
+			}
+			assert.Equal(t, tt.expectedFields, names)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+
+	t.Run("description shows escaped brackets plainly", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ls", `[path # like \[a-z\]*]`})
+		require.NoError(t, err)
+
+		assert.Equal(t, "like [a-z]*", bp.fields()[0].Description)
+	})
+}
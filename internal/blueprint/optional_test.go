@@ -0,0 +1,118 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_OptionalSuffix(t *testing.T) {
+	t.Run("a field after a literal flag stays required", func(t *testing.T) {
+		bp, err := FromArgs([]string{"pandoc", "-o", "{{output # file to write}}", "{{input}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, []string{"output", "input"}, schema.Required)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"output": "out.html", "input": "in.md"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pandoc", "-o", "out.html", "in.md"}, args)
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"input": "in.md"})
+		assert.ErrorContains(t, err, "output")
+	})
+
+	t.Run("a trailing question mark makes a field optional", func(t *testing.T) {
+		bp, err := FromArgs([]string{"pandoc", "{{input}}", "{{template? # template to use}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "template", Description: "template to use"}}, bp.ShellWords[2])
+		assert.Equal(t, "pandoc {{input}} [template]", bp.GetCommandFormat())
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, []string{"input"}, schema.Required)
+		assert.Contains(t, schema.Properties, "template")
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"input": "in.md", "template": "letter"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pandoc", "in.md", "letter"}, args)
+
+		args, err = bp.BuildCommandArgs(map[string]interface{}{"input": "in.md"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pandoc", "in.md"}, args)
+	})
+
+	t.Run("an optional field keeps its flag in a group", func(t *testing.T) {
+		bp, err := FromArgs([]string{"pandoc", "{?-o {{output?}}?}", "{{input}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, []string{"input"}, schema.Required)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"input": "in.md", "output": "out.html"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pandoc", "-o", "out.html", "in.md"}, args)
+
+		args, err = bp.BuildCommandArgs(map[string]interface{}{"input": "in.md"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pandoc", "in.md"}, args)
+	})
+
+	tests := []struct {
+		name     string
+		field    string
+		expected FieldToken
+	}{
+		{
+			name:     "plain",
+			field:    "{{output?}}",
+			expected: FieldToken{Name: "output"},
+		},
+		{
+			name:     "space before the question mark",
+			field:    "{{output ? # file to write}}",
+			expected: FieldToken{Name: "output", Description: "file to write"},
+		},
+		{
+			name:     "with a modifier",
+			field:    "{{name?:trim}}",
+			expected: FieldToken{Name: "name", Trim: true},
+		},
+		{
+			name:     "array",
+			field:    "{{files...?}}",
+			expected: FieldToken{Name: "files", IsArray: true},
+		},
+		{
+			name:     "with a default",
+			field:    "{{format?=html}}",
+			expected: FieldToken{Name: "format", Default: "html"},
+		},
+		{
+			name:     "new path modifier keeps the field required",
+			field:    "{{output:path?}}",
+			expected: FieldToken{Name: "output", Required: true, PathCheck: newPathSuffix},
+		},
+		{
+			name:     "optional new path",
+			field:    "{{output?:path?}}",
+			expected: FieldToken{Name: "output", PathCheck: newPathSuffix},
+		},
+		{
+			name:     "already optional",
+			field:    "[output?]",
+			expected: FieldToken{Name: "output"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseField(tt.field))
+		})
+	}
+
+	t.Run("a lone question mark is not a field", func(t *testing.T) {
+		assert.Nil(t, parseField("{{?}}"))
+	})
+}
@@ -3,6 +3,7 @@ package blueprint
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -33,22 +34,189 @@ func FromArgs(args []string) (*Blueprint, error) {
 
 	bp := &Blueprint{
 		BaseCommand: args[0],
-		ShellWords:  make([][]Token, len(args)),
+		ShellWords:  make([][]Token, 0, len(args)),
 	}
 
 	// Tokenize each shell word
-	for i, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		tokens := tokenizeShellWord(arg)
-		bp.ShellWords[i] = tokens
-		debug("  shellword[%d] %q -> %d tokens", i, arg, len(tokens))
+
+		// A | word of its own pipes one command into the next
+		switch {
+		case i > 0 && arg == pipeWord:
+			tokens = []Token{PipeToken{}}
+		case i > 0 && arg == escapedPipeWord:
+			tokens = []Token{TextToken{Value: pipeWord, Quoted: true}}
+		}
+
+		// Optional groups collect the shell words up to the closing ?}
+		start := i
+		if i > 0 && strings.HasPrefix(arg, groupStart) {
+			end := findGroupEnd(args, i)
+			if end == -1 {
+				return nil, &WordError{Index: i, Err: fmt.Errorf("unterminated optional group %q", arg)}
+			}
+			group, err := parseGroup(args[i : end+1])
+			if err != nil {
+				return nil, &WordError{Index: i, Err: err}
+			}
+			tokens = []Token{group}
+			arg = strings.Join(args[i:end+1], " ")
+			i = end
+		}
+
+		if err := checkTokens(tokens); err != nil {
+			return nil, &WordError{Index: start, Err: err}
+		}
+
+		bp.ShellWords = append(bp.ShellWords, tokens)
+		debug("  shellword[%d] %q -> %d tokens", len(bp.ShellWords)-1, arg, len(tokens))
 		for j, token := range tokens {
 			debug("    token[%d]: %T %q", j, token, token.String())
 		}
 	}
 
+	if err := bp.checkPipes(); err != nil {
+		return nil, fmt.Errorf("cannot create blueprint: %w", err)
+	}
+	if err := bp.checkItemCounts(); err != nil {
+		return nil, fmt.Errorf("cannot create blueprint: %w", err)
+	}
+	if err := bp.checkEachFields(); err != nil {
+		return nil, fmt.Errorf("cannot create blueprint: %w", err)
+	}
+
 	return bp, nil
 }
 
+// WordError is an error in the shell word at Index of the arguments given to
+// FromArgs, so callers can say where the word came from
+type WordError struct {
+	Index int
+	Err   error
+}
+
+func (e *WordError) Error() string {
+	return "cannot create blueprint: " + e.Err.Error()
+}
+
+func (e *WordError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	groupStart = "{?"
+	groupEnd   = "?}"
+)
+
+// optionalSuffix after a field name, like {{output?}}, makes the field optional
+const optionalSuffix = "?"
+
+// findGroupEnd returns the index of the argument that closes the optional group
+// opened at args[start], or -1 if the group is never closed
+func findGroupEnd(args []string, start int) int {
+	for i := start; i < len(args); i++ {
+		arg := args[i]
+		if i == start {
+			arg = arg[len(groupStart):]
+		}
+		if strings.HasSuffix(arg, groupEnd) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseGroup parses the arguments of an optional group such as {?--limit {{limit}}?}.
+// A group written as a single argument is split into words on whitespace, while
+// a group spread over several arguments keeps each argument as its own word.
+func parseGroup(args []string) (GroupToken, error) {
+	original := strings.Join(args, " ")
+
+	inner := append([]string{}, args...)
+	inner[0] = strings.TrimPrefix(inner[0], groupStart)
+	inner[len(inner)-1] = strings.TrimSuffix(inner[len(inner)-1], groupEnd)
+
+	var words []string
+	if len(inner) == 1 {
+		split, err := splitGroupWords(inner[0])
+		if err != nil {
+			return GroupToken{}, fmt.Errorf("invalid optional group %q: %w", original, err)
+		}
+		words = split
+	} else {
+		for _, word := range inner {
+			if word != "" {
+				words = append(words, word)
+			}
+		}
+	}
+
+	group := GroupToken{Words: make([][]Token, len(words))}
+	for i, word := range words {
+		group.Words[i] = tokenizeShellWord(word)
+	}
+
+	if len(group.Fields()) == 0 {
+		return GroupToken{}, fmt.Errorf("optional group %q must contain at least one field", original)
+	}
+
+	return group, nil
+}
+
+// splitGroupWords splits the content of an optional group into words on
+// whitespace. Templates are kept whole so descriptions can contain spaces, and
+// single or double quotes group words together like they would in a shell.
+func splitGroupWords(content string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote byte
+
+	for pos := 0; pos < len(content); pos++ {
+		c := content[pos]
+
+		if quote == 0 {
+			if match := findNextTemplate(content, pos); match != nil && match.Start == pos {
+				word.WriteString(content[match.Start:match.End])
+				inWord = true
+				pos = match.End - 1
+				continue
+			}
+		}
+
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
 // tokenizeShellWord tokenizes a single shell word into tokens
 func tokenizeShellWord(word string) []Token {
 	// Parse mixed content
@@ -60,14 +228,14 @@ func tokenizeShellWord(word string) []Token {
 		if templateStart == nil {
 			// No more templates, add remaining text
 			if pos < len(word) {
-				tokens = append(tokens, TextToken{Value: word[pos:]})
+				tokens = append(tokens, TextToken{Value: unescapeLiteral(word[pos:])})
 			}
 			break
 		}
 
 		// Add text before template
 		if templateStart.Start > pos {
-			tokens = append(tokens, TextToken{Value: word[pos:templateStart.Start]})
+			tokens = append(tokens, TextToken{Value: unescapeLiteral(word[pos:templateStart.Start])})
 		}
 
 		// Parse template
@@ -75,7 +243,7 @@ func tokenizeShellWord(word string) []Token {
 		if token := parseField(templateText); token != nil {
 			tokens = append(tokens, token)
 		} else {
-			tokens = append(tokens, TextToken{Value: templateText})
+			tokens = append(tokens, TextToken{Value: unescapeLiteral(templateText)})
 		}
 
 		pos = templateStart.End
@@ -83,7 +251,7 @@ func tokenizeShellWord(word string) []Token {
 
 	// Ensure we always return at least one token
 	if len(tokens) == 0 {
-		tokens = append(tokens, TextToken{Value: word})
+		tokens = append(tokens, TextToken{Value: unescapeLiteral(word)})
 	}
 
 	return tokens
@@ -96,12 +264,13 @@ type templateMatch struct {
 	Type  string
 }
 
-// findNextTemplate finds the next template starting from the given position
+// findNextTemplate finds the next template starting from the given position.
+// Brackets and braces escaped with a backslash never start a template.
 func findNextTemplate(word string, startPos int) *templateMatch {
 	remaining := word[startPos:]
 
-	requiredStart := strings.Index(remaining, "{{")
-	optionalStart := strings.Index(remaining, "[")
+	requiredStart := indexUnescaped(remaining, "{{")
+	optionalStart := indexUnescaped(remaining, "[")
 
 	// Find the closest template start
 	var nextStart int
@@ -131,6 +300,13 @@ func findNextTemplate(word string, startPos int) *templateMatch {
 	}
 
 	endIndex := strings.Index(remaining[nextStart:], endMarker)
+	if templateType == "optional" {
+		// Brackets in the description, like a markdown [link](url), are kept
+		// inside the field when they are balanced
+		if balanced := findClosingBracket(remaining[nextStart:]); balanced != -1 {
+			endIndex = balanced
+		}
+	}
 	if endIndex == -1 {
 		// Malformed template - treat rest as text by returning no match
 		return nil
@@ -145,6 +321,27 @@ func findNextTemplate(word string, startPos int) *templateMatch {
 	}
 }
 
+// findClosingBracket returns the index of the ] that closes the [ at the start
+// of text, or -1 if it is never closed. Escaped brackets are not counted.
+func findClosingBracket(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		if isEscaped(text, i) {
+			continue
+		}
+		switch text[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // parseField parses a field enclosed in {{ }} or [ ]
 func parseField(field string) Token {
 	var content string
@@ -175,8 +372,93 @@ func parseField(field string) Token {
 		return nil
 	}
 
+	var examples []string
 	if len(parts) > 1 {
-		description = strings.TrimSpace(parts[1])
+		description, examples = cutExamples(parts[1])
+		description = unescapeLiteral(cleanDescription(description))
+	}
+
+	// {{@all:json}} stands for the whole arguments object and takes no other modifiers
+	if name == allArgsName+allArgsSuffix {
+		return FieldToken{Name: allArgsName, Description: description, Required: required, AllArgs: true}
+	}
+
+	// {{@host}} and {{@env:NAME}} are read once, when the blueprint is made
+	if isStartupValue(name) {
+		return startupToken(field, name)
+	}
+
+	// Check for modifiers like a file field (name:@file), trimming (name:trim),
+	// a case change (name:upper) or a path check (name:path)
+	readsFile, trim, scalarOrArray, keyValue, each := false, false, false, false, false
+	var pathCheck, letterCase, delimiter, trueFlag, falseFlag, defaultFile string
+	maxLength := 0
+	for {
+		fieldName, modifier, found := cutModifier(name)
+		if !found {
+			break
+		}
+		name = strings.TrimSpace(fieldName)
+		switch modifier {
+		case fileSuffix:
+			readsFile = true
+		case trimSuffix:
+			trim = true
+		case upperSuffix, lowerSuffix:
+			letterCase = modifier
+		case scalarOrArraySuffix:
+			scalarOrArray = true
+		case csvSuffix:
+			delimiter = ","
+		case keyValueSuffix:
+			keyValue = true
+		case eachSuffix:
+			each = true
+		case pathSuffix, dirSuffix, newPathSuffix:
+			pathCheck = modifier
+		default:
+			if strings.HasPrefix(modifier, defaultFilePrefix) {
+				defaultFile = strings.TrimSpace(strings.TrimPrefix(modifier, defaultFilePrefix))
+				continue
+			}
+			if strings.HasPrefix(modifier, flagPairPrefix) {
+				trueFlag, falseFlag = parseFlagPair(modifier)
+				// Leaving out a flag pair passes neither flag, so it's never required
+				required = false
+				continue
+			}
+			maxLength, _ = strconv.Atoi(strings.TrimPrefix(modifier, maxLengthPrefix))
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	// Check for a default value (name=default or name=$ENV_VAR)
+	var defaultValue string
+	if !strings.HasPrefix(name, "-") {
+		if fieldName, value, found := strings.Cut(name, "="); found {
+			name = strings.TrimSpace(fieldName)
+			defaultValue = strings.TrimSpace(value)
+			if name == "" {
+				return nil
+			}
+		}
+	}
+
+	// A trailing ? makes a {{field?}} optional without switching to [field]
+	if strings.HasSuffix(name, optionalSuffix) {
+		name = strings.TrimSpace(strings.TrimSuffix(name, optionalSuffix))
+		required = false
+		if name == "" {
+			return nil
+		}
+	}
+
+	// Check for an item count after the array notation, like paths...(2-)
+	minItems, maxItems := 0, 0
+	if fieldName, low, high, found := cutItemCount(name); found {
+		name, minItems, maxItems = fieldName, low, high
 	}
 
 	// Check for array notation (...)
@@ -186,20 +468,72 @@ func parseField(field string) Token {
 		name = strings.TrimSpace(name)
 	}
 
+	// A field that takes a string or an array expands like an array
+	if scalarOrArray || delimiter != "" {
+		isArray = true
+	}
+
 	// Check for boolean flag (starts with - or --)
 	if !required && (strings.HasPrefix(name, "-") || strings.HasPrefix(name, "--")) {
 		originalFlag = name
 		name = strings.TrimLeft(name, "-")
 		if description == "" {
-			description = fmt.Sprintf("Enable %s flag", originalFlag)
+			description = flagDescription(originalFlag)
 		}
 	}
 
 	return FieldToken{
-		Name:         name,
-		Description:  description,
-		Required:     required,
-		IsArray:      isArray,
-		OriginalFlag: originalFlag,
+		Name:          name,
+		Description:   description,
+		Required:      required,
+		IsArray:       isArray,
+		ScalarOrArray: scalarOrArray,
+		Delimiter:     delimiter,
+		KeyValue:      keyValue,
+		Each:          each,
+		OriginalFlag:  originalFlag,
+		Default:       defaultValue,
+		DefaultFile:   defaultFile,
+		ReadsFile:     readsFile,
+		Trim:          trim,
+		Case:          letterCase,
+		PathCheck:     pathCheck,
+		MaxLength:     maxLength,
+		Examples:      examples,
+		TrueFlag:      trueFlag,
+		FalseFlag:     falseFlag,
+		MinItems:      minItems,
+		MaxItems:      maxItems,
 	}
 }
+
+// cutModifier cuts a modifier like :@file or :trim from the end of a field name
+func cutModifier(name string) (string, string, bool) {
+	for _, modifier := range []string{fileSuffix, trimSuffix, upperSuffix, lowerSuffix, pathSuffix, dirSuffix, newPathSuffix, scalarOrArraySuffix, csvSuffix, keyValueSuffix, eachSuffix} {
+		if fieldName, found := strings.CutSuffix(name, modifier); found {
+			return fieldName, modifier, true
+		}
+	}
+
+	if fieldName, modifier, found := cutFlagPair(name); found {
+		return fieldName, modifier, true
+	}
+
+	// name:file=path carries the path of its default file
+	if i := strings.LastIndex(name, defaultFilePrefix); i != -1 && i+len(defaultFilePrefix) < len(name) {
+		return name[:i], name[i:], true
+	}
+
+	// name:maxlen=N carries its limit
+	if i := strings.LastIndex(name, maxLengthPrefix); i != -1 {
+		if n, err := strconv.Atoi(name[i+len(maxLengthPrefix):]); err == nil && n > 0 {
+			return name[:i], name[i:], true
+		}
+	}
+	return name, "", false
+}
+
+// flagDescription is the description given to boolean flags without one
+func flagDescription(flag string) string {
+	return fmt.Sprintf("Enable %s flag", flag)
+}
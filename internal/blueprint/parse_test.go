@@ -127,6 +127,36 @@ func TestBlueprint_GetCommandFormat(t *testing.T) {
 			args:     []string{"echo", "[text]suffix"},
 			expected: "echo [text]suffix",
 		},
+		{
+			name:     "command with a required field with a default",
+			args:     []string{"aws", "{{region=us-east-1 # AWS region}}"},
+			expected: "aws {{region=us-east-1}}",
+		},
+		{
+			name:     "command with an optional field with a default",
+			args:     []string{"git", "log", "[ref=HEAD]"},
+			expected: "git log [ref=HEAD]",
+		},
+		{
+			name:     "command with an array with a default",
+			args:     []string{"ls", "[paths...=.]"},
+			expected: "ls [paths...=.]",
+		},
+		{
+			name:     "command with a default and modifiers",
+			args:     []string{"git", "checkout", "[branch=main:trim]"},
+			expected: "git checkout [branch=main:trim]",
+		},
+		{
+			name:     "command with a default inside a word",
+			args:     []string{"curl", "https://{{host=example.com}}/api"},
+			expected: "curl https://{{host=example.com}}/api",
+		},
+		{
+			name:     "command with an environment default",
+			args:     []string{"aws", "[region=$AWS_REGION]"},
+			expected: "aws [region]",
+		},
 		{
 			name:     "command with prefix literal",
 			args:     []string{"echo", "prefix[text]"},
@@ -253,6 +283,30 @@ func TestBlueprint_FromArgsTokenization(t *testing.T) {
 		assert.Equal(t, expected, bp.ShellWords)
 	})
 
+	t.Run("tokenizes array field without description", func(t *testing.T) {
+		bp, err := FromArgs([]string{"wc", "[files...]"})
+		require.NoError(t, err)
+
+		expected := [][]Token{
+			{TextToken{Value: "wc"}},
+			{FieldToken{Name: "files", Description: "", Required: false, IsArray: true}},
+		}
+		assert.Equal(t, expected, bp.ShellWords)
+	})
+
+	t.Run("tokenizes array field with description", func(t *testing.T) {
+		for _, arg := range []string{"[files...#paths to process]", "[files... # paths to process]"} {
+			bp, err := FromArgs([]string{"wc", arg})
+			require.NoError(t, err)
+
+			expected := [][]Token{
+				{TextToken{Value: "wc"}},
+				{FieldToken{Name: "files", Description: "paths to process", Required: false, IsArray: true}},
+			}
+			assert.Equal(t, expected, bp.ShellWords, arg)
+		}
+	})
+
 	t.Run("tokenizes command with prefix text and template", func(t *testing.T) {
 		bp, err := FromArgs([]string{"echo", "prefix{{text#desc}}"})
 		require.NoError(t, err)
@@ -367,3 +421,127 @@ func TestBlueprint_FromArgsTokenization(t *testing.T) {
 		assert.Equal(t, expected, bp.ShellWords)
 	})
 }
+
+func TestBlueprint_OptionalGroups(t *testing.T) {
+	t.Run("parses a group written as one argument", func(t *testing.T) {
+		bp, err := FromArgs([]string{"grep", "{?--max-count {{limit # max matches}}?}", "{{pattern}}"})
+		require.NoError(t, err)
+
+		require.Len(t, bp.ShellWords, 3)
+		require.Len(t, bp.ShellWords[1], 1)
+		group, ok := bp.ShellWords[1][0].(GroupToken)
+		require.True(t, ok, "expected a GroupToken")
+		require.Len(t, group.Words, 2)
+		assert.Equal(t, TextToken{Value: "--max-count"}, group.Words[0][0])
+		assert.Equal(t, "limit", group.Fields()[0].Name)
+		assert.Equal(t, "max matches", group.Fields()[0].Description)
+	})
+
+	t.Run("parses a group spread over several arguments", func(t *testing.T) {
+		bp, err := FromArgs([]string{"grep", "{?--max-count", "{{limit}}?}", "{{pattern}}"})
+		require.NoError(t, err)
+
+		require.Len(t, bp.ShellWords, 3)
+		group, ok := bp.ShellWords[1][0].(GroupToken)
+		require.True(t, ok, "expected a GroupToken")
+		require.Len(t, group.Words, 2)
+		assert.Equal(t, "grep [--max-count {{limit}}] {{pattern}}", bp.GetCommandFormat())
+	})
+
+	t.Run("keeps spaces inside arguments of a spread group", func(t *testing.T) {
+		bp, err := FromArgs([]string{"git", "commit", "{?", "--author", "{{author}} <bot@example.com>", "?}"})
+		require.NoError(t, err)
+
+		group, ok := bp.ShellWords[2][0].(GroupToken)
+		require.True(t, ok, "expected a GroupToken")
+		require.Len(t, group.Words, 2)
+		assert.Equal(t, "git commit [--author {{author}} <bot@example.com>]", bp.GetCommandFormat())
+	})
+
+	t.Run("shows groups as bracketed words in the command format", func(t *testing.T) {
+		bp, err := FromArgs([]string{"curl", "{?-H 'Authorization: Bearer {{token}}'?}", "{{url}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "curl [-H Authorization: Bearer {{token}}] {{url}}", bp.GetCommandFormat())
+	})
+
+	t.Run("rejects unterminated groups", func(t *testing.T) {
+		_, err := FromArgs([]string{"grep", "{?--max-count", "{{limit}}"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unterminated optional group")
+	})
+
+	t.Run("reports the word an error is in", func(t *testing.T) {
+		_, err := FromArgs([]string{"grep", "{{pattern}}", "{?-m {{limit}}", "file?}", "{?-la?}"})
+
+		var wordErr *WordError
+		require.ErrorAs(t, err, &wordErr)
+		assert.Equal(t, 4, wordErr.Index)
+		assert.Contains(t, err.Error(), "cannot create blueprint: optional group")
+	})
+
+	t.Run("rejects groups with unbalanced quotes", func(t *testing.T) {
+		_, err := FromArgs([]string{"curl", "{?-H 'Authorization: {{token}}?}"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unterminated quote")
+	})
+
+	t.Run("rejects groups without fields", func(t *testing.T) {
+		_, err := FromArgs([]string{"ls", "{?-la?}"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "must contain at least one field")
+	})
+
+	t.Run("does not treat the base command as a group", func(t *testing.T) {
+		bp, err := FromArgs([]string{"{?cmd?}"})
+		require.NoError(t, err)
+		assert.Equal(t, "{?cmd?}", bp.BaseCommand)
+	})
+}
+
+func TestBlueprint_FieldDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		expected FieldToken
+	}{
+		{
+			name:     "literal default",
+			field:    "[region=us-east-1]",
+			expected: FieldToken{Name: "region", Default: "us-east-1"},
+		},
+		{
+			name:     "environment default with description",
+			field:    "[region = $AWS_REGION # the AWS region]",
+			expected: FieldToken{Name: "region", Default: "$AWS_REGION", Description: "the AWS region"},
+		},
+		{
+			name:     "required field with default",
+			field:    "{{profile=default}}",
+			expected: FieldToken{Name: "profile", Default: "default", Required: true},
+		},
+		{
+			name:     "default containing equals",
+			field:    "[filter=name=web]",
+			expected: FieldToken{Name: "filter", Default: "name=web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := parseField(tt.field)
+			assert.Equal(t, tt.expected, token)
+		})
+	}
+
+	t.Run("resolves environment defaults when asked", func(t *testing.T) {
+		t.Setenv("STUDIO_TEST_REGION", "eu-west-1")
+
+		value, ok := FieldToken{Name: "region", Default: "$STUDIO_TEST_REGION"}.DefaultValue()
+		assert.True(t, ok)
+		assert.Equal(t, "eu-west-1", value)
+
+		_, ok = FieldToken{Name: "region", Default: "$STUDIO_TEST_UNSET_VARIABLE"}.DefaultValue()
+		assert.False(t, ok)
+	})
+}
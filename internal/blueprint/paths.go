@@ -0,0 +1,95 @@
+package blueprint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Modifiers for fields whose value is a path that is checked before the
+// command runs, as in {{input:path}}
+const (
+	pathSuffix    = ":path"  // the path must exist
+	dirSuffix     = ":dir"   // the path must be an existing directory
+	newPathSuffix = ":path?" // the path must not exist yet, like an output file
+)
+
+// pathDescription tells the client what kind of path a field takes
+func pathDescription(description string, check string) string {
+	var kind string
+	switch check {
+	case dirSuffix:
+		kind = "path to an existing directory"
+	case newPathSuffix:
+		kind = "path that doesn't exist yet"
+	default:
+		kind = "path to an existing file or directory"
+	}
+
+	if description == "" {
+		return "A " + kind
+	}
+	return description + " (" + kind + ")"
+}
+
+// checkPaths returns an error for the first value of a path field that
+// doesn't pass its check. Nothing is checked when SkipPathChecks is set.
+func (bp *Blueprint) checkPaths(params map[string]interface{}) error {
+	if bp.SkipPathChecks {
+		return nil
+	}
+
+	for _, fieldToken := range bp.fields() {
+		if fieldToken.PathCheck == "" {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists || !bp.hasValue(params[key]) {
+			continue
+		}
+
+		var paths []string
+		if fieldToken.IsArray {
+			paths = formatArray(params[key])
+		} else {
+			paths = []string{bp.valueToString(params[key])}
+		}
+
+		for _, path := range paths {
+			if err := checkPath(path, fieldToken.PathCheck); err != nil {
+				return fmt.Errorf("parameter '%s': %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPath checks a single path against one of the path modifiers
+func checkPath(path string, check string) error {
+	info, err := os.Stat(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot check path %s: %w", path, err)
+	}
+
+	switch check {
+	case newPathSuffix:
+		if exists {
+			return fmt.Errorf("%s already exists", path)
+		}
+	case dirSuffix:
+		if !exists {
+			return fmt.Errorf("%s does not exist", path)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+	default:
+		if !exists {
+			return fmt.Errorf("%s does not exist", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_PathFields(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0644))
+	missing := filepath.Join(dir, "missing.txt")
+
+	t.Run("parses path fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cp", "{{input:path # file to copy}}", "{{output:path?}}", "[dir:dir]", "[more...:path]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "input", Description: "file to copy", Required: true, PathCheck: pathSuffix}}, bp.ShellWords[1])
+		assert.Equal(t, []Token{FieldToken{Name: "output", Required: true, PathCheck: newPathSuffix}}, bp.ShellWords[2])
+		assert.Equal(t, []Token{FieldToken{Name: "dir", PathCheck: dirSuffix}}, bp.ShellWords[3])
+		assert.Equal(t, []Token{FieldToken{Name: "more", IsArray: true, PathCheck: pathSuffix}}, bp.ShellWords[4])
+		assert.Equal(t, "cp {{input:path}} {{output:path?}} [dir:dir] [more...:path]", bp.GetCommandFormat())
+	})
+
+	t.Run("describes path fields in the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cp", "{{input:path # file to copy}}", "{{output:path?}}", "[dir:dir]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "file to copy (path to an existing file or directory)", schema.Properties["input"].Description)
+		assert.Equal(t, "A path that doesn't exist yet", schema.Properties["output"].Description)
+		assert.Equal(t, "A path to an existing directory", schema.Properties["dir"].Description)
+	})
+
+	tests := []struct {
+		name          string
+		args          []string
+		params        map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:   "existing path",
+			args:   []string{"cat", "{{input:path}}"},
+			params: map[string]interface{}{"input": file},
+		},
+		{
+			name:          "missing path",
+			args:          []string{"cat", "{{input:path}}"},
+			params:        map[string]interface{}{"input": missing},
+			expectedError: "parameter 'input': " + missing + " does not exist",
+		},
+		{
+			name:   "directory",
+			args:   []string{"ls", "{{dir:dir}}"},
+			params: map[string]interface{}{"dir": dir},
+		},
+		{
+			name:          "file given for a directory",
+			args:          []string{"ls", "{{dir:dir}}"},
+			params:        map[string]interface{}{"dir": file},
+			expectedError: file + " is not a directory",
+		},
+		{
+			name:          "missing directory",
+			args:          []string{"ls", "{{dir:dir}}"},
+			params:        map[string]interface{}{"dir": missing},
+			expectedError: missing + " does not exist",
+		},
+		{
+			name:   "new path",
+			args:   []string{"touch", "{{output:path?}}"},
+			params: map[string]interface{}{"output": missing},
+		},
+		{
+			name:          "new path that exists",
+			args:          []string{"touch", "{{output:path?}}"},
+			params:        map[string]interface{}{"output": file},
+			expectedError: "parameter 'output': " + file + " already exists",
+		},
+		{
+			name:          "checks every value of an array",
+			args:          []string{"cat", "[files...:path]"},
+			params:        map[string]interface{}{"files": []interface{}{file, missing}},
+			expectedError: "parameter 'files': " + missing + " does not exist",
+		},
+		{
+			name:   "skips optional fields without a value",
+			args:   []string{"ls", "[dir:dir]"},
+			params: map[string]interface{}{},
+		},
+		{
+			name:   "plain fields aren't checked",
+			args:   []string{"echo", "{{text}}"},
+			params: map[string]interface{}{"text": missing},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			_, err = bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("skips checks with SkipPathChecks", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "{{input:path}}"})
+		require.NoError(t, err)
+		bp.SkipPathChecks = true
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"input": missing})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cat", missing}, args)
+	})
+}
@@ -0,0 +1,57 @@
+package blueprint
+
+import "fmt"
+
+const (
+	// pipeWord is a word of the template that pipes the output of the command
+	// before it into the command after it
+	pipeWord = "|"
+	// escapedPipeWord passes a literal | to the command instead
+	escapedPipeWord = `\|`
+)
+
+// PipeToken separates the commands of a pipeline, written as a word of its own
+type PipeToken struct{}
+
+func (t PipeToken) String() string {
+	return pipeWord
+}
+
+// isPipe reports whether a shell word is a pipe between two commands
+func isPipe(tokens []Token) bool {
+	if len(tokens) != 1 {
+		return false
+	}
+	_, ok := tokens[0].(PipeToken)
+	return ok
+}
+
+// IsPipeline reports whether the template pipes one command into another
+func (bp *Blueprint) IsPipeline() bool {
+	for _, tokens := range bp.ShellWords {
+		if isPipe(tokens) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPipes makes sure every command of a pipeline has at least a word
+func (bp *Blueprint) checkPipes() error {
+	for i, tokens := range bp.ShellWords {
+		if !isPipe(tokens) {
+			continue
+		}
+		if i == len(bp.ShellWords)-1 || isPipe(bp.ShellWords[i+1]) {
+			return fmt.Errorf("a pipe must be followed by a command, use %s to pass a literal %s", escapedPipeWord, pipeWord)
+		}
+	}
+	return nil
+}
+
+// BuildPipeline builds the command of each stage of the pipeline, in order.
+// Templates without pipes have a single stage. Values are always passed as
+// arguments, so a value of | never starts another command.
+func (bp *Blueprint) BuildPipeline(params map[string]interface{}) ([][]string, error) {
+	return bp.buildStages(params, func(value string) string { return value })
+}
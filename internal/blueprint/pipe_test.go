@@ -0,0 +1,98 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_BuildPipeline(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		params         map[string]interface{}
+		expectedStages [][]string
+		expectedScript string
+	}{
+		{
+			name:           "two commands",
+			args:           []string{"cat", "{{file}}", "|", "wc", "-l"},
+			params:         map[string]interface{}{"file": "notes.txt"},
+			expectedStages: [][]string{{"cat", "notes.txt"}, {"wc", "-l"}},
+			expectedScript: "cat notes.txt | wc -l",
+		},
+		{
+			name:           "values are never pipes",
+			args:           []string{"grep", "{{pattern}}", "{{file}}", "|", "sort", "[flags...]"},
+			params:         map[string]interface{}{"pattern": "|", "file": "| rm -rf /", "flags": []interface{}{"|", "-r"}},
+			expectedStages: [][]string{{"grep", "|", "| rm -rf /"}, {"sort", "|", "-r"}},
+			expectedScript: "grep '|' '| rm -rf /' | sort '|' -r",
+		},
+		{
+			name:           "escaped pipe is a literal argument",
+			args:           []string{"column", "-s", `\|`, "-t", "{{file}}"},
+			params:         map[string]interface{}{"file": "table.txt"},
+			expectedStages: [][]string{{"column", "-s", "|", "-t", "table.txt"}},
+			expectedScript: "column -s '|' -t table.txt",
+		},
+		{
+			name:           "pipe inside a word is text",
+			args:           []string{"grep", "-E", "a|b", "{{file}}"},
+			params:         map[string]interface{}{"file": "log"},
+			expectedStages: [][]string{{"grep", "-E", "a|b", "log"}},
+			expectedScript: "grep -E a|b log",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			stages, err := bp.BuildPipeline(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStages, stages)
+			assert.Equal(t, len(tt.expectedStages) > 1, bp.IsPipeline())
+
+			script, err := bp.BuildShellCommand(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedScript, script)
+		})
+	}
+
+	t.Run("command format shows the pipe", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "{{file}}", "|", "wc", "-l"})
+		require.NoError(t, err)
+		assert.Equal(t, "cat {{file}} | wc -l", bp.GetCommandFormat())
+
+		command, err := bp.BuildCommandArgs(map[string]interface{}{"file": "a"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cat", "a", "|", "wc", "-l"}, command)
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		bp, err := FromArgs([]string{"cat", "{{file}}", "|", "[filter]"})
+		require.NoError(t, err)
+
+		_, err = bp.BuildPipeline(map[string]interface{}{"file": "a"})
+		assert.EqualError(t, err, "command 2 of the pipeline is empty without its optional fields")
+	})
+}
+
+func TestBlueprint_PipeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "trailing pipe", args: []string{"cat", "{{file}}", "|"}},
+		{name: "two pipes in a row", args: []string{"cat", "{{file}}", "|", "|", "wc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromArgs(tt.args)
+			assert.ErrorContains(t, err, `a pipe must be followed by a command, use \| to pass a literal |`)
+		})
+	}
+}
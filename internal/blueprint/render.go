@@ -2,7 +2,10 @@ package blueprint
 
 import (
 	"fmt"
+	"maps"
 	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 )
 
 // normalizeFieldName converts field names to use underscores instead of dashes
@@ -10,77 +13,247 @@ func normalizeFieldName(name string) string {
 	return strings.ReplaceAll(name, "-", "_")
 }
 
+// propertyName returns the schema property name of a field, which has dashes
+// converted to underscores unless KeepDashes is set
+func (bp *Blueprint) propertyName(name string) string {
+	if bp.KeepDashes {
+		return name
+	}
+	return normalizeFieldName(name)
+}
+
 // findParamValue finds a parameter value by name, handling dash-underscore equivalence
 func findParamValue(params map[string]interface{}, fieldName string) (interface{}, bool) {
+	key, exists := findParamKey(params, fieldName)
+	if !exists {
+		return nil, false
+	}
+	return params[key], true
+}
+
+// findParamKey finds the key params uses for a field, handling dash-underscore equivalence
+func findParamKey(params map[string]interface{}, fieldName string) (string, bool) {
 	// Try exact match first
-	if value, exists := params[fieldName]; exists {
-		return value, true
+	if _, exists := params[fieldName]; exists {
+		return fieldName, true
 	}
 
 	// Try normalized version (dashes to underscores)
 	normalized := normalizeFieldName(fieldName)
-	if value, exists := params[normalized]; exists {
-		return value, true
+	if _, exists := params[normalized]; exists {
+		return normalized, true
 	}
 
 	// Try reverse (underscores to dashes) if original had underscores
 	if strings.Contains(fieldName, "_") {
 		dashed := strings.ReplaceAll(fieldName, "_", "-")
-		if value, exists := params[dashed]; exists {
-			return value, true
+		if _, exists := params[dashed]; exists {
+			return dashed, true
 		}
 	}
 
-	return nil, false
+	return "", false
 }
 
-// buildCommandArgsTokenized builds the actual command arguments using the tokenized approach
-func (bp *Blueprint) buildCommandArgsTokenized(params map[string]interface{}) ([]string, error) {
-	inputSchema := bp.GenerateInputSchema()
+// buildCommandArgsTokenized builds the actual command arguments using the tokenized approach.
+// Every value taken from params is passed through quote before it is added.
+func (bp *Blueprint) buildCommandArgsTokenized(params map[string]interface{}, quote func(string) string) ([]string, error) {
+	stages, err := bp.buildStages(params, quote)
+	if err != nil {
+		return nil, err
+	}
 
-	// Validate required parameters
+	// Shell scripts pipe the stages with the same | they were written with
+	result := stages[0]
+	for _, stage := range stages[1:] {
+		result = append(append(result, pipeWord), stage...)
+	}
+	return result, nil
+}
+
+// buildStages renders the words of each command of the pipeline
+func (bp *Blueprint) buildStages(params map[string]interface{}, quote func(string) string) ([][]string, error) {
+	params, err := bp.prepareParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := [][]string{}
+	result := []string{}
+
+	for _, shellWord := range bp.ShellWords {
+		if isPipe(shellWord) {
+			stages = append(stages, result)
+			result = []string{}
+			continue
+		}
+
+		// Optional groups render all of their words or none of them
+		if len(shellWord) == 1 {
+			if group, ok := shellWord[0].(GroupToken); ok {
+				result = append(result, bp.renderGroup(group, params, quote)...)
+				continue
+			}
+		}
+
+		// Check if this shell word should be included
+		shouldInclude, wordResult := bp.renderShellWord(shellWord, params, quote)
+		if shouldInclude {
+			if len(wordResult) == 0 {
+				// Empty result means skip this word
+				continue
+			}
+			result = append(result, wordResult...)
+		}
+	}
+	stages = append(stages, result)
+
+	if len(stages) > 1 {
+		for i, stage := range stages {
+			if len(stage) == 0 {
+				return nil, fmt.Errorf("command %d of the pipeline is empty without its optional fields", i+1)
+			}
+		}
+	}
+	return stages, nil
+}
+
+// MissingParameterError is returned for a call that leaves out a required field
+type MissingParameterError struct {
+	Name string
+}
+
+func (e *MissingParameterError) Error() string {
+	return fmt.Sprintf("missing required parameter: %s", e.Name)
+}
+
+// MissingParameter returns the name of the field that was left out
+func (e *MissingParameterError) MissingParameter() string {
+	return e.Name
+}
+
+// normalizeParams trims values and fills in defaults given in the template.
+// It works on a copy, so the steps change it in place and the caller's params
+// are left untouched. Nothing is read from disk.
+func (bp *Blueprint) normalizeParams(params map[string]interface{}) (map[string]interface{}, error) {
+	params = maps.Clone(params)
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	if err := bp.joinPairs(params); err != nil {
+		return nil, err
+	}
+	bp.wrapScalars(params)
+	bp.trimFields(params)
+	bp.caseFields(params)
+	bp.applyDefaults(params)
+	return params, nil
+}
+
+// checkRequired returns an error for the first required field params has no value for
+func checkRequired(inputSchema *jsonschema.Schema, params map[string]interface{}) error {
 	for _, required := range inputSchema.Required {
 		if _, exists := findParamValue(params, required); !exists {
-			return nil, fmt.Errorf("missing required parameter: %s", required)
+			return &MissingParameterError{Name: required}
 		}
 	}
+	return nil
+}
+
+// prepareParams normalizes values and fills in default files, then checks and
+// reads them the way fields ask for, before anything is rendered
+func (bp *Blueprint) prepareParams(params map[string]interface{}) (map[string]interface{}, error) {
+	inputSchema := bp.GenerateInputSchema()
+	args := params
+	params, err := bp.normalizeParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := bp.applyDefaultFiles(params); err != nil {
+		return nil, err
+	}
+
+	// Validate required parameters
+	if err := checkRequired(inputSchema, params); err != nil {
+		return nil, err
+	}
 
 	// Validate parameter types
 	for name, param := range params {
-		if schema, exists := inputSchema.Properties[normalizeFieldName(name)]; exists {
-			if schema.Type == "array" {
+		if schema, exists := inputSchema.Properties[bp.propertyName(name)]; exists {
+			if schema = arraySchema(schema); schema != nil {
 				// Check if it's an array type
+				length := 0
 				switch v := param.(type) {
 				case []string:
-					// Valid
+					length = len(v)
 				case []interface{}:
-					// Valid (from JSON)
+					// From JSON
+					length = len(v)
 				default:
 					return nil, fmt.Errorf("parameter '%s' must be an array, got %T", name, v)
 				}
+				// Required arrays need at least one value
+				if schema.MinItems != nil && length < *schema.MinItems {
+					return nil, fmt.Errorf("parameter '%s' needs at least %d value(s)", name, *schema.MinItems)
+				}
+				if schema.MaxItems != nil && length > *schema.MaxItems {
+					return nil, fmt.Errorf("parameter '%s' takes at most %d value(s), got %d", name, *schema.MaxItems, length)
+				}
+				// Refuse huge arrays before they are spread over the command line
+				if bp.MaxArrayItems > 0 && length > bp.MaxArrayItems {
+					return nil, fmt.Errorf("parameter '%s' has %d values, more than the limit of %d", name, length, bp.MaxArrayItems)
+				}
 			}
 		}
 	}
 
-	result := []string{}
+	// Check values against the rules of their tool file definitions
+	if err := bp.checkRules(params); err != nil {
+		return nil, err
+	}
 
-	for _, shellWord := range bp.ShellWords {
-		// Check if this shell word should be included
-		shouldInclude, wordResult := bp.renderShellWord(shellWord, params)
-		if shouldInclude {
-			if len(wordResult) == 0 {
-				// Empty result means skip this word
-				continue
-			}
-			result = append(result, wordResult...)
-		}
+	// Check paths given to name:path fields before anything runs
+	if err := bp.checkPaths(params); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	// Pass the contents of files given to name:@file fields instead of their paths
+	if err := bp.readFileFields(params); err != nil {
+		return nil, err
+	}
+
+	// Keep values short enough for the command line
+	if err := bp.checkLengths(params); err != nil {
+		return nil, err
+	}
+
+	return bp.addAllArgs(params, args)
+}
+
+// applyDefaults fills in defaults for fields that were not provided. Fields
+// defaulting to an unset environment variable are left out.
+func (bp *Blueprint) applyDefaults(params map[string]interface{}) {
+	for _, fieldToken := range bp.fields() {
+		if value, exists := findParamValue(params, fieldToken.Name); exists && bp.hasValue(value) {
+			continue
+		}
+
+		value, ok := fieldToken.DefaultValue()
+		if !ok {
+			continue
+		}
+
+		if fieldToken.IsArray {
+			params[bp.propertyName(fieldToken.Name)] = []interface{}{value}
+		} else {
+			params[bp.propertyName(fieldToken.Name)] = value
+		}
+	}
 }
 
 // renderShellWord renders a single shell word from its tokens
-func (bp *Blueprint) renderShellWord(tokens []Token, params map[string]interface{}) (bool, []string) {
+func (bp *Blueprint) renderShellWord(tokens []Token, params map[string]interface{}, quote func(string) string) (bool, []string) {
 	// Check if this word contains only optional fields that are not provided
 	hasRequiredContent := false
 	allOptionalFieldsEmpty := true
@@ -96,8 +269,9 @@ func (bp *Blueprint) renderShellWord(tokens []Token, params map[string]interface
 					hasRequiredContent = true
 					allOptionalFieldsEmpty = false
 				} else {
-					// Optional field - check if it has a meaningful value
-					if bp.hasValue(value) {
+					// Optional field - check if it has a meaningful value. False
+					// is meaningful for a flag pair since it picks a flag.
+					if bp.hasValue(value) || t.isFlagPair() {
 						allOptionalFieldsEmpty = false
 					}
 				}
@@ -115,15 +289,19 @@ func (bp *Blueprint) renderShellWord(tokens []Token, params map[string]interface
 	// Handle special cases for single field tokens
 	if len(tokens) == 1 {
 		if fieldToken, ok := tokens[0].(FieldToken); ok {
+			if fieldToken.isFlagPair() {
+				return bp.renderFlagPair(fieldToken, params)
+			}
+
 			inputSchema := bp.GenerateInputSchema()
 			// Check if this is an array field first (arrays take precedence)
-			if schema, exists := inputSchema.Properties[normalizeFieldName(fieldToken.Name)]; exists && schema.Type == "array" {
-				return bp.renderArrayField(fieldToken, params)
+			if schema, exists := inputSchema.Properties[bp.propertyName(fieldToken.Name)]; exists && (schema.Type == "array" || bp.takesString(fieldToken)) {
+				return bp.renderArrayField(fieldToken, params, quote)
 			}
 
 			// Then check if it's an optional field
 			if !fieldToken.Required {
-				return bp.renderSingleOptionalField(fieldToken, params)
+				return bp.renderSingleOptionalField(fieldToken, params, quote)
 			}
 		}
 	}
@@ -133,11 +311,15 @@ func (bp *Blueprint) renderShellWord(tokens []Token, params map[string]interface
 	for _, token := range tokens {
 		switch t := token.(type) {
 		case TextToken:
-			parts = append(parts, t.Value)
+			if t.Quoted {
+				parts = append(parts, quote(t.Value))
+			} else {
+				parts = append(parts, t.Value)
+			}
 		case FieldToken:
 			if value, exists := findParamValue(params, t.Name); exists {
 				if strValue := bp.valueToString(value); strValue != "" {
-					parts = append(parts, strValue)
+					parts = append(parts, quote(strValue))
 				}
 			}
 		}
@@ -150,8 +332,27 @@ func (bp *Blueprint) renderShellWord(tokens []Token, params map[string]interface
 	return false, nil
 }
 
+// renderGroup renders an optional group, which is left out unless every field
+// inside it has a value
+func (bp *Blueprint) renderGroup(group GroupToken, params map[string]interface{}, quote func(string) string) []string {
+	for _, fieldToken := range group.Fields() {
+		value, exists := findParamValue(params, fieldToken.Name)
+		if !exists || !bp.hasValue(value) {
+			return nil
+		}
+	}
+
+	result := []string{}
+	for _, tokens := range group.Words {
+		if shouldInclude, wordResult := bp.renderShellWord(tokens, params, quote); shouldInclude {
+			result = append(result, wordResult...)
+		}
+	}
+	return result
+}
+
 // renderSingleOptionalField handles rendering of a single optional field token
-func (bp *Blueprint) renderSingleOptionalField(fieldToken FieldToken, params map[string]interface{}) (bool, []string) {
+func (bp *Blueprint) renderSingleOptionalField(fieldToken FieldToken, params map[string]interface{}, quote func(string) string) (bool, []string) {
 	value, exists := findParamValue(params, fieldToken.Name)
 	if !exists {
 		return false, nil
@@ -159,7 +360,7 @@ func (bp *Blueprint) renderSingleOptionalField(fieldToken FieldToken, params map
 
 	inputSchema := bp.GenerateInputSchema()
 	// Check if this is a boolean flag
-	if schema, schemaExists := inputSchema.Properties[normalizeFieldName(fieldToken.Name)]; schemaExists && schema.Type == "boolean" {
+	if schema, schemaExists := inputSchema.Properties[bp.propertyName(fieldToken.Name)]; schemaExists && schema.Type == "boolean" {
 		if boolValue, ok := value.(bool); ok {
 			if boolValue {
 				// Use the original flag format if available, otherwise construct it
@@ -175,32 +376,25 @@ func (bp *Blueprint) renderSingleOptionalField(fieldToken FieldToken, params map
 
 	// Regular optional field
 	if strValue := bp.valueToString(value); strValue != "" {
-		return true, []string{strValue}
+		return true, []string{quote(strValue)}
 	}
 
 	return false, nil
 }
 
 // renderArrayField handles rendering of array fields
-func (bp *Blueprint) renderArrayField(fieldToken FieldToken, params map[string]interface{}) (bool, []string) {
+func (bp *Blueprint) renderArrayField(fieldToken FieldToken, params map[string]interface{}, quote func(string) string) (bool, []string) {
 	value, exists := findParamValue(params, fieldToken.Name)
 	if !exists {
 		return false, nil
 	}
 
-	if arr, ok := value.([]string); ok {
-		return len(arr) > 0, arr
-	} else if arr, ok := value.([]interface{}); ok {
-		result := make([]string, 0, len(arr))
-		for _, item := range arr {
-			if str, ok := item.(string); ok {
-				result = append(result, str)
-			}
-		}
-		return len(result) > 0, result
+	items := formatArray(value)
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = quote(item)
 	}
-
-	return false, nil
+	return len(result) > 0, result
 }
 
 // hasValue checks if a value is meaningful (not empty)
@@ -237,5 +431,47 @@ func (bp *Blueprint) valueToString(value interface{}) string {
 // BuildCommandArgs builds the actual command arguments from the template
 func (bp *Blueprint) BuildCommandArgs(params map[string]interface{}) ([]string, error) {
 	// Use the tokenized approach directly
-	return bp.buildCommandArgsTokenized(params)
+	return bp.buildCommandArgsTokenized(params, func(value string) string { return value })
+}
+
+// BuildShellCommand builds a script for sh -c from the template. Literal text in
+// the template is used as written so it can contain pipes and redirection, while
+// every value is quoted so it reaches the command as literal text, even when the
+// template puts it between quotes.
+func (bp *Blueprint) BuildShellCommand(params map[string]interface{}) (string, error) {
+	var values []string
+	words, err := bp.buildCommandArgsTokenized(params, func(value string) string {
+		values = append(values, value)
+		return valuePlaceholder(len(values) - 1)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, value := range values {
+		if strings.ContainsRune(value, 0) {
+			return "", fmt.Errorf("values can't contain NUL bytes in shell mode")
+		}
+	}
+	return quoteValues(strings.Join(words, " "), values), nil
+}
+
+// FieldValue returns the value the string field name passes to the command
+// for a call with params, after defaults and modifiers like :trim are applied.
+// It reports false when the field has no value or a required field is missing.
+// Nothing is read from disk, so the value matches the one the command got from
+// the same params, and fields whose value is the contents of a file have none.
+func (bp *Blueprint) FieldValue(params map[string]interface{}, name string) (string, bool) {
+	for _, fieldToken := range bp.fields() {
+		if fieldToken.ReadsFile && bp.propertyName(fieldToken.Name) == bp.propertyName(name) {
+			return "", false
+		}
+	}
+	normalized, err := bp.normalizeParams(params)
+	if err != nil || checkRequired(bp.GenerateInputSchema(), normalized) != nil {
+		return "", false
+	}
+	value, _ := findParamValue(normalized, name)
+	text, ok := value.(string)
+	return text, ok && text != ""
 }
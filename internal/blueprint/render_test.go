@@ -1,6 +1,10 @@
 package blueprint
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -62,6 +66,43 @@ func TestBlueprint_BuildCommandArgs(t *testing.T) {
 		assert.Equal(t, []string{"echo", "file1.txt", "file2.txt", "file3.txt"}, args)
 	})
 
+	t.Run("builds command without optional array argument", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "prefix", "[files...]", "suffix"})
+		require.NoError(t, err)
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"echo", "prefix", "suffix"}, args)
+	})
+
+	t.Run("requires a value for required array argument", func(t *testing.T) {
+		bp, err := FromArgs([]string{"wc", "{{files...}}"})
+		require.NoError(t, err)
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{})
+		assert.EqualError(t, err, "missing required parameter: files")
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"files": []interface{}{}})
+		assert.EqualError(t, err, "parameter 'files' needs at least 1 value(s)")
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"files": []interface{}{"a.txt"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"wc", "a.txt"}, args)
+	})
+
+	t.Run("limits the number of array values with MaxArrayItems", func(t *testing.T) {
+		bp, err := FromArgs([]string{"rm", "[files...]"})
+		require.NoError(t, err)
+		bp.MaxArrayItems = 2
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"files": []interface{}{"a", "b"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"rm", "a", "b"}, args)
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"files": []interface{}{"a", "b", "c"}})
+		assert.EqualError(t, err, "parameter 'files' has 3 values, more than the limit of 2")
+	})
+
 	t.Run("builds command with empty array argument", func(t *testing.T) {
 		bp, err := FromArgs([]string{"echo", "prefix", "[files...]"})
 		require.NoError(t, err)
@@ -392,6 +433,19 @@ func TestBlueprint_BuildCommandArgsTokenized(t *testing.T) {
 		assert.Equal(t, []string{"echo", "Hello World!"}, args)
 	})
 
+	t.Run("doesn't substitute into values that look like templates", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{first}}-{{second}}-[third]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{
+			"first":  "{{second}}",
+			"second": "[third]",
+			"third":  "{{first}}",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"echo", "{{second}}-[third]-{{first}}"}, args)
+	})
+
 	t.Run("builds command with optional field provided", func(t *testing.T) {
 		bp, err := FromArgs([]string{"echo", "hello", "[name]"})
 		require.NoError(t, err)
@@ -467,3 +521,389 @@ func TestBlueprint_BuildCommandArgsTokenized(t *testing.T) {
 		assert.Contains(t, err.Error(), "missing required parameter")
 	})
 }
+
+func TestBlueprint_KeepDashes(t *testing.T) {
+	args := []string{"terraform", "plan", "[--dry-run]", "[target-dir=.]", "[var-files...]", "{{plan-name}}"}
+	params := map[string]interface{}{
+		"dry-run":   true,
+		"var-files": []interface{}{"a.tfvars", "b.tfvars"},
+		"plan-name": "nightly",
+	}
+	expected := []string{"terraform", "plan", "--dry-run", ".", "a.tfvars", "b.tfvars", "nightly"}
+
+	t.Run("converts dashes to underscores by default", func(t *testing.T) {
+		bp, err := FromArgs(args)
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, []string{"dry_run", "target_dir", "var_files", "plan_name"}, schema.Extra[PropertyOrdering])
+		assert.Equal(t, []string{"plan_name"}, schema.Required)
+
+		result, err := bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("keeps dashes when KeepDashes is set", func(t *testing.T) {
+		bp, err := FromArgs(args)
+		require.NoError(t, err)
+		bp.KeepDashes = true
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, []string{"dry-run", "target-dir", "var-files", "plan-name"}, schema.Extra[PropertyOrdering])
+		assert.Equal(t, []string{"plan-name"}, schema.Required)
+		assert.Equal(t, "boolean", schema.Properties["dry-run"].Type)
+		assert.Equal(t, "array", schema.Properties["var-files"].Type)
+
+		result, err := bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("checks arrays by their dashed name", func(t *testing.T) {
+		bp, err := FromArgs(args)
+		require.NoError(t, err)
+		bp.KeepDashes = true
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"plan-name": "nightly", "var-files": "a.tfvars"})
+		assert.EqualError(t, err, "parameter 'var-files' must be an array, got string")
+	})
+}
+
+func TestBlueprint_BuildCommandArgsWithOptionalGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "includes the group when its field is present",
+			args:     []string{"grep", "{?--max-count {{limit}}?}", "{{pattern}}"},
+			params:   map[string]interface{}{"limit": "5", "pattern": "TODO"},
+			expected: []string{"grep", "--max-count", "5", "TODO"},
+		},
+		{
+			name:     "omits the group when its field is absent",
+			args:     []string{"grep", "{?--max-count {{limit}}?}", "{{pattern}}"},
+			params:   map[string]interface{}{"pattern": "TODO"},
+			expected: []string{"grep", "TODO"},
+		},
+		{
+			name:     "omits the group when its field is empty",
+			args:     []string{"grep", "{?--max-count {{limit}}?}", "{{pattern}}"},
+			params:   map[string]interface{}{"limit": "", "pattern": "TODO"},
+			expected: []string{"grep", "TODO"},
+		},
+		{
+			name:     "includes a spread group when present",
+			args:     []string{"grep", "{?--max-count", "{{limit}}?}", "{{pattern}}"},
+			params:   map[string]interface{}{"limit": "5", "pattern": "TODO"},
+			expected: []string{"grep", "--max-count", "5", "TODO"},
+		},
+		{
+			name:     "renders templates within group words",
+			args:     []string{"curl", "{?-H 'Authorization: Bearer {{token}}'?}", "{{url}}"},
+			params:   map[string]interface{}{"token": "abc", "url": "https://example.com"},
+			expected: []string{"curl", "-H", "Authorization: Bearer abc", "https://example.com"},
+		},
+		{
+			name:     "requires every field in the group",
+			args:     []string{"git", "{?--author {{name}} <{{email}}>?}"},
+			params:   map[string]interface{}{"name": "Studio"},
+			expected: []string{"git"},
+		},
+		{
+			name:     "includes the group when every field is present",
+			args:     []string{"git", "{?--author {{name}} <{{email}}>?}"},
+			params:   map[string]interface{}{"name": "Studio", "email": "studio@example.com"},
+			expected: []string{"git", "--author", "Studio", "<studio@example.com>"},
+		},
+		{
+			name:     "spreads arrays inside a group",
+			args:     []string{"rg", "{?--glob {{globs...}}?}", "{{pattern}}"},
+			params:   map[string]interface{}{"globs": []interface{}{"*.go", "*.md"}, "pattern": "x"},
+			expected: []string{"rg", "--glob", "*.go", "*.md", "x"},
+		},
+		{
+			name:     "includes the group when a boolean flag is true",
+			args:     []string{"ls", "{?[--all] [--long]?}"},
+			params:   map[string]interface{}{"all": true, "long": true},
+			expected: []string{"ls", "--all", "--long"},
+		},
+		{
+			name:     "omits the group when a boolean flag is false",
+			args:     []string{"ls", "{?[--all] [--long]?}"},
+			params:   map[string]interface{}{"all": true, "long": false},
+			expected: []string{"ls"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
+
+func TestBlueprint_BuildShellCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		params   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "keeps literal text as written",
+			args:     []string{"grep", "-r", "TODO", ".", "|", "head", "-n", "5"},
+			params:   map[string]interface{}{},
+			expected: "grep -r TODO . | head -n 5",
+		},
+		{
+			name:     "leaves simple values unquoted",
+			args:     []string{"git", "log", "{{ref}}"},
+			params:   map[string]interface{}{"ref": "main"},
+			expected: "git log main",
+		},
+		{
+			name:     "single quotes values with spaces",
+			args:     []string{"echo", "{{text}}"},
+			params:   map[string]interface{}{"text": "hello world"},
+			expected: "echo 'hello world'",
+		},
+		{
+			name:     "single quotes values with shell syntax",
+			args:     []string{"echo", "{{text}}", ">", "out.txt"},
+			params:   map[string]interface{}{"text": "$(rm -rf ~); `id`"},
+			expected: "echo '$(rm -rf ~); `id`' > out.txt",
+		},
+		{
+			name:     "escapes single quotes in values",
+			args:     []string{"echo", "{{text}}"},
+			params:   map[string]interface{}{"text": "it's"},
+			expected: `echo 'it'\''s'`,
+		},
+		{
+			name:     "quotes values within a partially templated word",
+			args:     []string{"curl", "https://example.com/{{path}}"},
+			params:   map[string]interface{}{"path": "a b"},
+			expected: "curl https://example.com/'a b'",
+		},
+		{
+			name:     "quotes each array item",
+			args:     []string{"ls", "[files...]"},
+			params:   map[string]interface{}{"files": []interface{}{"a.txt", "my file.txt"}},
+			expected: "ls a.txt 'my file.txt'",
+		},
+		{
+			name:     "uses boolean flags as written",
+			args:     []string{"ls", "[--all]", "[dir]"},
+			params:   map[string]interface{}{"all": true},
+			expected: "ls --all",
+		},
+		{
+			name:     "quotes values inside optional groups",
+			args:     []string{"grep", "{?-m {{limit}}?}", "{{pattern}}"},
+			params:   map[string]interface{}{"limit": "5", "pattern": "a|b"},
+			expected: "grep -m 5 'a|b'",
+		},
+		{
+			name:     "escapes values inside double quotes",
+			args:     []string{`echo "hi {{text}}"`},
+			params:   map[string]interface{}{"text": "$(id) `id` \\ \"x\""},
+			expected: "echo \"hi \\$(id) \\`id\\` \\\\ \\\"x\\\"\"",
+		},
+		{
+			name:     "escapes values inside single quotes",
+			args:     []string{`echo 'hi {{text}}'`},
+			params:   map[string]interface{}{"text": "it's $(id)"},
+			expected: `echo 'hi it'\''s $(id)'`,
+		},
+		{
+			name:     "quotes values inside command substitution in double quotes",
+			args:     []string{`echo "$(basename {{path}})"`},
+			params:   map[string]interface{}{"path": "a b"},
+			expected: `echo "$(basename 'a b')"`,
+		},
+		{
+			name:     "quotes values after escaped quotes",
+			args:     []string{`echo \" {{text}}`},
+			params:   map[string]interface{}{"text": "a;b"},
+			expected: `echo \" 'a;b'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			script, err := bp.BuildShellCommand(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, script)
+		})
+	}
+
+	t.Run("validates required parameters", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{text}}"})
+		require.NoError(t, err)
+
+		_, err = bp.BuildShellCommand(map[string]interface{}{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "missing required parameter: text")
+	})
+
+	t.Run("rejects NUL bytes", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{text}}"})
+		require.NoError(t, err)
+
+		_, err = bp.BuildShellCommand(map[string]interface{}{"text": "a\x00b"})
+		assert.ErrorContains(t, err, "values can't contain NUL bytes")
+	})
+
+	t.Run("passes malicious values through sh as literal text", func(t *testing.T) {
+		values := []string{"$(echo pwned)", "`echo pwned`", "'; echo pwned; '", "\"; echo pwned; \"", "\\\"$(echo pwned)", "a\nb"}
+		templates := []string{"printf %s {{text}}", `printf %s "{{text}}"`, `printf %s '{{text}}'`, `printf %s "<{{text}}>"`, `printf %s "$(printf %s {{text}})"`}
+
+		for _, template := range templates {
+			for _, value := range values {
+				bp, err := FromArgs([]string{template})
+				require.NoError(t, err)
+
+				script, err := bp.BuildShellCommand(map[string]interface{}{"text": value})
+				require.NoError(t, err)
+
+				output, err := exec.Command("sh", "-c", script).Output()
+				require.NoError(t, err, script)
+				assert.Contains(t, string(output), value, script)
+				assert.NotContains(t, strings.ReplaceAll(string(output), value, ""), "pwned", script)
+			}
+		}
+	})
+}
+
+func TestBlueprint_BuildCommandArgsWithDefaults(t *testing.T) {
+	t.Run("uses a literal default when the field is omitted", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "--region", "[region=us-east-1]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "--region", "us-east-1"}, args)
+	})
+
+	t.Run("prefers the provided value over the default", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "--region", "[region=us-east-1]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{"region": "eu-west-1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "--region", "eu-west-1"}, args)
+	})
+
+	t.Run("uses an environment variable default", func(t *testing.T) {
+		t.Setenv("STUDIO_TEST_REGION", "ap-south-1")
+		bp, err := FromArgs([]string{"aws", "--region=[region=$STUDIO_TEST_REGION]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "--region=ap-south-1"}, args)
+	})
+
+	t.Run("supports braces around the environment variable", func(t *testing.T) {
+		t.Setenv("STUDIO_TEST_REGION", "ap-south-1")
+		bp, err := FromArgs([]string{"aws", "[region=${STUDIO_TEST_REGION} # AWS region]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "ap-south-1"}, args)
+	})
+
+	t.Run("skips the argument when the environment variable is unset", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "s3", "ls", "[region=$STUDIO_TEST_UNSET_VARIABLE]"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "s3", "ls"}, args)
+	})
+
+	t.Run("skips an optional group when the environment variable is unset", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "{?--profile {{profile=$STUDIO_TEST_UNSET_VARIABLE}}?}", "s3", "ls"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "s3", "ls"}, args)
+	})
+
+	t.Run("includes an optional group filled by a default", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "{?--profile {{profile=dev}}?}", "s3", "ls"})
+		require.NoError(t, err)
+
+		args, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aws", "--profile", "dev", "s3", "ls"}, args)
+	})
+
+	t.Run("does not modify the provided params", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "[region=us-east-1]"})
+		require.NoError(t, err)
+
+		params := map[string]interface{}{}
+		_, err = bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Empty(t, params)
+	})
+}
+
+func TestBlueprint_FieldValue(t *testing.T) {
+	bp, err := FromArgs([]string{"ffmpeg", "-i", "{{input}}", "[out=out.mp4]", "[name:trim]", "[--overwrite]"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		field    string
+		expected string
+		ok       bool
+	}{
+		{"given value", map[string]interface{}{"input": "in.mov", "out": "clip.mp4"}, "out", "clip.mp4", true},
+		{"default value", map[string]interface{}{"input": "in.mov"}, "out", "out.mp4", true},
+		{"modified value", map[string]interface{}{"input": "in.mov", "name": " clip "}, "name", "clip", true},
+		{"no value", map[string]interface{}{"input": "in.mov"}, "name", "", false},
+		{"not a string", map[string]interface{}{"input": "in.mov", "overwrite": true}, "overwrite", "", false},
+		{"invalid call", map[string]interface{}{}, "out", "", false},
+	}
+
+	t.Run("reads nothing from disk", func(t *testing.T) {
+		bp, err := FromArgs([]string{"convert", "{{body:@file}}", "[out:file=$STUDIO_TEST_OUT_FILE]"})
+		require.NoError(t, err)
+		secret := filepath.Join(t.TempDir(), "out")
+		require.NoError(t, os.WriteFile(secret, []byte("secret.gif\n"), 0600))
+		t.Setenv("STUDIO_TEST_OUT_FILE", secret)
+
+		_, ok := bp.FieldValue(map[string]interface{}{"body": "notes.md"}, "body")
+		assert.False(t, ok, "the value of a file field is the contents of the file")
+		_, ok = bp.FieldValue(map[string]interface{}{"body": "notes.md"}, "out")
+		assert.False(t, ok, "a default file is not read")
+		value, ok := bp.FieldValue(map[string]interface{}{"body": "notes.md", "out": "clip.gif"}, "out")
+		assert.True(t, ok)
+		assert.Equal(t, "clip.gif", value)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := bp.FieldValue(tt.params, tt.field)
+			assert.Equal(t, tt.expected, value)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
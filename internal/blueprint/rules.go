@@ -0,0 +1,70 @@
+package blueprint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// hasRules reports whether the field limits its values with a pattern, an
+// enum or a minimum length
+func (t FieldToken) hasRules() bool {
+	return t.Pattern != "" || len(t.Enum) > 0 || t.MinLength > 0
+}
+
+// addRules adds the pattern, enum and minimum length of a field to the schema
+// of one of its values
+func addRules(schema *jsonschema.Schema, fieldToken FieldToken) *jsonschema.Schema {
+	schema.Pattern = fieldToken.Pattern
+	if fieldToken.MinLength > 0 {
+		schema.MinLength = jsonschema.Ptr(fieldToken.MinLength)
+	}
+	for _, value := range fieldToken.Enum {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+// checkRules returns an error for the first value that doesn't match its
+// field's pattern, isn't one of its enum values or is shorter than its
+// minimum length in bytes. Patterns match anywhere in the value unless they
+// are anchored with ^ and $.
+func (bp *Blueprint) checkRules(params map[string]interface{}) error {
+	for _, fieldToken := range bp.fields() {
+		if !fieldToken.hasRules() {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists {
+			continue
+		}
+
+		values := []string{bp.valueToString(params[key])}
+		if fieldToken.IsArray {
+			values = formatArray(params[key])
+		}
+
+		var pattern *regexp.Regexp
+		if fieldToken.Pattern != "" {
+			// The pattern was checked when the field was defined
+			pattern = regexp.MustCompile(fieldToken.Pattern)
+		}
+
+		for _, value := range values {
+			if len(fieldToken.Enum) > 0 && !slices.Contains(fieldToken.Enum, value) {
+				return fmt.Errorf("parameter '%s' must be one of %s, got %q", key, strings.Join(fieldToken.Enum, ", "), value)
+			}
+			if pattern != nil && !pattern.MatchString(value) {
+				return fmt.Errorf("parameter '%s' must match %s, got %q", key, fieldToken.Pattern, value)
+			}
+			if len(value) < fieldToken.MinLength {
+				return fmt.Errorf("parameter '%s' is %d bytes, shorter than the minimum of %d bytes", key, len(value), fieldToken.MinLength)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package blueprint
+
+import (
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// scalarOrArraySuffix marks an array field that also takes a single string,
+// as in {{tags:string|array}}. A string is passed as one argument.
+const scalarOrArraySuffix = ":string|array"
+
+// scalarOrArraySchema returns the schema of a field that takes either a
+// string or an array of strings
+func (bp *Blueprint) scalarOrArraySchema(fieldToken FieldToken, array *jsonschema.Schema) *jsonschema.Schema {
+	delimiter := bp.delimiter(fieldToken)
+	description := fieldToken.Description
+	if description == "" {
+		description = "One value or a list of values"
+		if delimiter != "" {
+			description = "A list of values"
+		}
+	}
+	if fieldToken.PathCheck != "" {
+		description = pathDescription(fieldToken.Description, fieldToken.PathCheck)
+	}
+
+	// A delimited string holds several values, so the limit of one doesn't fit it
+	scalar := addRules(&jsonschema.Schema{Type: "string", MaxLength: bp.maxLength(fieldToken), Examples: schemaExamples(fieldToken)}, fieldToken)
+	if delimiter != "" {
+		scalar = &jsonschema.Schema{Type: "string", Description: fmt.Sprintf("Values separated by %q", delimiter)}
+	}
+
+	array.Description = ""
+	return &jsonschema.Schema{
+		Description: description,
+		OneOf:       []*jsonschema.Schema{scalar, array},
+	}
+}
+
+// arraySchema returns schema when it is an array, or its array choice when it
+// takes a string or an array. Otherwise it returns nil.
+func arraySchema(schema *jsonschema.Schema) *jsonschema.Schema {
+	if schema.Type == "array" {
+		return schema
+	}
+	for _, choice := range schema.OneOf {
+		if choice.Type == "array" {
+			return choice
+		}
+	}
+	return nil
+}
+
+// wrapScalars wraps single strings given to scalar-or-array fields in an
+// array, so they expand like any other array. Strings given to delimited
+// fields are split into their values instead.
+func (bp *Blueprint) wrapScalars(params map[string]interface{}) {
+	for _, fieldToken := range bp.fields() {
+		if !bp.takesString(fieldToken) {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists {
+			continue
+		}
+		value, ok := params[key].(string)
+		if !ok {
+			continue
+		}
+
+		if delimiter := bp.delimiter(fieldToken); delimiter != "" {
+			params[key] = splitValues(value, delimiter)
+		} else if value == "" {
+			params[key] = []interface{}{}
+		} else {
+			params[key] = []interface{}{value}
+		}
+	}
+}
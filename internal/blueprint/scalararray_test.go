@@ -0,0 +1,107 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_ScalarOrArray(t *testing.T) {
+	t.Run("parses scalar or array fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "build", "{{tags:string|array # image tags}}", "[labels:string|array]"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "tags", Description: "image tags", Required: true, IsArray: true, ScalarOrArray: true}}, bp.ShellWords[2])
+		assert.Equal(t, []Token{FieldToken{Name: "labels", IsArray: true, ScalarOrArray: true}}, bp.ShellWords[3])
+		assert.Equal(t, "docker build {{tags:string|array}} [labels:string|array]", bp.GetCommandFormat())
+	})
+
+	t.Run("advertises a string or an array", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "build", "{{tags:string|array # image tags}}", "[labels:string|array]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		tags := schema.Properties["tags"]
+		assert.Equal(t, "image tags", tags.Description)
+		require.Len(t, tags.OneOf, 2)
+		assert.Equal(t, "string", tags.OneOf[0].Type)
+		assert.Equal(t, "array", tags.OneOf[1].Type)
+		assert.Equal(t, "string", tags.OneOf[1].Items.Type)
+		assert.Equal(t, 1, *tags.OneOf[1].MinItems)
+		assert.Equal(t, "One value or a list of values", schema.Properties["labels"].Description)
+		assert.Equal(t, []string{"tags"}, schema.Required)
+	})
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "string",
+			params:   map[string]interface{}{"tags": "app:latest"},
+			expected: []string{"docker", "build", "app:latest", "."},
+		},
+		{
+			name:     "array",
+			params:   map[string]interface{}{"tags": []interface{}{"app:latest", "app:1.0"}},
+			expected: []string{"docker", "build", "app:latest", "app:1.0", "."},
+		},
+		{
+			name:     "string with spaces stays one argument",
+			params:   map[string]interface{}{"tags": "my app"},
+			expected: []string{"docker", "build", "my app", "."},
+		},
+		{
+			name:          "empty string",
+			params:        map[string]interface{}{"tags": ""},
+			expectedError: "parameter 'tags' needs at least 1 value(s)",
+		},
+		{
+			name:          "empty array",
+			params:        map[string]interface{}{"tags": []interface{}{}},
+			expectedError: "parameter 'tags' needs at least 1 value(s)",
+		},
+		{
+			name:          "missing",
+			params:        map[string]interface{}{},
+			expectedError: "missing required parameter: tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs([]string{"docker", "build", "{{tags:string|array}}", "."})
+			require.NoError(t, err)
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("limits array values with MaxArrayItems", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "build", "[tags:string|array]"})
+		require.NoError(t, err)
+		bp.MaxArrayItems = 1
+
+		_, err = bp.BuildCommandArgs(map[string]interface{}{"tags": []interface{}{"a", "b"}})
+		assert.EqualError(t, err, "parameter 'tags' has 2 values, more than the limit of 1")
+	})
+
+	t.Run("leaves the caller's params untouched", func(t *testing.T) {
+		bp, err := FromArgs([]string{"docker", "build", "[tags:string|array]"})
+		require.NoError(t, err)
+
+		params := map[string]interface{}{"tags": "app"}
+		_, err = bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, "app", params["tags"])
+	})
+}
@@ -1,87 +1,148 @@
 package blueprint
 
 import (
-	"fmt"
-	"strings"
+	"encoding/json"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 )
 
+// PropertyOrdering is the schema keyword listing properties in the order their
+// fields appear in the template, since JSON object properties are unordered
+const PropertyOrdering = "propertyOrdering"
+
 // GenerateInputSchema creates a JSON schema from the tokenized shell words
 func (bp *Blueprint) GenerateInputSchema() *jsonschema.Schema {
 	properties := make(map[string]*jsonschema.Schema)
 	required := []string{}
+	order := []string{}
 
-	// Iterate through all shell words and their tokens
-	for _, tokens := range bp.ShellWords {
-		for _, token := range tokens {
-			if fieldToken, ok := token.(FieldToken); ok {
-				// Use normalized name for schema properties (dashes to underscores)
-				normalizedName := strings.ReplaceAll(fieldToken.Name, "-", "_")
+	// Iterate through all field tokens
+	for _, fieldToken := range bp.fields() {
+		// Use normalized name for schema properties (dashes to underscores)
+		normalizedName := bp.propertyName(fieldToken.Name)
 
-				// Skip if we already have this property and it has a description
-				if existingProp, exists := properties[normalizedName]; exists {
-					if fieldToken.Description != "" && existingProp.Description == "" {
-						// Update existing property with description
-						existingProp.Description = fieldToken.Description
-					}
-					// Handle required status - if any instance is required, make it required
-					if fieldToken.Required && !contains(required, normalizedName) {
-						required = append(required, normalizedName)
-					}
-					continue
-				}
+		// Skip if we already have this property and it has a description
+		if existingProp, exists := properties[normalizedName]; exists {
+			if fieldToken.Description != "" && existingProp.Description == "" {
+				// Update existing property with description
+				existingProp.Description = fieldToken.Description
+			}
+			// Handle required status - if any instance is required, make it required
+			if fieldToken.Required && !contains(required, normalizedName) {
+				required = append(required, normalizedName)
+			}
+			continue
+		}
 
-				// Create new property based on token type
-				var prop *jsonschema.Schema
+		// Create new property based on token type
+		var prop *jsonschema.Schema
 
-				if fieldToken.OriginalFlag != "" {
-					// Boolean flag
-					description := fieldToken.Description
-					if description == "" {
-						description = fmt.Sprintf("Enable %s flag", fieldToken.OriginalFlag)
-					}
-					prop = &jsonschema.Schema{
-						Type:        "boolean",
-						Description: description,
-					}
-				} else if fieldToken.IsArray {
-					// Array field
-					description := fieldToken.Description
-					if description == "" {
-						description = "Additional command line arguments"
-					}
-					prop = &jsonschema.Schema{
-						Type:        "array",
-						Items:       &jsonschema.Schema{Type: "string"},
-						Description: description,
-					}
-					// Array fields follow the same required logic as other fields
-					if fieldToken.Required && !contains(required, normalizedName) {
-						required = append(required, normalizedName)
-					}
-				} else {
-					// String field
-					prop = &jsonschema.Schema{Type: "string"}
-					if fieldToken.Description != "" {
-						prop.Description = fieldToken.Description
-					}
+		if fieldToken.isFlagPair() {
+			// Boolean that picks one of two flags
+			description := fieldToken.Description
+			if description == "" {
+				description = flagPairDescription(fieldToken)
+			}
+			prop = &jsonschema.Schema{
+				Type:        "boolean",
+				Description: description,
+			}
+		} else if fieldToken.OriginalFlag != "" {
+			// Boolean flag
+			description := fieldToken.Description
+			if description == "" {
+				description = flagDescription(fieldToken.OriginalFlag)
+			}
+			prop = &jsonschema.Schema{
+				Type:        "boolean",
+				Description: description,
+			}
+		} else if fieldToken.IsArray {
+			// Array field
+			description := fieldToken.Description
+			if description == "" {
+				description = "Additional command line arguments"
+			}
+			if fieldToken.PathCheck != "" {
+				description = pathDescription(fieldToken.Description, fieldToken.PathCheck)
+			}
+			array := &jsonschema.Schema{
+				Type:        "array",
+				Items:       addRules(&jsonschema.Schema{Type: "string", MaxLength: bp.maxLength(fieldToken), Examples: schemaExamples(fieldToken)}, fieldToken),
+				Description: description,
+			}
+			if fieldToken.KeyValue {
+				array.Items = keyValueSchema("")
+			}
+			// Array fields are optional unless written as {{name...}}, which
+			// needs at least one value
+			if fieldToken.Required {
+				array.MinItems = jsonschema.Ptr(1)
+				if !contains(required, normalizedName) {
+					required = append(required, normalizedName)
+				}
+			}
+			if fieldToken.MinItems > 0 {
+				array.MinItems = jsonschema.Ptr(fieldToken.MinItems)
+			}
+			if fieldToken.MaxItems > 0 {
+				array.MaxItems = jsonschema.Ptr(fieldToken.MaxItems)
+			}
+			prop = array
+			if bp.takesString(fieldToken) {
+				prop = bp.scalarOrArraySchema(fieldToken, array)
+			}
+		} else if fieldToken.KeyValue {
+			// Object joined into a key=value argument
+			description := fieldToken.Description
+			if description == "" {
+				description = "A key and a value, passed as key=value"
+			}
+			prop = keyValueSchema(description)
 
-					// Add to required if the token is marked as required
-					if fieldToken.Required && !contains(required, normalizedName) {
-						required = append(required, normalizedName)
-					}
+			if fieldToken.Required && !contains(required, normalizedName) {
+				required = append(required, normalizedName)
+			}
+		} else {
+			// String field
+			prop = addRules(&jsonschema.Schema{Type: "string", Examples: schemaExamples(fieldToken)}, fieldToken)
+			if fieldToken.Description != "" {
+				prop.Description = fieldToken.Description
+			}
+			if fieldToken.ReadsFile {
+				prop.Description = fileDescription(prop.Description)
+			} else {
+				prop.MaxLength = bp.maxLength(fieldToken)
+			}
+			if fieldToken.PathCheck != "" {
+				prop.Description = pathDescription(prop.Description, fieldToken.PathCheck)
+			}
+
+			// Literal defaults are shown to the client, environment defaults stay private
+			if fieldToken.Default != "" && !fieldToken.HasEnvDefault() {
+				if defaultJSON, err := json.Marshal(fieldToken.Default); err == nil {
+					prop.Default = defaultJSON
 				}
+			}
 
-				properties[normalizedName] = prop
+			// Add to required if the token is marked as required
+			if fieldToken.Required && !contains(required, normalizedName) {
+				required = append(required, normalizedName)
 			}
 		}
+
+		properties[normalizedName] = prop
+		order = append(order, normalizedName)
 	}
 
 	schema := &jsonschema.Schema{
 		Type:       "object",
 		Properties: properties,
 		Required:   required, // Always set, even if empty
+		Extra:      map[string]any{PropertyOrdering: order},
+	}
+	if !bp.SkipEnumValues {
+		DescribeEnums(schema)
 	}
 
 	// Debug logging
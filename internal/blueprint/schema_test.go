@@ -1,6 +1,7 @@
 package blueprint
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
@@ -21,6 +22,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 				Type:       "object",
 				Properties: map[string]*jsonschema.Schema{},
 				Required:   []string{},
+				Extra:      map[string]any{PropertyOrdering: []string{}},
 			},
 		},
 		{
@@ -36,6 +38,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"args"}},
 			},
 		},
 		{
@@ -50,6 +53,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"page"},
+				Extra:    map[string]any{PropertyOrdering: []string{"page"}},
 			},
 		},
 		{
@@ -63,6 +67,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"text"},
+				Extra:    map[string]any{PropertyOrdering: []string{"text"}},
 			},
 		},
 		{
@@ -77,6 +82,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"page"},
+				Extra:    map[string]any{PropertyOrdering: []string{"page"}},
 			},
 		},
 		{
@@ -94,6 +100,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"arg1"},
+				Extra:    map[string]any{PropertyOrdering: []string{"arg1", "arg2"}},
 			},
 		},
 		{
@@ -108,6 +115,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"text"},
+				Extra:    map[string]any{PropertyOrdering: []string{"text"}},
 			},
 		},
 		{
@@ -123,6 +131,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"files"}},
 			},
 		},
 		{
@@ -138,6 +147,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"files"}},
 			},
 		},
 		{
@@ -151,6 +161,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"optional"}},
 			},
 		},
 		{
@@ -165,6 +176,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"name"}},
 			},
 		},
 		{
@@ -178,6 +190,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"has_dashes"}},
 			},
 		},
 		{
@@ -192,6 +205,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"f"}},
 			},
 		},
 		{
@@ -206,6 +220,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"force"}},
 			},
 		},
 		{
@@ -220,6 +235,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{},
+				Extra:    map[string]any{PropertyOrdering: []string{"f"}},
 			},
 		},
 		{
@@ -240,6 +256,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"source", "dest"},
+				Extra:    map[string]any{PropertyOrdering: []string{"r", "source", "dest"}},
 			},
 		},
 		{
@@ -259,6 +276,7 @@ func TestBlueprint_GenerateInputSchema(t *testing.T) {
 					},
 				},
 				Required: []string{"flag"},
+				Extra:    map[string]any{PropertyOrdering: []string{"flag", "files"}},
 			},
 		},
 	}
@@ -291,4 +309,156 @@ func TestBlueprint_GenerateInputSchema_EdgeCases(t *testing.T) {
 		// Neither should be required
 		assert.Empty(t, schema.Required)
 	})
+
+	t.Run("array description defaults unless one is given", func(t *testing.T) {
+		bp, err := FromArgs([]string{"wc", "[files... # paths to process]", "[flags...]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+
+		assert.Equal(t, "paths to process", schema.Properties["files"].Description)
+		assert.Equal(t, "Additional command line arguments", schema.Properties["flags"].Description)
+	})
+
+	t.Run("array fields are optional unless written as required", func(t *testing.T) {
+		bp, err := FromArgs([]string{"wc", "[flags...]", "{{files...}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+
+		assert.Equal(t, []string{"files"}, schema.Required)
+		assert.Nil(t, schema.Properties["flags"].MinItems)
+		require.NotNil(t, schema.Properties["files"].MinItems)
+		assert.Equal(t, 1, *schema.Properties["files"].MinItems)
+	})
+
+	t.Run("orders properties as they appear in the template", func(t *testing.T) {
+		bp, err := FromArgs([]string{"rsync", "[-a]", "{{source}}", "--exclude={{pattern}}", "{?--limit {{limit}}?}", "{{dest}}", "{{source}}", "[extra...]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+
+		assert.Equal(t, []string{"a", "source", "pattern", "limit", "dest", "extra"}, schema.Extra[PropertyOrdering])
+		assert.Equal(t, []string{"source", "pattern", "dest"}, schema.Required)
+
+		schemaJSON, err := json.Marshal(schema)
+		require.NoError(t, err)
+		assert.Contains(t, string(schemaJSON), `"propertyOrdering":["a","source","pattern","limit","dest","extra"]`)
+	})
+}
+
+func TestBlueprint_GenerateInputSchema_OptionalGroups(t *testing.T) {
+	t.Run("fields inside groups are optional", func(t *testing.T) {
+		bp, err := FromArgs([]string{"grep", "{?--max-count {{limit # max matches}}?}", "{{pattern}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		require.Contains(t, schema.Properties, "limit")
+		assert.Equal(t, "string", schema.Properties["limit"].Type)
+		assert.Equal(t, "max matches", schema.Properties["limit"].Description)
+		assert.Equal(t, []string{"pattern"}, schema.Required)
+	})
+
+	t.Run("fields required elsewhere stay required", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{name}}", "{?--again {{name}}?}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, []string{"name"}, schema.Required)
+	})
+}
+
+func TestBlueprint_GenerateInputSchema_Defaults(t *testing.T) {
+	t.Run("fields with defaults are optional", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "{{region=us-east-1 # AWS region}}", "{{command}}"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		require.Contains(t, schema.Properties, "region")
+		assert.Equal(t, "AWS region", schema.Properties["region"].Description)
+		assert.Equal(t, []string{"command"}, schema.Required)
+	})
+
+	t.Run("literal defaults are included in the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "[region=us-east-1]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.JSONEq(t, `"us-east-1"`, string(schema.Properties["region"].Default))
+	})
+
+	t.Run("environment defaults are not included in the schema", func(t *testing.T) {
+		bp, err := FromArgs([]string{"aws", "[region=$AWS_REGION]"})
+		require.NoError(t, err)
+
+		schema := bp.GenerateInputSchema()
+		assert.Empty(t, schema.Properties["region"].Default)
+	})
+}
+
+func TestBlueprint_GenerateInputSchema_Examples(t *testing.T) {
+	t.Run("parses examples after the description", func(t *testing.T) {
+		bp, err := FromArgs([]string{"git", "checkout", "{{branch # git branch || main || release/1.0}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "branch", Description: "git branch", Required: true, Examples: []string{"main", "release/1.0"}}}, bp.ShellWords[2])
+	})
+
+	tests := []struct {
+		name                string
+		args                []string
+		field               string
+		expectedDescription string
+		expectedExamples    []any
+		expectedItems       []any
+	}{
+		{
+			name:                "string field",
+			args:                []string{"git", "checkout", "{{branch # git branch || main}}"},
+			field:               "branch",
+			expectedDescription: "git branch",
+			expectedExamples:    []any{"main"},
+		},
+		{
+			name:                "with a default and modifiers",
+			args:                []string{"git", "log", "[ref=HEAD:trim # where to start || v1.2.0 || HEAD~3]"},
+			field:               "ref",
+			expectedDescription: "where to start",
+			expectedExamples:    []any{"v1.2.0", "HEAD~3"},
+		},
+		{
+			name:             "without a description",
+			args:             []string{"git", "checkout", "{{branch # || main}}"},
+			field:            "branch",
+			expectedExamples: []any{"main"},
+		},
+		{
+			name:                "array items",
+			args:                []string{"git", "add", "[paths... # files to stage || src/main.go]"},
+			field:               "paths",
+			expectedDescription: "files to stage",
+			expectedItems:       []any{"src/main.go"},
+		},
+		{
+			name:                "no examples",
+			args:                []string{"git", "checkout", "{{branch # git branch}}"},
+			field:               "branch",
+			expectedDescription: "git branch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			prop := bp.GenerateInputSchema().Properties[tt.field]
+			require.NotNil(t, prop)
+			assert.Equal(t, tt.expectedDescription, prop.Description)
+			assert.Equal(t, tt.expectedExamples, prop.Examples)
+			if prop.Items != nil {
+				assert.Equal(t, tt.expectedItems, prop.Items.Examples)
+			}
+		})
+	}
 }
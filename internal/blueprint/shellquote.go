@@ -0,0 +1,108 @@
+package blueprint
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Shell contexts a value can land in, tracked while scanning a script
+const (
+	unquoted     = ' '
+	singleQuoted = '\''
+	doubleQuoted = '"'
+	subshell     = '('
+	backquoted   = '`'
+)
+
+// valuePlaceholder stands in for the value at index i until the script is
+// scanned. NUL can't appear in a script, so it never clashes with template text.
+func valuePlaceholder(i int) string {
+	return "\x00" + strconv.Itoa(i) + "\x00"
+}
+
+// quoteValues replaces every placeholder in script with its value, quoted for
+// where it sits. Values between quotes in the template are escaped for those
+// quotes, so a template like echo "{{text}}" can't be broken out of either.
+func quoteValues(script string, values []string) string {
+	var b strings.Builder
+	contexts := []byte{unquoted}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		context := contexts[len(contexts)-1]
+
+		if c == 0 {
+			end := i + 1 + strings.IndexByte(script[i+1:], 0)
+			index, _ := strconv.Atoi(script[i+1 : end])
+			b.WriteString(quoteFor(context, values[index]))
+			i = end
+			continue
+		}
+
+		b.WriteByte(c)
+		next := byte(0)
+		if i+1 < len(script) {
+			next = script[i+1]
+		}
+
+		switch context {
+		case singleQuoted:
+			if c == '\'' {
+				contexts = contexts[:len(contexts)-1]
+			}
+		case doubleQuoted:
+			switch {
+			case c == '\\' && next != 0:
+				b.WriteByte(next)
+				i++
+			case c == '"':
+				contexts = contexts[:len(contexts)-1]
+			case c == '$' && next == '(':
+				b.WriteByte(next)
+				i++
+				contexts = append(contexts, subshell)
+			case c == '`':
+				contexts = append(contexts, backquoted)
+			}
+		default:
+			switch {
+			case c == '\\' && next != 0:
+				b.WriteByte(next)
+				i++
+			case c == '\'':
+				contexts = append(contexts, singleQuoted)
+			case c == '"':
+				contexts = append(contexts, doubleQuoted)
+			case c == '`' && context == backquoted:
+				contexts = contexts[:len(contexts)-1]
+			case c == '`':
+				contexts = append(contexts, backquoted)
+			case c == '(':
+				contexts = append(contexts, subshell)
+			case c == ')' && context == subshell:
+				contexts = contexts[:len(contexts)-1]
+			}
+		}
+	}
+	return b.String()
+}
+
+// quoteFor quotes value so sh reads it back as literal text in context
+func quoteFor(context byte, value string) string {
+	switch context {
+	case singleQuoted:
+		// Close the quotes around an escaped ' and open them again
+		return strings.ReplaceAll(value, "'", `'\''`)
+	case doubleQuoted:
+		var b strings.Builder
+		for _, r := range value {
+			if strings.ContainsRune("\\\"$`", r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	default:
+		return shellQuote(value)
+	}
+}
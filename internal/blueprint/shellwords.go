@@ -0,0 +1,143 @@
+package blueprint
+
+import (
+	"strings"
+)
+
+// ParseShellWords splits a command line into words the way sh would, so the
+// result can be passed to FromArgs. Single quotes preserve everything literally,
+// double quotes allow backslash escapes of $ ` " \ and newline, and an unquoted
+// backslash escapes the next character. A backslash-newline joins lines.
+//
+// Unlike sh, # does not start a comment, since descriptions in templates like
+// [args... # more args] would otherwise be dropped. Variables, globs and
+// command substitution are not expanded.
+func ParseShellWords(line string) ([]string, error) {
+	words, _, err := ParseShellWordLines(line)
+	return words, err
+}
+
+// ShellWordsError is an error splitting text into shell words, on the line of
+// the quote or escape that caused it
+type ShellWordsError struct {
+	Line    int
+	Message string
+}
+
+func (e *ShellWordsError) Error() string {
+	return e.Message
+}
+
+// ParseShellWordLines is ParseShellWords for text of several lines, like a
+// command file. It also returns the line, counting from 1, that each word
+// starts on, and its errors are a *ShellWordsError.
+func ParseShellWordLines(text string) (words []string, lines []int, err error) {
+	words = []string{}
+	var word strings.Builder
+	inWord := false
+	start := 0
+
+	runes := []rune(text)
+	lineAt := func(pos int) int {
+		return 1 + strings.Count(string(runes[:pos]), "\n")
+	}
+	fail := func(pos int, message string) ([]string, []int, error) {
+		return nil, nil, &ShellWordsError{Line: lineAt(pos), Message: message}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !inWord {
+			start = i
+		}
+
+		switch {
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return fail(i, "unterminated escape at end of input")
+			}
+			i++
+			if runes[i] == '\n' {
+				// Line continuation
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end == -1 {
+				return fail(i, "unterminated single quote")
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+
+		case r == '"':
+			quote := i
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return fail(quote, "unterminated double quote")
+			}
+			inWord = true
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				lines = append(lines, lineAt(start))
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+		lines = append(lines, lineAt(start))
+	}
+
+	return words, lines, nil
+}
+
+// indexRune returns the index of the first r in runes at or after start, or -1
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// shellQuote quotes a value so sh reads it back as exactly one word
+func shellQuote(value string) string {
+	if value == "" {
+		return "''"
+	}
+
+	safe := true
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
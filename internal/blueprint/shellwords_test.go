@@ -0,0 +1,176 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShellWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected []string
+		errMsg   string
+	}{
+		{
+			name:     "simple words",
+			line:     "git status --short",
+			expected: []string{"git", "status", "--short"},
+		},
+		{
+			name:     "collapses runs of whitespace",
+			line:     "  echo \t hello\n\nworld  ",
+			expected: []string{"echo", "hello", "world"},
+		},
+		{
+			name:     "empty input",
+			line:     "   ",
+			expected: []string{},
+		},
+		{
+			name:     "double quoted word with spaces",
+			line:     `curl -H "Content-Type: application/json"`,
+			expected: []string{"curl", "-H", "Content-Type: application/json"},
+		},
+		{
+			name:     "single quoted word is literal",
+			line:     `echo '$HOME \n "quoted"'`,
+			expected: []string{"echo", `$HOME \n "quoted"`},
+		},
+		{
+			name:     "adjacent quoted and unquoted parts join",
+			line:     `echo foo"bar baz"'qux'`,
+			expected: []string{"echo", "foobar bazqux"},
+		},
+		{
+			name:     "empty quotes produce an empty word",
+			line:     `echo "" ''`,
+			expected: []string{"echo", "", ""},
+		},
+		{
+			name:     "backslash escapes a space",
+			line:     `ls my\ file.txt`,
+			expected: []string{"ls", "my file.txt"},
+		},
+		{
+			name:     "backslash escapes quotes",
+			line:     `echo \"hi\" \'there\'`,
+			expected: []string{"echo", `"hi"`, `'there'`},
+		},
+		{
+			name:     "escapes inside double quotes",
+			line:     `echo "a \"quoted\" \\ \$word"`,
+			expected: []string{"echo", `a "quoted" \ $word`},
+		},
+		{
+			name:     "other backslashes inside double quotes are kept",
+			line:     `printf "line\n"`,
+			expected: []string{"printf", `line\n`},
+		},
+		{
+			name:     "backslash newline continues the line",
+			line:     "curl \\\n  -X POST \\\n  {{url}}",
+			expected: []string{"curl", "-X", "POST", "{{url}}"},
+		},
+		{
+			name:     "hash does not start a comment",
+			line:     `git log [args... # extra args]`,
+			expected: []string{"git", "log", "[args...", "#", "extra", "args]"},
+		},
+		{
+			name:     "template with description in double quotes",
+			line:     `say -v siri "{{speech # a concise phrase to say}}"`,
+			expected: []string{"say", "-v", "siri", "{{speech # a concise phrase to say}}"},
+		},
+		{
+			name:     "optional array template in single quotes",
+			line:     `git status '[args... # any additional args]'`,
+			expected: []string{"git", "status", "[args... # any additional args]"},
+		},
+		{
+			name:     "template embedded in a quoted word",
+			line:     `curl "https://en.wikipedia.org/wiki/{{page # the page name}}?action=raw"`,
+			expected: []string{"curl", "https://en.wikipedia.org/wiki/{{page # the page name}}?action=raw"},
+		},
+		{
+			name:     "template split across quoted parts",
+			line:     `echo --name="{{name # who's there}}"`,
+			expected: []string{"echo", "--name={{name # who's there}}"},
+		},
+		{
+			name:   "unterminated double quote",
+			line:   `echo "hello`,
+			errMsg: "unterminated double quote",
+		},
+		{
+			name:   "unterminated single quote",
+			line:   `echo 'hello`,
+			errMsg: "unterminated single quote",
+		},
+		{
+			name:   "trailing backslash",
+			line:   `echo hello\`,
+			errMsg: "unterminated escape",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := ParseShellWords(tt.line)
+
+			if tt.errMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, words)
+		})
+	}
+}
+
+func TestParseShellWordLines(t *testing.T) {
+	t.Run("returns the line each word starts on", func(t *testing.T) {
+		words, lines, err := ParseShellWordLines("curl \\\n  -H 'Accept: */*'\n\n  \"{{url\n # the URL}}\"\n")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"curl", "-H", "Accept: */*", "{{url\n # the URL}}"}, words)
+		assert.Equal(t, []int{1, 2, 2, 4}, lines)
+	})
+
+	t.Run("reports the line an unterminated quote opens on", func(t *testing.T) {
+		_, _, err := ParseShellWordLines("curl\n  -H \"Accept\n\n{{url}}\n")
+
+		var wordsErr *ShellWordsError
+		require.ErrorAs(t, err, &wordsErr)
+		assert.Equal(t, 2, wordsErr.Line)
+		assert.Equal(t, "unterminated double quote", err.Error())
+	})
+}
+
+func TestParseShellWords_FromArgs(t *testing.T) {
+	words, err := ParseShellWords(`echo "Hello, {{name # who's there}}!" '[--loud # shout it]'`)
+	require.NoError(t, err)
+
+	bp, err := FromArgs(words)
+	require.NoError(t, err)
+
+	assert.Equal(t, "echo Hello, {{name}}! [--loud]", bp.GetCommandFormat())
+
+	schema := bp.GenerateInputSchema()
+	assert.Equal(t, "who's there", schema.Properties["name"].Description)
+	assert.Equal(t, "boolean", schema.Properties["loud"].Type)
+}
+
+func TestShellQuote_RoundTrip(t *testing.T) {
+	values := []string{"", "simple", "two words", "it's", `"double"`, "$HOME", "a\\b", "tab\there", "new\nline", "--flag=value"}
+
+	for _, value := range values {
+		words, err := ParseShellWords("echo " + shellQuote(value))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo", value}, words, "quoted as %s", shellQuote(value))
+	}
+}
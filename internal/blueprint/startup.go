@@ -0,0 +1,84 @@
+package blueprint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// startupHost is replaced with the name of the host, written {{@host}}
+	startupHost = "@host"
+	// startupEnvPrefix reads an environment variable of studio, written {{@env:NAME}}
+	startupEnvPrefix = "@env:"
+)
+
+// invalidToken stands in for a startup value that couldn't be read, so
+// FromArgs can report why
+type invalidToken struct {
+	text string
+	err  error
+}
+
+func (t invalidToken) String() string {
+	return t.text
+}
+
+// isStartupValue reports whether a field name is a startup value like @host
+// rather than an argument. The {{@all:json}} field is an argument.
+func isStartupValue(name string) bool {
+	return strings.HasPrefix(name, "@") && !strings.HasPrefix(name, allArgsName)
+}
+
+// resolveStartupValue returns the text a startup value stands for
+func resolveStartupValue(name string) (string, error) {
+	switch {
+	case name == startupHost:
+		host, err := os.Hostname()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the hostname: %w", err)
+		}
+		return host, nil
+	case strings.HasPrefix(name, startupEnvPrefix):
+		variable := strings.TrimPrefix(name, startupEnvPrefix)
+		if !envNamePattern.MatchString(variable) {
+			return "", fmt.Errorf("%q is not an environment variable name", variable)
+		}
+		value, ok := os.LookupEnv(variable)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", variable)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown startup value, use {{%s}} or {{%sNAME}}", startupHost, startupEnvPrefix)
+	}
+}
+
+// startupToken reads the startup value of a template like {{@host}} once,
+// when the blueprint is made, so it's literal text from then on
+func startupToken(template, name string) Token {
+	value, err := resolveStartupValue(name)
+	if err != nil {
+		return invalidToken{text: template, err: fmt.Errorf("%s: %w", template, err)}
+	}
+	debug("  startup value %s read", template)
+	return TextToken{Value: value, Quoted: true, Template: template}
+}
+
+// checkTokens returns the error of the first startup value in tokens that
+// couldn't be read
+func checkTokens(tokens []Token) error {
+	for _, token := range tokens {
+		switch t := token.(type) {
+		case invalidToken:
+			return t.err
+		case GroupToken:
+			for _, word := range t.Words {
+				if err := checkTokens(word); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package blueprint
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_StartupValues(t *testing.T) {
+	t.Setenv("STUDIO_SHA", "abc123")
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		args            []string
+		expectedFormat  string
+		expectedCommand []string
+	}{
+		{
+			name:            "environment variable",
+			args:            []string{"deploy", "--sha", "{{@env:STUDIO_SHA}}", "{{target}}"},
+			expectedFormat:  "deploy --sha abc123 {{target}}",
+			expectedCommand: []string{"deploy", "--sha", "abc123", "prod"},
+		},
+		{
+			name:            "host inside a word",
+			args:            []string{"ssh", "admin@{{@host}}", "{{target}}"},
+			expectedFormat:  "ssh admin@" + host + " {{target}}",
+			expectedCommand: []string{"ssh", "admin@" + host, "prod"},
+		},
+		{
+			name:            "inside an optional group",
+			args:            []string{"deploy", "{?--as {{@env:STUDIO_SHA}}-{{target}}?}"},
+			expectedFormat:  "deploy [--as abc123-{{target}}]",
+			expectedCommand: []string{"deploy", "--as", "abc123-prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedFormat, bp.GetCommandFormat())
+			properties := bp.GenerateInputSchema().Properties
+			assert.Len(t, properties, 1)
+			assert.Contains(t, properties, "target")
+
+			command, err := bp.BuildCommandArgs(map[string]interface{}{"target": "prod"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCommand, command)
+		})
+	}
+
+	t.Run("read once at startup", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{@env:STUDIO_SHA}}"})
+		require.NoError(t, err)
+
+		t.Setenv("STUDIO_SHA", "def456")
+		command, err := bp.BuildCommandArgs(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo", "abc123"}, command)
+	})
+
+	t.Run("kept out of the log", func(t *testing.T) {
+		t.Setenv("STUDIO_TOKEN", "s3cret-token")
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stderr := os.Stderr
+		os.Stderr = w
+		t.Cleanup(func() { os.Stderr = stderr })
+
+		_, err = FromArgs([]string{"curl", "-H", "Authorization: {{@env:STUDIO_TOKEN}}", "{?--user {{user}}:{{@env:STUDIO_TOKEN}}?}"})
+		require.NoError(t, err)
+		os.Stderr = stderr
+		require.NoError(t, w.Close())
+		logged, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(logged), "{{@env:STUDIO_TOKEN}}")
+		assert.NotContains(t, string(logged), "s3cret-token")
+	})
+
+	t.Run("quoted in shell scripts", func(t *testing.T) {
+		t.Setenv("STUDIO_MESSAGE", "it's $(here)")
+		bp, err := FromArgs([]string{"echo {{@env:STUDIO_MESSAGE}}"})
+		require.NoError(t, err)
+
+		script, err := bp.BuildShellCommand(map[string]interface{}{})
+		require.NoError(t, err)
+		assert.Equal(t, `echo 'it'\''s $(here)'`, script)
+	})
+}
+
+func TestBlueprint_StartupValueErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{
+			name:          "unset variable",
+			args:          []string{"echo", "{{@env:STUDIO_UNSET_VARIABLE}}"},
+			expectedError: "{{@env:STUDIO_UNSET_VARIABLE}}: environment variable STUDIO_UNSET_VARIABLE is not set",
+		},
+		{
+			name:          "invalid variable name",
+			args:          []string{"echo", "{{@env:NOT-A-NAME}}"},
+			expectedError: `"NOT-A-NAME" is not an environment variable name`,
+		},
+		{
+			name:          "unknown startup value",
+			args:          []string{"echo", "{{@hostname}}"},
+			expectedError: "{{@hostname}}: unknown startup value, use {{@host}} or {{@env:NAME}}",
+		},
+		{
+			name:          "inside an optional group",
+			args:          []string{"echo", "{?{{@env:STUDIO_UNSET_VARIABLE}} {{text}}?}"},
+			expectedError: "environment variable STUDIO_UNSET_VARIABLE is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromArgs(tt.args)
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+
+	t.Run("environment of the command", func(t *testing.T) {
+		bp, err := FromArgs([]string{"deploy"})
+		require.NoError(t, err)
+		assert.ErrorContains(t, bp.AddEnv("SHA={{@env:STUDIO_UNSET_VARIABLE}}"), "environment variable SHA: {{@env:STUDIO_UNSET_VARIABLE}}")
+	})
+}
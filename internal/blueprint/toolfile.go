@@ -0,0 +1,165 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// ToolFile describes a tool as its command and the fields it takes, so
+// descriptions don't have to fit inside the template tags
+//
+//	{
+//	  "command": ["gh", "issue", "list", "--repo", "{{repo}}", "[limit]"],
+//	  "fields": {"repo": {"description": "Repository in owner/name form"}}
+//	}
+type ToolFile struct {
+	Command []string                   `json:"command"`
+	Fields  map[string]FieldDefinition `json:"fields"`
+}
+
+// FieldDefinition overrides what a template tag says about a field. Anything
+// left out keeps the value from the tag.
+type FieldDefinition struct {
+	Description string `json:"description,omitempty"`
+	// Type is string, array or boolean. Booleans must be written as a flag.
+	Type     string `json:"type,omitempty"`
+	Required *bool  `json:"required,omitempty"`
+	// Pattern, Enum, MinLength and MaxLength limit every value of a string or
+	// array field. Calls with other values fail before the command runs.
+	Pattern   string   `json:"pattern,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	MinLength int      `json:"minLength,omitempty"`
+	MaxLength int      `json:"maxLength,omitempty"`
+}
+
+// LoadToolFile reads a tool from a JSON file with its command and fields
+func LoadToolFile(filename string) (ToolFile, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return ToolFile{}, fmt.Errorf("failed to read tool file: %w", err)
+	}
+
+	var toolFile ToolFile
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&toolFile); err != nil {
+		return ToolFile{}, fmt.Errorf("failed to parse tool file %s: %w", filename, err)
+	}
+	if len(toolFile.Command) == 0 {
+		return ToolFile{}, fmt.Errorf("tool file %s has no command", filename)
+	}
+	return toolFile, nil
+}
+
+// ApplyFields updates the fields of the blueprint with their definitions.
+// Definitions for names that aren't fields are skipped, see UnusedFields.
+func (bp *Blueprint) ApplyFields(definitions map[string]FieldDefinition) error {
+	byName := make(map[string]FieldDefinition, len(definitions))
+	for name, definition := range definitions {
+		byName[normalizeFieldName(name)] = definition
+	}
+
+	var err error
+	bp.updateFields(func(fieldToken FieldToken) FieldToken {
+		definition, ok := byName[normalizeFieldName(fieldToken.Name)]
+		if !ok || err != nil {
+			return fieldToken
+		}
+		fieldToken, err = definition.apply(fieldToken)
+		return fieldToken
+	})
+	return err
+}
+
+// UnusedFields returns the names of definitions that match no field, sorted
+func (bp *Blueprint) UnusedFields(definitions map[string]FieldDefinition) []string {
+	used := map[string]bool{}
+	for _, fieldToken := range bp.fields() {
+		used[normalizeFieldName(fieldToken.Name)] = true
+	}
+
+	var unused []string
+	for name := range definitions {
+		if !used[normalizeFieldName(name)] {
+			unused = append(unused, name)
+		}
+	}
+	slices.Sort(unused)
+	return unused
+}
+
+// apply returns fieldToken changed to match the definition
+func (d FieldDefinition) apply(fieldToken FieldToken) (FieldToken, error) {
+	isBoolean := fieldToken.OriginalFlag != "" || fieldToken.isFlagPair()
+
+	switch d.Type {
+	case "":
+	case "boolean":
+		if !isBoolean {
+			return fieldToken, fmt.Errorf("field %s has type boolean but is not written as a flag like [--%s]", fieldToken.Name, fieldToken.Name)
+		}
+	case "string", "array":
+		if isBoolean {
+			return fieldToken, fmt.Errorf("field %s has type %s but is written as a boolean flag", fieldToken.Name, d.Type)
+		}
+		fieldToken.IsArray = d.Type == "array"
+		if !fieldToken.IsArray {
+			fieldToken.ScalarOrArray = false
+			fieldToken.Delimiter = ""
+			fieldToken.Each = false
+			fieldToken.MinItems = 0
+			fieldToken.MaxItems = 0
+		}
+	default:
+		return fieldToken, fmt.Errorf("field %s has unknown type %q, use string, array or boolean", fieldToken.Name, d.Type)
+	}
+
+	if d.Required != nil {
+		if isBoolean && *d.Required {
+			return fieldToken, fmt.Errorf("field %s is a boolean flag and can't be required", fieldToken.Name)
+		}
+		fieldToken.Required = *d.Required
+	}
+
+	if d.Description != "" {
+		fieldToken.Description = d.Description
+	}
+	return d.applyRules(fieldToken, isBoolean)
+}
+
+// applyRules returns fieldToken with the value rules of the definition, or an
+// error when they can't be checked
+func (d FieldDefinition) applyRules(fieldToken FieldToken, isBoolean bool) (FieldToken, error) {
+	if d.Pattern == "" && len(d.Enum) == 0 && d.MinLength == 0 && d.MaxLength == 0 {
+		return fieldToken, nil
+	}
+	if isBoolean || fieldToken.KeyValue {
+		return fieldToken, fmt.Errorf("field %s takes no text values, so it can't have a pattern, enum or length", fieldToken.Name)
+	}
+	if d.Pattern != "" {
+		if _, err := regexp.Compile(d.Pattern); err != nil {
+			return fieldToken, fmt.Errorf("field %s has an invalid pattern: %w", fieldToken.Name, err)
+		}
+		fieldToken.Pattern = d.Pattern
+	}
+	if d.MinLength < 0 || d.MaxLength < 0 {
+		return fieldToken, fmt.Errorf("field %s has a negative length", fieldToken.Name)
+	}
+	if d.MaxLength > 0 && d.MinLength > d.MaxLength {
+		return fieldToken, fmt.Errorf("field %s has a minLength of %d, more than its maxLength of %d", fieldToken.Name, d.MinLength, d.MaxLength)
+	}
+	if len(d.Enum) > 0 {
+		fieldToken.Enum = d.Enum
+	}
+	if d.MinLength > 0 {
+		fieldToken.MinLength = d.MinLength
+	}
+	if d.MaxLength > 0 {
+		fieldToken.MaxLength = d.MaxLength
+	}
+	return fieldToken, nil
+}
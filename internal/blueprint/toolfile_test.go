@@ -0,0 +1,192 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_ToolFile(t *testing.T) {
+	writeToolFile := func(t *testing.T, content string) string {
+		filename := filepath.Join(t.TempDir(), "tool.json")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	t.Run("loads the command and fields from JSON", func(t *testing.T) {
+		toolFile, err := LoadToolFile(writeToolFile(t, `{
+			"command": ["gh", "issue", "list", "--repo", "{{repo}}", "[labels]"],
+			"fields": {
+				"repo": {"description": "Repository in owner/name form"},
+				"labels": {"type": "array", "required": false}
+			}
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"gh", "issue", "list", "--repo", "{{repo}}", "[labels]"}, toolFile.Command)
+		assert.Equal(t, "Repository in owner/name form", toolFile.Fields["repo"].Description)
+		assert.Equal(t, "array", toolFile.Fields["labels"].Type)
+		require.NotNil(t, toolFile.Fields["labels"].Required)
+		assert.False(t, *toolFile.Fields["labels"].Required)
+	})
+
+	t.Run("reports files that can't be used", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid JSON", `command: ls`, "failed to parse tool file"},
+			{"unknown keys", `{"command": ["ls"], "feilds": {}}`, "failed to parse tool file"},
+			{"no command", `{"fields": {"path": {}}}`, "has no command"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := LoadToolFile(writeToolFile(t, tt.content))
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expected)
+			})
+		}
+	})
+
+	t.Run("reports missing files", func(t *testing.T) {
+		_, err := LoadToolFile(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read tool file")
+	})
+
+	t.Run("builds the same schema as the inline syntax", func(t *testing.T) {
+		inline, err := FromArgs([]string{"gh", "issue", "list", "--repo", "{{repo # Repository in owner/name form}}", "[--closed # Include closed issues]", "{{labels... # Labels to filter by}}"})
+		require.NoError(t, err)
+
+		bp, err := FromArgs([]string{"gh", "issue", "list", "--repo", "[repo]", "[--closed]", "[labels]"})
+		require.NoError(t, err)
+		require.NoError(t, bp.ApplyFields(map[string]FieldDefinition{
+			"repo":   {Description: "Repository in owner/name form", Required: jsonschema.Ptr(true)},
+			"closed": {Description: "Include closed issues", Type: "boolean"},
+			"labels": {Description: "Labels to filter by", Type: "array", Required: jsonschema.Ptr(true)},
+		}))
+
+		assert.Equal(t, inline.GenerateInputSchema(), bp.GenerateInputSchema())
+
+		args, err := bp.BuildCommandArgs(map[string]any{"repo": "cli/cli", "labels": []any{"bug", "help"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gh", "issue", "list", "--repo", "cli/cli", "bug", "help"}, args)
+	})
+
+	t.Run("definitions win over template descriptions", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ls", "{{path # from the tag}}"})
+		require.NoError(t, err)
+		require.NoError(t, bp.ApplyFields(map[string]FieldDefinition{"path": {Description: "from the tool file"}}))
+
+		assert.Equal(t, "from the tool file", bp.GenerateInputSchema().Properties["path"].Description)
+	})
+
+	t.Run("turns an array into a string", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "[words...(2-)]"})
+		require.NoError(t, err)
+		require.NoError(t, bp.ApplyFields(map[string]FieldDefinition{"words": {Type: "string"}}))
+
+		assert.Equal(t, "string", bp.GenerateInputSchema().Properties["words"].Type)
+	})
+
+	t.Run("reports definitions that don't fit the field", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			definition FieldDefinition
+			expected   string
+		}{
+			{"boolean without a flag", FieldDefinition{Type: "boolean"}, "is not written as a flag"},
+			{"unknown type", FieldDefinition{Type: "number"}, `unknown type "number"`},
+			{"invalid pattern", FieldDefinition{Pattern: "[a-z"}, "field path has an invalid pattern: error parsing regexp"},
+			{"negative length", FieldDefinition{MinLength: -1}, "has a negative length"},
+			{"minLength over maxLength", FieldDefinition{MinLength: 5, MaxLength: 2}, "minLength of 5, more than its maxLength of 2"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				bp, err := FromArgs([]string{"ls", "[path]"})
+				require.NoError(t, err)
+
+				err = bp.ApplyFields(map[string]FieldDefinition{"path": tt.definition})
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expected)
+			})
+		}
+
+		bp, err := FromArgs([]string{"ls", "[-l]"})
+		require.NoError(t, err)
+
+		err = bp.ApplyFields(map[string]FieldDefinition{"l": {Type: "array"}})
+		assert.ErrorContains(t, err, "written as a boolean flag")
+
+		err = bp.ApplyFields(map[string]FieldDefinition{"l": {Required: jsonschema.Ptr(true)}})
+		assert.ErrorContains(t, err, "can't be required")
+
+		err = bp.ApplyFields(map[string]FieldDefinition{"l": {Enum: []string{"yes"}}})
+		assert.ErrorContains(t, err, "can't have a pattern, enum or length")
+	})
+
+	t.Run("checks values against the rules of a definition", func(t *testing.T) {
+		bp, err := FromArgs([]string{"git", "checkout", "{{branch}}", "[env]", "[tags...]"})
+		require.NoError(t, err)
+		require.NoError(t, bp.ApplyFields(map[string]FieldDefinition{
+			"branch": {Pattern: "^[a-z0-9/-]+$", MaxLength: 20},
+			"env":    {Enum: []string{"dev", "prod"}},
+			"tags":   {MinLength: 2},
+		}))
+
+		schema := bp.GenerateInputSchema()
+		assert.Equal(t, "^[a-z0-9/-]+$", schema.Properties["branch"].Pattern)
+		assert.Equal(t, jsonschema.Ptr(20), schema.Properties["branch"].MaxLength)
+		assert.Equal(t, []any{"dev", "prod"}, schema.Properties["env"].Enum)
+		assert.Equal(t, "One of: dev, prod", schema.Properties["env"].Description)
+		assert.Equal(t, jsonschema.Ptr(2), schema.Properties["tags"].Items.MinLength)
+
+		bp.SkipEnumValues = true
+		assert.Empty(t, bp.GenerateInputSchema().Properties["env"].Description)
+		bp.SkipEnumValues = false
+
+		tests := []struct {
+			name     string
+			params   map[string]any
+			expected string
+		}{
+			{"valid values", map[string]any{"branch": "feature/rules", "env": "dev", "tags": []any{"ok"}}, ""},
+			{"pattern", map[string]any{"branch": "main; rm -rf /"}, `parameter 'branch' must match ^[a-z0-9/-]+$, got "main; rm -rf /"`},
+			{"maxLength", map[string]any{"branch": strings.Repeat("a", 21)}, "parameter 'branch' is 21 bytes, longer than the limit of 20 bytes"},
+			{"enum", map[string]any{"branch": "main", "env": "qa"}, `parameter 'env' must be one of dev, prod, got "qa"`},
+			{"minLength of each value", map[string]any{"branch": "main", "tags": []any{"ok", "x"}}, "parameter 'tags' is 1 bytes, shorter than the minimum of 2 bytes"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := bp.BuildCommandArgs(tt.params)
+				if tt.expected == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, tt.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("lists definitions that match no field", func(t *testing.T) {
+		bp, err := FromArgs([]string{"ls", "[path]", "{?--color {{color}}?}"})
+		require.NoError(t, err)
+
+		unused := bp.UnusedFields(map[string]FieldDefinition{
+			"path":   {},
+			"color":  {},
+			"sort":   {},
+			"hidden": {},
+		})
+		assert.Equal(t, []string{"hidden", "sort"}, unused)
+	})
+}
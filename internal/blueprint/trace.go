@@ -0,0 +1,83 @@
+package blueprint
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// Token types of a trace
+const (
+	TraceText  = "text"
+	TraceField = "field"
+	TraceGroup = "group"
+)
+
+// WordTrace is a shell word of the template and the tokens it was parsed into
+type WordTrace struct {
+	Word   string       `json:"word"`
+	Tokens []TokenTrace `json:"tokens"`
+}
+
+// TokenTrace is a token of a shell word as it was parsed. Fields also have
+// the schema property they became, and groups the words inside them.
+type TokenTrace struct {
+	Type  string      `json:"type"`
+	Token string      `json:"token"`
+	Field *FieldTrace `json:"field,omitempty"`
+	Words []WordTrace `json:"words,omitempty"`
+}
+
+// FieldTrace is a field of the template and the schema property it became
+type FieldTrace struct {
+	Name        string             `json:"name"`
+	Property    string             `json:"property"`
+	Required    bool               `json:"required"`
+	Description string             `json:"description,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+// Trace returns how every shell word of the template was parsed, for finding
+// out why a field didn't get the type or description it was meant to
+func (bp *Blueprint) Trace() []WordTrace {
+	schema := bp.GenerateInputSchema()
+	return bp.traceWords(bp.ShellWords, schema)
+}
+
+// traceWords traces words, looking up the properties of fields in schema
+func (bp *Blueprint) traceWords(words [][]Token, schema *jsonschema.Schema) []WordTrace {
+	traces := make([]WordTrace, 0, len(words))
+	for _, tokens := range words {
+		var word strings.Builder
+		trace := WordTrace{Tokens: make([]TokenTrace, 0, len(tokens))}
+		for _, token := range tokens {
+			tokenTrace := bp.traceToken(token, schema)
+			word.WriteString(tokenTrace.Token)
+			trace.Tokens = append(trace.Tokens, tokenTrace)
+		}
+		trace.Word = word.String()
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+// traceToken traces a single token
+func (bp *Blueprint) traceToken(token Token, schema *jsonschema.Schema) TokenTrace {
+	switch t := token.(type) {
+	case FieldToken:
+		property := bp.propertyName(t.Name)
+		field := &FieldTrace{
+			Name:        t.Name,
+			Property:    property,
+			Required:    slices.Contains(schema.Required, property),
+			Description: t.Description,
+			Schema:      schema.Properties[property],
+		}
+		return TokenTrace{Type: TraceField, Token: bp.renderFieldTokenForDisplay(t), Field: field}
+	case GroupToken:
+		return TokenTrace{Type: TraceGroup, Token: t.String(), Words: bp.traceWords(t.Words, schema)}
+	default:
+		return TokenTrace{Type: TraceText, Token: token.String()}
+	}
+}
@@ -0,0 +1,44 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_Trace(t *testing.T) {
+	bp, err := FromArgs([]string{"gh", "--repo={{repo # owner/name}}", "[--web]", "{?--limit {{limit}}?}", "[labels...]"})
+	require.NoError(t, err)
+
+	words := bp.Trace()
+	require.Len(t, words, 5)
+
+	assert.Equal(t, WordTrace{Word: "gh", Tokens: []TokenTrace{{Type: TraceText, Token: "gh"}}}, words[0])
+
+	repo := words[1]
+	assert.Equal(t, "--repo={{repo}}", repo.Word)
+	require.Len(t, repo.Tokens, 2)
+	assert.Equal(t, TokenTrace{Type: TraceText, Token: "--repo="}, repo.Tokens[0])
+	assert.Equal(t, TraceField, repo.Tokens[1].Type)
+	field := repo.Tokens[1].Field
+	assert.Equal(t, "repo", field.Name)
+	assert.True(t, field.Required)
+	assert.Equal(t, "owner/name", field.Description)
+	assert.Equal(t, "string", field.Schema.Type)
+
+	web := words[2].Tokens[0]
+	assert.Equal(t, "[--web]", web.Token)
+	assert.False(t, web.Field.Required)
+	assert.Equal(t, "boolean", web.Field.Schema.Type)
+
+	group := words[3].Tokens[0]
+	assert.Equal(t, TraceGroup, group.Type)
+	require.Len(t, group.Words, 2)
+	limit := group.Words[1].Tokens[0].Field
+	assert.Equal(t, "limit", limit.Name)
+	assert.False(t, limit.Required, "fields in a group are optional")
+
+	labels := words[4].Tokens[0].Field
+	assert.Equal(t, "array", labels.Schema.Type)
+}
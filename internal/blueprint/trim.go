@@ -0,0 +1,47 @@
+package blueprint
+
+import "strings"
+
+// trimSuffix marks a field whose value has leading and trailing whitespace
+// trimmed, as in {{name:trim}}. Whitespace inside the value is kept.
+const trimSuffix = ":trim"
+
+// trimFields trims the values of trimmed fields. Every field is trimmed when
+// TrimArgs is set.
+func (bp *Blueprint) trimFields(params map[string]interface{}) {
+	for _, fieldToken := range bp.fields() {
+		if !fieldToken.Trim && !bp.TrimArgs {
+			continue
+		}
+
+		key, exists := findParamKey(params, fieldToken.Name)
+		if !exists {
+			continue
+		}
+
+		params[key] = mapStrings(params[key], strings.TrimSpace)
+	}
+}
+
+// mapStrings applies fn to a string, or each string in an array. Other values
+// are returned as they are.
+func mapStrings(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case []string:
+		mapped := make([]string, len(v))
+		for i, item := range v {
+			mapped[i] = fn(item)
+		}
+		return mapped
+	case []interface{}:
+		mapped := make([]interface{}, len(v))
+		for i, item := range v {
+			mapped[i] = mapStrings(item, fn)
+		}
+		return mapped
+	default:
+		return value
+	}
+}
@@ -0,0 +1,88 @@
+package blueprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueprint_TrimFields(t *testing.T) {
+	t.Run("parses trimmed fields", func(t *testing.T) {
+		bp, err := FromArgs([]string{"git", "checkout", "{{branch:trim # branch name}}", "[paths...:trim]", "{{body:@file:trim}}"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Token{FieldToken{Name: "branch", Description: "branch name", Required: true, Trim: true}}, bp.ShellWords[2])
+		assert.Equal(t, []Token{FieldToken{Name: "paths", IsArray: true, Trim: true}}, bp.ShellWords[3])
+		assert.Equal(t, []Token{FieldToken{Name: "body", Required: true, ReadsFile: true, Trim: true}}, bp.ShellWords[4])
+		assert.Equal(t, "git checkout {{branch:trim}} [paths...:trim] {{body:@file:trim}}", bp.GetCommandFormat())
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		trimArgs bool
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "trims leading and trailing newlines",
+			args:     []string{"git", "checkout", "{{branch:trim}}"},
+			params:   map[string]interface{}{"branch": "\n  main\n"},
+			expected: []string{"git", "checkout", "main"},
+		},
+		{
+			name:     "keeps whitespace inside the value",
+			args:     []string{"echo", "{{text:trim}}"},
+			params:   map[string]interface{}{"text": " hello\n  world \r\n"},
+			expected: []string{"echo", "hello\n  world"},
+		},
+		{
+			name:     "trims each value of an array",
+			args:     []string{"ls", "[paths...:trim]"},
+			params:   map[string]interface{}{"paths": []interface{}{" a.txt\n", "b c.txt "}},
+			expected: []string{"ls", "a.txt", "b c.txt"},
+		},
+		{
+			name:     "trims fields inside words",
+			args:     []string{"curl", "https://example.com/{{path:trim}}"},
+			params:   map[string]interface{}{"path": "users\n"},
+			expected: []string{"curl", "https://example.com/users"},
+		},
+		{
+			name:     "leaves other fields alone",
+			args:     []string{"echo", "{{a:trim}}", "{{b}}"},
+			params:   map[string]interface{}{"a": " a ", "b": " b\n"},
+			expected: []string{"echo", "a", " b\n"},
+		},
+		{
+			name:     "trims every field with TrimArgs",
+			args:     []string{"echo", "{{a}}", "[b...]", "[--verbose]"},
+			trimArgs: true,
+			params:   map[string]interface{}{"a": " a\n", "b": []interface{}{"\tb "}, "verbose": true},
+			expected: []string{"echo", "a", "b", "--verbose"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := FromArgs(tt.args)
+			require.NoError(t, err)
+			bp.TrimArgs = tt.trimArgs
+
+			args, err := bp.BuildCommandArgs(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	t.Run("leaves the caller's params untouched", func(t *testing.T) {
+		bp, err := FromArgs([]string{"echo", "{{text:trim}}"})
+		require.NoError(t, err)
+
+		params := map[string]interface{}{"text": " hi "}
+		_, err = bp.BuildCommandArgs(params)
+		require.NoError(t, err)
+		assert.Equal(t, " hi ", params["text"])
+	})
+}
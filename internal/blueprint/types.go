@@ -1,7 +1,12 @@
 package blueprint
 
 import (
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 )
 
 // Token represents a part of a shell word after parsing
@@ -11,33 +16,155 @@ type Token interface {
 
 // TextToken represents literal text in a shell word
 type TextToken struct {
-	Value string
+	Value    string
+	Quoted   bool   // The text stands for a value, like {{@host}} or \|, so shell scripts quote it
+	Template string // The startup value the text was read from, shown in place of a secret it may hold
 }
 
+// String returns the text as written, so a startup value stays a template
 func (t TextToken) String() string {
+	if t.Template != "" {
+		return t.Template
+	}
 	return t.Value
 }
 
 // FieldToken represents a template field in a shell word
 type FieldToken struct {
-	Name         string
-	Description  string
-	Required     bool
-	IsArray      bool   // Indicates if this field represents an array (has ...)
-	OriginalFlag string // For boolean flags, stores the original flag format (e.g., "-f", "--verbose")
+	Name          string
+	Description   string
+	Required      bool
+	IsArray       bool     // Indicates if this field represents an array (has ...)
+	ScalarOrArray bool     // The array also takes a single string (name:string|array)
+	Delimiter     string   // The array also takes one string of values split on this (name:csv)
+	KeyValue      bool     // The value is an object with a key and a value, passed as key=value (name:kv)
+	Each          bool     // The command runs once for each value of the array (name:each)
+	OriginalFlag  string   // For boolean flags, stores the original flag format (e.g., "-f", "--verbose")
+	Default       string   // Value used when the field is not provided, or $VAR to read an environment variable
+	DefaultFile   string   // File whose contents are used when the field is not provided, or $VAR naming it (name:file=$VAR)
+	ReadsFile     bool     // The value is a path, and the contents of the file are used instead (name:@file)
+	Trim          bool     // Leading and trailing whitespace is trimmed from the value (name:trim)
+	Case          string   // The value is changed to upper (:upper) or lower (:lower) case
+	PathCheck     string   // The value is a path that must exist (:path), be a directory (:dir) or not exist (:path?)
+	MaxLength     int      // Longest value allowed in bytes, unlimited when zero (name:maxlen=N)
+	MinLength     int      // Shortest value allowed in bytes, no minimum when zero (tool file only)
+	Pattern       string   // Regular expression every value must match (tool file only)
+	Enum          []string // Values the field allows, any when empty (tool file only)
+	Examples      []string // Example values shown in the schema (# description || example)
+	TrueFlag      string   // For flag pairs, the flag passed when true (name:bool(--on|--off))
+	FalseFlag     string   // For flag pairs, the flag passed when false
+	AllArgs       bool     // Every argument of the call is passed as one JSON object ({{@all:json}})
+	MinItems      int      // Fewest values an array field takes, no minimum when zero (name...(2-))
+	MaxItems      int      // Most values an array field takes, no maximum when zero (name...(-5))
+}
+
+// DefaultValue resolves the field's default. Defaults written as $VAR or ${VAR}
+// read the environment variable when the command is built, and report false
+// when the variable is unset.
+func (t FieldToken) DefaultValue() (string, bool) {
+	if t.Default == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(t.Default, "$") {
+		name := strings.TrimPrefix(t.Default, "$")
+		if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+			name = name[1 : len(name)-1]
+		}
+		return os.LookupEnv(name)
+	}
+
+	return t.Default, true
+}
+
+// HasEnvDefault reports whether the field defaults to an environment variable
+func (t FieldToken) HasEnvDefault() bool {
+	return strings.HasPrefix(t.Default, "$")
 }
 
 func (t FieldToken) String() string {
+	name := t.Name
+	if t.AllArgs {
+		name += allArgsSuffix
+	}
+	if t.ReadsFile {
+		name += fileSuffix
+	}
+	if t.Trim {
+		name += trimSuffix
+	}
+	name += t.Case
+	name += t.PathCheck
+	if t.MaxLength > 0 {
+		name += maxLengthPrefix + strconv.Itoa(t.MaxLength)
+	}
+	if t.isFlagPair() {
+		name += t.flagPairModifier()
+	}
+	if t.ScalarOrArray {
+		name += scalarOrArraySuffix
+	}
+	if t.Delimiter != "" {
+		name += csvSuffix
+	}
+	if t.KeyValue {
+		name += keyValueSuffix
+	}
+	if t.Each {
+		name += eachSuffix
+	}
 	if t.Required {
-		return "{{" + t.Name + "}}"
+		return "{{" + name + "}}"
+	}
+	return "[" + name + "]"
+}
+
+// GroupToken represents an optional group of shell words written as {?...?}.
+// The group is only included when every field inside it has a value.
+type GroupToken struct {
+	Words [][]Token
+}
+
+func (t GroupToken) String() string {
+	words := make([]string, len(t.Words))
+	for i, tokens := range t.Words {
+		var word strings.Builder
+		for _, token := range tokens {
+			word.WriteString(token.String())
+		}
+		words[i] = word.String()
+	}
+	return "{?" + strings.Join(words, " ") + "?}"
+}
+
+// Fields returns the field tokens inside the group
+func (t GroupToken) Fields() []FieldToken {
+	var fields []FieldToken
+	for _, tokens := range t.Words {
+		for _, token := range tokens {
+			if fieldToken, ok := token.(FieldToken); ok {
+				fields = append(fields, fieldToken)
+			}
+		}
 	}
-	return "[" + t.Name + "]"
+	return fields
 }
 
 // Blueprint represents a parsed command template
 type Blueprint struct {
-	BaseCommand string
-	ShellWords  [][]Token // Tokenized shell words
+	BaseCommand    string
+	ShellWords     [][]Token // Tokenized shell words
+	Env            []EnvVar  // Environment variables of the command set from fields
+	FileRoot       string    // Directory that name:@file fields must read from, anywhere when empty
+	TrimArgs       bool      // Trim every value as if each field were written name:trim
+	SkipPathChecks bool      // Skip the filesystem checks of name:path, name:dir and name:path? fields
+	MaxArgLength   int       // Longest value allowed in bytes for fields without name:maxlen=N, unlimited when zero
+	MaxArrayItems  int       // Most values an array field may have, unlimited when zero
+	ArrayDelimiter string    // Array fields also take one string of values split on this, when set
+	KeepDashes     bool      // Keep dashes in schema property names instead of converting them to underscores
+	SkipEnumValues bool      // Leave the allowed values of enum fields out of their descriptions
+
+	InputSchema *jsonschema.Schema // Schema of the tool's arguments used instead of the one generated from the fields
 }
 
 // GetBaseCommand returns the base command
@@ -60,6 +187,16 @@ func (bp *Blueprint) renderTokensForDisplay(tokens []Token) string {
 		if fieldToken, ok := tokens[0].(FieldToken); ok {
 			return bp.renderFieldTokenForDisplay(fieldToken)
 		}
+		if group, ok := tokens[0].(GroupToken); ok {
+			words := make([]string, len(group.Words))
+			for i, groupTokens := range group.Words {
+				words[i] = bp.renderTokensForDisplay(groupTokens)
+			}
+			return "[" + strings.Join(words, " ") + "]"
+		}
+		if text, ok := tokens[0].(TextToken); ok {
+			return text.Value
+		}
 		return tokens[0].String()
 	}
 
@@ -84,8 +221,54 @@ func (bp *Blueprint) renderFieldTokenForDisplay(token FieldToken) string {
 		name = token.OriginalFlag
 	}
 
-	if token.IsArray {
-		name = name + "..."
+	if token.IsArray && !token.ScalarOrArray && token.Delimiter == "" {
+		name = name + "..." + token.itemCount()
+	}
+
+	// Show literal defaults so the LLM knows what leaving the field out does.
+	// Environment defaults stay private like they do in the schema.
+	if token.Default != "" && !token.HasEnvDefault() {
+		name = name + "=" + token.Default
+	}
+
+	if token.ReadsFile {
+		name = name + fileSuffix
+	}
+
+	if token.Trim {
+		name = name + trimSuffix
+	}
+
+	name = name + token.Case
+
+	name = name + token.PathCheck
+
+	if token.MaxLength > 0 {
+		name = name + maxLengthPrefix + strconv.Itoa(token.MaxLength)
+	}
+
+	if token.isFlagPair() {
+		name = name + token.flagPairModifier()
+	}
+
+	if token.ScalarOrArray {
+		name = name + scalarOrArraySuffix
+	}
+
+	if token.Delimiter != "" {
+		name = name + csvSuffix
+	}
+
+	if token.KeyValue {
+		name = name + keyValueSuffix
+	}
+
+	if token.Each {
+		name = name + eachSuffix
+	}
+
+	if token.AllArgs {
+		name = name + allArgsSuffix
 	}
 
 	// For required fields, use template format
@@ -97,7 +280,72 @@ func (bp *Blueprint) renderFieldTokenForDisplay(token FieldToken) string {
 	return "[" + name + "]"
 }
 
-// GetInputSchema returns the input schema
+// fields returns every field token in the blueprint in order, followed by the
+// fields of environment variables. Fields inside optional groups or with a
+// default are returned as optional since they can be left out by the client.
+// The {{@all:json}} field isn't an argument, so it's left out.
+func (bp *Blueprint) fields() []FieldToken {
+	words := slices.Clone(bp.ShellWords)
+	for _, envVar := range bp.Env {
+		words = append(words, envVar.Tokens)
+	}
+
+	var fields []FieldToken
+	for _, tokens := range words {
+		for _, token := range tokens {
+			switch t := token.(type) {
+			case FieldToken:
+				if t.AllArgs {
+					continue
+				}
+				if t.Default != "" || t.DefaultFile != "" {
+					t.Required = false
+				}
+				fields = append(fields, t)
+			case GroupToken:
+				for _, fieldToken := range t.Fields() {
+					if fieldToken.AllArgs {
+						continue
+					}
+					fieldToken.Required = false
+					fields = append(fields, fieldToken)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// updateFields replaces every field of the blueprint, including those in
+// groups and environment variables, with the result of update
+func (bp *Blueprint) updateFields(update func(FieldToken) FieldToken) {
+	apply := func(tokens []Token) {
+		for i, token := range tokens {
+			if fieldToken, ok := token.(FieldToken); ok {
+				tokens[i] = update(fieldToken)
+			}
+		}
+	}
+
+	for _, tokens := range bp.ShellWords {
+		apply(tokens)
+		for _, token := range tokens {
+			if group, ok := token.(GroupToken); ok {
+				for _, groupTokens := range group.Words {
+					apply(groupTokens)
+				}
+			}
+		}
+	}
+	for _, envVar := range bp.Env {
+		apply(envVar.Tokens)
+	}
+}
+
+// GetInputSchema returns the input schema, InputSchema when it is set
 func (bp *Blueprint) GetInputSchema() interface{} {
+	if bp.InputSchema != nil {
+		return bp.InputSchema
+	}
 	return bp.GenerateInputSchema()
 }
@@ -0,0 +1,104 @@
+package studio
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// capabilities returns the server capabilities for the features enabled in
+// this invocation. The tool is always offered; resources and prompts only
+// when a flag that needs them is passed. The tool list never changes, so listChanged
+// is left out.
+func (s *Studio) capabilities() map[string]any {
+	capabilities := map[string]any{"tools": map[string]any{}}
+	if s.Resources || s.PageSize > 0 {
+		capabilities["resources"] = map[string]any{}
+	}
+	if s.Prompts {
+		capabilities["prompts"] = map[string]any{}
+	}
+	return capabilities
+}
+
+// capabilitiesTransport wraps a transport so that the initialize response
+// advertises the given capabilities. The SDK always advertises every feature
+// it supports, even ones this server doesn't offer.
+type capabilitiesTransport struct {
+	mcp.Transport
+	capabilities map[string]any
+}
+
+// Connect connects the wrapped transport
+func (t capabilitiesTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &capabilitiesConn{Connection: conn, capabilities: t.capabilities}, nil
+}
+
+// capabilitiesConn is a connection that replaces the capabilities in the
+// response to initialize
+type capabilitiesConn struct {
+	mcp.Connection
+	capabilities map[string]any
+
+	mu         sync.Mutex
+	initialize []mcp.JSONRPCID // IDs of initialize requests waiting for a response
+}
+
+// Read reads the next message, remembering initialize requests
+func (c *capabilitiesConn) Read(ctx context.Context) (mcp.JSONRPCMessage, error) {
+	msg, err := c.Connection.Read(ctx)
+	if req, ok := msg.(*mcp.JSONRPCRequest); ok && req.Method == "initialize" {
+		c.mu.Lock()
+		c.initialize = append(c.initialize, req.ID)
+		c.mu.Unlock()
+	}
+	return msg, err
+}
+
+// Write writes msg, replacing the capabilities of initialize results
+func (c *capabilitiesConn) Write(ctx context.Context, msg mcp.JSONRPCMessage) error {
+	if resp, ok := msg.(*mcp.JSONRPCResponse); ok && c.isInitialize(resp.ID) && resp.Error == nil {
+		result, err := c.withCapabilities(resp.Result)
+		if err != nil {
+			return err
+		}
+		rewritten := *resp
+		rewritten.Result = result
+		msg = &rewritten
+	}
+	return c.Connection.Write(ctx, msg)
+}
+
+// isInitialize reports whether id belongs to an initialize request, and
+// forgets it since each request gets one response
+func (c *capabilitiesConn) isInitialize(id mcp.JSONRPCID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pending := range c.initialize {
+		if pending == id {
+			c.initialize = append(c.initialize[:i], c.initialize[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// withCapabilities returns the initialize result with its capabilities replaced
+func (c *capabilitiesConn) withCapabilities(result json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(result, &fields); err != nil {
+		return nil, err
+	}
+	capabilities, err := json.Marshal(c.capabilities)
+	if err != nil {
+		return nil, err
+	}
+	fields["capabilities"] = capabilities
+	return json.Marshal(fields)
+}
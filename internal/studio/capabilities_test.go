@@ -0,0 +1,64 @@
+package studio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStudio_Capabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{"tools only by default", Options{}, `{"tools":{}}`},
+		{"resources", Options{Resources: true}, `{"tools":{},"resources":{}}`},
+		{"prompts", Options{Prompts: true}, `{"tools":{},"prompts":{}}`},
+		{"paged output", Options{PageSize: 4096}, `{"tools":{},"resources":{}}`},
+		{"everything", Options{Resources: true, Prompts: true}, `{"tools":{},"resources":{},"prompts":{}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Studio{Options: tt.opts}
+			data, err := json.Marshal(s.capabilities())
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestCapabilities_Conn(t *testing.T) {
+	sdkResult := json.RawMessage(`{"protocolVersion":"2025-03-26","capabilities":{"completions":{},"logging":{},"prompts":{"listChanged":true},"resources":{"listChanged":true},"tools":{"listChanged":true}},"serverInfo":{"name":"studio","version":"dev"}}`)
+
+	t.Run("replaces the capabilities in the initialize result", func(t *testing.T) {
+		inner := &fakeConn{reads: []fakeRead{{msg: &mcp.JSONRPCRequest{Method: "initialize"}}}}
+		conn := &capabilitiesConn{Connection: inner, capabilities: map[string]any{"tools": map[string]any{}}}
+
+		_, err := conn.Read(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, conn.Write(context.Background(), &mcp.JSONRPCResponse{Result: sdkResult}))
+
+		require.Len(t, inner.writes, 1)
+		result := inner.writes[0].(*mcp.JSONRPCResponse).Result
+		assert.JSONEq(t, `{"protocolVersion":"2025-03-26","capabilities":{"tools":{}},"serverInfo":{"name":"studio","version":"dev"}}`, string(result))
+	})
+
+	t.Run("leaves other responses alone", func(t *testing.T) {
+		inner := &fakeConn{reads: []fakeRead{{msg: &mcp.JSONRPCRequest{Method: "ping"}}}}
+		conn := &capabilitiesConn{Connection: inner, capabilities: map[string]any{"tools": map[string]any{}}}
+
+		_, err := conn.Read(context.Background())
+		require.NoError(t, err)
+		response := &mcp.JSONRPCResponse{Result: sdkResult}
+		require.NoError(t, conn.Write(context.Background(), response))
+
+		require.Len(t, inner.writes, 1)
+		assert.Same(t, response, inner.writes[0])
+	})
+}
@@ -0,0 +1,40 @@
+package studio
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/studio-mcp/studio/internal/blueprint"
+	"github.com/studio-mcp/studio/internal/tool"
+)
+
+// DefaultCheckArgs are passed to the base command by Check when CheckArgs is empty
+var DefaultCheckArgs = []string{"--version"}
+
+// Check runs the base command with CheckArgs to make sure it exists and can
+// run, so a missing command fails at startup instead of on the first call
+func (s *Studio) Check() error {
+	for _, token := range s.Blueprint.ShellWords[0] {
+		if _, ok := token.(blueprint.TextToken); !ok {
+			return fmt.Errorf("check failed: the command %q is a template, so it can't be run before a call", s.Blueprint.BaseCommand)
+		}
+	}
+
+	args := s.CheckArgs
+	if len(args) == 0 {
+		args = DefaultCheckArgs
+	}
+	command := strings.Join(append([]string{s.Blueprint.BaseCommand}, args...), " ")
+
+	if _, err := exec.LookPath(s.Blueprint.BaseCommand); err != nil {
+		return fmt.Errorf("check failed: %s could not run: %w", s.Blueprint.BaseCommand, err)
+	}
+	if output, err := tool.Execute(s.Blueprint.BaseCommand, args...); err != nil {
+		if output == "" {
+			return fmt.Errorf("check failed: %s: %w", command, err)
+		}
+		return fmt.Errorf("check failed: %s: %w: %s", command, err, output)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package studio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStudio_Check(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		checkArgs     []string
+		expectedError string
+	}{
+		{
+			name: "command that runs",
+			args: []string{"echo", "{{text}}"},
+		},
+		{
+			name:          "missing command",
+			args:          []string{"studio-command-that-does-not-exist", "{{text}}"},
+			expectedError: "check failed: studio-command-that-does-not-exist could not run",
+		},
+		{
+			name:          "command that fails",
+			args:          []string{"sh", "-c", "{{script}}"},
+			checkArgs:     []string{"-c", "echo broken >&2; exit 3"},
+			expectedError: "check failed: sh -c echo broken >&2; exit 3: command failed with exit code 3: broken",
+		},
+		{
+			name:          "templated command",
+			args:          []string{"{{cmd}}", "--version"},
+			expectedError: "check failed: the command \"{{cmd}}\" is a template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.args, Options{CheckArgs: tt.checkArgs})
+			require.NoError(t, err)
+
+			err = s.Check()
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package studio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/studio-mcp/studio/internal/tool"
+)
+
+// Framings choose how messages are delimited on stdin and stdout
+const (
+	FramingNewline       = "newline"
+	FramingContentLength = "content-length"
+)
+
+// maxFrameLength is the largest Content-Length studio reads, so a bad header
+// fails the stream instead of allocating whatever it claims
+const maxFrameLength = 64 << 20
+
+// Framings lists the supported framings
+var Framings = []string{FramingNewline, FramingContentLength}
+
+// IsFraming reports whether framing is a supported framing
+func IsFraming(framing string) bool {
+	return slices.Contains(Framings, framing)
+}
+
+// contentLengthTransport returns a transport whose messages are framed with a
+// Content-Length header on r and w, like LSP. The SDK only delimits stdio with
+// newlines, so its stdio transport is given pipes instead, and every message
+// is reframed on its way through.
+func contentLengthTransport(r io.Reader, w io.Writer) (mcp.Transport, error) {
+	sdkIn, toSDK, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+	fromSDK, sdkOut, err := os.Pipe()
+	if err != nil {
+		sdkIn.Close()
+		toSDK.Close()
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	// NewStdioTransport takes os.Stdin and os.Stdout when it is called and has
+	// no constructor for other streams, so they are swapped for the pipes only
+	// while it runs. ServeWithContext calls this before the session starts:
+	// studio never reads stdin and only writes stdout through the transport
+	// when serving, and its debug logs go to stderr or the log file, so no
+	// other goroutine can see the swap.
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = sdkIn, sdkOut
+	transport := mcp.NewStdioTransport()
+	os.Stdin, os.Stdout = stdin, stdout
+
+	// Closing a pipe ends the session when the client goes away, and ends
+	// writing once the session closes
+	go func() {
+		if err := readFrames(r, toSDK); err != nil {
+			tool.Debug("Stopped reading messages: %s", err)
+		}
+		toSDK.Close()
+	}()
+	go func() {
+		if err := writeFrames(fromSDK, w); err != nil {
+			tool.Debug("Stopped writing messages: %s", err)
+		}
+		fromSDK.Close()
+	}()
+	return transport, nil
+}
+
+// readFrames copies every message framed with a Content-Length header from r
+// to w as a line of JSON. Other headers, like Content-Type, are ignored.
+func readFrames(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		length := -1
+		for headers := 0; ; headers++ {
+			line, err := reader.ReadString('\n')
+			if err == io.EOF && line == "" && headers == 0 {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("reading headers: %w", err)
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				break
+			}
+			name, value, ok := strings.Cut(line, ":")
+			if !ok || !isHeaderName(name) {
+				return fmt.Errorf("invalid header %q", line)
+			}
+			if strings.EqualFold(name, "Content-Length") {
+				length, err = strconv.Atoi(strings.TrimSpace(value))
+				if err != nil || length < 0 {
+					return fmt.Errorf("invalid Content-Length %q", strings.TrimSpace(value))
+				}
+				if length > maxFrameLength {
+					return fmt.Errorf("Content-Length %d is more than the limit of %d bytes", length, maxFrameLength)
+				}
+			}
+		}
+		if length < 0 {
+			return fmt.Errorf("message without a Content-Length header")
+		}
+
+		message := make([]byte, length+1)
+		if _, err := io.ReadFull(reader, message[:length]); err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+		message[length] = '\n'
+		if _, err := w.Write(message); err != nil {
+			return err
+		}
+	}
+}
+
+// writeFrames copies every line of JSON from r to w, framed with a
+// Content-Length header
+func writeFrames(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if message := bytes.TrimSpace(line); len(message) > 0 {
+			frame := fmt.Appendf(nil, "Content-Length: %d\r\n\r\n", len(message))
+			if _, err := w.Write(append(frame, message...)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isHeaderName reports whether name can be the name of a header, so a client
+// sending newline delimited JSON fails rather than being ignored
+func isHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
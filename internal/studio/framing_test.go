@@ -0,0 +1,124 @@
+package studio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frame returns message framed with a Content-Length header
+func frame(message string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(message), message)
+}
+
+// readFrame reads the next framed message from r
+func readFrame(t *testing.T, r *bufio.Reader) []byte {
+	header, err := r.ReadString('\n')
+	require.NoError(t, err)
+	length, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "Content-Length:")))
+	require.NoError(t, err)
+	blank, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "\r\n", blank)
+
+	message := make([]byte, length)
+	_, err = io.ReadFull(r, message)
+	require.NoError(t, err)
+	return message
+}
+
+func TestReadFrames(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{"one message", frame(`{"id":1}`), "{\"id\":1}\n", ""},
+		{"two messages", frame(`{"id":1}`) + frame(`{"id":2}`), "{\"id\":1}\n{\"id\":2}\n", ""},
+		{"newlines in a message", frame("{\n\"id\": 1\n}"), "{\n\"id\": 1\n}\n", ""},
+		{"other headers", "content-length: 2\nContent-Type: application/json\n\n{}", "{}\n", ""},
+		{"no messages", "", "", ""},
+		{"no Content-Length", "Content-Type: application/json\r\n\r\n{}", "", "message without a Content-Length header"},
+		{"invalid Content-Length", "Content-Length: many\r\n\r\n{}", "", `invalid Content-Length "many"`},
+		{"huge Content-Length", "Content-Length: 9999999999999\r\n\r\n{}", "", "Content-Length 9999999999999 is more than the limit"},
+		{"invalid header", "{\"id\":1}\n", "", "invalid header"},
+		{"short message", "Content-Length: 10\r\n\r\n{}", "", "reading message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := readFrames(strings.NewReader(tt.input), &out)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestWriteFrames(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeFrames(strings.NewReader("{\"id\":1}\n{\"id\":2}\n"), &out))
+	assert.Equal(t, frame(`{"id":1}`)+frame(`{"id":2}`), out.String())
+}
+
+func TestStudio_ContentLengthFraming(t *testing.T) {
+	// About 700KB of output, far more than a pipe buffers at once
+	s, err := New([]string{"sh", "-c", "yes studio | head -n 100000"}, Options{Framing: FramingContentLength})
+	require.NoError(t, err)
+
+	clientOut, serverIn := io.Pipe()
+	serverOut, clientIn := io.Pipe()
+	transport, err := contentLengthTransport(clientOut, clientIn)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	session, err := s.newServer(ctx).Connect(ctx, transport)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	responses := bufio.NewReader(serverOut)
+	send := func(message string) {
+		_, err := io.WriteString(serverIn, frame(message))
+		require.NoError(t, err)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	var initialized struct {
+		ID     int            `json:"id"`
+		Result map[string]any `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(readFrame(t, responses), &initialized))
+	assert.Equal(t, 1, initialized.ID)
+	assert.Equal(t, "studio", initialized.Result["serverInfo"].(map[string]any)["name"])
+
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`)
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"sh","arguments":{}}}`)
+	var called struct {
+		ID     int `json:"id"`
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(readFrame(t, responses), &called))
+	assert.Equal(t, 2, called.ID)
+	assert.False(t, called.Result.IsError)
+	require.Len(t, called.Result.Content, 1)
+	assert.Equal(t, strings.TrimSpace(strings.Repeat("studio\n", 100000)), called.Result.Content[0].Text)
+}
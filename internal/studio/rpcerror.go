@@ -0,0 +1,271 @@
+package studio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Standard JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// rpcError is a JSON-RPC error in the JSON form the spec fixes. Studio
+// creates the errors it answers requests with as rpcErrors.
+type rpcError struct {
+	Code    int64           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// newRPCError creates an error that is sent to the client with code and data
+func newRPCError(code int64, message string, data json.RawMessage) *rpcError {
+	return &rpcError{Code: code, Message: message, Data: data}
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// wireError returns e in the SDK's error type, the only one the SDK sends
+// with a code. The type lives in an internal package, so an error the SDK
+// builds is filled in from e's JSON, which TestRPCError_WireError checks.
+// e itself is returned, sent without a code, if that can't be done.
+func (e *rpcError) wireError() error {
+	wire := mcp.ResourceNotFoundError("")
+	data, err := json.Marshal(e)
+	if err != nil || json.Unmarshal(data, wire) != nil {
+		return e
+	}
+	if len(e.Data) == 0 {
+		// Only the code of a wrapped error is sent, without the data it was built with
+		return &codedError{message: e.Message, wire: wire}
+	}
+	return wire
+}
+
+// codedError is an error sent with the message it has and the code of the
+// SDK error it wraps
+type codedError struct {
+	message string
+	wire    error
+}
+
+func (e *codedError) Error() string { return e.message }
+func (e *codedError) Unwrap() error { return e.wire }
+
+// methodData returns the data of an error answering a request for method,
+// which names it, or nothing when the method isn't known
+func methodData(method string) json.RawMessage {
+	if method == "" {
+		return nil
+	}
+	data, _ := json.Marshal(map[string]string{"method": method})
+	return data
+}
+
+// rpcErrorCode returns the JSON-RPC error code carried by err, or 0 if it has
+// none. Errors with a code have the JSON form of a JSON-RPC error.
+func rpcErrorCode(err error) int64 {
+	for ; err != nil; err = errors.Unwrap(err) {
+		var wire rpcError
+		if data, marshalErr := json.Marshal(err); marshalErr == nil && json.Unmarshal(data, &wire) == nil && wire.Code != 0 {
+			return wire.Code
+		}
+	}
+	return 0
+}
+
+// checkRequests returns middleware that answers requests the SDK would fail
+// without a code, like calls to tools that don't exist and arguments that
+// aren't an object, with invalid params. prompt is nil without prompts.
+func checkRequests(serverTool *mcp.ServerTool, prompt *mcp.ServerPrompt) mcp.Middleware[*mcp.ServerSession] {
+	// The SDK only checks arguments against the schema of tools it didn't make
+	// with NewServerTool. Studio checks the others itself, with a tool error.
+	var schema *jsonschema.Resolved
+	if serverTool.Handler != nil && serverTool.Tool.InputSchema != nil {
+		// A schema is resolved once, by the SDK, so a copy is resolved the same way
+		var copied jsonschema.Schema
+		if data, err := json.Marshal(serverTool.Tool.InputSchema); err == nil && json.Unmarshal(data, &copied) == nil {
+			schema, _ = copied.Resolve(&jsonschema.ResolveOptions{ValidateDefaults: true})
+		}
+	}
+
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, session *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			switch p := params.(type) {
+			case *mcp.CallToolParamsFor[json.RawMessage]:
+				if p.Name != serverTool.Tool.Name {
+					return nil, newRPCError(codeInvalidParams, fmt.Sprintf("unknown tool %q", p.Name), methodData(method)).wireError()
+				}
+				if err := checkArguments(p.Arguments, schema); err != nil {
+					return nil, newRPCError(codeInvalidParams, fmt.Sprintf("invalid arguments for tool %q: %s", p.Name, err), methodData(method)).wireError()
+				}
+			case *mcp.GetPromptParams:
+				if prompt == nil || p.Name != prompt.Prompt.Name {
+					return nil, newRPCError(codeInvalidParams, fmt.Sprintf("unknown prompt %q", p.Name), methodData(method)).wireError()
+				}
+			}
+			return next(ctx, session, method, params)
+		}
+	}
+}
+
+// checkArguments checks tool arguments the way the SDK does before calling
+// the tool: they must be an object, which matches schema once its defaults
+// apply when there is one
+func checkArguments(arguments json.RawMessage, schema *jsonschema.Resolved) error {
+	if arguments == nil {
+		return nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+	if err := schema.ApplyDefaults(&args); err != nil {
+		return err
+	}
+	return schema.Validate(&args)
+}
+
+// isInvalidMessage reports whether err came from a line of valid JSON that is
+// not a JSON-RPC message. The stream can still be read after these. The SDK
+// makes some of these errors without a type to tell them by, so those are
+// told by their messages, which TestRPCError_InvalidMessages checks.
+func isInvalidMessage(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	if rpcErrorCode(err) != 0 || errors.As(err, &typeErr) {
+		return true
+	}
+	message := err.Error()
+	for _, prefix := range []string{"invalid message version tag", "empty batch", "duplicate message ID"} {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// rpcErrorTransport wraps a transport so that error responses always carry a
+// standard JSON-RPC error code. The SDK sends some errors, like requests
+// before initialize and params that can't be unmarshaled, with code 0.
+type rpcErrorTransport struct {
+	mcp.Transport
+}
+
+// Connect connects the wrapped transport
+func (t rpcErrorTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcErrorConn{Connection: conn, methods: make(map[mcp.JSONRPCID]string)}, nil
+}
+
+// rpcErrorConn is a connection that answers requests the SDK would fail
+// without a code and fills in missing JSON-RPC error codes
+type rpcErrorConn struct {
+	mcp.Connection
+
+	mu          sync.Mutex
+	methods     map[mcp.JSONRPCID]string // methods of requests waiting for a response
+	initialized bool                     // an initialize request was read
+}
+
+// Read reads the next message. Messages that aren't valid JSON-RPC get an
+// error response instead of closing the connection, while invalid JSON is
+// reported before the connection closes since the stream can't continue.
+func (c *rpcErrorConn) Read(ctx context.Context) (mcp.JSONRPCMessage, error) {
+	for {
+		msg, err := c.Connection.Read(ctx)
+		if err != nil {
+			var syntaxErr *json.SyntaxError
+			switch {
+			case errors.As(err, &syntaxErr):
+				c.writeError(ctx, mcp.JSONRPCID{}, newRPCError(codeParseError, fmt.Sprintf("Parse error: %s", err), nil))
+				return nil, err
+			case isInvalidMessage(err):
+				c.writeError(ctx, mcp.JSONRPCID{}, newRPCError(codeInvalidRequest, fmt.Sprintf("Invalid request: %s", err), nil))
+				continue
+			default:
+				return nil, err
+			}
+		}
+
+		if req, ok := msg.(*mcp.JSONRPCRequest); ok && req.ID.IsValid() {
+			if rpcErr := c.checkRequest(req); rpcErr != nil {
+				c.writeError(ctx, req.ID, rpcErr)
+				continue
+			}
+			c.mu.Lock()
+			c.methods[req.ID] = req.Method
+			c.mu.Unlock()
+		}
+		return msg, nil
+	}
+}
+
+// checkRequest returns the error for a request the SDK would fail without a
+// code: one other than ping before initialize, which the SDK handles first as
+// it handles requests in order, or one with params that aren't an object
+func (c *rpcErrorConn) checkRequest(req *mcp.JSONRPCRequest) *rpcError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch req.Method {
+	case "initialize":
+		c.initialized = true
+	case "ping":
+	default:
+		if !c.initialized {
+			return newRPCError(codeInvalidRequest, fmt.Sprintf("method %q is invalid before initialize", req.Method), methodData(req.Method))
+		}
+	}
+
+	var params map[string]json.RawMessage
+	if len(req.Params) > 0 && json.Unmarshal(req.Params, &params) != nil {
+		return newRPCError(codeInvalidParams, fmt.Sprintf("params of %q must be an object", req.Method), methodData(req.Method))
+	}
+	return nil
+}
+
+// Write writes msg, sending errors without a code as internal errors that
+// name the method of the request
+func (c *rpcErrorConn) Write(ctx context.Context, msg mcp.JSONRPCMessage) error {
+	resp, ok := msg.(*mcp.JSONRPCResponse)
+	if !ok {
+		return c.Connection.Write(ctx, msg)
+	}
+
+	c.mu.Lock()
+	method := c.methods[resp.ID]
+	delete(c.methods, resp.ID)
+	c.mu.Unlock()
+
+	if resp.Error != nil && rpcErrorCode(resp.Error) == 0 {
+		coded := *resp
+		coded.Error = newRPCError(codeInternalError, resp.Error.Error(), methodData(method)).wireError()
+		msg = &coded
+	}
+
+	return c.Connection.Write(ctx, msg)
+}
+
+// writeError sends an error response to the request with id, which is left
+// out for a message that could not be read
+func (c *rpcErrorConn) writeError(ctx context.Context, id mcp.JSONRPCID, rpcErr *rpcError) {
+	// The connection is closing if this fails, which Read reports on its own
+	_ = c.Connection.Write(ctx, &mcp.JSONRPCResponse{ID: id, Error: rpcErr.wireError()})
+}
@@ -0,0 +1,288 @@
+package studio
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn is a connection that reads queued results and records writes
+type fakeConn struct {
+	reads  []fakeRead
+	writes []mcp.JSONRPCMessage
+}
+
+type fakeRead struct {
+	msg mcp.JSONRPCMessage
+	err error
+}
+
+func (c *fakeConn) Read(ctx context.Context) (mcp.JSONRPCMessage, error) {
+	if len(c.reads) == 0 {
+		return nil, io.EOF
+	}
+	next := c.reads[0]
+	c.reads = c.reads[1:]
+	return next.msg, next.err
+}
+
+func (c *fakeConn) Write(ctx context.Context, msg mcp.JSONRPCMessage) error {
+	c.writes = append(c.writes, msg)
+	return nil
+}
+
+func (c *fakeConn) Close() error      { return nil }
+func (c *fakeConn) SessionID() string { return "" }
+
+// wireJSON returns the JSON the client sees for the error in msg, which is
+// written through the SDK's encoding
+func wireJSON(t *testing.T, msg mcp.JSONRPCMessage) string {
+	out, w := io.Pipe()
+	transport, err := contentLengthTransport(strings.NewReader(""), w)
+	require.NoError(t, err)
+	conn, err := transport.Connect(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.NoError(t, conn.Write(context.Background(), msg))
+	var response struct {
+		Error json.RawMessage `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(readFrame(t, bufio.NewReader(out)), &response))
+	return string(response.Error)
+}
+
+// readMessage decodes message the way the SDK's transports do
+func readMessage(t *testing.T, message string) mcp.JSONRPCMessage {
+	transport, err := contentLengthTransport(strings.NewReader(frame(message)), io.Discard)
+	require.NoError(t, err)
+	conn, err := transport.Connect(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	msg, err := conn.Read(context.Background())
+	require.NoError(t, err)
+	return msg
+}
+
+func TestRPCError_Code(t *testing.T) {
+	err := newRPCError(codeInvalidParams, "bad", nil).wireError()
+
+	assert.Equal(t, "bad", err.Error())
+	assert.Equal(t, int64(codeInvalidParams), rpcErrorCode(err))
+	assert.Equal(t, int64(codeInvalidParams), rpcErrorCode(fmt.Errorf("wrapped: %w", err)))
+	assert.Equal(t, int64(mcp.CodeResourceNotFound), rpcErrorCode(mcp.ResourceNotFoundError("studio://nope")))
+	assert.Equal(t, int64(0), rpcErrorCode(errors.New("plain")))
+}
+
+func TestRPCError_Conn(t *testing.T) {
+	t.Run("sends errors without a code as internal errors", func(t *testing.T) {
+		inner := &fakeConn{}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		require.NoError(t, conn.Write(context.Background(), &mcp.JSONRPCResponse{
+			Error: errors.New("something broke"),
+		}))
+
+		require.Len(t, inner.writes, 1)
+		assert.JSONEq(t, `{"code":-32603,"message":"something broke"}`, wireJSON(t, inner.writes[0]))
+	})
+
+	t.Run("answers requests before initialize", func(t *testing.T) {
+		list := readMessage(t, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+		initialize := readMessage(t, `{"jsonrpc":"2.0","id":2,"method":"initialize"}`)
+		inner := &fakeConn{reads: []fakeRead{{msg: list}, {msg: initialize}}}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		msg, err := conn.Read(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, initialize, msg)
+
+		require.Len(t, inner.writes, 1)
+		assert.Equal(t, list.(*mcp.JSONRPCRequest).ID, inner.writes[0].(*mcp.JSONRPCResponse).ID)
+		assert.JSONEq(t, `{"code":-32600,"message":"method \"tools/list\" is invalid before initialize","data":{"method":"tools/list"}}`, wireJSON(t, inner.writes[0]))
+	})
+
+	t.Run("answers params that aren't an object", func(t *testing.T) {
+		inner := &fakeConn{reads: []fakeRead{
+			{msg: readMessage(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":"bad"}`)},
+		}}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		_, err := conn.Read(context.Background())
+		assert.Equal(t, io.EOF, err)
+
+		require.Len(t, inner.writes, 1)
+		assert.JSONEq(t, `{"code":-32602,"message":"params of \"initialize\" must be an object","data":{"method":"initialize"}}`, wireJSON(t, inner.writes[0]))
+	})
+
+	t.Run("keeps errors that already have a code", func(t *testing.T) {
+		inner := &fakeConn{}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		notFound := mcp.ResourceNotFoundError("studio://nope")
+		require.NoError(t, conn.Write(context.Background(), &mcp.JSONRPCResponse{Error: notFound}))
+
+		require.Len(t, inner.writes, 1)
+		assert.Equal(t, notFound, inner.writes[0].(*mcp.JSONRPCResponse).Error)
+	})
+
+	t.Run("answers invalid messages and keeps reading", func(t *testing.T) {
+		request := &mcp.JSONRPCRequest{Method: "ping"}
+		inner := &fakeConn{reads: []fakeRead{
+			{err: errors.New(`invalid message version tag "1.0"; expected "2.0"`)},
+			{msg: request},
+		}}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		msg, err := conn.Read(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, request, msg)
+
+		require.Len(t, inner.writes, 1)
+		assert.Contains(t, wireJSON(t, inner.writes[0]), `"code":-32600`)
+	})
+
+	t.Run("answers invalid JSON before closing", func(t *testing.T) {
+		var syntaxErr *json.SyntaxError
+		syntax := json.Unmarshal([]byte("{not json"), &struct{}{})
+		require.ErrorAs(t, syntax, &syntaxErr)
+
+		inner := &fakeConn{reads: []fakeRead{{err: syntax}}}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		_, err := conn.Read(context.Background())
+		assert.Equal(t, syntax, err)
+
+		require.Len(t, inner.writes, 1)
+		assert.Contains(t, wireJSON(t, inner.writes[0]), `"code":-32700`)
+	})
+
+	t.Run("passes read errors through", func(t *testing.T) {
+		inner := &fakeConn{}
+		conn := &rpcErrorConn{Connection: inner, methods: make(map[mcp.JSONRPCID]string)}
+
+		_, err := conn.Read(context.Background())
+		assert.Equal(t, io.EOF, err)
+		assert.Empty(t, inner.writes)
+	})
+}
+
+// TestRPCError_WireError sends studio's errors through the SDK's encoding,
+// so a change to the SDK's error type shows up as a missing code here
+func TestRPCError_WireError(t *testing.T) {
+	// Studio's errors are built from this one, in the SDK's error type
+	data, err := json.Marshal(mcp.ResourceNotFoundError("studio://nope"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"code":-32002,"message":"Resource not found","data":{"uri":"studio://nope"}}`, string(data),
+		"the SDK's error type changed, so studio's errors can't carry a code")
+
+	tests := []struct {
+		name     string
+		err      *rpcError
+		expected string
+	}{
+		{"with data", newRPCError(codeInvalidParams, "bad", json.RawMessage(`{"method":"tools/call"}`)), `{"code":-32602,"message":"bad","data":{"method":"tools/call"}}`},
+		{"without data", newRPCError(codeInvalidRequest, "bad", nil), `{"code":-32600,"message":"bad"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.JSONEq(t, tt.expected, wireJSON(t, &mcp.JSONRPCResponse{Error: tt.err.wireError()}))
+		})
+	}
+}
+
+// serveErrors serves s over pipes, returning a function that sends a message
+// and returns the code of the error it's answered with, and one that
+// initializes the session
+func serveErrors(t *testing.T, s *Studio) (call func(message string) int64, initialize func()) {
+	clientOut, serverIn := io.Pipe()
+	serverOut, clientIn := io.Pipe()
+	transport, err := contentLengthTransport(clientOut, clientIn)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	session, err := s.newServer(ctx).Connect(ctx, rpcErrorTransport{transport})
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	responses := bufio.NewReader(serverOut)
+	call = func(message string) int64 {
+		_, err := io.WriteString(serverIn, frame(message))
+		require.NoError(t, err)
+		var response struct {
+			Error *rpcError `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(readFrame(t, responses), &response))
+		require.NotNil(t, response.Error, "expected an error response to %s", message)
+		return response.Error.Code
+	}
+	initialize = func() {
+		_, err := io.WriteString(serverIn, frame(`{"jsonrpc":"2.0","id":"init","method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`))
+		require.NoError(t, err)
+		readFrame(t, responses)
+		_, err = io.WriteString(serverIn, frame(`{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`))
+		require.NoError(t, err)
+	}
+	return call, initialize
+}
+
+// TestRPCError_SDKErrors sends requests the SDK fails without a code, so a
+// change to how the SDK handles them shows up as a wrong code here
+func TestRPCError_SDKErrors(t *testing.T) {
+	s, err := New([]string{"echo", "{{text}}"}, Options{Framing: FramingContentLength})
+	require.NoError(t, err)
+	call, initialize := serveErrors(t, s)
+
+	assert.Equal(t, int64(codeInvalidRequest), call(`{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`), "request before initialize")
+
+	initialize()
+	assert.Equal(t, int64(codeInvalidParams), call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"nope","arguments":{}}}`), "unknown tool")
+	assert.Equal(t, int64(codeInvalidParams), call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":"bad"}}`), "arguments that don't unmarshal")
+	assert.Equal(t, int64(codeInvalidParams), call(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":"bad"}`), "params that aren't an object")
+	assert.Equal(t, int64(codeInvalidParams), call(`{"jsonrpc":"2.0","id":5,"method":"prompts/get","params":{"name":"nope"}}`), "unknown prompt")
+
+	t.Run("arguments the SDK validates", func(t *testing.T) {
+		schema := filepath.Join(t.TempDir(), "output.json")
+		require.NoError(t, os.WriteFile(schema, []byte(`{"type": "object"}`), 0644))
+		s, err := New([]string{"echo", "{{text}}"}, Options{Framing: FramingContentLength, OutputSchema: schema})
+		require.NoError(t, err)
+		call, initialize := serveErrors(t, s)
+
+		initialize()
+		assert.Equal(t, int64(codeInvalidParams), call(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{}}}`))
+	})
+}
+
+// TestRPCError_InvalidMessages sends messages the SDK's transport can't read,
+// so a change to the SDK's error messages shows up as a closed connection here
+func TestRPCError_InvalidMessages(t *testing.T) {
+	inner, err := contentLengthTransport(strings.NewReader(
+		frame(`{"jsonrpc":"1.0","id":1,"method":"ping"}`)+
+			frame(`{"jsonrpc":"2.0","id":1,"method":5}`)+
+			frame(`[]`)+
+			frame(`[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","id":1,"method":"tools/list"}]`)+
+			frame(`{"jsonrpc":"2.0","id":2,"method":"ping"}`),
+	), io.Discard)
+	require.NoError(t, err)
+	conn, err := rpcErrorTransport{inner}.Connect(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	msg, err := conn.Read(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ping", msg.(*mcp.JSONRPCRequest).Method)
+	assert.Equal(t, int64(2), msg.(*mcp.JSONRPCRequest).ID.Raw())
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/studio-mcp/studio/internal/blueprint"
 	"github.com/studio-mcp/studio/internal/tool"
@@ -12,39 +17,257 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// DefaultServerName is the name reported to clients unless one is configured
+const DefaultServerName = "studio"
+
+// Options configures a Studio instance
+type Options struct {
+	DebugMode  bool
+	LogFile    string
+	AuditLog   string // File that every command run is appended to as a JSON line
+	Color      bool   // Color debug logs written to stderr
+	ServerName string // Name reported in serverInfo, studio when empty
+	NamePrefix string // Put in front of the tool name, like repo1_ for repo1_git
+	Framing    string // How messages are delimited on stdio, newline when empty
+	Version    string
+	Commit     string // Git commit studio was built from, logged at startup
+	Resources  bool   // Expose the last command output as an MCP resource
+	Prompts    bool   // Expose a prompt explaining how to call the tool
+	Shell      bool   // Run the command through sh -c
+	MIMEType   string // MIME type of binary output, sniffed when empty
+	OutputType string // How output is returned: auto, text, image or binary
+	Encode     string // Encoding of stdout returned as text, base64 or hex, none when empty
+	Quiet      bool   // Leave stderr out of successful results
+
+	MaxConcurrency int       // Maximum number of commands running at once, zero for unlimited
+	RateLimit      tool.Rate // Calls allowed per period, unlimited when unset
+	EchoCommand    bool      // Include the executed argv in result metadata
+	OutputSize     bool      // Include the bytes written to stdout and stderr, and whether the content was cut, in result metadata
+	ReportDuration bool      // Include how long the command ran in result metadata
+	RunAsUser      string    // User to run commands as, a name, uid or uid:gid
+	MergeOutput    bool      // Capture stdout and stderr as one stream in order
+
+	// Fields override the description, type and required flag of the template tags
+	Fields map[string]blueprint.FieldDefinition
+
+	// Delimiters replace the {{ }} and [ ] markers around fields when set
+	Delimiters blueprint.Delimiters
+
+	// RequireDescriptions refuses to start when a required field has no description
+	RequireDescriptions bool
+
+	Glossary     string // JSON file of default field descriptions
+	InputSchema  string // JSON schema file of the tool's arguments, inferred from the fields when empty
+	OutputSchema string // JSON schema file of the command's JSON output, returned as structured content when set
+	Messages     string // JSON file overriding the wording of studio's errors in results
+	FileRoot     string // Directory that name:@file fields read from and the output file must be in
+	TrimArgs     bool   // Trim leading and trailing whitespace from every value
+	NoPathChecks bool   // Skip the filesystem checks of name:path fields
+	KeepDashes   bool   // Keep dashes in property names instead of converting them to underscores
+	MaxArgLength int    // Longest value allowed in bytes, unlimited when zero
+	MaxArgs      int    // Most values an array field may have, unlimited when zero
+	SuccessCodes []int  // Exit codes that count as success, only 0 when empty
+	FileLists    bool   // Let array fields read their values from a file
+	StrictArgs   bool   // Reject calls with arguments that aren't fields instead of ignoring them
+	ErrorSummary bool   // Lead failed results with the first line of stderr
+
+	ArrayDelimiter string         // Array fields also take one string of values split on this, when set
+	ErrorIfMatch   *regexp.Regexp // Output that makes a result an error, whatever the exit code
+	SuccessIfMatch *regexp.Regexp // Output that makes a result a success, whatever the exit code
+	SkipEnumValues bool           // Leave the allowed values out of the descriptions of enum properties
+
+	InactivityTimeout time.Duration       // Stop commands that write no output for this long
+	KillGrace         time.Duration       // Time a stopped command gets to exit after SIGTERM, killed right away when zero
+	Detach            time.Duration       // Leave commands running and return what they wrote in this long, waits for them when zero
+	Limits            tool.Limits         // CPU time and memory a command can use on Linux, unlimited when zero
+	ContentMIMEType   string              // MIME type of text output, plain text when empty
+	CacheTTL          time.Duration       // How long the output of a successful run is returned for identical calls, never when zero
+	Select            tool.Selector       // Values to pick out of JSON output, all of it when unset
+	SplitOn           string              // Delimiter that splits text output into content blocks
+	OutputTemplate    tool.OutputTemplate // Formats text output with the command and exit code, as is when unset
+	PageSize          int                 // Most bytes of text output in a result, the rest is read as resources
+	SummaryLines      int                 // Lines kept from the start and end of long text output, all of it when zero
+	MaxOutputLines    int                 // Lines kept from the start of long text output, all of it when zero
+	Redact            []*regexp.Regexp    // Output replaced with *** before it is returned
+	OutputFile        string              // File the command writes its result to, returned after its output
+	RemoveOutputFile  bool                // Delete the output file after every call, unless it was there before it
+
+	Env      []string          // Environment variables of the command, like TOKEN={{token}}
+	MetaEnv  map[string]string // _meta keys passed to the command as environment variables
+	MetaArgs map[string]string // _meta keys used as field values when the field has no argument
+
+	ClearEnv       bool     // Start commands from an empty environment instead of studio's
+	EnvPassthrough []string // Variables of studio's environment kept when ClearEnv is set
+	ExecPrefix     []string // Wrapper every command runs through, like nice -n 10
+
+	CheckArgs []string // Arguments Check passes to the base command, DefaultCheckArgs when empty
+}
+
 // Studio represents the main application logic
 type Studio struct {
+	Options
 	Blueprint *blueprint.Blueprint
-	DebugMode bool
-	LogFile   string
-	Version   string
+	runAs     *tool.Credential
+	audit     *tool.AuditLog
+	output    *tool.OutputSchema
+	messages  tool.Messages
 }
 
 // New creates a new Studio instance from command arguments
-func New(args []string, debugMode bool, logFile string, version string) (*Studio, error) {
+func New(args []string, opts Options) (*Studio, error) {
 	if len(args) == 0 {
 		return nil, fmt.Errorf("no command provided")
 	}
 
+	args, err := opts.Delimiters.Translate(args)
+	if err != nil {
+		return nil, err
+	}
+
 	bp, err := blueprint.FromArgs(args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create blueprint: %w", err)
 	}
 
+	for _, assignment := range opts.Env {
+		if err := bp.AddEnv(assignment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bp.ApplyFields(opts.Fields); err != nil {
+		return nil, err
+	}
+
+	if opts.Glossary != "" {
+		glossary, err := blueprint.LoadGlossary(opts.Glossary)
+		if err != nil {
+			return nil, err
+		}
+		bp.ApplyGlossary(glossary)
+	}
+
+	if opts.InputSchema != "" {
+		if bp.InputSchema, err = blueprint.LoadInputSchema(opts.InputSchema); err != nil {
+			return nil, err
+		}
+		if !opts.SkipEnumValues {
+			blueprint.DescribeEnums(bp.InputSchema)
+		}
+	} else if bp.TakesAllArgs() {
+		// The arguments {{@all:json}} passes can't be inferred from the template
+		return nil, fmt.Errorf("{{@all:json}} needs an input schema describing the arguments")
+	}
+
+	bp.FileRoot = opts.FileRoot
+	bp.TrimArgs = opts.TrimArgs
+	bp.SkipPathChecks = opts.NoPathChecks
+	bp.KeepDashes = opts.KeepDashes
+	bp.SkipEnumValues = opts.SkipEnumValues
+	bp.MaxArgLength = opts.MaxArgLength
+	bp.MaxArrayItems = opts.MaxArgs
+	bp.ArrayDelimiter = opts.ArrayDelimiter
+
+	if err := bp.CheckInputSchema(); err != nil {
+		return nil, fmt.Errorf("%w in %s", err, opts.InputSchema)
+	}
+	if opts.RequireDescriptions {
+		if err := bp.CheckDescriptions(); err != nil {
+			return nil, err
+		}
+	}
+
+	// A detached command is a single process that outlives the call
+	if opts.Detach > 0 && bp.IsPipeline() && !opts.Shell {
+		return nil, fmt.Errorf("--detach can't start a pipeline, use --shell to run it as one script")
+	}
+
+	// Structured content is the output of one command that has exited
+	if opts.OutputSchema != "" && opts.Detach > 0 {
+		return nil, fmt.Errorf("--output-schema needs the command to finish, it can't be combined with --detach")
+	}
+	if opts.OutputSchema != "" && bp.RunsEach() {
+		return nil, fmt.Errorf("--output-schema describes the output of one command, it can't be used with a name:each field")
+	}
+
+	if err := checkMetaArgs(bp, opts.MetaArgs); err != nil {
+		return nil, err
+	}
+
+	// The output file is read once a command has exited, on every call
+	if opts.OutputFile != "" {
+		if opts.Detach > 0 {
+			return nil, fmt.Errorf("--output-file needs the command to finish, it can't be combined with --detach")
+		}
+		if opts.CacheTTL > 0 {
+			return nil, fmt.Errorf("--output-file is written by every run, it can't be combined with --cache-ttl")
+		}
+		if err := checkOutputFile(bp, opts.OutputFile); err != nil {
+			return nil, err
+		}
+		// Clients choose the file studio reads and removes, so it must stay in one directory
+		if len(tool.OutputFileFields(opts.OutputFile)) > 0 && opts.FileRoot == "" {
+			return nil, fmt.Errorf("output file %s is named by the client, it needs --file-root to keep it inside a directory", opts.OutputFile)
+		}
+	}
+
+	if opts.Framing != "" && !IsFraming(opts.Framing) {
+		return nil, fmt.Errorf("framing must be one of: %s", strings.Join(Framings, ", "))
+	}
+
+	if opts.NamePrefix != "" {
+		name := tool.Options{NamePrefix: opts.NamePrefix}.ToolName(bp)
+		if !tool.ValidToolName(name) {
+			return nil, fmt.Errorf("tool name %q must be 1 to 64 letters, numbers, underscores or dashes", name)
+		}
+	}
+
 	// Set debug mode and log file on tool
-	tool.SetDebugMode(debugMode)
-	if logFile != "" {
-		err = tool.SetLogFile(logFile)
+	tool.SetDebugMode(opts.DebugMode)
+	tool.SetColorMode(opts.Color)
+	if opts.LogFile != "" {
+		err = tool.SetLogFile(opts.LogFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to set log file: %w", err)
 		}
 	}
+	tool.Debug("studio %s (commit %s) starting for: %s", opts.Version, opts.Commit, bp.GetCommandFormat())
+
+	var runAs *tool.Credential
+	if opts.RunAsUser != "" {
+		if runAs, err = tool.LookupCredential(opts.RunAsUser); err != nil {
+			return nil, err
+		}
+	}
+
+	var audit *tool.AuditLog
+	if opts.AuditLog != "" {
+		if audit, err = tool.OpenAuditLog(opts.AuditLog); err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+	}
+
+	var output *tool.OutputSchema
+	if opts.OutputSchema != "" {
+		if output, err = tool.LoadOutputSchema(opts.OutputSchema); err != nil {
+			return nil, err
+		}
+	}
+
+	var messages tool.Messages
+	if opts.Messages != "" {
+		if messages, err = tool.LoadMessages(opts.Messages); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Studio{
+		Options:   opts,
 		Blueprint: bp,
-		DebugMode: debugMode,
-		LogFile:   logFile,
-		Version:   version,
+		runAs:     runAs,
+		audit:     audit,
+		output:    output,
+		messages:  messages,
 	}, nil
 }
 
@@ -53,18 +276,115 @@ func (s *Studio) Serve() error {
 	return s.ServeWithContext(context.Background())
 }
 
-// ServeWithContext starts the MCP server over stdio with a context
-func (s *Studio) ServeWithContext(ctx context.Context) error {
+// newServer creates the MCP server with the tool, and the resource and prompt
+// when enabled. Running commands are stopped when ctx is done.
+func (s *Studio) newServer(ctx context.Context) *mcp.Server {
 	// Create server with version from build
-	server := mcp.NewServer("studio", s.Version, nil)
+	name := s.ServerName
+	if name == "" {
+		name = DefaultServerName
+	}
+	server := mcp.NewServer(name, s.Version, nil)
+
+	toolOptions := tool.Options{
+		Shell:      s.Shell,
+		MIMEType:   s.MIMEType,
+		OutputType: s.OutputType,
+		Encode:     s.Encode,
+		Quiet:      s.Quiet,
+
+		MaxConcurrency: s.MaxConcurrency,
+		RateLimit:      s.RateLimit,
+		EchoCommand:    s.EchoCommand,
+		OutputSize:     s.OutputSize,
+		ReportDuration: s.ReportDuration,
+		RunAs:          s.runAs,
+		MergeOutput:    s.MergeOutput,
+		SuccessCodes:   s.SuccessCodes,
+		ErrorIfMatch:   s.ErrorIfMatch,
+		SuccessIfMatch: s.SuccessIfMatch,
+		Redact:         s.Redact,
+		FileLists:      s.FileLists,
+		StrictArgs:     s.StrictArgs,
+		ErrorSummary:   s.ErrorSummary,
+		Shutdown:       ctx,
+
+		InactivityTimeout: s.InactivityTimeout,
+		KillGrace:         s.KillGrace,
+		Detach:            s.Detach,
+		Limits:            s.Limits,
+		ContentMIMEType:   s.ContentMIMEType,
+		Select:            s.Select,
+		SplitOn:           s.SplitOn,
+		OutputTemplate:    s.OutputTemplate,
+		PageSize:          s.PageSize,
+		SummaryLines:      s.SummaryLines,
+		MaxOutputLines:    s.MaxOutputLines,
+		OutputSchema:      s.output,
+		OutputFile:        s.OutputFile,
+		RemoveOutputFile:  s.RemoveOutputFile,
+		FileRoot:          s.FileRoot,
+
+		MetaEnv:  s.MetaEnv,
+		MetaArgs: s.MetaArgs,
+
+		ClearEnv:       s.ClearEnv,
+		EnvPassthrough: s.EnvPassthrough,
+		ExecPrefix:     s.ExecPrefix,
+
+		NamePrefix: s.NamePrefix,
+		Audit:      s.audit,
+		Messages:   s.messages,
+	}
+
+	// Expose the last command output as a resource when enabled
+	if s.Resources {
+		toolOptions.LastOutput = &tool.OutputStore{}
+		server.AddResources(tool.CreateLastOutputResource(toolOptions.LastOutput))
+	}
+
+	// Return recent output of identical calls instead of running them again
+	if s.CacheTTL > 0 {
+		toolOptions.Cache = tool.NewResultCache(s.CacheTTL, tool.DefaultCacheMemory)
+	}
+
+	// Keep output longer than a page so it can be read a page at a time
+	if s.PageSize > 0 {
+		toolOptions.Pages = tool.NewPagedOutputs(tool.DefaultPageMemory)
+		server.AddResourceTemplates(tool.CreateOutputPageResource(toolOptions.Pages, s.PageSize))
+	}
+
+	// Expose a prompt describing how to call the tool when enabled
+	var prompt *mcp.ServerPrompt
+	if s.Prompts {
+		prompt = tool.CreateToolPromptWithOptions(s.Blueprint, toolOptions)
+		server.AddPrompts(prompt)
+	}
 
 	// Add the tool to the server using CreateServerTool from tool package
-	serverTool := tool.CreateServerTool(s.Blueprint)
+	serverTool := tool.CreateServerToolWithOptions(s.Blueprint, toolOptions)
 
 	server.AddTools(serverTool)
 
-	// Create base transport
-	var transport mcp.Transport = mcp.NewStdioTransport()
+	// Answer bad tool calls with a JSON-RPC error code the SDK leaves out
+	server.AddReceivingMiddleware(checkRequests(serverTool, prompt))
+
+	return server
+}
+
+// ServeWithContext starts the MCP server over stdio with a context
+func (s *Studio) ServeWithContext(ctx context.Context) error {
+	server := s.newServer(ctx)
+
+	// Create base transport, newline delimited unless asked otherwise
+	var stdio mcp.Transport = mcp.NewStdioTransport()
+	if s.Framing == FramingContentLength {
+		var err error
+		if stdio, err = contentLengthTransport(os.Stdin, os.Stdout); err != nil {
+			return err
+		}
+	}
+	var transport mcp.Transport = closableTransport{stdio}
 
 	// Wrap with logging transport if debug mode is enabled or log file is specified
 	if s.DebugMode || s.LogFile != "" {
@@ -85,6 +405,104 @@ func (s *Studio) ServeWithContext(ctx context.Context) error {
 		transport = mcp.NewLoggingTransport(transport, logWriter)
 	}
 
-	// Run the server with the configured transport
-	return server.Run(ctx, transport)
+	// Advertise only the capabilities enabled by the flags
+	transport = capabilitiesTransport{Transport: transport, capabilities: s.capabilities()}
+
+	// Send standard JSON-RPC error codes the SDK leaves out
+	transport = rpcErrorTransport{transport}
+
+	session, err := server.Connect(ctx, transport)
+	if err != nil {
+		return err
+	}
+
+	// When ctx is done, running commands are stopped through the tool's Shutdown
+	// context. Closing the session stops new requests and waits for in-flight
+	// calls so their results are still sent before the server exits.
+	defer context.AfterFunc(ctx, func() {
+		session.Close()
+	})()
+
+	return session.Wait()
+}
+
+// ListTools returns what tools/list returns, asking a server built the same
+// way ServeWithContext builds it over an in-memory connection
+func (s *Studio) ListTools(ctx context.Context) (*mcp.ListToolsResult, error) {
+	session, disconnect, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer disconnect()
+
+	return session.ListTools(ctx, nil)
+}
+
+// CallTool calls the tool once with arguments and returns what tools/call
+// returns, the same way ListTools asks its server
+func (s *Studio) CallTool(ctx context.Context, arguments map[string]any) (*mcp.CallToolResult, error) {
+	session, disconnect, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer disconnect()
+
+	return session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      tool.Options{NamePrefix: s.NamePrefix}.ToolName(s.Blueprint),
+		Arguments: arguments,
+	})
+}
+
+// connect returns a client session to a server built the same way
+// ServeWithContext builds it, over an in-memory connection, and a function
+// that closes both sides
+func (s *Studio) connect(ctx context.Context) (*mcp.ClientSession, func(), error) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	serverSession, err := s.newServer(ctx).Connect(ctx, serverTransport)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := mcp.NewClient("studio", s.Version, nil).Connect(ctx, clientTransport)
+	if err != nil {
+		serverSession.Close()
+		return nil, nil, err
+	}
+	return session, func() {
+		session.Close()
+		serverSession.Close()
+	}, nil
+}
+
+// checkMetaArgs makes sure every field mapped from _meta exists and is
+// optional, since clients validate arguments before _meta can fill them
+func checkMetaArgs(bp *blueprint.Blueprint, metaArgs map[string]string) error {
+	schema := bp.GenerateInputSchema()
+	for _, key := range slices.Sorted(maps.Keys(metaArgs)) {
+		field := metaArgs[key]
+		if _, ok := schema.Properties[field]; !ok {
+			return fmt.Errorf("_meta key %s is mapped to %s, which is not a field of the command", key, field)
+		}
+		if slices.Contains(schema.Required, field) {
+			return fmt.Errorf("_meta key %s is mapped to %s, which must be optional to be left out of the arguments", key, field)
+		}
+	}
+	return nil
+}
+
+// checkOutputFile makes sure every placeholder of the output file path is a
+// string field, since only one value can name a file
+func checkOutputFile(bp *blueprint.Blueprint, path string) error {
+	schema := bp.GenerateInputSchema()
+	for _, name := range tool.OutputFileFields(path) {
+		property, ok := schema.Properties[name]
+		if !ok {
+			return fmt.Errorf("output file %s uses {{%s}}, which is not a field of the command", path, name)
+		}
+		if property.Type != "string" {
+			return fmt.Errorf("output file %s uses {{%s}}, which must be a string field", path, name)
+		}
+	}
+	return nil
 }
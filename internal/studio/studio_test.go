@@ -0,0 +1,446 @@
+package studio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+	"github.com/studio-mcp/studio/internal/tool"
+)
+
+func TestStudio_New_MetaArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		metaArgs      map[string]string
+		expectedError string
+	}{
+		{
+			name:     "optional field",
+			metaArgs: map[string]string{"requestId": "trace_id"},
+		},
+		{
+			name:          "unknown field",
+			metaArgs:      map[string]string{"requestId": "nope"},
+			expectedError: "_meta key requestId is mapped to nope, which is not a field of the command",
+		},
+		{
+			name:          "required field",
+			metaArgs:      map[string]string{"requestId": "url"},
+			expectedError: "_meta key requestId is mapped to url, which must be optional",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]string{"curl", "{{url}}", "[trace_id]"}, Options{MetaArgs: tt.metaArgs})
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestStudio_New_NamePrefix(t *testing.T) {
+	t.Run("prefixes the tool and prompt names", func(t *testing.T) {
+		s, err := New([]string{"git-lfs", "[args...]"}, Options{NamePrefix: "repo1_", Prompts: true})
+		require.NoError(t, err)
+
+		result, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		assert.Equal(t, "repo1_git_lfs", result.Tools[0].Name)
+	})
+
+	tests := []struct {
+		name       string
+		namePrefix string
+		args       []string
+	}{
+		{"characters clients reject", "repo 1.", []string{"git"}},
+		{"longer than 64 characters", "a_very_long_prefix_that_goes_on_and_on_and_on_and_on_and_on_and_", []string{"git"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.args, Options{NamePrefix: tt.namePrefix})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "must be 1 to 64 letters, numbers, underscores or dashes")
+		})
+	}
+}
+
+func TestStudio_New_AuditLog(t *testing.T) {
+	t.Run("opens the audit log for appending", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0600))
+
+		s, err := New([]string{"echo"}, Options{AuditLog: path})
+		require.NoError(t, err)
+		assert.NotNil(t, s.audit)
+
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{}\n", string(contents))
+	})
+
+	t.Run("fails when the audit log can't be opened", func(t *testing.T) {
+		_, err := New([]string{"echo"}, Options{AuditLog: filepath.Join(t.TempDir(), "missing", "audit.jsonl")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to open audit log")
+	})
+}
+
+func TestStudio_New_InputSchema(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"type": "object", "properties": {"query": {"type": "string"}}}`), 0644))
+
+	t.Run("advertises the schema from the file", func(t *testing.T) {
+		s, err := New([]string{"handler", "{{@all:json}}"}, Options{InputSchema: schemaFile})
+		require.NoError(t, err)
+
+		result, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		assert.Contains(t, result.Tools[0].InputSchema.Properties, "query")
+	})
+
+	t.Run("@all:json needs an input schema", func(t *testing.T) {
+		_, err := New([]string{"handler", "{{@all:json}}"}, Options{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "{{@all:json}} needs an input schema")
+	})
+
+	t.Run("fails when the schema can't be read", func(t *testing.T) {
+		_, err := New([]string{"handler", "{{@all:json}}"}, Options{InputSchema: filepath.Join(t.TempDir(), "missing.json")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read input schema")
+	})
+
+	richFile := filepath.Join(t.TempDir(), "rich.json")
+	require.NoError(t, os.WriteFile(richFile, []byte(`{
+		"type": "object",
+		"properties": {
+			"repo": {"type": "string", "pattern": "^[a-z]+/[a-z]+$"},
+			"limit": {"type": "integer", "minimum": 1},
+			"filter": {
+				"type": "object",
+				"properties": {"state": {"oneOf": [{"const": "open"}, {"const": "closed"}]}}
+			}
+		},
+		"required": ["repo"]
+	}`), 0644))
+
+	t.Run("fills the template from a hand written schema", func(t *testing.T) {
+		s, err := New([]string{"echo", "{{repo}}", "[limit]", "{{@all:json}}"}, Options{InputSchema: richFile})
+		require.NoError(t, err)
+
+		tools, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, tools.Tools[0].InputSchema.Properties["filter"].Properties["state"].OneOf, 2)
+
+		result, err := s.CallTool(context.Background(), map[string]any{"repo": "octo/cat", "limit": 5, "filter": map[string]any{"state": "open"}})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, `octo/cat 5 {"filter":{"state":"open"},"limit":5,"repo":"octo/cat"}`, result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("every field needs a property", func(t *testing.T) {
+		_, err := New([]string{"gh", "issue", "list", "{{repo}}", "[label]", "[--web]"}, Options{InputSchema: richFile})
+		assert.EqualError(t, err, "input schema has no properties for the fields label, web in "+richFile)
+	})
+
+	enumFile := filepath.Join(t.TempDir(), "enum.json")
+	require.NoError(t, os.WriteFile(enumFile, []byte(`{"type": "object", "properties": {"env": {"type": "string", "description": "target", "enum": ["dev", "prod"]}}}`), 0644))
+
+	t.Run("adds enum values to descriptions", func(t *testing.T) {
+		s, err := New([]string{"deploy", "{{env}}"}, Options{InputSchema: enumFile})
+		require.NoError(t, err)
+
+		result, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "target (one of: dev, prod)", result.Tools[0].InputSchema.Properties["env"].Description)
+	})
+
+	t.Run("leaves enum values out when asked", func(t *testing.T) {
+		s, err := New([]string{"deploy", "{{env}}"}, Options{InputSchema: enumFile, SkipEnumValues: true})
+		require.NoError(t, err)
+
+		result, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "target", result.Tools[0].InputSchema.Properties["env"].Description)
+	})
+}
+
+func TestStudio_New_Delimiters(t *testing.T) {
+	t.Run("runs a template written with custom delimiters", func(t *testing.T) {
+		delimiters := blueprint.Delimiters{Open: "<<", Close: ">>", OptionalOpen: "(", OptionalClose: ")"}
+		s, err := New([]string{"echo", "{[<<text>>]}", "(more)"}, Options{Delimiters: delimiters})
+		require.NoError(t, err)
+
+		result, err := s.CallTool(context.Background(), map[string]any{"text": "hi", "more": "there"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+		assert.Equal(t, "{[hi]} there", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("rejects invalid delimiters", func(t *testing.T) {
+		_, err := New([]string{"echo", "<<text>>"}, Options{Delimiters: blueprint.Delimiters{Open: "<<"}})
+		assert.ErrorContains(t, err, "both an open and a close marker")
+	})
+}
+
+func TestStudio_New_RequireDescriptions(t *testing.T) {
+	t.Run("allows undescribed fields by default", func(t *testing.T) {
+		_, err := New([]string{"gh", "{{repo}}", "{{title}}"}, Options{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("lists every required field without a description", func(t *testing.T) {
+		_, err := New([]string{"gh", "{{repo}}", "{{title}}", "[limit]"}, Options{RequireDescriptions: true})
+		assert.EqualError(t, err, "required fields repo, title have no description")
+	})
+
+	t.Run("counts descriptions from the tool file", func(t *testing.T) {
+		fields := map[string]blueprint.FieldDefinition{"repo": {Description: "owner/name"}}
+		_, err := New([]string{"gh", "{{repo}}"}, Options{Fields: fields, RequireDescriptions: true})
+		assert.NoError(t, err)
+	})
+}
+
+func TestStudio_New_LogsVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "studio.log")
+	t.Cleanup(func() { tool.SetDebugMode(false) })
+
+	_, err := New([]string{"echo", "{{text}}"}, Options{DebugMode: true, LogFile: path, Version: "1.2.3", Commit: "abc1234"})
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "studio 1.2.3 (commit abc1234) starting for: echo {{text}}")
+}
+
+func TestStudio_ListTools_MarkdownDescriptions(t *testing.T) {
+	description := "Search query.\n\n- Use `AND` and `OR`\n- See [the docs](https://example.com)"
+	glossary := filepath.Join(t.TempDir(), "glossary.json")
+	require.NoError(t, os.WriteFile(glossary, []byte(`{"limit": "Most results.\n\n**Default:** 10"}`), 0644))
+
+	s, err := New([]string{"search", "{{query # " + description + "}}", "[limit]"}, Options{Glossary: glossary})
+	require.NoError(t, err)
+
+	result, err := s.ListTools(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Tools, 1)
+	properties := result.Tools[0].InputSchema.Properties
+	assert.Equal(t, description, properties["query"].Description)
+	assert.Equal(t, "Most results.\n\n**Default:** 10", properties["limit"].Description)
+}
+
+func TestStudio_NoArguments(t *testing.T) {
+	s, err := New([]string{"echo", "hi"}, Options{})
+	require.NoError(t, err)
+
+	t.Run("advertises an object schema with nothing required", func(t *testing.T) {
+		result, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+
+		schema := result.Tools[0].InputSchema
+		assert.Equal(t, "object", schema.Type)
+		assert.Empty(t, schema.Properties)
+		assert.Empty(t, schema.Required)
+	})
+
+	calls := []struct {
+		name string
+		args map[string]any
+	}{
+		{name: "absent arguments", args: nil},
+		{name: "empty arguments", args: map[string]any{}},
+		{name: "stray argument is ignored", args: map[string]any{"verbose": true}},
+	}
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.CallTool(context.Background(), tt.args)
+			require.NoError(t, err)
+			assert.False(t, result.IsError)
+			require.Len(t, result.Content, 1)
+			assert.Equal(t, "hi", result.Content[0].(*mcp.TextContent).Text)
+		})
+	}
+
+	t.Run("stray arguments are ignored by tools with fields too", func(t *testing.T) {
+		s, err := New([]string{"echo", "{{text}}"}, Options{})
+		require.NoError(t, err)
+
+		result, err := s.CallTool(context.Background(), map[string]any{"text": "hi", "verbose": true})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "hi", result.Content[0].(*mcp.TextContent).Text)
+	})
+}
+
+func TestStudio_New_Pipeline(t *testing.T) {
+	s, err := New([]string{"printf", "{{text}}", "|", "wc", "-c"}, Options{})
+	require.NoError(t, err)
+
+	result, err := s.CallTool(context.Background(), map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "5", strings.TrimSpace(result.Content[0].(*mcp.TextContent).Text))
+
+	t.Run("can't be detached", func(t *testing.T) {
+		_, err := New([]string{"printf", "{{text}}", "|", "wc", "-c"}, Options{Detach: time.Second})
+		assert.EqualError(t, err, "--detach can't start a pipeline, use --shell to run it as one script")
+	})
+}
+
+func TestStudio_StartupValues(t *testing.T) {
+	t.Setenv("STUDIO_SHA", "abc123")
+	s, err := New([]string{"deploy", "--sha", "{{@env:STUDIO_SHA}}", "{{target}}"}, Options{})
+	require.NoError(t, err)
+
+	result, err := s.ListTools(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Tools, 1)
+	assert.Contains(t, result.Tools[0].Description, "deploy --sha abc123 {{target}}")
+	assert.Equal(t, []string{"target"}, result.Tools[0].InputSchema.Required)
+}
+
+func TestStudio_StrictArgs(t *testing.T) {
+	s, err := New([]string{"echo", "{{text}}"}, Options{StrictArgs: true})
+	require.NoError(t, err)
+
+	t.Run("advertises that other arguments are rejected", func(t *testing.T) {
+		result, err := s.ListTools(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Tools, 1)
+		assert.NotNil(t, result.Tools[0].InputSchema.AdditionalProperties)
+	})
+
+	t.Run("runs without unexpected arguments", func(t *testing.T) {
+		result, err := s.CallTool(context.Background(), map[string]any{"text": "hi"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "hi", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("rejects unexpected arguments by name", func(t *testing.T) {
+		result, err := s.CallTool(context.Background(), map[string]any{"text": "hi", "verbose": true})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Equal(t, "Validation error: unknown arguments: verbose", result.Content[0].(*mcp.TextContent).Text)
+	})
+}
+
+func TestStudio_FieldRules(t *testing.T) {
+	fields := map[string]blueprint.FieldDefinition{"name": {Pattern: "^[a-z]+$"}}
+	_, err := New([]string{"echo", "{{name}}"}, Options{Fields: map[string]blueprint.FieldDefinition{"name": {Pattern: "("}}})
+	assert.ErrorContains(t, err, "field name has an invalid pattern")
+
+	s, err := New([]string{"echo", "{{name}}"}, Options{Fields: fields})
+	require.NoError(t, err)
+
+	result, err := s.CallTool(context.Background(), map[string]any{"name": "studio"})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	result, err = s.CallTool(context.Background(), map[string]any{"name": "$(whoami)"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, `parameter 'name' must match ^[a-z]+$, got "$(whoami)"`)
+}
+
+func TestStudio_OutputSchema(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "output.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{"type": "object", "properties": {"count": {"type": "integer"}}, "required": ["count"]}`), 0644))
+
+	s, err := New([]string{"printf", "{{json}}"}, Options{OutputSchema: schema})
+	require.NoError(t, err)
+
+	tools, err := s.ListTools(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+	require.NotNil(t, tools.Tools[0].OutputSchema)
+	assert.Equal(t, "object", tools.Tools[0].OutputSchema.Type)
+
+	result, err := s.CallTool(context.Background(), map[string]any{"json": `{"count": 3}`})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, map[string]any{"count": float64(3)}, result.StructuredContent)
+	assert.Equal(t, `{"count": 3}`, result.Content[0].(*mcp.TextContent).Text)
+
+	result, err = s.CallTool(context.Background(), map[string]any{"json": `{"total": 3}`})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Nil(t, result.StructuredContent)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "Studio error: output doesn't match the output schema")
+
+	_, err = New([]string{"cat", "{{files...:each}}"}, Options{OutputSchema: schema})
+	assert.ErrorContains(t, err, "can't be used with a name:each field")
+}
+
+func TestStudio_OutputFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "clip.txt")
+	s, err := New([]string{"sh", "-c", `printf clip > "$1"`, "sh", "[out=" + out + "]"}, Options{OutputFile: "{{out}}", RemoveOutputFile: true, FileRoot: filepath.Dir(out)})
+	require.NoError(t, err)
+
+	result, err := s.CallTool(context.Background(), map[string]any{})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "clip", result.Content[len(result.Content)-1].(*mcp.EmbeddedResource).Resource.Text)
+	assert.NoFileExists(t, out)
+
+	tests := []struct {
+		name     string
+		args     []string
+		opts     Options
+		expected string
+	}{
+		{"unknown field", []string{"ffmpeg", "{{input}}"}, Options{OutputFile: "{{out}}"}, "{{out}}, which is not a field of the command"},
+		{"array field", []string{"ffmpeg", "{{out...}}"}, Options{OutputFile: "{{out}}"}, "{{out}}, which must be a string field"},
+		{"no file root", []string{"ffmpeg", "{{out}}"}, Options{OutputFile: "{{out}}"}, "needs --file-root"},
+		{"detach", []string{"ffmpeg", "{{out}}"}, Options{OutputFile: "{{out}}", FileRoot: ".", Detach: time.Second}, "can't be combined with --detach"},
+		{"cache", []string{"ffmpeg", "{{out}}"}, Options{OutputFile: "{{out}}", FileRoot: ".", CacheTTL: time.Minute}, "can't be combined with --cache-ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.args, tt.opts)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestStudio_CallTool(t *testing.T) {
+	t.Run("runs the command with the arguments", func(t *testing.T) {
+		s, err := New([]string{"echo", "{{text}}", "[words...]"}, Options{NamePrefix: "local_"})
+		require.NoError(t, err)
+
+		result, err := s.CallTool(context.Background(), map[string]any{"text": "hi", "words": []any{"there", "you"}})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+		assert.Equal(t, "hi there you", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("returns validation errors as tool errors", func(t *testing.T) {
+		s, err := New([]string{"echo", "{{text}}"}, Options{})
+		require.NoError(t, err)
+
+		result, err := s.CallTool(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "missing required parameter: text")
+	})
+}
@@ -0,0 +1,58 @@
+package studio
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// closableTransport wraps a transport so that closing a connection also ends a
+// pending Read. Reading stdin blocks until the client sends something, so
+// without this a session could not finish closing while the client is idle.
+type closableTransport struct {
+	mcp.Transport
+}
+
+// Connect connects the wrapped transport
+func (t closableTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &closableConn{Connection: conn, closed: make(chan struct{})}, nil
+}
+
+// closableConn is a connection whose Read returns io.EOF once it is closed
+type closableConn struct {
+	mcp.Connection
+	once   sync.Once
+	closed chan struct{}
+}
+
+// Read reads the next message, or returns io.EOF if the connection is closed first
+func (c *closableConn) Read(ctx context.Context) (mcp.JSONRPCMessage, error) {
+	type result struct {
+		msg mcp.JSONRPCMessage
+		err error
+	}
+	read := make(chan result, 1)
+	go func() {
+		msg, err := c.Connection.Read(ctx)
+		read <- result{msg, err}
+	}()
+
+	select {
+	case r := <-read:
+		return r.msg, r.err
+	case <-c.closed:
+		return nil, io.EOF
+	}
+}
+
+// Close closes the wrapped connection and ends any pending Read
+func (c *closableConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.Connection.Close()
+}
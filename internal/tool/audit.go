@@ -0,0 +1,96 @@
+package tool
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secret values in the audit log
+const redacted = "[REDACTED]"
+
+// AuditLog records every command a tool runs as one JSON object per line
+type AuditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// auditEntry is a line of the audit log
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Tool       string    `json:"tool"`
+	Command    []string  `json:"command"`
+	Env        []string  `json:"env,omitempty"` // names of the variables set for the command
+	ExitCode   int       `json:"exitCode"`      // -1 when the command didn't exit on its own
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// NewAuditLog creates an audit log that writes to w
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+// OpenAuditLog creates an audit log that appends to the file at path
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditLog(file), nil
+}
+
+// record writes an entry for a command that ran. Values passed in env are
+// secrets, so they are left out and redacted wherever they show up in argv,
+// like the values read from secret files.
+func (a *AuditLog) record(tool string, argv []string, env []string, start time.Time, result commandResult, err error) {
+	entry := auditEntry{
+		Time:       start.UTC(),
+		Tool:       tool,
+		Command:    redactCommand(redactArgs(argv, env)),
+		ExitCode:   result.ExitCode,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	for _, assignment := range env {
+		name, _, _ := strings.Cut(assignment, "=")
+		entry.Env = append(entry.Env, name)
+	}
+	if err != nil {
+		entry.Error = redactSecrets(err.Error())
+	}
+
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		debug("Failed to encode audit entry: %s", jsonErr)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, writeErr := a.w.Write(append(line, '\n')); writeErr != nil {
+		debug("Failed to write audit entry: %s", writeErr)
+	}
+}
+
+// redactArgs returns argv with the values of env replaced by [REDACTED]
+func redactArgs(argv []string, env []string) []string {
+	var pairs []string
+	for _, assignment := range env {
+		if _, value, _ := strings.Cut(assignment, "="); value != "" {
+			pairs = append(pairs, value, redacted)
+		}
+	}
+	if len(pairs) == 0 {
+		return argv
+	}
+
+	replacer := strings.NewReplacer(pairs...)
+	result := make([]string, len(argv))
+	for i, arg := range argv {
+		result[i] = replacer.Replace(arg)
+	}
+	return result
+}
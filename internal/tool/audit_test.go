@@ -0,0 +1,90 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_AuditLog(t *testing.T) {
+	// entries parses the JSON lines written to the audit log
+	entries := func(t *testing.T, log *bytes.Buffer) []map[string]any {
+		var result []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), "line %q", line)
+			result = append(result, entry)
+		}
+		return result
+	}
+
+	call := func(t *testing.T, bp *blueprint.Blueprint, opts Options, args map[string]any) {
+		_, err := CreateToolFunctionWithOptions(bp, opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: args})
+		require.NoError(t, err)
+	}
+
+	t.Run("records each command that runs", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"echo", "{{text}}"})
+		require.NoError(t, err)
+
+		var log bytes.Buffer
+		opts := Options{Audit: NewAuditLog(&log), NamePrefix: "repo1_"}
+		call(t, bp, opts, map[string]any{"text": "one"})
+		call(t, bp, opts, map[string]any{"text": "two"})
+
+		logged := entries(t, &log)
+		require.Len(t, logged, 2)
+		assert.Equal(t, "repo1_echo", logged[0]["tool"])
+		assert.Equal(t, []any{"echo", "one"}, logged[0]["command"])
+		assert.Equal(t, float64(0), logged[0]["exitCode"])
+		assert.Contains(t, logged[0], "time")
+		assert.Contains(t, logged[0], "durationMs")
+		assert.NotContains(t, logged[0], "error")
+		assert.Equal(t, []any{"echo", "two"}, logged[1]["command"])
+	})
+
+	t.Run("records failures with their exit code", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"sh", "-c", "exit 3"})
+		require.NoError(t, err)
+
+		var log bytes.Buffer
+		call(t, bp, Options{Audit: NewAuditLog(&log)}, map[string]any{})
+
+		logged := entries(t, &log)
+		require.Len(t, logged, 1)
+		assert.Equal(t, float64(3), logged[0]["exitCode"])
+		assert.Equal(t, "command failed with exit code 3", logged[0]["error"])
+	})
+
+	t.Run("redacts values passed in the environment", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"echo", "{{url}}"})
+		require.NoError(t, err)
+		require.NoError(t, bp.AddEnv("API_TOKEN={{token}}"))
+
+		var log bytes.Buffer
+		call(t, bp, Options{Audit: NewAuditLog(&log)}, map[string]any{"url": "https://example.com/?token=s3cret", "token": "s3cret"})
+
+		assert.NotContains(t, log.String(), "s3cret")
+		logged := entries(t, &log)
+		require.Len(t, logged, 1)
+		assert.Equal(t, []any{"echo", "https://example.com/?token=[REDACTED]"}, logged[0]["command"])
+		assert.Equal(t, []any{"API_TOKEN"}, logged[0]["env"])
+	})
+
+	t.Run("skips calls that never run", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"echo", "{{text}}"})
+		require.NoError(t, err)
+
+		var log bytes.Buffer
+		call(t, bp, Options{Audit: NewAuditLog(&log)}, map[string]any{})
+
+		assert.Empty(t, log.String())
+	})
+}
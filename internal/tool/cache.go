@@ -0,0 +1,111 @@
+package tool
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheMemory is how many bytes of output the result cache keeps
+const DefaultCacheMemory = 16 << 20
+
+// ResultCache keeps the output of successful commands for a while, so an
+// identical call returns it without running the command again. The oldest
+// results are dropped once together they take more than the memory limit.
+type ResultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	limit   int
+	entries map[string]cachedResult
+	order   []string // keys from oldest to newest
+	size    int
+}
+
+// cachedResult is the output of a command and when it stops being used
+type cachedResult struct {
+	result  commandResult
+	expires time.Time
+}
+
+// NewResultCache creates a cache that keeps results for ttl and at most limit bytes of them
+func NewResultCache(ttl time.Duration, limit int) *ResultCache {
+	return &ResultCache{ttl: ttl, limit: limit, entries: make(map[string]cachedResult)}
+}
+
+// cacheKey identifies a run by its argv and the environment it adds
+func cacheKey(command []string, env []string) string {
+	return strings.Join(command, "\x00") + "\x00\x00" + strings.Join(env, "\x00")
+}
+
+// get returns a copy of the result kept under key, if it hasn't expired.
+// A nil cache never has a result.
+func (c *ResultCache) get(key string) (commandResult, bool) {
+	if c == nil {
+		return commandResult{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return commandResult{}, false
+	}
+	if time.Now().After(entry.expires) {
+		c.remove(key)
+		return commandResult{}, false
+	}
+	return cloneResult(entry.result), true
+}
+
+// put keeps a copy of result under key. Results larger than the memory limit
+// aren't kept. A nil cache keeps nothing.
+func (c *ResultCache) put(key string, result commandResult) {
+	if c == nil {
+		return
+	}
+	size := len(key) + len(result.Stdout) + len(result.Stderr)
+	if size > c.limit {
+		debug("Not caching %d bytes of output, more than the limit of %d", size, c.limit)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+	for len(c.order) > 0 && c.size+size > c.limit {
+		debug("Dropped cached result of %q", strings.ReplaceAll(c.order[0], "\x00", " "))
+		c.remove(c.order[0])
+	}
+
+	c.entries[key] = cachedResult{result: cloneResult(result), expires: time.Now().Add(c.ttl)}
+	c.order = append(c.order, key)
+	c.size += size
+}
+
+// remove drops the result kept under key, called with the lock held
+func (c *ResultCache) remove(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	c.size -= len(key) + len(entry.result.Stdout) + len(entry.result.Stderr)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// cloneResult copies result so changes to one don't show in the other
+func cloneResult(result commandResult) commandResult {
+	return commandResult{
+		Stdout:   bytes.Clone(result.Stdout),
+		Stderr:   bytes.Clone(result.Stderr),
+		ExitCode: result.ExitCode,
+	}
+}
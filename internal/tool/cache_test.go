@@ -0,0 +1,136 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestResultCache(t *testing.T) {
+	t.Run("returns a copy of a kept result", func(t *testing.T) {
+		cache := NewResultCache(time.Minute, DefaultCacheMemory)
+		cache.put("key", commandResult{Stdout: []byte("out"), Stderr: []byte("err")})
+
+		result, ok := cache.get("key")
+		require.True(t, ok)
+		assert.Equal(t, "out", string(result.Stdout))
+
+		result.Stdout[0] = 'X'
+		again, _ := cache.get("key")
+		assert.Equal(t, "out", string(again.Stdout))
+	})
+
+	t.Run("forgets results after the ttl", func(t *testing.T) {
+		cache := NewResultCache(20*time.Millisecond, DefaultCacheMemory)
+		cache.put("key", commandResult{Stdout: []byte("out")})
+
+		time.Sleep(40 * time.Millisecond)
+		_, ok := cache.get("key")
+		assert.False(t, ok)
+		assert.Zero(t, cache.size)
+	})
+
+	t.Run("drops the oldest results over the memory limit", func(t *testing.T) {
+		cache := NewResultCache(time.Minute, 10)
+		cache.put("a", commandResult{Stdout: []byte("1234")})
+		cache.put("b", commandResult{Stdout: []byte("1234")})
+		cache.put("c", commandResult{Stdout: []byte("1234")})
+
+		_, ok := cache.get("a")
+		assert.False(t, ok)
+		_, ok = cache.get("c")
+		assert.True(t, ok)
+		assert.Equal(t, 10, cache.size)
+	})
+
+	t.Run("skips results larger than the limit", func(t *testing.T) {
+		cache := NewResultCache(time.Minute, 10)
+		cache.put("a", commandResult{Stdout: []byte("0123456789")})
+
+		_, ok := cache.get("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("nil cache keeps nothing", func(t *testing.T) {
+		var cache *ResultCache
+		cache.put("a", commandResult{Stdout: []byte("out")})
+
+		_, ok := cache.get("a")
+		assert.False(t, ok)
+	})
+}
+
+func TestTool_CreateToolFunctionCache(t *testing.T) {
+	// The command counts its runs in a file and prints its argument
+	runs := filepath.Join(t.TempDir(), "runs")
+	bp, err := blueprint.FromArgs([]string{"sh", "-c", `echo run >> "$0"; echo "$1"; exit "$2"`, runs, "{{text}}", "[code=0]"})
+	require.NoError(t, err)
+
+	countRuns := func(t *testing.T) int {
+		content, err := os.ReadFile(runs)
+		if os.IsNotExist(err) {
+			return 0
+		}
+		require.NoError(t, err)
+		return strings.Count(string(content), "run")
+	}
+
+	call := func(t *testing.T, handler mcp.ToolHandlerFor[map[string]any, map[string]any], args map[string]any) *mcp.CallToolResultFor[map[string]any] {
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: args})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("returns the cached output of an identical call", func(t *testing.T) {
+		os.Remove(runs)
+		handler := CreateToolFunctionWithOptions(bp, Options{Cache: NewResultCache(time.Minute, DefaultCacheMemory)})
+
+		first := call(t, handler, map[string]any{"text": "hello"})
+		second := call(t, handler, map[string]any{"text": "hello"})
+
+		assert.Equal(t, 1, countRuns(t))
+		assert.Equal(t, "hello", second.Content[0].(*mcp.TextContent).Text)
+		assert.Nil(t, first.Meta)
+		assert.Equal(t, true, second.Meta["cached"])
+	})
+
+	t.Run("runs calls with different arguments", func(t *testing.T) {
+		os.Remove(runs)
+		handler := CreateToolFunctionWithOptions(bp, Options{Cache: NewResultCache(time.Minute, DefaultCacheMemory)})
+
+		call(t, handler, map[string]any{"text": "hello"})
+		result := call(t, handler, map[string]any{"text": "world"})
+
+		assert.Equal(t, 2, countRuns(t))
+		assert.Equal(t, "world", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("doesn't cache failed runs", func(t *testing.T) {
+		os.Remove(runs)
+		handler := CreateToolFunctionWithOptions(bp, Options{Cache: NewResultCache(time.Minute, DefaultCacheMemory)})
+
+		call(t, handler, map[string]any{"text": "hello", "code": "1"})
+		result := call(t, handler, map[string]any{"text": "hello", "code": "1"})
+
+		assert.Equal(t, 2, countRuns(t))
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("runs every call without a cache", func(t *testing.T) {
+		os.Remove(runs)
+		handler := CreateToolFunctionWithOptions(bp, Options{})
+
+		call(t, handler, map[string]any{"text": "hello"})
+		call(t, handler, map[string]any{"text": "hello"})
+
+		assert.Equal(t, 2, countRuns(t))
+	})
+}
@@ -0,0 +1,23 @@
+package tool
+
+// ANSI colors for studio's own diagnostics. Command output is never colored.
+const (
+	Red   = "31"
+	Green = "32"
+	Cyan  = "36"
+)
+
+var colorMode bool
+
+// SetColorMode enables or disables color in debug logs written to stderr
+func SetColorMode(enabled bool) {
+	colorMode = enabled
+}
+
+// Paint wraps text in an ANSI color when enabled is set
+func Paint(enabled bool, color string, text string) string {
+	if !enabled {
+		return text
+	}
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
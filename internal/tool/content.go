@@ -0,0 +1,204 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// OutputURI identifies binary command output embedded in a tool result
+const OutputURI = "studio://output"
+
+// Output types select how command output is returned in a tool result
+const (
+	OutputAuto   = "auto"
+	OutputText   = "text"
+	OutputImage  = "image"
+	OutputBinary = "binary"
+)
+
+// OutputTypes lists the supported output types
+var OutputTypes = []string{OutputAuto, OutputText, OutputImage, OutputBinary}
+
+// IsOutputType reports whether outputType is a supported output type
+func IsOutputType(outputType string) bool {
+	for _, t := range OutputTypes {
+		if t == outputType {
+			return true
+		}
+	}
+	return false
+}
+
+// Encodes return stdout as text whatever bytes it holds, see Options.Encode
+const (
+	EncodeBase64 = "base64"
+	EncodeHex    = "hex"
+)
+
+// Encodings lists the supported encodings
+var Encodings = []string{EncodeBase64, EncodeHex}
+
+// IsEncoding reports whether encoding is a supported encoding
+func IsEncoding(encoding string) bool {
+	return slices.Contains(Encodings, encoding)
+}
+
+// createContent converts command output into tool result content. By default
+// output that is valid UTF-8 is returned as text, images as image content and
+// any other binary output as a base64 blob. Stderr follows binary output as text.
+// Text output is split into a block per chunk when opts.SplitOn is set, typed
+// when opts.ContentMIMEType is set, and stdout is returned as encoded text when
+// opts.Encode is set.
+func createContent(result commandResult, opts Options) []mcp.Content {
+	if opts.Encode != "" {
+		return encodedContent(result, opts.Encode)
+	}
+
+	outputType := opts.OutputType
+	if outputType == "" || outputType == OutputAuto {
+		outputType = detectOutputType(result.Stdout, opts.MIMEType)
+	}
+
+	if outputType == OutputText && opts.ContentMIMEType != "" && len(result.Stdout) > 0 {
+		return typedContent(result, opts)
+	}
+
+	if outputType == OutputText && opts.SplitOn != "" {
+		if chunks := splitOutput(string(result.Stdout), opts.SplitOn); len(chunks) > 0 {
+			return splitContent(chunks, result.Stderr)
+		}
+	}
+
+	if outputType == OutputText {
+		return []mcp.Content{&mcp.TextContent{Text: result.Output()}}
+	}
+
+	mimeType := detectMIMEType(result.Stdout, opts.MIMEType)
+	debug("Returning %d bytes of %s output as %s", len(result.Stdout), outputType, mimeType)
+
+	var content []mcp.Content
+	if outputType == OutputImage {
+		content = append(content, &mcp.ImageContent{
+			Data:     result.Stdout,
+			MIMEType: mimeType,
+		})
+	} else {
+		content = append(content, &mcp.EmbeddedResource{
+			Resource: &mcp.ResourceContents{
+				URI:      OutputURI,
+				MIMEType: mimeType,
+				Blob:     result.Stdout,
+			},
+		})
+	}
+
+	if stderr := strings.TrimSpace(string(result.Stderr)); stderr != "" {
+		content = append(content, &mcp.TextContent{Text: stderr})
+	}
+
+	return content
+}
+
+// encodedContent returns stdout encoded as text, followed by stderr as is
+func encodedContent(result commandResult, encoding string) []mcp.Content {
+	debug("Returning %d bytes of output as %s", len(result.Stdout), encoding)
+
+	var encoded string
+	switch encoding {
+	case EncodeHex:
+		encoded = hex.EncodeToString(result.Stdout)
+	default:
+		encoded = base64.StdEncoding.EncodeToString(result.Stdout)
+	}
+
+	content := []mcp.Content{&mcp.TextContent{Text: encoded}}
+	if stderr := strings.TrimSpace(string(result.Stderr)); stderr != "" {
+		content = append(content, &mcp.TextContent{Text: stderr})
+	}
+	return content
+}
+
+// typedContent returns stdout as text resources of opts.ContentMIMEType, one per
+// chunk when opts.SplitOn is set, followed by stderr as plain text
+func typedContent(result commandResult, opts Options) []mcp.Content {
+	debug("Returning %d bytes of output as %s", len(result.Stdout), opts.ContentMIMEType)
+
+	chunks := []string{string(result.Stdout)}
+	if opts.SplitOn != "" {
+		if split := splitOutput(chunks[0], opts.SplitOn); len(split) > 0 {
+			chunks = split
+		}
+	}
+
+	content := make([]mcp.Content, 0, len(chunks)+1)
+	for _, chunk := range chunks {
+		content = append(content, &mcp.EmbeddedResource{
+			Resource: &mcp.ResourceContents{
+				URI:      OutputURI,
+				MIMEType: opts.ContentMIMEType,
+				Text:     chunk,
+			},
+		})
+	}
+	if stderr := strings.TrimSpace(string(result.Stderr)); stderr != "" {
+		content = append(content, &mcp.TextContent{Text: stderr})
+	}
+	return content
+}
+
+// splitOutput splits output on delimiter, dropping empty chunks at the end
+func splitOutput(output string, delimiter string) []string {
+	chunks := strings.Split(output, delimiter)
+	for len(chunks) > 0 && strings.TrimSpace(chunks[len(chunks)-1]) == "" {
+		chunks = chunks[:len(chunks)-1]
+	}
+	return chunks
+}
+
+// splitContent returns a text block for each chunk of output, followed by stderr
+func splitContent(chunks []string, stderr []byte) []mcp.Content {
+	debug("Returning output as %d chunks", len(chunks))
+
+	content := make([]mcp.Content, 0, len(chunks)+1)
+	for _, chunk := range chunks {
+		content = append(content, &mcp.TextContent{Text: chunk})
+	}
+	if stderr := strings.TrimSpace(string(stderr)); stderr != "" {
+		content = append(content, &mcp.TextContent{Text: stderr})
+	}
+	return content
+}
+
+// detectOutputType picks text, image or binary output from the output bytes
+func detectOutputType(output []byte, mimeType string) string {
+	if utf8.Valid(output) {
+		return OutputText
+	}
+	if strings.HasPrefix(detectMIMEType(output, mimeType), "image/") {
+		return OutputImage
+	}
+	return OutputBinary
+}
+
+// detectMIMEType returns mimeType when set, otherwise sniffs it from the output
+func detectMIMEType(output []byte, mimeType string) string {
+	if mimeType != "" {
+		return mimeType
+	}
+
+	detected := http.DetectContentType(output)
+
+	// SVG is text, so it sniffs as XML rather than as an image
+	if !strings.HasPrefix(detected, "image/") && bytes.Contains(output, []byte("<svg")) {
+		return "image/svg+xml"
+	}
+
+	return detected
+}
@@ -0,0 +1,290 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_CreateContent(t *testing.T) {
+	gzipBytes := []byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff}
+
+	t.Run("returns valid UTF-8 as text", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: []byte("héllo\n"), Stderr: []byte("warning\n")}, Options{})
+
+		require.Len(t, content, 1)
+		text, ok := content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "héllo\n\nwarning", text.Text)
+	})
+
+	t.Run("returns binary output as a blob with a sniffed MIME type", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: gzipBytes}, Options{})
+
+		require.Len(t, content, 1)
+		resource, ok := content[0].(*mcp.EmbeddedResource)
+		require.True(t, ok, "Expected content to be EmbeddedResource")
+		assert.Equal(t, OutputURI, resource.Resource.URI)
+		assert.Equal(t, "application/x-gzip", resource.Resource.MIMEType)
+		assert.Equal(t, gzipBytes, resource.Resource.Blob)
+	})
+
+	t.Run("uses the configured MIME type for binary output", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: gzipBytes}, Options{MIMEType: "application/gzip"})
+
+		resource, ok := content[0].(*mcp.EmbeddedResource)
+		require.True(t, ok, "Expected content to be EmbeddedResource")
+		assert.Equal(t, "application/gzip", resource.Resource.MIMEType)
+	})
+
+	t.Run("keeps stderr as text alongside binary output", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: gzipBytes, Stderr: []byte("compressed 10 bytes\n")}, Options{})
+
+		require.Len(t, content, 2)
+		text, ok := content[1].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "compressed 10 bytes", text.Text)
+	})
+
+	t.Run("tool calls return binary output as a blob", func(t *testing.T) {
+		handler := CreateToolFunction(&MockBlueprint{commandArgs: []string{"printf", `\x1f\x8b\x08\x00`}})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		resource, ok := result.Content[0].(*mcp.EmbeddedResource)
+		require.True(t, ok, "Expected content to be EmbeddedResource")
+		assert.Equal(t, []byte{0x1f, 0x8b, 0x08, 0x00}, resource.Resource.Blob)
+		assert.False(t, result.IsError)
+	})
+
+	pngBytes := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 'I', 'H', 'D', 'R'}
+
+	t.Run("returns sniffed images as image content", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: pngBytes}, Options{})
+
+		require.Len(t, content, 1)
+		image, ok := content[0].(*mcp.ImageContent)
+		require.True(t, ok, "Expected content to be ImageContent")
+		assert.Equal(t, "image/png", image.MIMEType)
+		assert.Equal(t, pngBytes, image.Data)
+	})
+
+	t.Run("returns text as an image when the output type is image", func(t *testing.T) {
+		svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+		content := createContent(commandResult{Stdout: svg}, Options{OutputType: OutputImage})
+
+		image, ok := content[0].(*mcp.ImageContent)
+		require.True(t, ok, "Expected content to be ImageContent")
+		assert.Equal(t, "image/svg+xml", image.MIMEType)
+		assert.Equal(t, svg, image.Data)
+	})
+
+	t.Run("returns images as a blob when the output type is binary", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: pngBytes}, Options{OutputType: OutputBinary})
+
+		resource, ok := content[0].(*mcp.EmbeddedResource)
+		require.True(t, ok, "Expected content to be EmbeddedResource")
+		assert.Equal(t, "image/png", resource.Resource.MIMEType)
+	})
+
+	t.Run("returns binary as text when the output type is text", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: gzipBytes}, Options{OutputType: OutputText})
+
+		_, ok := content[0].(*mcp.TextContent)
+		assert.True(t, ok, "Expected content to be TextContent")
+	})
+}
+
+func TestTool_CreateContentEncode(t *testing.T) {
+	tests := []struct {
+		name     string
+		encode   string
+		result   commandResult
+		expected []string
+	}{
+		{
+			name:     "base64",
+			encode:   EncodeBase64,
+			result:   commandResult{Stdout: []byte{0x1f, 0x8b, 0x00, 0xff}},
+			expected: []string{"H4sA/w=="},
+		},
+		{
+			name:     "hex",
+			encode:   EncodeHex,
+			result:   commandResult{Stdout: []byte("hi\n")},
+			expected: []string{"68690a"},
+		},
+		{
+			name:     "stderr stays readable",
+			encode:   EncodeHex,
+			result:   commandResult{Stdout: []byte{0x00}, Stderr: []byte("warning\n")},
+			expected: []string{"00", "warning"},
+		},
+		{
+			name:     "empty output",
+			encode:   EncodeBase64,
+			result:   commandResult{},
+			expected: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := createContent(tt.result, Options{Encode: tt.encode})
+
+			var texts []string
+			for _, c := range content {
+				text, ok := c.(*mcp.TextContent)
+				require.True(t, ok, "Expected content to be TextContent")
+				texts = append(texts, text.Text)
+			}
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
+
+func TestTool_CreateContentEncoding(t *testing.T) {
+	// The stdio transport writes one JSON message per line, so output must
+	// never put a raw newline or control character in the encoded result
+	outputs := map[string]commandResult{
+		"control characters": {Stdout: []byte("one\ntwo\tthree\x00four\r\x1b[31mred\u2028end\n"), Stderr: []byte("err\x00\n")},
+		"forced text":        {Stdout: []byte{'a', 0xff, '\n', 0x00}},
+	}
+
+	for name, output := range outputs {
+		t.Run(name, func(t *testing.T) {
+			result := &mcp.CallToolResult{Content: createContent(output, Options{OutputType: OutputText})}
+
+			encoded, err := json.Marshal(result)
+			require.NoError(t, err)
+			for _, b := range encoded {
+				assert.GreaterOrEqual(t, b, byte(0x20), "encoded result has a raw control character: %q", encoded)
+			}
+			assert.True(t, json.Valid(encoded))
+		})
+	}
+}
+
+func TestTool_SplitContent(t *testing.T) {
+	texts := func(t *testing.T, content []mcp.Content) []string {
+		var result []string
+		for _, c := range content {
+			text, ok := c.(*mcp.TextContent)
+			require.True(t, ok, "Expected content to be TextContent")
+			result = append(result, text.Text)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		result   commandResult
+		splitOn  string
+		expected []string
+	}{
+		{
+			name:     "splits on null bytes and drops the empty trailing chunk",
+			result:   commandResult{Stdout: []byte("./a.txt\x00./b c.txt\x00")},
+			splitOn:  "\x00",
+			expected: []string{"./a.txt", "./b c.txt"},
+		},
+		{
+			name:     "splits on a custom delimiter",
+			result:   commandResult{Stdout: []byte("first\n---\nsecond\n---\n\n")},
+			splitOn:  "---",
+			expected: []string{"first\n", "\nsecond\n"},
+		},
+		{
+			name:     "keeps empty chunks in the middle",
+			result:   commandResult{Stdout: []byte("a,,b")},
+			splitOn:  ",",
+			expected: []string{"a", "", "b"},
+		},
+		{
+			name:     "adds stderr as its own block",
+			result:   commandResult{Stdout: []byte("a\x00b"), Stderr: []byte("warning\n")},
+			splitOn:  "\x00",
+			expected: []string{"a", "b", "warning"},
+		},
+		{
+			name:     "returns a single block without the delimiter",
+			result:   commandResult{Stdout: []byte("just one\n")},
+			splitOn:  "\x00",
+			expected: []string{"just one\n"},
+		},
+		{
+			name:     "returns the usual block for empty output",
+			result:   commandResult{Stderr: []byte("nothing found\n")},
+			splitOn:  "\x00",
+			expected: []string{"nothing found"},
+		},
+		{
+			name:     "doesn't split without a delimiter",
+			result:   commandResult{Stdout: []byte("a\x00b\x00")},
+			expected: []string{"a\x00b\x00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := createContent(tt.result, Options{SplitOn: tt.splitOn})
+			assert.Equal(t, tt.expected, texts(t, content))
+		})
+	}
+}
+
+func TestTool_CreateContentMIMEType(t *testing.T) {
+	t.Run("returns text output as a typed resource", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: []byte(`{"ok":true}`), Stderr: []byte("warning\n")}, Options{ContentMIMEType: "application/json"})
+
+		require.Len(t, content, 2)
+		resource, ok := content[0].(*mcp.EmbeddedResource)
+		require.True(t, ok, "Expected content to be EmbeddedResource")
+		assert.Equal(t, &mcp.ResourceContents{URI: OutputURI, MIMEType: "application/json", Text: `{"ok":true}`}, resource.Resource)
+		assert.Equal(t, &mcp.TextContent{Text: "warning"}, content[1])
+	})
+
+	t.Run("types every chunk of split output", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: []byte("{\"a\":1}\n{\"b\":2}\n")}, Options{ContentMIMEType: "application/json", SplitOn: "\n"})
+
+		require.Len(t, content, 2)
+		assert.Equal(t, `{"a":1}`, content[0].(*mcp.EmbeddedResource).Resource.Text)
+		assert.Equal(t, `{"b":2}`, content[1].(*mcp.EmbeddedResource).Resource.Text)
+	})
+
+	t.Run("returns plain text without stdout", func(t *testing.T) {
+		content := createContent(commandResult{Stderr: []byte("not found\n")}, Options{ContentMIMEType: "application/json"})
+
+		assert.Equal(t, []mcp.Content{&mcp.TextContent{Text: "not found"}}, content)
+	})
+
+	t.Run("leaves binary output to the binary MIME type", func(t *testing.T) {
+		content := createContent(commandResult{Stdout: []byte{0x1f, 0x8b, 0x08, 0x00}}, Options{ContentMIMEType: "application/json", MIMEType: "application/gzip"})
+
+		resource, ok := content[0].(*mcp.EmbeddedResource)
+		require.True(t, ok, "Expected content to be EmbeddedResource")
+		assert.Equal(t, "application/gzip", resource.Resource.MIMEType)
+		assert.Empty(t, resource.Resource.Text)
+	})
+
+	t.Run("leaves images alone", func(t *testing.T) {
+		pngBytes := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 'I', 'H', 'D', 'R'}
+		content := createContent(commandResult{Stdout: pngBytes}, Options{ContentMIMEType: "application/json"})
+
+		_, ok := content[0].(*mcp.ImageContent)
+		assert.True(t, ok, "Expected content to be ImageContent")
+	})
+}
+
+func TestTool_IsOutputType(t *testing.T) {
+	for _, outputType := range []string{"auto", "text", "image", "binary"} {
+		assert.True(t, IsOutputType(outputType), outputType)
+	}
+	assert.False(t, IsOutputType("audio"))
+	assert.False(t, IsOutputType(""))
+}
@@ -0,0 +1,16 @@
+package tool
+
+import "fmt"
+
+// Credential is the user and groups a command runs as
+type Credential struct {
+	Name   string // The user as it was given, for messages
+	UID    uint32
+	GID    uint32
+	Groups []uint32
+}
+
+// String returns the user the credential was looked up from
+func (c *Credential) String() string {
+	return fmt.Sprintf("%s (uid %d, gid %d)", c.Name, c.UID, c.GID)
+}
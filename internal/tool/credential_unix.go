@@ -0,0 +1,78 @@
+//go:build !windows
+
+package tool
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// LookupCredential finds the user to run commands as, given as a user name,
+// a uid or uid:gid. Changing to another user needs studio to run as root.
+func LookupCredential(name string) (*Credential, error) {
+	credential, err := lookupCredential(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if uid := os.Geteuid(); uid != 0 && uint32(uid) != credential.UID {
+		return nil, fmt.Errorf("studio must run as root to run commands as %s, but runs as uid %d", credential, uid)
+	}
+	return credential, nil
+}
+
+// lookupCredential resolves name without checking studio is allowed to use it
+func lookupCredential(name string) (*Credential, error) {
+	uidPart, gidPart, hasGID := strings.Cut(name, ":")
+
+	if hasGID {
+		uid, uidErr := strconv.ParseUint(uidPart, 10, 32)
+		gid, gidErr := strconv.ParseUint(gidPart, 10, 32)
+		if uidErr != nil || gidErr != nil {
+			return nil, fmt.Errorf("user %q must be a name, uid or uid:gid", name)
+		}
+		return &Credential{Name: name, UID: uint32(uid), GID: uint32(gid), Groups: []uint32{uint32(gid)}}, nil
+	}
+
+	var u *user.User
+	var err error
+	if _, parseErr := strconv.ParseUint(name, 10, 32); parseErr == nil {
+		if u, err = user.LookupId(name); err != nil {
+			return nil, fmt.Errorf("uid %s has no entry in the user database, give its group as uid:gid", name)
+		}
+	} else if u, err = user.Lookup(name); err != nil {
+		return nil, fmt.Errorf("unknown user %q", name)
+	}
+	return userCredential(name, u)
+}
+
+// userCredential converts a user from the system user database
+func userCredential(name string, u *user.User) (*Credential, error) {
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %q has an unexpected uid %q", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %q has an unexpected gid %q", name, u.Gid)
+	}
+
+	credential := &Credential{Name: name, UID: uint32(uid), GID: uint32(gid)}
+	groups, _ := u.GroupIds()
+	for _, group := range groups {
+		if id, err := strconv.ParseUint(group, 10, 32); err == nil {
+			credential.Groups = append(credential.Groups, uint32(id))
+		}
+	}
+	return credential, nil
+}
+
+// owns reports whether the file described by info belongs to the user of c
+func (c *Credential) owns(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Uid == c.UID
+}
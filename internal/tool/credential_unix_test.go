@@ -0,0 +1,104 @@
+//go:build !windows
+
+package tool
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_LookupCredential(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+	uid, err := strconv.ParseUint(current.Uid, 10, 32)
+	require.NoError(t, err)
+	gid, err := strconv.ParseUint(current.Gid, 10, 32)
+	require.NoError(t, err)
+
+	t.Run("by name", func(t *testing.T) {
+		credential, err := LookupCredential(current.Username)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(uid), credential.UID)
+		assert.Equal(t, uint32(gid), credential.GID)
+	})
+
+	t.Run("by uid", func(t *testing.T) {
+		credential, err := LookupCredential(current.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(uid), credential.UID)
+		assert.Equal(t, uint32(gid), credential.GID)
+	})
+
+	t.Run("by uid and gid", func(t *testing.T) {
+		credential, err := lookupCredential("4242:4343")
+		require.NoError(t, err)
+		assert.Equal(t, &Credential{Name: "4242:4343", UID: 4242, GID: 4343, Groups: []uint32{4343}}, credential)
+	})
+
+	failures := map[string]string{
+		"studio-user-that-does-not-exist": `unknown user "studio-user-that-does-not-exist"`,
+		"4242:staff":                      `user "4242:staff" must be a name, uid or uid:gid`,
+		"4242424":                         "uid 4242424 has no entry in the user database, give its group as uid:gid",
+	}
+	for name, expected := range failures {
+		t.Run(name, func(t *testing.T) {
+			_, err := LookupCredential(name)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), expected)
+		})
+	}
+
+	t.Run("needs root to change user", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("running as root")
+		}
+		_, err := LookupCredential(strconv.FormatUint(uid+1, 10) + ":" + current.Gid)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "studio must run as root to run commands as")
+	})
+}
+
+func TestTool_CreateToolFunctionRunAs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing user needs root")
+	}
+
+	credential, err := LookupCredential("65534:65534")
+	require.NoError(t, err)
+	handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", "id -u; id -g; id -G"}}, Options{RunAs: credential})
+
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+	require.NoError(t, err)
+
+	textContent, ok := result.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "Expected content to be TextContent")
+	assert.Equal(t, "65534\n65534\n65534", textContent.Text)
+	assert.False(t, result.IsError)
+}
+
+func TestTool_OutputFileRunAs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing user needs root")
+	}
+
+	credential, err := LookupCredential("65534:65534")
+	require.NoError(t, err)
+	out := filepath.Join(t.TempDir(), "shadow.txt")
+	require.NoError(t, os.WriteFile(out, []byte("root only"), 0600))
+	handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"true"}}, Options{RunAs: credential, OutputFile: out})
+
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+	require.NoError(t, err)
+
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "isn't owned by 65534:65534")
+	assert.NotContains(t, result.Content[0].(*mcp.TextContent).Text, "root only")
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package tool
+
+import (
+	"fmt"
+	"os"
+)
+
+// LookupCredential fails since Windows has no uids to change to
+func LookupCredential(name string) (*Credential, error) {
+	return nil, fmt.Errorf("running commands as another user is not supported on Windows")
+}
+
+// owns reports false, since no credential can be looked up on Windows
+func (c *Credential) owns(info os.FileInfo) bool {
+	return false
+}
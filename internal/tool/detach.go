@@ -0,0 +1,97 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// detachedProcess is a command that was still running when its call returned
+type detachedProcess struct {
+	PID     int
+	LogFile string
+}
+
+// note tells the client the command is still running and where its output goes
+func (d *detachedProcess) note() string {
+	return fmt.Sprintf("Studio note: the command is still running in the background as pid %d, writing its output to %s", d.PID, d.LogFile)
+}
+
+// startDetached starts a command that keeps running after the call returns,
+// like a server, and returns what it wrote within settle, or until ctx is
+// done. The output goes to a log file rather than a pipe, so the command can
+// keep writing after studio reads it or exits. A command that exits within
+// settle is reported like any other and its log file removed.
+//
+// Studio never stops a detached command. It only waits on it in the
+// background, so it doesn't linger as a zombie while studio runs.
+func startDetached(ctx context.Context, run runOptions, settle time.Duration, command string, args ...string) (commandResult, *detachedProcess, error) {
+	debug("Starting detached command: %s %s", command, strings.Join(args, " "))
+
+	cmd := exec.Command(command, args...)
+	// Its own process group keeps it out of signals sent to studio's, and
+	// nothing cancels it, so there is nothing to stop it with
+	configureProcess(cmd, run.credential, 0)
+	cmd.Cancel = nil
+	if err := prepareCommand(cmd); err != nil {
+		debug("Refusing to run: %s", err)
+		return commandResult{ExitCode: -1}, nil, fmt.Errorf("Studio error: %w", err)
+	}
+	if !run.limits.IsZero() && cmd.Err == nil {
+		run.limits.wrap(cmd)
+	}
+	if len(run.env) > 0 || run.clearEnv {
+		cmd.Env = append(run.baseEnv(), run.env...)
+	}
+
+	logFile, err := os.CreateTemp("", "studio-detach-*.log")
+	if err != nil {
+		return commandResult{ExitCode: -1}, nil, fmt.Errorf("Studio error: %w", err)
+	}
+	// The command keeps its own handle to the file once started
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Start(); err != nil {
+		os.Remove(logFile.Name())
+		debug("Spawn error: %s", err.Error())
+		return commandResult{ExitCode: -1}, nil, fmt.Errorf("Studio error: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		debug("Detached command %d exited: %v", cmd.Process.Pid, err)
+		exited <- err
+	}()
+
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	select {
+	case err := <-exited:
+		output, _ := os.ReadFile(logFile.Name())
+		os.Remove(logFile.Name())
+		result := commandResult{Stdout: output, ExitCode: -1}
+		if err != nil {
+			return result, nil, exitError(err, &result, run)
+		}
+		result.ExitCode = 0
+		debug("Detached command exited with exit code 0 within %s", settle)
+		return result, nil, nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	output, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		debug("Failed to read the output of the detached command: %s", err)
+	}
+	detached := &detachedProcess{PID: cmd.Process.Pid, LogFile: logFile.Name()}
+	debug("Detached command is running as pid %d, logging to %s", detached.PID, detached.LogFile)
+	// It hasn't exited, so it has no exit code yet
+	return commandResult{Stdout: output, ExitCode: -1}, detached, nil
+}
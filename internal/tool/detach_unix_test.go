@@ -0,0 +1,67 @@
+//go:build !windows
+
+package tool
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_Detach(t *testing.T) {
+	call := func(t *testing.T, script string) *mcp.CallToolResultFor[map[string]any] {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{Detach: 200 * time.Millisecond})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("returns while the command is still running", func(t *testing.T) {
+		start := time.Now()
+		result := call(t, "echo started; sleep 0.5; echo later; exec sleep 30")
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		pid, ok := result.Meta["pid"].(int)
+		require.True(t, ok, "the result should have the pid")
+		logFile := result.Meta["logFile"].(string)
+		t.Cleanup(func() {
+			syscall.Kill(pid, syscall.SIGKILL)
+			os.Remove(logFile)
+		})
+
+		assert.False(t, result.IsError)
+		assert.NoError(t, syscall.Kill(pid, 0), "the command should still be running")
+		text := result.Content[0].(*mcp.TextContent).Text
+		assert.Contains(t, text, "started")
+		assert.NotContains(t, text, "later")
+		assert.Contains(t, text, "still running in the background")
+
+		// Output written after the call returned still reaches the log file
+		assert.Eventually(t, func() bool {
+			output, _ := os.ReadFile(logFile)
+			return string(output) == "started\nlater\n"
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("commands that exit within the settle time are reported as usual", func(t *testing.T) {
+		result := call(t, "echo done")
+
+		assert.False(t, result.IsError)
+		assert.Nil(t, result.Meta["pid"])
+		assert.Equal(t, "done", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("commands that fail within the settle time are errors", func(t *testing.T) {
+		result := call(t, "echo broken; exit 3")
+
+		assert.True(t, result.IsError)
+		assert.Nil(t, result.Meta["pid"])
+		assert.Equal(t, "broken", result.Content[0].(*mcp.TextContent).Text)
+	})
+}
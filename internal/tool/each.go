@@ -0,0 +1,75 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultEachConcurrency is how many runs of a name:each field go at once when
+// MaxConcurrency is unset
+const DefaultEachConcurrency = 4
+
+// eachConcurrency returns how many runs of a name:each field go at once
+func (o Options) eachConcurrency() int {
+	if o.MaxConcurrency > 0 {
+		return o.MaxConcurrency
+	}
+	return DefaultEachConcurrency
+}
+
+// runEach runs the command once for each value of the argument key, at most
+// limit at a time, and combines the results in order. The content of every run
+// follows a line naming its value and whether it failed, and the result is an
+// error when any run failed. The metadata of each run is listed under "runs".
+func runEach(ctx context.Context, key string, values []interface{}, args map[string]interface{}, limit int, run func(context.Context, map[string]interface{}) *mcp.CallToolResultFor[map[string]any]) *mcp.CallToolResultFor[map[string]any] {
+	results := make([]*mcp.CallToolResultFor[map[string]any], len(values))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, value := range values {
+		runArgs := maps.Clone(args)
+		runArgs[key] = []interface{}{value}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = run(ctx, runArgs)
+		}()
+	}
+	wg.Wait()
+
+	combined := &mcp.CallToolResultFor[map[string]any]{}
+	runs := make([]map[string]any, len(results))
+	for i, result := range results {
+		status := "succeeded"
+		if result.IsError {
+			status = "failed"
+			combined.IsError = true
+		}
+		combined.Content = append(combined.Content, &mcp.TextContent{Text: fmt.Sprintf("%s=%s %s:", key, eachLabel(values[i]), status)})
+		combined.Content = append(combined.Content, result.Content...)
+
+		runs[i] = map[string]any{"value": values[i], "isError": result.IsError}
+		maps.Copy(runs[i], result.Meta)
+	}
+	combined.Meta = mcp.Meta{"runs": runs}
+	return combined
+}
+
+// eachLabel formats a value of a name:each field for the line before its run
+func eachLabel(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	label, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(label)
+}
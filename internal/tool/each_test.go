@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_CreateToolFunctionEach(t *testing.T) {
+	// The command prints its argument and fails for "bad"
+	bp, err := blueprint.FromArgs([]string{"sh", "-c", `echo "checked $0"; test "$0" != bad`, "{{items...:each}}"})
+	require.NoError(t, err)
+
+	call := func(t *testing.T, opts Options, args map[string]any) *mcp.CallToolResultFor[map[string]any] {
+		handler := CreateToolFunctionWithOptions(bp, opts)
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: args})
+		require.NoError(t, err)
+		return result
+	}
+
+	texts := func(result *mcp.CallToolResultFor[map[string]any]) []string {
+		var texts []string
+		for _, content := range result.Content {
+			texts = append(texts, content.(*mcp.TextContent).Text)
+		}
+		return texts
+	}
+
+	t.Run("runs once per value", func(t *testing.T) {
+		result := call(t, Options{}, map[string]any{"items": []any{"a", "b"}})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, []string{"items=a succeeded:", "checked a", "items=b succeeded:", "checked b"}, texts(result))
+		assert.Equal(t, []map[string]any{
+			{"value": "a", "isError": false},
+			{"value": "b", "isError": false},
+		}, result.Meta["runs"])
+	})
+
+	t.Run("is an error when any run fails", func(t *testing.T) {
+		result := call(t, Options{SuccessCodes: []int{0}}, map[string]any{"items": []any{"a", "bad", "c"}})
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, []string{"items=a succeeded:", "checked a", "items=bad failed:", "checked bad", "items=c succeeded:", "checked c"}, texts(result))
+		assert.Equal(t, []map[string]any{
+			{"value": "a", "isError": false, "exitCode": 0},
+			{"value": "bad", "isError": true, "exitCode": 1},
+			{"value": "c", "isError": false, "exitCode": 0},
+		}, result.Meta["runs"])
+	})
+
+	t.Run("runs once without values", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"echo", "checked", "[items...:each]"})
+		require.NoError(t, err)
+
+		handler := CreateToolFunctionWithOptions(bp, Options{})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+		require.NoError(t, err)
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, []string{"checked"}, texts(result))
+		assert.Nil(t, result.Meta)
+	})
+
+	t.Run("checks the whole call before running", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"sh", "-c", "echo ran", "{{items...(-2):each}}"})
+		require.NoError(t, err)
+
+		handler := CreateToolFunctionWithOptions(bp, Options{})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{"items": []any{"a", "b", "c"}}})
+		require.NoError(t, err)
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, []string{"Validation error: parameter 'items' takes at most 2 value(s), got 3"}, texts(result))
+	})
+}
+
+func TestRunEach(t *testing.T) {
+	t.Run("runs at most limit at once and keeps the order", func(t *testing.T) {
+		var running, most atomic.Int32
+		run := func(ctx context.Context, args map[string]interface{}) *mcp.CallToolResultFor[map[string]any] {
+			now := running.Add(1)
+			defer running.Add(-1)
+			for {
+				seen := most.Load()
+				if now <= seen || most.CompareAndSwap(seen, now) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			value := args["item"].([]interface{})[0].(string)
+			return createToolResult(value, value == "c")
+		}
+
+		result := runEach(context.Background(), "item", []interface{}{"a", "b", "c", "d", "e"}, map[string]interface{}{"other": "x"}, 2, run)
+
+		assert.LessOrEqual(t, most.Load(), int32(2))
+		assert.True(t, result.IsError)
+		require.Len(t, result.Content, 10)
+		assert.Equal(t, "item=c failed:", result.Content[4].(*mcp.TextContent).Text)
+		assert.Equal(t, "e", result.Content[9].(*mcp.TextContent).Text)
+	})
+
+	t.Run("labels values that aren't strings as JSON", func(t *testing.T) {
+		assert.Equal(t, "web", eachLabel("web"))
+		assert.Equal(t, `{"key":"env","value":"prod"}`, eachLabel(map[string]interface{}{"key": "env", "value": "prod"}))
+	})
+}
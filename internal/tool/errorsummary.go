@@ -0,0 +1,21 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+)
+
+// errorSummary returns the first line of stderr with any text, which is
+// usually the reason a command failed, or a message from studio when the
+// command wrote nothing to stderr
+func errorSummary(result commandResult, err error) string {
+	for _, line := range strings.Split(string(result.Stderr), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("Studio error: %s", err)
+	}
+	return "Studio error: the command failed"
+}
@@ -0,0 +1,66 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		err      error
+		expected string
+	}{
+		{name: "first line", stderr: "fatal: not a git repository\nhint: run git init\n", expected: "fatal: not a git repository"},
+		{name: "blank lines are skipped", stderr: "\n  \n\tError: no such file\n", expected: "Error: no such file"},
+		{name: "empty stderr", err: errors.New("command failed with exit code 2"), expected: "Studio error: command failed with exit code 2"},
+		{name: "empty stderr without an error", stderr: " \n", expected: "Studio error: the command failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errorSummary(commandResult{Stderr: []byte(tt.stderr)}, tt.err))
+		})
+	}
+}
+
+func TestTool_ErrorSummary(t *testing.T) {
+	call := func(t *testing.T, script string) *mcp.CallToolResultFor[map[string]any] {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{ErrorSummary: true})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("leads with the first line of stderr", func(t *testing.T) {
+		result := call(t, "echo 'reading config'; echo 'error: config.yml: no such file' >&2; echo 'usage: tool [config]' >&2; exit 2")
+
+		assert.True(t, result.IsError)
+		require.Len(t, result.Content, 2)
+		assert.Equal(t, "error: config.yml: no such file", result.Content[0].(*mcp.TextContent).Text)
+		assert.Equal(t, "reading config\n\nerror: config.yml: no such file\nusage: tool [config]", result.Content[1].(*mcp.TextContent).Text)
+		assert.Equal(t, "error: config.yml: no such file", result.Meta["errorSummary"])
+	})
+
+	t.Run("falls back without stderr", func(t *testing.T) {
+		result := call(t, "echo 'half done'; exit 3")
+
+		require.Len(t, result.Content, 2)
+		assert.Equal(t, "Studio error: command failed with exit code 3", result.Content[0].(*mcp.TextContent).Text)
+		assert.Equal(t, "half done", result.Content[1].(*mcp.TextContent).Text)
+	})
+
+	t.Run("successful commands have no summary", func(t *testing.T) {
+		result := call(t, "echo ok; echo 'warning: deprecated' >&2")
+
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+		assert.Nil(t, result.Meta["errorSummary"])
+	})
+}
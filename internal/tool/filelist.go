@@ -0,0 +1,101 @@
+package tool
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// withFileLists returns a copy of schema where every array field also accepts
+// {"file": "list.txt"}, naming a file with one value per line
+func withFileLists(schema *jsonschema.Schema) *jsonschema.Schema {
+	listed := *schema
+	listed.Properties = maps.Clone(schema.Properties)
+
+	for name, property := range schema.Properties {
+		if property.Type != "array" {
+			continue
+		}
+
+		array := *property
+		array.Description = ""
+		listed.Properties[name] = &jsonschema.Schema{
+			Description: property.Description,
+			AnyOf: []*jsonschema.Schema{
+				&array,
+				{
+					Type: "object",
+					Properties: map[string]*jsonschema.Schema{
+						"file": {Type: "string", Description: "Path to a file with one value per line"},
+					},
+					Required: []string{"file"},
+				},
+			},
+		}
+	}
+
+	return &listed
+}
+
+// expandFileLists replaces {"file": path} values of array fields with the
+// lines of the file. Blank lines are skipped.
+func expandFileLists(args map[string]interface{}, schema *jsonschema.Schema) (map[string]interface{}, error) {
+	var expanded map[string]interface{}
+
+	for name, value := range args {
+		property, ok := schema.Properties[name]
+		if !ok {
+			property, ok = schema.Properties[strings.ReplaceAll(name, "-", "_")]
+		}
+		if !ok || property.Type != "array" {
+			continue
+		}
+
+		list, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path, ok := list["file"].(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("parameter '%s' must be an array or {\"file\": path}", name)
+		}
+
+		values, err := readFileList(path)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", name, err)
+		}
+		debug("Expanded %s from %s into %d values", name, path, len(values))
+
+		if expanded == nil {
+			expanded = maps.Clone(args)
+		}
+		expanded[name] = values
+	}
+
+	if expanded == nil {
+		return args, nil
+	}
+	return expanded, nil
+}
+
+// readFileList reads the non-blank lines of a file
+func readFileList(path string) ([]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file list: %w", err)
+	}
+
+	values := []interface{}{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		values = append(values, line)
+	}
+	return values, nil
+}
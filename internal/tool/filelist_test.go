@@ -0,0 +1,106 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_FileLists(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "{{prefix}}", "[files... # paths to print]"})
+	require.NoError(t, err)
+
+	listFile := filepath.Join(t.TempDir(), "list.txt")
+	require.NoError(t, os.WriteFile(listFile, []byte("a.txt\r\n\nb c.txt\n"), 0644))
+
+	call := func(opts Options, files any) *mcp.CallToolResultFor[map[string]any] {
+		result, err := CreateToolFunctionWithOptions(bp, opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"prefix": "files:", "files": files},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	text := func(result *mcp.CallToolResultFor[map[string]any]) string {
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		return textContent.Text
+	}
+
+	t.Run("expands a file list into the argv", func(t *testing.T) {
+		result := call(Options{FileLists: true, EchoCommand: true}, map[string]any{"file": listFile})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, []string{"echo", "files:", "a.txt", "b c.txt"}, result.Meta["command"])
+	})
+
+	t.Run("keeps the plain array form working", func(t *testing.T) {
+		result := call(Options{FileLists: true}, []any{"x", "y"})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "files: x y", text(result))
+	})
+
+	t.Run("reports a missing file", func(t *testing.T) {
+		result := call(Options{FileLists: true}, map[string]any{"file": filepath.Join(t.TempDir(), "missing.txt")})
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, text(result), "Validation error: parameter 'files': failed to read file list")
+	})
+
+	t.Run("requires a file path", func(t *testing.T) {
+		result := call(Options{FileLists: true}, map[string]any{"path": listFile})
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, text(result), `parameter 'files' must be an array or {"file": path}`)
+	})
+
+	t.Run("finds fields that keep their dashes", func(t *testing.T) {
+		dashed, err := blueprint.FromArgs([]string{"echo", "[var-files...]"})
+		require.NoError(t, err)
+		dashed.KeepDashes = true
+
+		result, err := CreateToolFunctionWithOptions(dashed, Options{FileLists: true})(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"var-files": map[string]any{"file": listFile}},
+		})
+		require.NoError(t, err)
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "a.txt b c.txt", text(result))
+	})
+
+	t.Run("doesn't read files unless enabled", func(t *testing.T) {
+		result := call(Options{}, map[string]any{"file": listFile})
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, text(result), "must be an array")
+	})
+}
+
+func TestTool_WithFileLists(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "{{prefix}}", "[files... # paths to print]"})
+	require.NoError(t, err)
+	schema := bp.GenerateInputSchema()
+
+	listed := withFileLists(schema)
+
+	assert.Equal(t, schema.Properties["prefix"], listed.Properties["prefix"])
+
+	files := listed.Properties["files"]
+	assert.Equal(t, "paths to print", files.Description)
+	require.Len(t, files.AnyOf, 2)
+	assert.Equal(t, "array", files.AnyOf[0].Type)
+	assert.Equal(t, &jsonschema.Schema{Type: "string"}, files.AnyOf[0].Items)
+	assert.Equal(t, "object", files.AnyOf[1].Type)
+	assert.Equal(t, []string{"file"}, files.AnyOf[1].Required)
+
+	// The blueprint's own schema is left alone
+	assert.Equal(t, "array", schema.Properties["files"].Type)
+}
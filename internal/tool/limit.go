@@ -0,0 +1,36 @@
+package tool
+
+import "context"
+
+// limiter bounds how many commands can run at the same time. A nil limiter
+// allows any number of commands.
+type limiter chan struct{}
+
+// newLimiter returns a limiter allowing n commands at once, or nil when n is not positive
+func newLimiter(n int) limiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(limiter, n)
+}
+
+// acquire waits for a free slot, giving up when ctx is done
+func (l limiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (l limiter) release() {
+	if l != nil {
+		<-l
+	}
+}
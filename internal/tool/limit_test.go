@@ -0,0 +1,90 @@
+package tool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_Limiter(t *testing.T) {
+	t.Run("nil limiter never blocks", func(t *testing.T) {
+		l := newLimiter(0)
+		assert.Nil(t, l)
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, l.acquire(context.Background()))
+		}
+		l.release()
+	})
+
+	t.Run("bounds concurrent holders", func(t *testing.T) {
+		l := newLimiter(2)
+		var running, maxRunning int32
+		var wg sync.WaitGroup
+
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, l.acquire(context.Background()))
+				defer l.release()
+
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			}()
+		}
+
+		wg.Wait()
+		assert.Equal(t, int32(2), maxRunning)
+	})
+
+	t.Run("gives up when the context is done", func(t *testing.T) {
+		l := newLimiter(1)
+		require.NoError(t, l.acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := l.acquire(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestTool_CreateToolFunctionMaxConcurrency(t *testing.T) {
+	t.Run("queued calls report an error when cancelled", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sleep", "0.2"}}, Options{MaxConcurrency: 1})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		result, err := handler(ctx, nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Contains(t, textContent.Text, "gave up waiting to run command")
+
+		<-done
+	})
+}
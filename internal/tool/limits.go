@@ -0,0 +1,34 @@
+package tool
+
+import "fmt"
+
+// Limits caps what a command can use, so a runaway command can't take
+// over the host. They are only applied where LimitsSupported is true.
+type Limits struct {
+	CPUSeconds  int   // CPU time before the command is stopped, unlimited when zero
+	MemoryBytes int64 // Virtual memory the command can allocate, unlimited when zero
+}
+
+// IsZero reports whether no limit is set
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// memoryNote explains that a failed command may have run out of memory,
+// since allocations just fail once the limit is reached
+func (l Limits) memoryNote() string {
+	return fmt.Sprintf("Studio note: the command can use at most %s of memory, which may be why it failed", formatMemory(l.MemoryBytes))
+}
+
+// formatMemory formats bytes in the largest unit that divides it, like 512M
+func formatMemory(bytes int64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}} {
+		if bytes%unit.size == 0 {
+			return fmt.Sprintf("%d%s", bytes/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
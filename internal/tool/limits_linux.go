@@ -0,0 +1,36 @@
+package tool
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// LimitsSupported reports whether Limits are applied here
+const LimitsSupported = true
+
+// wrap makes cmd run through sh, which sets the limits with ulimit and then
+// execs the command in its place. The limits are in place before the command
+// runs any code, and every process it starts inherits them. Running out of CPU
+// time sends SIGXCPU, and SIGKILL a second later if the command keeps going.
+func (l Limits) wrap(cmd *exec.Cmd) {
+	var ulimits []string
+	if l.CPUSeconds > 0 {
+		// The soft limit goes first since the hard one can't be below it
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -S -t %d", l.CPUSeconds), fmt.Sprintf("ulimit -H -t %d", l.CPUSeconds+1))
+	}
+	if l.MemoryBytes > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", max(l.MemoryBytes/1024, 1)))
+	}
+	script := strings.Join(append(ulimits, `exec "$@"`), " && ")
+
+	cmd.Args = append([]string{"sh", "-c", script, "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+}
+
+// isCPULimitSignal reports whether signal is the one sent when a command runs
+// out of CPU time
+func isCPULimitSignal(signal syscall.Signal) bool {
+	return signal == syscall.SIGXCPU
+}
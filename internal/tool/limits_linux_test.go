@@ -0,0 +1,54 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_Limits(t *testing.T) {
+	run := func(t *testing.T, script string, limits Limits) *mcp.CallToolResultFor[map[string]any] {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{Limits: limits})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+		require.NoError(t, err)
+		require.Len(t, result.Content, 1)
+		return result
+	}
+
+	t.Run("applies the limits to the command", func(t *testing.T) {
+		result := run(t, "ulimit -t; ulimit -v", Limits{CPUSeconds: 5, MemoryBytes: 256 << 20})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "5\n262144", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("stops a command that runs out of CPU time", func(t *testing.T) {
+		result := run(t, "echo started; while :; do :; done", Limits{CPUSeconds: 1})
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, "started\n\nStudio error: command used more than its limit of 1s of CPU time", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("hints at the memory limit when the command fails", func(t *testing.T) {
+		result := run(t, "echo 'cannot allocate' >&2; exit 1", Limits{MemoryBytes: 64 << 20})
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, "cannot allocate\nStudio note: the command can use at most 64M of memory, which may be why it failed", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("reports a missing command as before", func(t *testing.T) {
+		_, err := executeCommand(context.Background(), runOptions{limits: Limits{CPUSeconds: 5}}, "studio-no-such-command")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "executable file not found")
+	})
+
+	t.Run("says nothing when the command succeeds", func(t *testing.T) {
+		result := run(t, "echo fine", Limits{MemoryBytes: 64 << 20})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "fine", result.Content[0].(*mcp.TextContent).Text)
+	})
+}
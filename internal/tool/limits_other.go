@@ -0,0 +1,19 @@
+//go:build !linux
+
+package tool
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// LimitsSupported reports whether Limits are applied here
+const LimitsSupported = false
+
+// wrap leaves cmd as it is, since limits are only applied on Linux
+func (l Limits) wrap(cmd *exec.Cmd) {}
+
+// isCPULimitSignal is always false, since CPU limits are never applied here
+func isCPULimitSignal(signal syscall.Signal) bool {
+	return false
+}
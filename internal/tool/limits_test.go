@@ -0,0 +1,14 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMemory(t *testing.T) {
+	assert.Equal(t, "2G", formatMemory(2<<30))
+	assert.Equal(t, "512M", formatMemory(512<<20))
+	assert.Equal(t, "1536K", formatMemory(1536<<10))
+	assert.Equal(t, "1000 bytes", formatMemory(1000))
+}
@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Keys of the messages studio writes into results on its own behalf
+const (
+	messageValidationError   = "validationError"
+	messageMissingParameter  = "missingParameter"
+	messageUnknownArguments  = "unknownArguments"
+	messageRateLimited       = "rateLimited"
+	messageGaveUpWaiting     = "gaveUpWaiting"
+	messageInactivityTimeout = "inactivityTimeout"
+)
+
+// defaultMessages holds the wording used for every key that isn't overridden.
+// Placeholders like {{duration}} are filled in when the message is written.
+var defaultMessages = map[string]string{
+	messageValidationError:   "Validation error: {{error}}",
+	messageMissingParameter:  "Validation error: missing required parameter: {{name}}",
+	messageUnknownArguments:  "Validation error: unknown arguments: {{names}}",
+	messageRateLimited:       "Studio error: rate limit of {{rate}} reached, retry after {{seconds}}s",
+	messageGaveUpWaiting:     "Studio error: gave up waiting to run command: {{error}}",
+	messageInactivityTimeout: "Studio error: command stopped: no output for {{duration}}",
+}
+
+// Messages overrides the wording of the messages studio writes into results,
+// by key. Keys left out keep the default English message.
+type Messages map[string]string
+
+// LoadMessages reads message overrides from a JSON file of keys and messages.
+// Unknown keys and placeholders are errors, so a typo fails at startup
+// rather than going unnoticed.
+//
+//	{"inactivityTimeout": "Abgebrochen: keine Ausgabe seit {{duration}}"}
+func LoadMessages(filename string) (Messages, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	var messages Messages
+	if err := json.Unmarshal(content, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse messages %s: %w", filename, err)
+	}
+
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if err := checkMessage(key, messages[key]); err != nil {
+			return nil, fmt.Errorf("messages %s: %w", filename, err)
+		}
+	}
+	return messages, nil
+}
+
+// checkMessage returns an error when key isn't a message or the message uses
+// placeholders its default doesn't have
+func checkMessage(key, message string) error {
+	defaultMessage, ok := defaultMessages[key]
+	if !ok {
+		known := make([]string, 0, len(defaultMessages))
+		for name := range defaultMessages {
+			known = append(known, name)
+		}
+		slices.Sort(known)
+		return fmt.Errorf("unknown message %q, use one of: %s", key, strings.Join(known, ", "))
+	}
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("message %s is empty", key)
+	}
+	allowed := placeholderPattern.FindAllString(defaultMessage, -1)
+	for _, placeholder := range placeholderPattern.FindAllString(message, -1) {
+		if !slices.Contains(allowed, placeholder) {
+			return fmt.Errorf("message %s has unknown placeholder %s, use %s", key, placeholder, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// format returns the message for key with each placeholder replaced by its
+// value, given as pairs like "duration", "30s"
+func (m Messages) format(key string, values ...string) string {
+	message, ok := m[key]
+	if !ok {
+		message = defaultMessages[key]
+	}
+	pairs := make([]string, 0, len(values))
+	for i := 0; i+1 < len(values); i += 2 {
+		pairs = append(pairs, "{{"+values[i]+"}}", values[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// missingParameter is an error for a call without a required field
+type missingParameter interface {
+	MissingParameter() string
+}
+
+// validationError returns the message for a call whose arguments don't fit
+// the tool
+func (m Messages) validationError(err error) string {
+	var missing missingParameter
+	if errors.As(err, &missing) {
+		return m.format(messageMissingParameter, "name", missing.MissingParameter())
+	}
+	return m.format(messageValidationError, "error", err.Error())
+}
@@ -0,0 +1,88 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestLoadMessages(t *testing.T) {
+	writeMessages := func(t *testing.T, content string) string {
+		filename := filepath.Join(t.TempDir(), "messages.json")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	t.Run("loads overrides and keeps the defaults", func(t *testing.T) {
+		messages, err := LoadMessages(writeMessages(t, `{"inactivityTimeout": "Abgebrochen: keine Ausgabe seit {{duration}}"}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Abgebrochen: keine Ausgabe seit 30s", messages.format(messageInactivityTimeout, "duration", "30s"))
+		assert.Equal(t, "Studio error: gave up waiting to run command: busy", messages.format(messageGaveUpWaiting, "error", "busy"))
+	})
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"invalid JSON", `{"rateLimited": `, "failed to parse messages"},
+		{"unknown key", `{"timeout": "Too slow"}`, `unknown message "timeout", use one of: gaveUpWaiting, inactivityTimeout, missingParameter`},
+		{"empty message", `{"rateLimited": " "}`, "message rateLimited is empty"},
+		{"unknown placeholder", `{"rateLimited": "Retry in {{secs}}s"}`, "message rateLimited has unknown placeholder {{secs}}, use {{rate}}, {{seconds}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMessages(writeMessages(t, tt.content))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	_, err := LoadMessages(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read messages")
+}
+
+func TestTool_Messages(t *testing.T) {
+	messages := Messages{
+		messageInactivityTimeout: "Abgebrochen: keine Ausgabe seit {{duration}}",
+		messageMissingParameter:  "Fehlender Parameter: {{name}}",
+		messageValidationError:   "Ungültig: {{error}}",
+	}
+
+	call := func(t *testing.T, bp Blueprint, opts Options, args map[string]any) string {
+		result, err := CreateToolFunctionWithOptions(bp, opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: args})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		return result.Content[0].(*mcp.TextContent).Text
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		bp := &MockBlueprint{commandArgs: []string{"sh", "-c", "echo started; sleep 30"}}
+		text := call(t, bp, Options{InactivityTimeout: 200 * time.Millisecond, Messages: messages}, map[string]any{})
+		assert.Equal(t, "started\n\nAbgebrochen: keine Ausgabe seit 200ms", text)
+	})
+
+	bp, err := blueprint.FromArgs([]string{"echo", "{{text}}", "[count:maxlen=2]"})
+	require.NoError(t, err)
+
+	t.Run("missing parameter", func(t *testing.T) {
+		assert.Equal(t, "Fehlender Parameter: text", call(t, bp, Options{Messages: messages}, map[string]any{}))
+	})
+
+	t.Run("other validation errors", func(t *testing.T) {
+		text := call(t, bp, Options{Messages: messages}, map[string]any{"text": "hi", "count": "100"})
+		assert.Equal(t, "Ungültig: parameter 'count' is 3 bytes, longer than the limit of 2 bytes", text)
+	})
+
+	t.Run("defaults without overrides", func(t *testing.T) {
+		assert.Equal(t, "Validation error: missing required parameter: text", call(t, bp, Options{}, map[string]any{}))
+	})
+}
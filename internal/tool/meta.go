@@ -0,0 +1,59 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// metaValue formats a _meta value for the command. Strings are used as they
+// are and anything else as JSON.
+func metaValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// metaEnv returns NAME=value for every _meta key mapped to an environment
+// variable. Keys the call didn't send are left out.
+func metaEnv(meta mcp.Meta, mapping map[string]string) []string {
+	var env []string
+	for _, key := range slices.Sorted(maps.Keys(mapping)) {
+		if value, ok := meta[key]; ok && value != nil {
+			env = append(env, mapping[key]+"="+metaValue(value))
+		}
+	}
+	return env
+}
+
+// applyMetaArgs fills fields mapped from _meta keys. Arguments the call sent
+// take precedence, so _meta only fills fields that were left out or empty.
+func applyMetaArgs(args map[string]any, meta mcp.Meta, mapping map[string]string) map[string]any {
+	if len(mapping) == 0 || len(meta) == 0 {
+		return args
+	}
+
+	filled := maps.Clone(args)
+	if filled == nil {
+		filled = map[string]any{}
+	}
+	for key, field := range mapping {
+		value, ok := meta[key]
+		if !ok || value == nil {
+			continue
+		}
+		if current, ok := filled[field]; ok && current != nil && current != "" {
+			continue
+		}
+		filled[field] = metaValue(value)
+	}
+	return filled
+}
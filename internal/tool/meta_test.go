@@ -0,0 +1,95 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_MetaValue(t *testing.T) {
+	assert.Equal(t, "req-1", metaValue("req-1"))
+	assert.Equal(t, "42", metaValue(42.0))
+	assert.Equal(t, "true", metaValue(true))
+	assert.Equal(t, `{"a":1}`, metaValue(map[string]any{"a": 1}))
+}
+
+func TestTool_ApplyMetaArgs(t *testing.T) {
+	meta := mcp.Meta{"requestId": "req-1", "empty": nil}
+	mapping := map[string]string{"requestId": "trace_id", "empty": "other"}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "fills a missing field",
+			args:     map[string]any{"url": "https://example.com"},
+			expected: map[string]any{"url": "https://example.com", "trace_id": "req-1"},
+		},
+		{
+			name:     "fills an empty field",
+			args:     map[string]any{"trace_id": ""},
+			expected: map[string]any{"trace_id": "req-1"},
+		},
+		{
+			name:     "arguments take precedence",
+			args:     map[string]any{"trace_id": "mine"},
+			expected: map[string]any{"trace_id": "mine"},
+		},
+		{
+			name:     "fills calls without arguments",
+			args:     nil,
+			expected: map[string]any{"trace_id": "req-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, applyMetaArgs(tt.args, meta, mapping))
+		})
+	}
+
+	t.Run("leaves the arguments of the call untouched", func(t *testing.T) {
+		args := map[string]any{}
+		applyMetaArgs(args, meta, mapping)
+		assert.Empty(t, args)
+	})
+}
+
+func TestTool_CreateToolFunctionMeta(t *testing.T) {
+	t.Run("passes mapped _meta keys as environment variables", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", `echo "[$REQUEST_ID] [$UNSENT]"`}}, Options{
+			MetaEnv: map[string]string{"requestId": "REQUEST_ID", "unsent": "UNSENT"},
+		})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Meta: mcp.Meta{"requestId": "req-1", "secret": "nope"},
+		})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "[req-1] []", textContent.Text)
+	})
+
+	t.Run("fills fields from mapped _meta keys", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"echo", "{{text}}", "[trace_id]"})
+		require.NoError(t, err)
+		handler := CreateToolFunctionWithOptions(bp, Options{MetaArgs: map[string]string{"requestId": "trace_id"}})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Meta:      mcp.Meta{"requestId": "req-1"},
+			Arguments: map[string]any{"text": "hello"},
+		})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "hello req-1", textContent.Text)
+	})
+}
@@ -0,0 +1,25 @@
+package tool
+
+import "sync"
+
+// OutputStore remembers the output of the most recent command run by a tool
+type OutputStore struct {
+	mu     sync.RWMutex
+	output string
+	set    bool
+}
+
+// Set records the output of a command
+func (s *OutputStore) Set(output string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = output
+	s.set = true
+}
+
+// Get returns the most recent output and whether any command has run yet
+func (s *OutputStore) Get() (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.output, s.set
+}
@@ -0,0 +1,171 @@
+package tool
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// FieldValues is a Blueprint that can tell the value a field passes to the
+// command, after defaults and modifiers like :trim are applied
+type FieldValues interface {
+	FieldValue(args map[string]interface{}, name string) (string, bool)
+}
+
+// OutputFileFields returns the names of the fields used by the {{name}}
+// placeholders of an output file path
+func OutputFileFields(path string) []string {
+	var names []string
+	for _, placeholder := range placeholderPattern.FindAllString(path, -1) {
+		names = append(names, strings.TrimSpace(placeholder[2:len(placeholder)-2]))
+	}
+	return names
+}
+
+// outputFilePath fills in the placeholders of the output file path with the
+// values the fields of a call with args pass to the command
+func outputFilePath(blueprint Blueprint, args map[string]interface{}, path string) (string, error) {
+	var missing error
+	path = placeholderPattern.ReplaceAllStringFunc(path, func(placeholder string) string {
+		name := strings.TrimSpace(placeholder[2 : len(placeholder)-2])
+		if fields, ok := blueprint.(FieldValues); ok {
+			if value, ok := fields.FieldValue(args, name); ok {
+				return value
+			}
+		}
+		if missing == nil {
+			missing = fmt.Errorf("the output file needs a value for %s", name)
+		}
+		return ""
+	})
+	return path, missing
+}
+
+// outputFile is the file a call expects its command to write
+type outputFile struct {
+	path    string
+	root    string // Directory the file must be inside, anywhere when empty
+	existed bool   // The file was there before the command ran, so it is never removed
+}
+
+// newOutputFile returns the output file of a call with args, refusing paths
+// outside of root before anything runs. It notes whether the file already
+// exists so a file the command didn't create is never removed.
+func newOutputFile(blueprint Blueprint, args map[string]interface{}, path string, root string) (*outputFile, error) {
+	path, err := outputFilePath(blueprint, args, path)
+	if err != nil {
+		return nil, err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("invalid output file: %w", err)
+	}
+	if root != "" {
+		if root, err = filepath.Abs(root); err != nil {
+			return nil, fmt.Errorf("invalid file root: %w", err)
+		}
+		if !inRoot(root, path) {
+			return nil, fmt.Errorf("output file %s is outside of %s", path, root)
+		}
+	}
+
+	_, err = os.Lstat(path)
+	return &outputFile{path: path, root: root, existed: err == nil}, nil
+}
+
+// inRoot reports whether the absolute path is root or inside of it
+func inRoot(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// open opens the file once the command has written it. The file is refused
+// when a symlink leads it outside of the root, or when a command run as
+// another user doesn't own it, since studio reads it as its own user.
+func (f *outputFile) open(runAs *Credential) (*os.File, error) {
+	path, err := filepath.EvalSymlinks(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("the command didn't write its output file %s", f.path)
+		}
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+	if f.root != "" {
+		root := f.root
+		if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+			root = realRoot
+		}
+		if !inRoot(root, path) {
+			return nil, fmt.Errorf("output file %s is outside of %s", path, root)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+	if runAs != nil {
+		info, err := file.Stat()
+		if err != nil || !runAs.owns(info) {
+			file.Close()
+			return nil, fmt.Errorf("output file %s isn't owned by %s", f.path, runAs.Name)
+		}
+	}
+	return file, nil
+}
+
+// read returns the file the command wrote as content. Text is returned as a
+// resource of the file with every match of patterns replaced by ***, images
+// as image content and anything else as a base64 blob.
+func (f *outputFile) read(runAs *Credential, patterns []*regexp.Regexp) (mcp.Content, error) {
+	file, err := f.open(runAs)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(f.path))
+	if mimeType == "" {
+		mimeType = detectMIMEType(data, "")
+	}
+	uri := "file://" + filepath.ToSlash(f.path)
+	debug("Returning %d bytes of output file %s as %s", len(data), f.path, mimeType)
+
+	switch {
+	case utf8.Valid(data):
+		for _, re := range patterns {
+			data = re.ReplaceAllLiteral(data, []byte(redactedOutput))
+		}
+		return &mcp.EmbeddedResource{
+			Resource: &mcp.ResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)},
+		}, nil
+	case strings.HasPrefix(mimeType, "image/"):
+		return &mcp.ImageContent{Data: data, MIMEType: mimeType}, nil
+	default:
+		return &mcp.EmbeddedResource{
+			Resource: &mcp.ResourceContents{URI: uri, MIMEType: mimeType, Blob: data},
+		}, nil
+	}
+}
+
+// remove deletes the output file once the call is done, unless it was there
+// before the call
+func (f *outputFile) remove() {
+	if f.existed {
+		debug("Keeping output file %s, it existed before the call", f.path)
+		return
+	}
+	if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+		debug("Failed to remove output file %s: %s", f.path, err)
+	}
+}
@@ -0,0 +1,162 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestOutputFileFields(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"out.mp4", nil},
+		{"{{out}}", []string{"out"}},
+		{"/tmp/{{ name }}.{{format}}", []string{"name", "format"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, OutputFileFields(tt.path))
+		})
+	}
+}
+
+func TestTool_OutputFile(t *testing.T) {
+	call := func(t *testing.T, script string, opts Options, args map[string]any) *mcp.CallToolResultFor[map[string]any] {
+		bp, err := blueprint.FromArgs([]string{"sh", "-c", script, "sh", "[out]"})
+		require.NoError(t, err)
+		handler := CreateToolFunctionWithOptions(bp, opts)
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: args})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("returns the text file the command wrote after its output", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		result := call(t, `echo done; printf 'hello\n' > "$1"`, Options{OutputFile: "{{out}}"}, map[string]any{"out": out})
+
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 2)
+		assert.Equal(t, "done", result.Content[0].(*mcp.TextContent).Text)
+		resource := result.Content[1].(*mcp.EmbeddedResource).Resource
+		assert.Equal(t, "file://"+filepath.ToSlash(out), resource.URI)
+		assert.Equal(t, "text/plain; charset=utf-8", resource.MIMEType)
+		assert.Equal(t, "hello\n", resource.Text)
+		assert.FileExists(t, out)
+	})
+
+	t.Run("returns binary files as a blob", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "data.bin")
+		result := call(t, `printf '\377\376\000' > "$1"`, Options{OutputFile: out}, map[string]any{"out": out})
+
+		assert.False(t, result.IsError)
+		resource := result.Content[len(result.Content)-1].(*mcp.EmbeddedResource).Resource
+		assert.Equal(t, []byte{0xff, 0xfe, 0}, resource.Blob)
+		assert.Equal(t, "application/octet-stream", resource.MIMEType)
+		assert.Empty(t, resource.Text)
+	})
+
+	t.Run("redacts text files", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		opts := Options{OutputFile: "{{out}}", Redact: []*regexp.Regexp{regexp.MustCompile(`sk-\w+`)}}
+		result := call(t, `printf 'key sk-abc123\n' > "$1"`, opts, map[string]any{"out": out})
+
+		assert.Equal(t, "key ***\n", result.Content[len(result.Content)-1].(*mcp.EmbeddedResource).Resource.Text)
+	})
+
+	t.Run("removes the file after the call", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		result := call(t, `printf hello > "$1"`, Options{OutputFile: "{{out}}", RemoveOutputFile: true}, map[string]any{"out": out})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "hello", result.Content[len(result.Content)-1].(*mcp.EmbeddedResource).Resource.Text)
+		assert.NoFileExists(t, out)
+	})
+
+	t.Run("fails when the command didn't write the file", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		result := call(t, `true`, Options{OutputFile: "{{out}}"}, map[string]any{"out": out})
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "Studio error: the command didn't write its output file "+out)
+	})
+
+	t.Run("doesn't return the file of a failed command", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		result := call(t, `printf partial > "$1"; exit 1`, Options{OutputFile: "{{out}}"}, map[string]any{"out": out})
+
+		assert.True(t, result.IsError)
+		require.Len(t, result.Content, 1)
+		assert.FileExists(t, out)
+	})
+
+	t.Run("removes the file of a failed command", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		result := call(t, `printf partial > "$1"; exit 1`, Options{OutputFile: "{{out}}", RemoveOutputFile: true}, map[string]any{"out": out})
+
+		assert.True(t, result.IsError)
+		assert.NoFileExists(t, out)
+	})
+
+	t.Run("removes the file of a result flagged as an error", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		opts := Options{OutputFile: "{{out}}", RemoveOutputFile: true, ErrorIfMatch: regexp.MustCompile(`FAILED`)}
+		result := call(t, `printf partial > "$1"; echo FAILED`, opts, map[string]any{"out": out})
+
+		assert.True(t, result.IsError)
+		assert.NoFileExists(t, out)
+	})
+
+	t.Run("never removes a file that was there before the call", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "precious.txt")
+		require.NoError(t, os.WriteFile(out, []byte("keep"), 0644))
+		result := call(t, `printf hello > "$1"`, Options{OutputFile: "{{out}}", RemoveOutputFile: true}, map[string]any{"out": out})
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "hello", result.Content[len(result.Content)-1].(*mcp.EmbeddedResource).Resource.Text)
+		assert.FileExists(t, out)
+	})
+
+	t.Run("refuses a path outside of the file root before running", func(t *testing.T) {
+		root := t.TempDir()
+		out := filepath.Join(t.TempDir(), "precious.txt")
+		require.NoError(t, os.WriteFile(out, []byte("keep"), 0644))
+		opts := Options{OutputFile: "{{out}}", RemoveOutputFile: true, FileRoot: root}
+		result := call(t, `printf overwritten > "$1"`, opts, map[string]any{"out": out})
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "is outside of "+root)
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, "keep", string(data))
+	})
+
+	t.Run("refuses a file the command links outside of the file root", func(t *testing.T) {
+		root := t.TempDir()
+		secret := filepath.Join(t.TempDir(), "secret.txt")
+		require.NoError(t, os.WriteFile(secret, []byte("secret"), 0644))
+		out := filepath.Join(root, "report.txt")
+		opts := Options{OutputFile: "{{out}}", RemoveOutputFile: true, FileRoot: root}
+		result := call(t, `ln -s "`+secret+`" "$1"`, opts, map[string]any{"out": out})
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "is outside of")
+		assert.FileExists(t, secret)
+	})
+
+	t.Run("needs a value for every field of the path", func(t *testing.T) {
+		result := call(t, `true`, Options{OutputFile: "{{out}}"}, map[string]any{})
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, "Validation error: the output file needs a value for out", result.Content[0].(*mcp.TextContent).Text)
+	})
+}
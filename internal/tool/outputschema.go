@@ -0,0 +1,73 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// OutputSchema describes the JSON object a command writes to stdout, which
+// is returned as the structured content of its results
+type OutputSchema struct {
+	schema   *jsonschema.Schema
+	resolved *jsonschema.Resolved
+}
+
+// LoadOutputSchema reads a JSON schema from a file. Structured content is
+// always an object, so the schema must have type object.
+func LoadOutputSchema(filename string) (*OutputSchema, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output schema: %w", err)
+	}
+
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse output schema %s: %w", filename, err)
+	}
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("output schema %s must have type object", filename)
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output schema %s: %w", filename, err)
+	}
+	return &OutputSchema{schema: &schema, resolved: resolved}, nil
+}
+
+// structure parses stdout as a JSON object and checks it against the schema
+func (o *OutputSchema) structure(stdout []byte) (map[string]any, error) {
+	var output map[string]any
+	if err := json.Unmarshal(stdout, &output); err != nil {
+		return nil, fmt.Errorf("output is not a JSON object: %w", err)
+	}
+	if err := o.resolved.Validate(output); err != nil {
+		return nil, fmt.Errorf("output doesn't match the output schema: %w", err)
+	}
+	return output, nil
+}
+
+// withStructuredContent wraps handler for a tool with an output schema. The
+// SDK's typed tools drop structured content from their results, so these
+// tools are built from the untyped handler instead.
+func withStructuredContent(tool *mcp.Tool, handler mcp.ToolHandlerFor[map[string]any, map[string]any]) *mcp.ServerTool {
+	return &mcp.ServerTool{
+		Tool: tool,
+		Handler: func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResult, error) {
+			result, err := handler(ctx, session, params)
+			if result == nil {
+				return nil, err
+			}
+			untyped := &mcp.CallToolResult{Meta: result.Meta, Content: result.Content, IsError: result.IsError}
+			// A nil map would be written as null rather than left out
+			if result.StructuredContent != nil {
+				untyped.StructuredContent = result.StructuredContent
+			}
+			return untyped, err
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOutputSchema(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "output.json")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+func TestLoadOutputSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"invalid JSON", `{"type": `, "failed to parse output schema"},
+		{"not an object", `{"type": "array"}`, "must have type object"},
+		{"invalid schema", `{"type": "object", "$ref": "#/$defs/missing"}`, "invalid output schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadOutputSchema(writeOutputSchema(t, tt.content))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	_, err := LoadOutputSchema(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read output schema")
+}
+
+func TestTool_OutputSchema(t *testing.T) {
+	schema, err := LoadOutputSchema(writeOutputSchema(t, `{
+		"type": "object",
+		"properties": {"name": {"type": "string"}, "stars": {"type": "integer"}},
+		"required": ["name", "stars"]
+	}`))
+	require.NoError(t, err)
+
+	call := func(t *testing.T, output string) *mcp.CallToolResultFor[map[string]any] {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"printf", "%s", output}}, Options{OutputSchema: schema})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("returns matching output as structured content", func(t *testing.T) {
+		result := call(t, `{"name": "studio", "stars": 42}`)
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, map[string]any{"name": "studio", "stars": float64(42)}, result.StructuredContent)
+		assert.Equal(t, `{"name": "studio", "stars": 42}`, result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("fails output that doesn't match", func(t *testing.T) {
+		result := call(t, `{"name": "studio", "stars": "many"}`)
+
+		assert.True(t, result.IsError)
+		assert.Nil(t, result.StructuredContent)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "Studio error: output doesn't match the output schema")
+	})
+
+	t.Run("fails output that isn't a JSON object", func(t *testing.T) {
+		result := call(t, `["studio"]`)
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "Studio error: output is not a JSON object")
+	})
+
+	t.Run("lists the schema with the tool", func(t *testing.T) {
+		serverTool := CreateServerToolWithOptions(&MockBlueprint{commandArgs: []string{"gh"}}, Options{OutputSchema: schema})
+		assert.Equal(t, "object", serverTool.Tool.OutputSchema.Type)
+	})
+}
@@ -0,0 +1,68 @@
+package tool
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// outputPlaceholders are the values an output template can use
+var outputPlaceholders = []string{"{{output}}", "{{command}}", "{{exitCode}}"}
+
+// placeholderPattern matches anything written like a placeholder
+var placeholderPattern = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// OutputTemplate formats the text of a result, like "$ {{command}}\n{{output}}".
+// The zero OutputTemplate is unset and leaves output as is.
+type OutputTemplate struct {
+	text string
+}
+
+// ParseOutputTemplate checks that text uses only {{output}}, {{command}} and
+// {{exitCode}}, and uses {{output}} so the output isn't lost
+func ParseOutputTemplate(text string) (OutputTemplate, error) {
+	for _, placeholder := range placeholderPattern.FindAllString(text, -1) {
+		if !slices.Contains(outputPlaceholders, placeholder) {
+			return OutputTemplate{}, fmt.Errorf("unknown placeholder %s in output template, use %s", placeholder, strings.Join(outputPlaceholders, ", "))
+		}
+	}
+	if !strings.Contains(text, "{{output}}") {
+		return OutputTemplate{}, fmt.Errorf("output template must include {{output}}")
+	}
+	return OutputTemplate{text: text}, nil
+}
+
+// IsZero reports whether the template is unset
+func (t OutputTemplate) IsZero() bool {
+	return t.text == ""
+}
+
+func (t OutputTemplate) String() string {
+	return t.text
+}
+
+// Format fills in the template. Placeholders are replaced in a single pass over
+// the template, so output or commands that contain {{output}} are returned as
+// they are rather than read as placeholders again.
+func (t OutputTemplate) Format(output string, command []string, exitCode int) string {
+	return strings.NewReplacer(
+		"{{output}}", output,
+		"{{command}}", strings.Join(command, " "),
+		"{{exitCode}}", strconv.Itoa(exitCode),
+	).Replace(t.text)
+}
+
+// formatContent formats a result returned as a single text block, leaving
+// split, encoded and binary results as they are
+func (t OutputTemplate) formatContent(content []mcp.Content, command []string, exitCode int) {
+	if t.IsZero() || len(content) != 1 {
+		return
+	}
+	if text, ok := content[0].(*mcp.TextContent); ok {
+		text.Text = t.Format(text.Text, command, exitCode)
+	}
+}
@@ -0,0 +1,106 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestParseOutputTemplate(t *testing.T) {
+	tests := []struct {
+		text          string
+		expectedError string
+	}{
+		{text: "{{output}}"},
+		{text: "$ {{command}} (exit {{exitCode}})\n{{output}}"},
+		{text: "{ {{output}} }"},
+		{text: "$ {{command}}", expectedError: "must include {{output}}"},
+		{text: "{{stdout}}", expectedError: "unknown placeholder {{stdout}} in output template, use {{output}}, {{command}}, {{exitCode}}"},
+		{text: "{{ output }}", expectedError: "unknown placeholder {{ output }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			template, err := ParseOutputTemplate(tt.text)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.text, template.String())
+			assert.False(t, template.IsZero())
+		})
+	}
+}
+
+func TestOutputTemplate_Format(t *testing.T) {
+	template, err := ParseOutputTemplate("$ {{command}} => {{exitCode}}\n{{output}}")
+	require.NoError(t, err)
+
+	t.Run("fills in every placeholder", func(t *testing.T) {
+		assert.Equal(t, "$ git status => 0\nclean", template.Format("clean", []string{"git", "status"}, 0))
+	})
+
+	t.Run("doesn't read output as a template", func(t *testing.T) {
+		formatted := template.Format("{{command}} {{exitCode}} {{output}}", []string{"echo", "{{output}}"}, 1)
+		assert.Equal(t, "$ echo {{output}} => 1\n{{command}} {{exitCode}} {{output}}", formatted)
+	})
+}
+
+func TestTool_OutputTemplate(t *testing.T) {
+	call := func(t *testing.T, opts Options, script string) *mcp.CallToolResultFor[map[string]any] {
+		bp, err := blueprint.FromArgs([]string{"sh", "-c", "{{script}}"})
+		require.NoError(t, err)
+
+		result, err := CreateToolFunctionWithOptions(bp, opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"script": script},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	template, err := ParseOutputTemplate("exit {{exitCode}}: {{output}}")
+	require.NoError(t, err)
+
+	t.Run("formats text output", func(t *testing.T) {
+		result := call(t, Options{OutputTemplate: template}, "echo hello")
+
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+		assert.Equal(t, "exit 0: hello", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("formats failed commands", func(t *testing.T) {
+		result := call(t, Options{OutputTemplate: template}, "echo oops >&2; exit 3")
+
+		assert.True(t, result.IsError)
+		assert.Equal(t, "exit 3: oops", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("includes the command", func(t *testing.T) {
+		withCommand, err := ParseOutputTemplate("$ {{command}}\n{{output}}")
+		require.NoError(t, err)
+		result := call(t, Options{OutputTemplate: withCommand}, "echo hi")
+
+		assert.Equal(t, "$ sh -c echo hi\nhi", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("leaves output alone by default", func(t *testing.T) {
+		result := call(t, Options{}, "echo '{{output}}'")
+
+		assert.Equal(t, "{{output}}", result.Content[0].(*mcp.TextContent).Text)
+	})
+
+	t.Run("leaves binary output alone", func(t *testing.T) {
+		result := call(t, Options{OutputTemplate: template, OutputType: OutputBinary}, "printf 'abc'")
+
+		require.Len(t, result.Content, 1)
+		_, ok := result.Content[0].(*mcp.EmbeddedResource)
+		assert.True(t, ok, "Expected content to be EmbeddedResource")
+	})
+}
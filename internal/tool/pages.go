@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// OutputPageURITemplate is the resource URI template for a page of output that
+// was too large for one result
+const OutputPageURITemplate = "studio://output/{id}/{offset}"
+
+// outputPagePrefix starts the URI of every page of output
+const outputPagePrefix = "studio://output/"
+
+// DefaultPageMemory is how many bytes of paged output are kept for reading
+const DefaultPageMemory = 16 << 20
+
+// PagedOutputs keeps output that was too large for one result so it can be
+// read a page at a time. Each output gets an id, and the oldest outputs are
+// dropped once together they take more than the memory limit.
+type PagedOutputs struct {
+	mu      sync.Mutex
+	limit   int
+	nextID  int
+	outputs map[string]string
+	order   []string // ids from oldest to newest
+	size    int
+}
+
+// NewPagedOutputs creates a store for paged output that keeps at most limit bytes
+func NewPagedOutputs(limit int) *PagedOutputs {
+	return &PagedOutputs{limit: limit, outputs: make(map[string]string)}
+}
+
+// add keeps output and returns its id. Output larger than the memory limit is
+// cut to fit, so the kept output may be shorter than what was given.
+func (p *PagedOutputs) add(output string) (string, string) {
+	if len(output) > p.limit {
+		output = output[:runeBoundary(output, p.limit)]
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.order) > 0 && p.size+len(output) > p.limit {
+		oldest := p.order[0]
+		p.order = p.order[1:]
+		p.size -= len(p.outputs[oldest])
+		delete(p.outputs, oldest)
+		debug("Dropped paged output %s", oldest)
+	}
+
+	p.nextID++
+	id := strconv.Itoa(p.nextID)
+	p.outputs[id] = output
+	p.order = append(p.order, id)
+	p.size += len(output)
+	return id, output
+}
+
+// get returns the output kept under id
+func (p *PagedOutputs) get(id string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	output, ok := p.outputs[id]
+	return output, ok
+}
+
+// runeBoundary returns the largest offset at or before n that doesn't split a
+// UTF-8 character of s
+func runeBoundary(s string, n int) int {
+	if n >= len(s) {
+		return len(s)
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// outputPageURI returns the URI of the page of output id starting at offset
+func outputPageURI(id string, offset int) string {
+	return fmt.Sprintf("%s%s/%d", outputPagePrefix, id, offset)
+}
+
+// pageResult replaces the text of result with its first page when it is
+// longer than pageSize, keeping the full text in pages, and reports whether it
+// did. Results that aren't a single text block are left alone.
+func pageResult(result *mcp.CallToolResultFor[map[string]any], pages *PagedOutputs, pageSize int) bool {
+	if len(result.Content) != 1 {
+		return false
+	}
+	text, ok := result.Content[0].(*mcp.TextContent)
+	if !ok || len(text.Text) <= pageSize {
+		return false
+	}
+
+	id, output := pages.add(text.Text)
+	end := runeBoundary(output, pageSize)
+	debug("Paged %d bytes of output as %s", len(output), id)
+
+	note := fmt.Sprintf("Studio note: output is %d bytes, showing the first %d. Read the resource %s for the next page.", len(text.Text), end, outputPageURI(id, end))
+	if len(output) < len(text.Text) {
+		note += fmt.Sprintf(" Only the first %d bytes are kept.", len(output))
+	}
+	result.Content = []mcp.Content{&mcp.TextContent{Text: output[:end] + "\n\n" + note}}
+
+	setMeta(result, "output", map[string]any{
+		"id":         id,
+		"totalBytes": len(output),
+		"next":       outputPageURI(id, end),
+	})
+	return true
+}
+
+// CreateOutputPageResource creates an MCP resource template that serves pages
+// of output kept in pages, pageSize bytes at a time
+func CreateOutputPageResource(pages *PagedOutputs, pageSize int) *mcp.ServerResourceTemplate {
+	return &mcp.ServerResourceTemplate{
+		ResourceTemplate: &mcp.ResourceTemplate{
+			URITemplate: OutputPageURITemplate,
+			Name:        "output-page",
+			Description: "A page of command output that was too large for one result, starting at a byte offset",
+			MIMEType:    "text/plain",
+		},
+		Handler: func(ctx context.Context, session *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+			id, offsetText, _ := strings.Cut(strings.TrimPrefix(params.URI, outputPagePrefix), "/")
+			output, ok := pages.get(id)
+			offset, err := strconv.Atoi(offsetText)
+			if !ok || err != nil || offset < 0 || offset > len(output) {
+				debug("Resource %s is not a kept page of output", params.URI)
+				return nil, mcp.ResourceNotFoundError(params.URI)
+			}
+
+			end := runeBoundary(output, offset+pageSize)
+			if end <= offset {
+				// Always make progress, even with a page smaller than a character
+				end = min(offset+utf8.UTFMax, len(output))
+			}
+
+			result := &mcp.ReadResourceResult{
+				Contents: []*mcp.ResourceContents{{URI: params.URI, MIMEType: "text/plain", Text: output[offset:end]}},
+				Meta:     mcp.Meta{"totalBytes": len(output)},
+			}
+			if end < len(output) {
+				result.Meta["next"] = outputPageURI(id, end)
+			}
+			return result, nil
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_PagedOutput(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"printf", "%s", "{{text}}"})
+	require.NoError(t, err)
+
+	call := func(t *testing.T, opts Options, text string) *mcp.CallToolResultFor[map[string]any] {
+		result, err := CreateToolFunctionWithOptions(bp, opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"text": text},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	read := func(t *testing.T, resource *mcp.ServerResourceTemplate, uri string) *mcp.ReadResourceResult {
+		require.True(t, resource.Matches(uri), "resource template should match %s", uri)
+		result, err := resource.Handler(context.Background(), nil, &mcp.ReadResourceParams{URI: uri})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("leaves short output alone", func(t *testing.T) {
+		pages := NewPagedOutputs(DefaultPageMemory)
+		result := call(t, Options{PageSize: 10, Pages: pages}, "short")
+
+		assert.Equal(t, "short", result.Content[0].(*mcp.TextContent).Text)
+		assert.Nil(t, result.Meta)
+	})
+
+	t.Run("returns the first page and serves the rest", func(t *testing.T) {
+		pages := NewPagedOutputs(DefaultPageMemory)
+		resource := CreateOutputPageResource(pages, 10)
+		output := "0123456789abcdefghijKLMNOPQRSTuvwxy"
+
+		result := call(t, Options{PageSize: 10, Pages: pages}, output)
+		assert.Equal(t, "0123456789\n\nStudio note: output is 35 bytes, showing the first 10. Read the resource studio://output/1/10 for the next page.", result.Content[0].(*mcp.TextContent).Text)
+		assert.Equal(t, map[string]any{"id": "1", "totalBytes": 35, "next": "studio://output/1/10"}, result.Meta["output"])
+
+		collected := "0123456789"
+		next := "studio://output/1/10"
+		for next != "" {
+			page := read(t, resource, next)
+			require.Len(t, page.Contents, 1)
+			assert.Equal(t, next, page.Contents[0].URI)
+			assert.Equal(t, 35, page.Meta["totalBytes"])
+			collected += page.Contents[0].Text
+
+			next, _ = page.Meta["next"].(string)
+		}
+		assert.Equal(t, output, collected)
+	})
+
+	t.Run("doesn't split characters across pages", func(t *testing.T) {
+		pages := NewPagedOutputs(DefaultPageMemory)
+		resource := CreateOutputPageResource(pages, 2)
+		output := strings.Repeat("héllo ", 5)
+
+		result := call(t, Options{PageSize: 2, Pages: pages}, output)
+		first, _, _ := strings.Cut(result.Content[0].(*mcp.TextContent).Text, "\n\nStudio note")
+		assert.Equal(t, "h", first)
+
+		collected := first
+		next := result.Meta["output"].(map[string]any)["next"].(string)
+		for next != "" {
+			page := read(t, resource, next)
+			assert.True(t, utf8.ValidString(page.Contents[0].Text), "page %q splits a character", page.Contents[0].Text)
+			collected += page.Contents[0].Text
+			next, _ = page.Meta["next"].(string)
+		}
+		assert.Equal(t, strings.TrimSpace(output), collected)
+	})
+
+	t.Run("drops the oldest output beyond the memory limit", func(t *testing.T) {
+		pages := NewPagedOutputs(25)
+		resource := CreateOutputPageResource(pages, 5)
+
+		call(t, Options{PageSize: 5, Pages: pages}, strings.Repeat("a", 12))
+		call(t, Options{PageSize: 5, Pages: pages}, strings.Repeat("b", 12))
+		assert.Equal(t, "aaaaa", read(t, resource, "studio://output/1/5").Contents[0].Text)
+
+		call(t, Options{PageSize: 5, Pages: pages}, strings.Repeat("c", 12))
+		_, err := resource.Handler(context.Background(), nil, &mcp.ReadResourceParams{URI: "studio://output/1/5"})
+		assert.Error(t, err, "the first output should be dropped")
+		assert.Equal(t, "ccccc", read(t, resource, "studio://output/3/5").Contents[0].Text)
+	})
+
+	t.Run("keeps only the start of output larger than the memory limit", func(t *testing.T) {
+		pages := NewPagedOutputs(8)
+
+		result := call(t, Options{PageSize: 4, Pages: pages}, strings.Repeat("x", 20))
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "output is 20 bytes, showing the first 4")
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "Only the first 8 bytes are kept.")
+		assert.Equal(t, 8, result.Meta["output"].(map[string]any)["totalBytes"])
+	})
+
+	t.Run("reports pages that aren't kept as not found", func(t *testing.T) {
+		pages := NewPagedOutputs(DefaultPageMemory)
+		resource := CreateOutputPageResource(pages, 4)
+		call(t, Options{PageSize: 4, Pages: pages}, "0123456789")
+
+		for _, uri := range []string{"studio://output/9/0", "studio://output/1/99", "studio://output/1/-1", "studio://output/1/x"} {
+			_, err := resource.Handler(context.Background(), nil, &mcp.ReadResourceParams{URI: uri})
+			assert.Error(t, err, uri)
+		}
+	})
+}
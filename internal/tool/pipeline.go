@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Pipeline is a Blueprint whose command can pipe the output of one command
+// into the next without a shell
+type Pipeline interface {
+	BuildPipeline(args map[string]interface{}) ([][]string, error)
+}
+
+// lockedWriter serializes writes to w from the commands of a pipeline
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// executePipeline runs the stages of a pipeline together, with the stdout of
+// each connected straight to the stdin of the next. The result has the stdout
+// of the last stage and the stderr of every stage. Like sh with pipefail, the
+// pipeline fails when any stage fails, taking the exit code of the last stage
+// that did. Stages stopped by a broken pipe, because a later stage quit
+// reading, don't count.
+func executePipeline(ctx context.Context, run runOptions, stages [][]string) (commandResult, error) {
+	debug("Executing pipeline of %d commands", len(stages))
+
+	// A stage that fails to start stops the stages already started
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var stdout, stderr bytes.Buffer
+	var stdoutWriter, stderrWriter io.Writer = &stdout, &stderr
+	if run.onOutput != nil {
+		stdoutWriter = &activityWriter{w: &stdout, onWrite: run.onOutput}
+		stderrWriter = &activityWriter{w: &stderr, onWrite: run.onOutput}
+	}
+	stdoutWriter = lockedWriter{mu: &mu, w: stdoutWriter}
+	stderrWriter = lockedWriter{mu: &mu, w: stderrWriter}
+	if run.mergeOutput {
+		stderrWriter = stdoutWriter
+	}
+
+	cmds := make([]*exec.Cmd, len(stages))
+	for i, stage := range stages {
+		debug("  pipeline command %d: %s", i+1, strings.Join(stage, " "))
+		cmd, err := newCommand(ctx, run, stage[0], stage[1:]...)
+		if err != nil {
+			return commandResult{ExitCode: -1}, err
+		}
+		cmd.Stderr = stderrWriter
+		cmds[i] = cmd
+	}
+	cmds[len(cmds)-1].Stdout = stdoutWriter
+
+	var pipes []*os.File
+	for i := range cmds[1:] {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeFiles(pipes)
+			return commandResult{ExitCode: -1}, fmt.Errorf("Studio error: %w", err)
+		}
+		cmds[i].Stdout = w
+		cmds[i+1].Stdin = r
+		pipes = append(pipes, r, w)
+	}
+
+	started := 0
+	var startErr error
+	for _, cmd := range cmds {
+		if startErr = cmd.Start(); startErr != nil {
+			break
+		}
+		started++
+	}
+	// Only the commands hold the pipes from here, so each stage sees the end
+	// of its input once the stage before it exits
+	closeFiles(pipes)
+
+	if startErr != nil {
+		stopped := context.Cause(ctx)
+		cancel()
+		for _, cmd := range cmds[:started] {
+			cmd.Wait()
+		}
+		if stopped != nil {
+			debug("Pipeline stopped: %s", stopped)
+			return commandResult{ExitCode: -1}, fmt.Errorf("command stopped: %w", stopped)
+		}
+		debug("Spawn error in pipeline command %d: %s", started+1, startErr)
+		return commandResult{ExitCode: -1}, fmt.Errorf("Studio error: pipeline command %d, %s: %w", started+1, stages[started][0], startErr)
+	}
+
+	errs := make([]error, len(cmds))
+	for i, cmd := range cmds {
+		errs[i] = cmd.Wait()
+	}
+
+	result := commandResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), ExitCode: -1}
+
+	failed := -1
+	for i, err := range errs {
+		if err != nil && !(i < len(errs)-1 && brokenPipe(err)) {
+			failed = i
+		}
+	}
+	if failed == -1 {
+		result.ExitCode = 0
+		debug("Pipeline completed successfully with exit code 0")
+		return result, nil
+	}
+
+	if cause := context.Cause(ctx); cause != nil {
+		debug("Pipeline stopped: %s", cause)
+		return result, fmt.Errorf("command stopped: %w", cause)
+	}
+	err := fmt.Errorf("pipeline command %d, %s: %w", failed+1, stages[failed][0], exitError(errs[failed], &result, run))
+	// The exit code alone doesn't say which stage failed, unless it's the last
+	if failed < len(errs)-1 && result.Signal == "" {
+		result.addNote(fmt.Sprintf("Studio error: %s", err))
+	}
+	return result, err
+}
+
+// brokenPipe reports whether a command was stopped by writing to a pipe that
+// nothing reads anymore
+func brokenPipe(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGPIPE
+}
+
+// closeFiles closes every file, ignoring errors
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
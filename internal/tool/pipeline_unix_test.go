@@ -0,0 +1,92 @@
+//go:build !windows
+
+package tool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_Pipeline(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		params          map[string]any
+		expectedError   bool
+		expectedContent string
+	}{
+		{
+			name:            "two commands",
+			args:            []string{"printf", "{{text}}", "|", "wc", "-c"},
+			params:          map[string]any{"text": "hello"},
+			expectedContent: "5",
+		},
+		{
+			name:            "values are passed as arguments",
+			args:            []string{"echo", "{{text}}", "|", "tr", "a-z", "A-Z"},
+			params:          map[string]any{"text": "a | rm -rf /; echo b"},
+			expectedContent: "A | RM -RF /; ECHO B",
+		},
+		{
+			name:            "first command fails",
+			args:            []string{"sh", "-c", "echo partial; echo broken >&2; exit 3", "|", "cat"},
+			params:          map[string]any{},
+			expectedError:   true,
+			expectedContent: "partial\n\nbroken\nStudio error: pipeline command 1, sh: command failed with exit code 3",
+		},
+		{
+			name:            "last command fails",
+			args:            []string{"echo", "{{text}}", "|", "grep", "missing"},
+			params:          map[string]any{"text": "hello"},
+			expectedError:   true,
+			expectedContent: "",
+		},
+		{
+			name:            "earlier commands stopped by a closed pipe are fine",
+			args:            []string{"yes", "|", "head", "-n", "2"},
+			params:          map[string]any{},
+			expectedContent: "y\ny",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := blueprint.FromArgs(tt.args)
+			require.NoError(t, err)
+
+			handler := CreateToolFunctionWithOptions(bp, Options{})
+			result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: tt.params})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedError, result.IsError)
+			assert.Equal(t, tt.expectedContent, result.Content[0].(*mcp.TextContent).Text)
+		})
+	}
+
+	t.Run("exit code of the failed command", func(t *testing.T) {
+		result, err := executePipeline(context.Background(), runOptions{}, [][]string{{"sh", "-c", "exit 4"}, {"cat"}, {"sh", "-c", "cat; exit 0"}})
+		assert.EqualError(t, err, "pipeline command 1, sh: command failed with exit code 4")
+		assert.Equal(t, 4, result.ExitCode)
+	})
+
+	t.Run("missing command", func(t *testing.T) {
+		_, err := executePipeline(context.Background(), runOptions{}, [][]string{{"sleep", "30"}, {"studio-missing-command"}})
+		assert.ErrorContains(t, err, "Studio error: pipeline command 2, studio-missing-command: exec: \"studio-missing-command\": executable file not found")
+	})
+
+	t.Run("stops every command when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := executePipeline(ctx, runOptions{}, [][]string{{"sleep", "30"}, {"cat"}})
+		assert.ErrorContains(t, err, "command stopped")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
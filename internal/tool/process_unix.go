@@ -0,0 +1,44 @@
+//go:build !windows
+
+package tool
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// configureProcess starts the command in its own process group so stopping it
+// also stops any processes it started. The command runs as credential when set.
+// With a grace period the group gets SIGTERM first, and SIGKILL only if it's
+// still running once the grace period is over.
+func configureProcess(cmd *exec.Cmd, credential *Credential, grace time.Duration) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if credential != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid:    credential.UID,
+			Gid:    credential.GID,
+			Groups: credential.Groups,
+		}
+	}
+	cmd.Cancel = func() error {
+		// A negative pid signals the whole process group
+		group := -cmd.Process.Pid
+		if grace <= 0 {
+			return syscall.Kill(group, syscall.SIGKILL)
+		}
+		if err := syscall.Kill(group, syscall.SIGTERM); err != nil {
+			return err
+		}
+		// Children can outlive the command, so the group is killed even if
+		// the command itself exits in time
+		time.AfterFunc(grace, func() { syscall.Kill(group, syscall.SIGKILL) })
+		return nil
+	}
+}
+
+// prepareCommand leaves the command as is since exec runs any executable
+// directly on Unix
+func prepareCommand(cmd *exec.Cmd) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+//go:build !windows
+
+package tool
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_ExecuteCommandKillsChildren(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	result, err := executeCommand(ctx, runOptions{}, "sh", "-c", "sleep 30 & echo $!; wait")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command stopped")
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(result.Stdout)))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) == syscall.ESRCH
+	}, 5*time.Second, 50*time.Millisecond, "sleep should be killed with its parent")
+}
+
+func TestTool_ExecuteCommandCrash(t *testing.T) {
+	result, err := executeCommand(context.Background(), runOptions{}, "sh", "-c", "echo partial; echo oops >&2; kill -SEGV $$")
+	require.Error(t, err)
+	assert.Equal(t, "command killed by signal: segmentation fault", err.Error())
+	assert.Equal(t, "partial\n", string(result.Stdout))
+	assert.Equal(t, "oops\n", string(result.Stderr))
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Equal(t, "segmentation fault", result.Signal)
+
+	handler := CreateToolFunction(&MockBlueprint{commandArgs: []string{"sh", "-c", "echo partial; echo oops >&2; kill -SEGV $$"}})
+	toolResult, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+	require.NoError(t, err)
+	assert.True(t, toolResult.IsError)
+	require.Len(t, toolResult.Content, 1)
+	assert.Equal(t, "partial\n\noops\nStudio error: command killed by signal: segmentation fault", toolResult.Content[0].(*mcp.TextContent).Text)
+}
+
+func TestTool_ExecuteCommandKillGrace(t *testing.T) {
+	// The trap runs once the sleep it waits on is stopped by the same SIGTERM
+	const cleanup = `trap 'echo cleaned up; exit 0' TERM; echo started; while :; do sleep 0.05; done`
+	stop := func(t *testing.T, grace time.Duration, script string) (commandResult, time.Duration) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result, err := executeCommand(ctx, runOptions{killGrace: grace}, "sh", "-c", script)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command stopped")
+		return result, time.Since(start)
+	}
+
+	t.Run("lets the command clean up", func(t *testing.T) {
+		result, elapsed := stop(t, 5*time.Second, cleanup)
+
+		assert.Equal(t, "started\ncleaned up\n", string(result.Stdout))
+		assert.Less(t, elapsed, 2*time.Second, "should not wait out the grace period once the command exits")
+	})
+
+	t.Run("kills the command once the grace period is over", func(t *testing.T) {
+		result, elapsed := stop(t, 500*time.Millisecond, `trap '' TERM; echo started; while :; do sleep 0.05; done`)
+
+		assert.Equal(t, "started\n", string(result.Stdout))
+		assert.GreaterOrEqual(t, elapsed, 700*time.Millisecond)
+		assert.Less(t, elapsed, 3*time.Second)
+	})
+
+	t.Run("kills right away without a grace period", func(t *testing.T) {
+		result, _ := stop(t, 0, cleanup)
+
+		assert.Equal(t, "started\n", string(result.Stdout))
+	})
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package tool
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// batchUnsafe lists the characters cmd.exe expands or splits on even inside
+// quotes, so they can't be passed to a batch file as literal arguments
+const batchUnsafe = "%!^\"&|<>\r\n"
+
+// generateConsoleCtrlEvent sends a console control event to a process group
+var generateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+// configureProcess starts the command in its own process group and stops the
+// whole process tree when the command is stopped. With a grace period the
+// group gets CTRL_BREAK first, and is killed only if it's still running once
+// the grace period is over. LookupCredential never returns a credential on
+// Windows.
+func configureProcess(cmd *exec.Cmd, credential *Credential, grace time.Duration) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	cmd.Cancel = func() error {
+		if grace <= 0 {
+			return killTree(cmd.Process)
+		}
+		// The group id is the pid of the process that started it
+		if ok, _, err := generateConsoleCtrlEvent.Call(syscall.CTRL_BREAK_EVENT, uintptr(cmd.Process.Pid)); ok == 0 {
+			debug("CTRL_BREAK failed, stopping the command now: %s", err)
+			return killTree(cmd.Process)
+		}
+		time.AfterFunc(grace, func() { killTree(cmd.Process) })
+		return nil
+	}
+}
+
+// killTree stops process and every process it started
+func killTree(process *os.Process) error {
+	// Windows has no process group signal, but taskkill /T stops children too
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		debug("taskkill failed, stopping only the command: %s", err)
+		return process.Kill()
+	}
+	return nil
+}
+
+// prepareCommand runs batch files through cmd /c. exec finds commands on PATH
+// with the extensions in PATHEXT, but .bat and .cmd files need cmd.exe, which
+// reads its command line by its own rules. Arguments it would expand or split
+// are refused rather than passed through changed.
+func prepareCommand(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		// Run reports commands that can't be found
+		return nil
+	}
+	switch strings.ToLower(filepath.Ext(cmd.Path)) {
+	case ".bat", ".cmd":
+	default:
+		return nil
+	}
+
+	words := []string{`"` + cmd.Path + `"`}
+	for _, arg := range cmd.Args[1:] {
+		if strings.ContainsAny(arg, batchUnsafe) {
+			return fmt.Errorf("argument %q can't be passed safely to the batch file %s", arg, filepath.Base(cmd.Path))
+		}
+		words = append(words, `"`+arg+`"`)
+	}
+
+	// /s strips only the outer quotes, keeping the quotes around each word
+	cmd.SysProcAttr.CmdLine = `cmd.exe /d /s /c "` + strings.Join(words, " ") + `"`
+	cmd.Path = comspec()
+	return nil
+}
+
+// comspec returns the path of cmd.exe
+func comspec() string {
+	if path := os.Getenv("ComSpec"); path != "" {
+		return path
+	}
+	return filepath.Join(os.Getenv("SystemRoot"), "System32", "cmd.exe")
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBatchFile(t *testing.T) string {
+	dir := t.TempDir()
+	script := "@echo off\r\necho %~1\r\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greet.bat"), []byte(script), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestTool_ExecuteCommandBatchFile(t *testing.T) {
+	writeBatchFile(t)
+
+	t.Run("found through PATHEXT", func(t *testing.T) {
+		result, err := executeCommand(context.Background(), runOptions{}, "greet", "hello world")
+		require.NoError(t, err)
+		assert.Equal(t, "hello world\r\n", string(result.Stdout))
+	})
+
+	tests := []struct {
+		name string
+		arg  string
+	}{
+		{"variable", "%PATH%"},
+		{"delayed variable", "!PATH!"},
+		{"command separator", "a & whoami"},
+		{"quote", `a" & whoami & "`},
+		{"redirect", "a > out.txt"},
+		{"newline", "a\r\nwhoami"},
+	}
+	for _, tt := range tests {
+		t.Run("refuses "+tt.name, func(t *testing.T) {
+			_, err := executeCommand(context.Background(), runOptions{}, "greet", tt.arg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "can't be passed safely to the batch file greet.bat")
+		})
+	}
+}
+
+func TestTool_ExecuteCommandKillsChildren(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := executeCommand(ctx, runOptions{}, "cmd", "/c", "ping -n 30 127.0.0.1 > nul")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command stopped")
+	assert.Less(t, time.Since(start), 5*time.Second, "ping should be killed with cmd")
+}
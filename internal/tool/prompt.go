@@ -0,0 +1,121 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CreateToolPrompt creates an MCP prompt that explains how to call the tool
+// created from blueprint. Each field of the blueprint is a prompt argument, and
+// values given for them are suggested to the model as the arguments to use.
+func CreateToolPrompt(blueprint Blueprint) *mcp.ServerPrompt {
+	return CreateToolPromptWithOptions(blueprint, Options{})
+}
+
+// CreateToolPromptWithOptions creates the prompt for the tool created from
+// blueprint with opts, named after that tool
+func CreateToolPromptWithOptions(blueprint Blueprint, opts Options) *mcp.ServerPrompt {
+	schema, ok := blueprint.GetInputSchema().(*jsonschema.Schema)
+	if !ok {
+		panic("blueprint.GetInputSchema() must return *jsonschema.Schema")
+	}
+
+	toolName := opts.ToolName(blueprint)
+	names := promptFieldNames(schema)
+
+	arguments := make([]*mcp.PromptArgument, 0, len(names))
+	for _, name := range names {
+		arguments = append(arguments, &mcp.PromptArgument{
+			Name:        name,
+			Description: schema.Properties[name].Description,
+		})
+	}
+
+	description := fmt.Sprintf("How to call the %s tool", toolName)
+
+	return &mcp.ServerPrompt{
+		Prompt: &mcp.Prompt{
+			Name:        toolName,
+			Description: description,
+			Arguments:   arguments,
+		},
+		Handler: func(ctx context.Context, session *mcp.ServerSession, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+			debug("Prompt %s requested with args: %v", params.Name, params.Arguments)
+
+			var text strings.Builder
+			fmt.Fprintf(&text, "Use the %s tool to run the shell command `%s`.\n", toolName, blueprint.GetCommandFormat())
+
+			if len(names) > 0 {
+				text.WriteString("\nIt takes these arguments:\n")
+				for _, name := range names {
+					text.WriteString(promptFieldLine(name, schema))
+				}
+			}
+
+			var given []string
+			for _, name := range names {
+				if value, ok := params.Arguments[name]; ok && value != "" {
+					given = append(given, fmt.Sprintf("- %s: %s\n", name, value))
+				}
+			}
+			if len(given) > 0 {
+				text.WriteString("\nCall it with:\n")
+				text.WriteString(strings.Join(given, ""))
+			}
+
+			return &mcp.GetPromptResult{
+				Description: description,
+				Messages: []*mcp.PromptMessage{
+					{Role: "user", Content: &mcp.TextContent{Text: text.String()}},
+				},
+			}, nil
+		},
+	}
+}
+
+// promptFieldNames returns the field names of schema in the order they appear
+// in the template, or required fields first when the schema has no ordering
+func promptFieldNames(schema *jsonschema.Schema) []string {
+	if order, ok := schema.Extra["propertyOrdering"].([]string); ok {
+		return order
+	}
+
+	names := slices.Clone(schema.Required)
+
+	var optional []string
+	for name := range schema.Properties {
+		if !slices.Contains(names, name) {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(optional)
+
+	return append(names, optional...)
+}
+
+// promptFieldLine describes a single field of schema for the prompt text
+func promptFieldLine(name string, schema *jsonschema.Schema) string {
+	property := schema.Properties[name]
+
+	kind := property.Type
+	if kind == "array" {
+		kind = "array of strings"
+	}
+	if slices.Contains(schema.Required, name) {
+		kind += ", required"
+	} else {
+		kind += ", optional"
+	}
+
+	line := fmt.Sprintf("- %s (%s)", name, kind)
+	if property.Description != "" {
+		line += ": " + property.Description
+	}
+	return line + "\n"
+}
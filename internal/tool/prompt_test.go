@@ -0,0 +1,65 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_ToolPrompt(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"git-log", "[--oneline]", "{{repo # path to the repository}}", "[paths...]"})
+	require.NoError(t, err)
+
+	t.Run("describes the prompt and its arguments", func(t *testing.T) {
+		prompt := CreateToolPrompt(bp)
+
+		assert.Equal(t, "git_log", prompt.Prompt.Name)
+		assert.Equal(t, "How to call the git_log tool", prompt.Prompt.Description)
+
+		var names []string
+		for _, argument := range prompt.Prompt.Arguments {
+			names = append(names, argument.Name)
+		}
+		assert.Equal(t, []string{"oneline", "repo", "paths"}, names)
+		assert.Equal(t, "path to the repository", prompt.Prompt.Arguments[1].Description)
+	})
+
+	t.Run("is named after the prefixed tool", func(t *testing.T) {
+		prompt := CreateToolPromptWithOptions(bp, Options{NamePrefix: "repo1_"})
+
+		assert.Equal(t, "repo1_git_log", prompt.Prompt.Name)
+		assert.Equal(t, "How to call the repo1_git_log tool", prompt.Prompt.Description)
+	})
+
+	t.Run("explains the fields of the tool", func(t *testing.T) {
+		result, err := CreateToolPrompt(bp).Handler(context.Background(), nil, &mcp.GetPromptParams{Name: "git_log"})
+		require.NoError(t, err)
+		require.Len(t, result.Messages, 1)
+
+		assert.Equal(t, mcp.Role("user"), result.Messages[0].Role)
+		textContent, ok := result.Messages[0].Content.(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "Use the git_log tool to run the shell command `git-log [--oneline] {{repo}} [paths...]`.\n"+
+			"\n"+
+			"It takes these arguments:\n"+
+			"- oneline (boolean, optional): Enable --oneline flag\n"+
+			"- repo (string, required): path to the repository\n"+
+			"- paths (array of strings, optional): Additional command line arguments\n", textContent.Text)
+	})
+
+	t.Run("suggests the given argument values", func(t *testing.T) {
+		result, err := CreateToolPrompt(bp).Handler(context.Background(), nil, &mcp.GetPromptParams{
+			Name:      "git_log",
+			Arguments: map[string]string{"repo": "~/src/studio"},
+		})
+		require.NoError(t, err)
+
+		textContent, ok := result.Messages[0].Content.(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Contains(t, textContent.Text, "\nCall it with:\n- repo: ~/src/studio\n")
+	})
+}
@@ -0,0 +1,105 @@
+package tool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate is a number of calls allowed per period, like 10/min
+type Rate struct {
+	Calls  int
+	Period time.Duration
+}
+
+// rateUnits are the periods that can follow the slash in a rate
+var rateUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+}
+
+// ParseRate parses a rate like 10/min, 1/s or 100/hour. The period can also
+// be a duration like 10/30s.
+func ParseRate(value string) (Rate, error) {
+	calls, period, found := strings.Cut(value, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(calls))
+	if !found || err != nil || n < 1 {
+		return Rate{}, fmt.Errorf("rate must look like 10/min, got %q", value)
+	}
+
+	period = strings.TrimSpace(period)
+	d, ok := rateUnits[period]
+	if !ok {
+		d, err = time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return Rate{}, fmt.Errorf("rate period must be s, min, hour, day or a duration like 30s, got %q", period)
+		}
+	}
+	return Rate{Calls: n, Period: d}, nil
+}
+
+// IsZero reports whether the rate was never set
+func (r Rate) IsZero() bool {
+	return r.Calls == 0
+}
+
+// String returns the rate as calls per period, like 10 calls per minute
+func (r Rate) String() string {
+	calls := "calls"
+	if r.Calls == 1 {
+		calls = "call"
+	}
+	for _, unit := range []string{"second", "minute", "hour", "day"} {
+		if r.Period == rateUnits[unit] {
+			return fmt.Sprintf("%d %s per %s", r.Calls, calls, unit)
+		}
+	}
+	return fmt.Sprintf("%d %s per %s", r.Calls, calls, r.Period)
+}
+
+// rateLimiter is a token bucket holding up to rate.Calls tokens, refilled
+// evenly over rate.Period. A nil rateLimiter allows every call.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   Rate
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newRateLimiter returns a full bucket for rate, or nil when rate is not set
+func newRateLimiter(rate Rate) *rateLimiter {
+	if rate.IsZero() {
+		return nil
+	}
+	return &rateLimiter{rate: rate, tokens: float64(rate.Calls), now: time.Now}
+}
+
+// take uses a token if one is left. Otherwise it reports how long until the
+// next token is available.
+func (l *rateLimiter) take() (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	perToken := l.rate.Period / time.Duration(l.rate.Calls)
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(perToken)
+		l.tokens = min(l.tokens, float64(l.rate.Calls))
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - l.tokens) * float64(perToken))
+}
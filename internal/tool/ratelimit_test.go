@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      Rate
+		expectedText  string
+		expectedError string
+	}{
+		{value: "10/min", expected: Rate{10, time.Minute}, expectedText: "10 calls per minute"},
+		{value: "1/s", expected: Rate{1, time.Second}, expectedText: "1 call per second"},
+		{value: "100/hour", expected: Rate{100, time.Hour}, expectedText: "100 calls per hour"},
+		{value: "1000/day", expected: Rate{1000, 24 * time.Hour}, expectedText: "1000 calls per day"},
+		{value: "5/30s", expected: Rate{5, 30 * time.Second}, expectedText: "5 calls per 30s"},
+		{value: "10", expectedError: "rate must look like 10/min"},
+		{value: "0/min", expectedError: "rate must look like 10/min"},
+		{value: "ten/min", expectedError: "rate must look like 10/min"},
+		{value: "10/fortnight", expectedError: "rate period must be s, min, hour, day or a duration"},
+		{value: "10/-1s", expectedError: "rate period must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			rate, err := ParseRate(tt.value)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rate)
+			assert.Equal(t, tt.expectedText, rate.String())
+		})
+	}
+}
+
+func TestTool_RateLimiter(t *testing.T) {
+	t.Run("nil limiter allows every call", func(t *testing.T) {
+		l := newRateLimiter(Rate{})
+		assert.Nil(t, l)
+
+		ok, _ := l.take()
+		assert.True(t, ok)
+	})
+
+	t.Run("allows a burst and refills over the period", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		l := newRateLimiter(Rate{Calls: 2, Period: time.Minute})
+		l.now = func() time.Time { return now }
+
+		ok, _ := l.take()
+		assert.True(t, ok)
+		ok, _ = l.take()
+		assert.True(t, ok)
+
+		ok, retryAfter := l.take()
+		assert.False(t, ok)
+		assert.Equal(t, 30*time.Second, retryAfter)
+
+		now = now.Add(20 * time.Second)
+		ok, retryAfter = l.take()
+		assert.False(t, ok)
+		assert.Equal(t, 10*time.Second, retryAfter)
+
+		now = now.Add(10 * time.Second)
+		ok, _ = l.take()
+		assert.True(t, ok)
+	})
+
+	t.Run("never holds more than a full bucket", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		l := newRateLimiter(Rate{Calls: 1, Period: time.Second})
+		l.now = func() time.Time { return now }
+
+		ok, _ := l.take()
+		assert.True(t, ok)
+
+		now = now.Add(time.Hour)
+		ok, _ = l.take()
+		assert.True(t, ok)
+		ok, _ = l.take()
+		assert.False(t, ok)
+	})
+}
+
+func TestTool_RateLimit(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "runs")
+	bp, err := blueprint.FromArgs([]string{"sh", "-c", "echo run >> " + marker})
+	require.NoError(t, err)
+
+	handler := CreateToolFunctionWithOptions(bp, Options{RateLimit: Rate{Calls: 1, Period: time.Hour}})
+	call := func() *mcp.CallToolResultFor[map[string]any] {
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.False(t, call().IsError)
+
+	result := call()
+	assert.True(t, result.IsError)
+	textContent, ok := result.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "Expected content to be TextContent")
+	assert.Equal(t, "Studio error: rate limit of 1 call per hour reached, retry after 3600s", textContent.Text)
+	assert.Equal(t, 3600, result.Meta["retryAfter"])
+
+	runs, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs), "the rejected call should not run the command")
+}
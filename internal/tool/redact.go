@@ -0,0 +1,22 @@
+package tool
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// redactedOutput replaces the parts of output that match a Redact pattern
+const redactedOutput = "***"
+
+// redactOutput returns result with every match of patterns in its output
+// replaced by ***. Binary stdout is left alone, since replacing bytes in it
+// would corrupt it rather than hide anything readable.
+func redactOutput(result commandResult, patterns []*regexp.Regexp) commandResult {
+	for _, re := range patterns {
+		if utf8.Valid(result.Stdout) {
+			result.Stdout = re.ReplaceAllLiteral(result.Stdout, []byte(redactedOutput))
+		}
+		result.Stderr = re.ReplaceAllLiteral(result.Stderr, []byte(redactedOutput))
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// LastOutputURI is the resource URI for the output of the most recent command
+const LastOutputURI = "studio://last-output"
+
+// CreateLastOutputResource creates an MCP resource that serves the most recent
+// command output recorded in store
+func CreateLastOutputResource(store *OutputStore) *mcp.ServerResource {
+	return &mcp.ServerResource{
+		Resource: &mcp.Resource{
+			URI:         LastOutputURI,
+			Name:        "last-output",
+			Description: "Output of the most recent command run by the tool",
+			MIMEType:    "text/plain",
+		},
+		Handler: func(ctx context.Context, session *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+			output, ok := store.Get()
+			if !ok {
+				debug("Resource %s read before any command ran", params.URI)
+				return nil, mcp.ResourceNotFoundError(params.URI)
+			}
+
+			contents := &mcp.ResourceContents{URI: LastOutputURI, MIMEType: "text/plain", Text: output}
+			if !utf8.ValidString(output) {
+				contents = &mcp.ResourceContents{URI: LastOutputURI, MIMEType: http.DetectContentType([]byte(output)), Blob: []byte(output)}
+			}
+
+			return &mcp.ReadResourceResult{
+				Contents: []*mcp.ResourceContents{contents},
+			}, nil
+		},
+	}
+}
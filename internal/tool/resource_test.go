@@ -0,0 +1,43 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_LastOutputResource(t *testing.T) {
+	t.Run("describes the last output resource", func(t *testing.T) {
+		resource := CreateLastOutputResource(&OutputStore{})
+
+		assert.Equal(t, "studio://last-output", resource.Resource.URI)
+		assert.Equal(t, "text/plain", resource.Resource.MIMEType)
+	})
+
+	t.Run("reports not found before any command runs", func(t *testing.T) {
+		resource := CreateLastOutputResource(&OutputStore{})
+
+		_, err := resource.Handler(context.Background(), nil, &mcp.ReadResourceParams{URI: LastOutputURI})
+		assert.Error(t, err)
+	})
+
+	t.Run("serves the output of the most recent tool call", func(t *testing.T) {
+		store := &OutputStore{}
+		resource := CreateLastOutputResource(store)
+
+		for _, message := range []string{"first", "second"} {
+			handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"echo", message}}, Options{LastOutput: store})
+			_, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+			require.NoError(t, err)
+		}
+
+		result, err := resource.Handler(context.Background(), nil, &mcp.ReadResourceParams{URI: LastOutputURI})
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+		assert.Equal(t, LastOutputURI, result.Contents[0].URI)
+		assert.Equal(t, "second", result.Contents[0].Text)
+	})
+}
@@ -0,0 +1,79 @@
+package tool
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Secrets is a Blueprint whose commands can hold values the client never
+// sent, like defaults read from secret files, that must stay out of logs
+type Secrets interface {
+	SecretValues(args map[string]interface{}) []string
+}
+
+// hidden holds every secret seen so far. Secrets rarely change, so the set
+// stays small for the life of the server.
+var hidden = struct {
+	mu       sync.RWMutex
+	values   map[string]bool
+	replacer *strings.Replacer
+}{}
+
+// hideSecrets keeps the secrets of a call with args out of everything logged
+// from now on
+func hideSecrets(blueprint Blueprint, args map[string]interface{}) {
+	secrets, ok := blueprint.(Secrets)
+	if !ok {
+		return
+	}
+	values := secrets.SecretValues(args)
+	if len(values) == 0 {
+		return
+	}
+
+	hidden.mu.Lock()
+	defer hidden.mu.Unlock()
+	if hidden.values == nil {
+		hidden.values = map[string]bool{}
+	}
+	added := false
+	for _, value := range values {
+		if !hidden.values[value] {
+			hidden.values[value] = true
+			added = true
+		}
+	}
+	if !added {
+		return
+	}
+
+	// Longer secrets go first so one holding another is replaced whole
+	values = slices.Collect(maps.Keys(hidden.values))
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+	var pairs []string
+	for _, value := range values {
+		pairs = append(pairs, value, redacted)
+	}
+	hidden.replacer = strings.NewReplacer(pairs...)
+}
+
+// redactSecrets replaces every hidden secret in text with [REDACTED]
+func redactSecrets(text string) string {
+	hidden.mu.RLock()
+	defer hidden.mu.RUnlock()
+	if hidden.replacer == nil {
+		return text
+	}
+	return hidden.replacer.Replace(text)
+}
+
+// redactCommand returns command with every hidden secret replaced
+func redactCommand(command []string) []string {
+	result := make([]string, len(command))
+	for i, arg := range command {
+		result[i] = redactSecrets(arg)
+	}
+	return result
+}
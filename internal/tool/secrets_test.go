@@ -0,0 +1,45 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestTool_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(secret, []byte("hunter2\n"), 0600))
+	t.Setenv("STUDIO_TEST_TOKEN_FILE", secret)
+
+	logPath := filepath.Join(dir, "studio.log")
+	require.NoError(t, SetLogFile(logPath))
+	SetDebugMode(true)
+	t.Cleanup(func() { SetDebugMode(false) })
+
+	// The command only succeeds when it gets the secret
+	bp, err := blueprint.FromArgs([]string{"sh", "-c", `test "$(printf %s "$1" | tr a-z A-Z)" = HUNTER2`, "sh", "{{token:file=$STUDIO_TEST_TOKEN_FILE}}"})
+	require.NoError(t, err)
+
+	var audit bytes.Buffer
+	handler := CreateToolFunctionWithOptions(bp, Options{Audit: NewAuditLog(&audit), EchoCommand: true})
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	assert.Equal(t, "[REDACTED]", result.Meta["command"].([]string)[4])
+	assert.Contains(t, audit.String(), `"sh","[REDACTED]"]`)
+	assert.NotContains(t, audit.String(), "hunter2")
+
+	logged, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(logged), "Built command: sh -c")
+	assert.NotContains(t, string(logged), "hunter2")
+}
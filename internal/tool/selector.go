@@ -0,0 +1,166 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Selector picks values out of JSON output with a small subset of jq paths:
+// .name, ["name"], [n] for an index (negative counts from the end) and [] for
+// every value, taking object values in key order. The zero Selector is unset.
+type Selector struct {
+	expr  string
+	steps []selectorStep
+}
+
+// selectorStep is one part of a selector path
+type selectorStep struct {
+	key     string // object key, when set
+	index   int    // array index, when isIndex is set
+	isIndex bool
+	iterate bool // every value of an array or object
+}
+
+// ParseSelector parses a path like .items[].name
+func ParseSelector(expr string) (Selector, error) {
+	if !strings.HasPrefix(expr, ".") {
+		return Selector{}, fmt.Errorf("selector %q must start with .", expr)
+	}
+
+	selector := Selector{expr: expr}
+	rest := expr
+	if expr == "." {
+		rest = ""
+	}
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".["):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return Selector{}, fmt.Errorf("selector %q has an empty key", expr)
+			}
+			selector.steps = append(selector.steps, selectorStep{key: key})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return Selector{}, fmt.Errorf("selector %q has an unterminated [", expr)
+			}
+			step, err := parseBracket(rest[1:end])
+			if err != nil {
+				return Selector{}, fmt.Errorf("selector %q: %w", expr, err)
+			}
+			selector.steps = append(selector.steps, step)
+			rest = rest[end+1:]
+		default:
+			return Selector{}, fmt.Errorf("selector %q has unexpected %q", expr, rest)
+		}
+	}
+	return selector, nil
+}
+
+// parseBracket parses what is inside [ ]: nothing, an index or a quoted key
+func parseBracket(inner string) (selectorStep, error) {
+	if inner == "" {
+		return selectorStep{iterate: true}, nil
+	}
+	if strings.HasPrefix(inner, `"`) {
+		key, err := strconv.Unquote(inner)
+		if err != nil {
+			return selectorStep{}, fmt.Errorf("invalid key %s", inner)
+		}
+		return selectorStep{key: key}, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return selectorStep{}, fmt.Errorf("invalid index %q", inner)
+	}
+	return selectorStep{index: index, isIndex: true}, nil
+}
+
+// IsZero reports whether the selector was never set
+func (s Selector) IsZero() bool {
+	return s.expr == ""
+}
+
+// String returns the selector as it was written
+func (s Selector) String() string {
+	return s.expr
+}
+
+// Select returns the values the selector picks out of output, one per line.
+// Strings are written as they are and everything else as compact JSON. It
+// fails when output isn't JSON or nothing matches.
+func (s Selector) Select(output []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("output is not JSON")
+	}
+
+	values := []interface{}{value}
+	for _, step := range s.steps {
+		values = step.apply(values)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s matched nothing", s.expr)
+	}
+
+	var selected bytes.Buffer
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			selected.WriteString(str)
+		} else {
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			selected.Write(encoded)
+		}
+		selected.WriteByte('\n')
+	}
+	return selected.Bytes(), nil
+}
+
+// apply runs the step on every value, dropping values it doesn't match
+func (step selectorStep) apply(values []interface{}) []interface{} {
+	var next []interface{}
+	for _, value := range values {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if step.iterate {
+				for _, key := range slices.Sorted(maps.Keys(v)) {
+					next = append(next, v[key])
+				}
+			} else if item, ok := v[step.key]; ok && !step.isIndex {
+				next = append(next, item)
+			}
+		case []interface{}:
+			if step.iterate {
+				next = append(next, v...)
+			} else if step.isIndex {
+				index := step.index
+				if index < 0 {
+					index += len(v)
+				}
+				if index >= 0 && index < len(v) {
+					next = append(next, v[index])
+				}
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,132 @@
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		expr          string
+		expectedSteps []selectorStep
+		expectedError string
+	}{
+		{expr: ".", expectedSteps: nil},
+		{expr: ".name", expectedSteps: []selectorStep{{key: "name"}}},
+		{expr: ".items[].name", expectedSteps: []selectorStep{{key: "items"}, {iterate: true}, {key: "name"}}},
+		{expr: ".[0]", expectedSteps: []selectorStep{{index: 0, isIndex: true}}},
+		{expr: ".items[-1]", expectedSteps: []selectorStep{{key: "items"}, {index: -1, isIndex: true}}},
+		{expr: `.["a.b"].c`, expectedSteps: []selectorStep{{key: "a.b"}, {key: "c"}}},
+		{expr: "name", expectedError: "must start with ."},
+		{expr: ".a..b", expectedError: "has an empty key"},
+		{expr: ".items[", expectedError: "has an unterminated ["},
+		{expr: ".items[x]", expectedError: `invalid index "x"`},
+		{expr: `.["a]`, expectedError: "invalid key"},
+		{expr: ".items[]x", expectedError: `has unexpected "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := ParseSelector(tt.expr)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSteps, selector.steps)
+			assert.Equal(t, tt.expr, selector.String())
+		})
+	}
+}
+
+func TestSelector_Select(t *testing.T) {
+	output := []byte(`{"items": [{"name": "web", "replicas": 3}, {"name": "db", "replicas": 1, "tags": {"tier": "data"}}], "count": 2}`)
+
+	tests := []struct {
+		expr          string
+		output        string
+		expected      string
+		expectedError string
+	}{
+		{expr: ".count", expected: "2\n"},
+		{expr: ".items[].name", expected: "web\ndb\n"},
+		{expr: ".items[0]", expected: `{"name":"web","replicas":3}` + "\n"},
+		{expr: ".items[-1].tags", expected: `{"tier":"data"}` + "\n"},
+		{expr: ".items[].tags.tier", expected: "data\n"},
+		{expr: ".items[1][]", expected: "db\n1\n" + `{"tier":"data"}` + "\n"},
+		{expr: ".", output: `"just a string"`, expected: "just a string\n"},
+		{expr: ".missing", expectedError: ".missing matched nothing"},
+		{expr: ".items[5]", expectedError: "matched nothing"},
+		{expr: ".count.name", expectedError: "matched nothing"},
+		{expr: ".name", output: "not json", expectedError: "output is not JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := ParseSelector(tt.expr)
+			require.NoError(t, err)
+
+			input := output
+			if tt.output != "" {
+				input = []byte(tt.output)
+			}
+
+			selected, err := selector.Select(input)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(selected))
+		})
+	}
+}
+
+func TestTool_Select(t *testing.T) {
+	call := func(t *testing.T, expr string, output string) *mcp.CallToolResultFor[map[string]any] {
+		bp, err := blueprint.FromArgs([]string{"sh", "-c", "{{script}}"})
+		require.NoError(t, err)
+		selector, err := ParseSelector(expr)
+		require.NoError(t, err)
+
+		result, err := CreateToolFunctionWithOptions(bp, Options{Select: selector})(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"script": output},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	text := func(t *testing.T, result *mcp.CallToolResultFor[map[string]any]) string {
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		return textContent.Text
+	}
+
+	t.Run("returns the selected values", func(t *testing.T) {
+		result := call(t, ".items[].name", `echo '{"items": [{"name": "a"}, {"name": "b"}]}'`)
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "a\nb", text(t, result))
+	})
+
+	t.Run("returns the full output with a warning when it isn't JSON", func(t *testing.T) {
+		result := call(t, ".name", "echo plain text")
+
+		assert.False(t, result.IsError)
+		assert.Equal(t, "plain text\n\nStudio warning: --select .name: output is not JSON, returning the full output", text(t, result))
+	})
+
+	t.Run("leaves failed commands alone", func(t *testing.T) {
+		result := call(t, ".name", `echo '{"name": "x"}'; exit 1`)
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, text(t, result), `{"name": "x"}`)
+	})
+}
@@ -0,0 +1,118 @@
+package tool
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// summarize keeps the first and last n lines of text with a marker counting
+// the lines left out between them. Text of at most 2n lines is returned as is.
+func summarize(text string, n int) (string, int) {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= 2*n {
+		return text, 0
+	}
+
+	omitted := len(lines) - 2*n
+	head := strings.Join(lines[:n], "\n")
+	tail := strings.Join(lines[len(lines)-n:], "\n")
+	return fmt.Sprintf("%s\n... [%d lines omitted] ...\n%s", head, omitted, tail), omitted
+}
+
+// summarizeContent folds a single block of text output into its first and last
+// n lines, noting where to read the full output when it is kept
+func summarizeContent(content []mcp.Content, n int, lastOutput *OutputStore) {
+	if n <= 0 || len(content) != 1 {
+		return
+	}
+	text, ok := content[0].(*mcp.TextContent)
+	if !ok {
+		return
+	}
+
+	summary, omitted := summarize(text.Text, n)
+	if omitted == 0 {
+		return
+	}
+	debug("Summarized output, omitting %d lines", omitted)
+
+	if lastOutput != nil {
+		summary += fmt.Sprintf("\n\nStudio note: read the resource %s for the full output.", LastOutputURI)
+	}
+	text.Text = summary
+}
+
+// truncatedMarker follows output cut short by truncateLines
+const truncatedMarker = "[... truncated]"
+
+// ansiEscape matches ANSI escape sequences, like colors, which aren't text
+var ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b\n]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// countLines counts the lines of text by newline once ANSI escape sequences
+// are stripped, so a color reset after the last newline isn't a line
+func countLines(text string) int {
+	return strings.Count(strings.TrimSuffix(ansiEscape.ReplaceAllString(text, ""), "\n"), "\n") + 1
+}
+
+// firstLines returns the first n lines of text, which has more than n lines,
+// without the newline that ends them
+func firstLines(text string, n int) string {
+	end := 0
+	for range n {
+		end += strings.IndexByte(text[end:], '\n') + 1
+	}
+	return text[:end-1]
+}
+
+// truncateLines keeps the first n lines of text followed by a marker, and
+// reports how many lines it cut. Text of at most n lines is returned as is.
+func truncateLines(text string, n int) (string, int) {
+	lines := countLines(text)
+	if lines <= n {
+		return text, 0
+	}
+	return firstLines(text, n) + "\n" + truncatedMarker, lines - n
+}
+
+// truncateContent cuts text content to its first n lines, counted across its
+// text blocks in order, like the chunks of --split-on followed by stderr, and
+// reports whether it cut any. A single block is left for paging when its first
+// pageSize bytes end before its first n lines do, so whichever limit is hit
+// first applies. The note says where to read the full output when it is kept.
+func truncateContent(content []mcp.Content, n int, pageSize int, lastOutput *OutputStore) ([]mcp.Content, bool) {
+	if n <= 0 {
+		return content, false
+	}
+
+	remaining := n
+	for i, c := range content {
+		text, ok := c.(*mcp.TextContent)
+		if !ok {
+			continue
+		}
+		lines := countLines(text.Text)
+		if lines <= remaining {
+			remaining -= lines
+			continue
+		}
+
+		cut := truncatedMarker
+		if remaining > 0 {
+			first := firstLines(text.Text, remaining)
+			if pageSize > 0 && len(content) == 1 && len(first) > pageSize {
+				return content, false
+			}
+			cut = first + "\n" + truncatedMarker
+		}
+		debug("Truncated output to %d lines, dropping %d content blocks after it", n, len(content)-i-1)
+
+		if lastOutput != nil {
+			cut += fmt.Sprintf("\n\nStudio note: read the resource %s for the full output.", LastOutputURI)
+		}
+		return append(content[:i:i], &mcp.TextContent{Text: cut}), true
+	}
+	return content, false
+}
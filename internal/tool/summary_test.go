@@ -0,0 +1,228 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		lines           int
+		expected        string
+		expectedOmitted int
+	}{
+		{
+			name:     "shorter than both ends",
+			text:     "one\ntwo\nthree",
+			lines:    2,
+			expected: "one\ntwo\nthree",
+		},
+		{
+			name:     "exactly both ends",
+			text:     "one\ntwo\nthree\nfour",
+			lines:    2,
+			expected: "one\ntwo\nthree\nfour",
+		},
+		{
+			name:            "keeps the first and last lines",
+			text:            "one\ntwo\nthree\nfour\nfive\nsix\nseven",
+			lines:           2,
+			expected:        "one\ntwo\n... [3 lines omitted] ...\nsix\nseven",
+			expectedOmitted: 3,
+		},
+		{
+			name:            "one line from each end",
+			text:            "header\nnoise\nnoise\nresult",
+			lines:           1,
+			expected:        "header\n... [2 lines omitted] ...\nresult",
+			expectedOmitted: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, omitted := summarize(tt.text, tt.lines)
+			assert.Equal(t, tt.expected, summary)
+			assert.Equal(t, tt.expectedOmitted, omitted)
+		})
+	}
+}
+
+func TestTool_CreateToolFunctionSummaryLines(t *testing.T) {
+	script := `seq 1 10; echo failed >&2`
+
+	t.Run("folds long output into its first and last lines", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{SummaryLines: 2})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n2\n... [8 lines omitted] ...\n\nfailed", textContent.Text)
+	})
+
+	t.Run("points to the full output when it is kept", func(t *testing.T) {
+		lastOutput := &OutputStore{}
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"seq", "1", "10"}}, Options{SummaryLines: 2, LastOutput: lastOutput})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n2\n... [6 lines omitted] ...\n9\n10\n\nStudio note: read the resource studio://last-output for the full output.", textContent.Text)
+
+		output, ok := lastOutput.Get()
+		require.True(t, ok)
+		assert.Equal(t, "1\n2\n3\n4\n5\n6\n7\n8\n9\n10", output)
+	})
+
+	t.Run("returns short output as is", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"seq", "1", "4"}}, Options{SummaryLines: 2})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n2\n3\n4", textContent.Text)
+	})
+}
+
+func TestTruncateLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		lines       int
+		expected    string
+		expectedCut int
+	}{
+		{
+			name:     "fewer lines",
+			text:     "one\ntwo",
+			lines:    3,
+			expected: "one\ntwo",
+		},
+		{
+			name:     "exactly the limit",
+			text:     "one\ntwo\nthree",
+			lines:    3,
+			expected: "one\ntwo\nthree",
+		},
+		{
+			name:     "exactly the limit with a trailing newline",
+			text:     "one\ntwo\nthree\n",
+			lines:    3,
+			expected: "one\ntwo\nthree\n",
+		},
+		{
+			name:        "more lines",
+			text:        "one\ntwo\nthree\nfour\nfive",
+			lines:       3,
+			expected:    "one\ntwo\nthree\n[... truncated]",
+			expectedCut: 2,
+		},
+		{
+			name:     "a color reset after the last line",
+			text:     "one\ntwo\n\x1b[0m",
+			lines:    2,
+			expected: "one\ntwo\n\x1b[0m",
+		},
+		{
+			name:        "colored lines",
+			text:        "\x1b[31mone\x1b[0m\ntwo\nthree",
+			lines:       2,
+			expected:    "\x1b[31mone\x1b[0m\ntwo\n[... truncated]",
+			expectedCut: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncated, cut := truncateLines(tt.text, tt.lines)
+			assert.Equal(t, tt.expected, truncated)
+			assert.Equal(t, tt.expectedCut, cut)
+		})
+	}
+}
+
+func TestTool_CreateToolFunctionMaxOutputLines(t *testing.T) {
+	callResult := func(t *testing.T, command []string, opts Options) *mcp.CallToolResultFor[map[string]any] {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: command}, opts)
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+		return result
+	}
+	call := func(t *testing.T, command []string, opts Options) string {
+		result := callResult(t, command, opts)
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		return textContent.Text
+	}
+
+	t.Run("cuts long output to its first lines", func(t *testing.T) {
+		text := call(t, []string{"seq", "1", "10"}, Options{MaxOutputLines: 3})
+		assert.Equal(t, "1\n2\n3\n[... truncated]", text)
+	})
+
+	t.Run("points to the full output when it is kept", func(t *testing.T) {
+		text := call(t, []string{"seq", "1", "10"}, Options{MaxOutputLines: 3, LastOutput: &OutputStore{}})
+		assert.Equal(t, "1\n2\n3\n[... truncated]\n\nStudio note: read the resource studio://last-output for the full output.", text)
+	})
+
+	t.Run("returns output of exactly the limit as is", func(t *testing.T) {
+		text := call(t, []string{"seq", "1", "3"}, Options{MaxOutputLines: 3})
+		assert.Equal(t, "1\n2\n3", text)
+	})
+
+	t.Run("pages output when a page ends before the lines do", func(t *testing.T) {
+		text := call(t, []string{"seq", "100001", "100010"}, Options{MaxOutputLines: 5, PageSize: 10, Pages: NewPagedOutputs(DefaultPageMemory)})
+		assert.True(t, strings.HasPrefix(text, "100001\n100"), text)
+		assert.Contains(t, text, "Studio note: output is 69 bytes")
+		assert.NotContains(t, text, truncatedMarker)
+	})
+
+	t.Run("leaves paging out when the lines are short enough", func(t *testing.T) {
+		text := call(t, []string{"seq", "1", "100"}, Options{MaxOutputLines: 2, PageSize: 100, Pages: NewPagedOutputs(DefaultPageMemory)})
+		assert.Equal(t, "1\n2\n[... truncated]", text)
+	})
+
+	t.Run("counts lines without color codes", func(t *testing.T) {
+		text := call(t, []string{"printf", `\033[31mone\033[0m\ntwo\n\033[0m`}, Options{MaxOutputLines: 2})
+		assert.Equal(t, "\x1b[31mone\x1b[0m\ntwo\n\x1b[0m", text)
+	})
+
+	t.Run("counts lines across split chunks", func(t *testing.T) {
+		result := callResult(t, []string{"printf", `1\n2\n---\n3\n4\n---\n5\n6`}, Options{MaxOutputLines: 3, SplitOn: "\n---\n"})
+		require.Len(t, result.Content, 2)
+		assert.Equal(t, "1\n2", result.Content[0].(*mcp.TextContent).Text)
+		assert.Equal(t, "3\n[... truncated]", result.Content[1].(*mcp.TextContent).Text)
+	})
+
+	t.Run("cuts stderr after encoded output", func(t *testing.T) {
+		result := callResult(t, []string{"sh", "-c", "echo hi; seq 1 5 >&2"}, Options{MaxOutputLines: 3, Encode: EncodeBase64})
+		require.Len(t, result.Content, 2)
+		assert.Equal(t, "aGkK", result.Content[0].(*mcp.TextContent).Text)
+		assert.Contains(t, result.Content[1].(*mcp.TextContent).Text, "[... truncated]")
+	})
+
+	t.Run("summarizes when the summary is shorter than the line limit", func(t *testing.T) {
+		text := call(t, []string{"seq", "1", "100"}, Options{MaxOutputLines: 50, SummaryLines: 2})
+		assert.NotContains(t, text, truncatedMarker)
+		assert.True(t, strings.HasPrefix(text, "1\n2\n"), text)
+	})
+
+	t.Run("truncates when the line limit is shorter than the summary", func(t *testing.T) {
+		text := call(t, []string{"seq", "1", "100"}, Options{MaxOutputLines: 3, SummaryLines: 10})
+		assert.Equal(t, "1\n2\n3\n[... truncated]", text)
+	})
+}
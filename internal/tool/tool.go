@@ -3,11 +3,18 @@ package tool
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -16,11 +23,155 @@ import (
 // Blueprint interface defines what we need from a blueprint
 type Blueprint interface {
 	BuildCommandArgs(args map[string]interface{}) ([]string, error)
+	BuildShellCommand(args map[string]interface{}) (string, error)
+	BuildEnv(args map[string]interface{}) ([]string, error)
+	EachValues(args map[string]interface{}) (string, []interface{}, error)
 	GetBaseCommand() string
 	GetCommandFormat() string
 	GetInputSchema() interface{}
 }
 
+// Options configures how a tool runs its command
+type Options struct {
+	// LastOutput records the output of every command when set
+	LastOutput *OutputStore
+	// Shell runs the command as a script with sh -c instead of executing it directly
+	Shell bool
+	// MIMEType is the MIME type of binary output, sniffed from the output when empty
+	MIMEType string
+	// ContentMIMEType returns text output as a text resource of this MIME type,
+	// like application/json, so clients can highlight it. Plain text when empty.
+	ContentMIMEType string
+	// OutputType chooses the content type of results, see OutputTypes
+	OutputType string
+	// Encode returns stdout as text in this encoding, see Encodings
+	Encode string
+	// Quiet leaves stderr out of successful results
+	Quiet bool
+	// MaxConcurrency limits how many commands run at once, zero means unlimited
+	MaxConcurrency int
+	// RateLimit rejects calls beyond this rate without running the command, unlimited when unset
+	RateLimit Rate
+	// EchoCommand adds the executed argv to the result metadata under "command"
+	EchoCommand bool
+	// OutputSize adds how many bytes the command wrote to the result metadata
+	// under "stdoutBytes" and "stderrBytes"
+	OutputSize bool
+	// ReportDuration adds how long the command ran to the result metadata under "duration"
+	ReportDuration bool
+	// Shutdown stops running and waiting commands when it is done
+	Shutdown context.Context
+	// SuccessCodes lists the exit codes that count as success, only 0 when empty.
+	// The exit code is added to the result metadata under "exitCode" when set.
+	SuccessCodes []int
+	// ErrorIfMatch flags results as errors when the output matches, whatever the exit code
+	ErrorIfMatch *regexp.Regexp
+	// SuccessIfMatch flags results as successful when the output matches, whatever
+	// the exit code. ErrorIfMatch wins when both match.
+	SuccessIfMatch *regexp.Regexp
+	// ErrorSummary puts the first line of stderr of a failed command, usually
+	// the reason it failed, in front of the output and in the result metadata
+	// under "errorSummary"
+	ErrorSummary bool
+	// Redact replaces every match in stdout and stderr with *** before the
+	// output is returned, cached or kept, so secrets never reach the client
+	Redact []*regexp.Regexp
+	// FileLists lets array fields take {"file": path} to read their values from a file
+	FileLists bool
+	// StrictArgs rejects calls with arguments that aren't fields instead of
+	// ignoring them, and says so in the schema with additionalProperties: false
+	StrictArgs bool
+	// InactivityTimeout stops commands that write no output for this long, zero means never
+	InactivityTimeout time.Duration
+	// Detach starts commands in the background and returns what they wrote
+	// within this long, leaving them running. Zero waits for commands to exit.
+	Detach time.Duration
+	// KillGrace is how long a stopped command gets to exit after SIGTERM before
+	// it is killed. Zero kills it right away.
+	KillGrace time.Duration
+	// RunAs runs commands as another user when set, see LookupCredential
+	RunAs *Credential
+	// Limits caps the CPU time and memory of commands where LimitsSupported,
+	// unlimited when zero
+	Limits Limits
+	// MergeOutput captures stdout and stderr as one stream in the order they were written
+	MergeOutput bool
+	// ClearEnv starts commands from an empty environment instead of studio's.
+	// Variables set with --set-env or from _meta are still added.
+	ClearEnv bool
+	// EnvPassthrough lists the variables of studio's environment kept when ClearEnv is set
+	EnvPassthrough []string
+	// ExecPrefix is put in front of every command that runs, like nice -n 10,
+	// so a wrapper applies the same policy to every call
+	ExecPrefix []string
+	// MetaEnv maps _meta keys of a call to environment variables of the command
+	MetaEnv map[string]string
+	// MetaArgs maps _meta keys of a call to fields, used when the field has no argument
+	MetaArgs map[string]string
+	// SplitOn splits text output into a content block per chunk, when set
+	SplitOn string
+	// Select replaces the stdout of successful commands with the values it picks
+	// out of their JSON output, when set
+	Select Selector
+	// OutputSchema returns the JSON output of successful commands as structured
+	// content, and fails calls whose output doesn't match it, when set
+	OutputSchema *OutputSchema
+	// NamePrefix is put in front of the tool name, like repo1_ for repo1_git
+	NamePrefix string
+	// Audit records every command that runs, when set
+	Audit *AuditLog
+	// PageSize is the most bytes of text output returned in a result. Longer
+	// output is kept in Pages to be read a page at a time. Zero means no limit.
+	PageSize int
+	// Pages keeps output longer than PageSize, needed when PageSize is set
+	Pages *PagedOutputs
+	// OutputTemplate formats text results with the command and exit code, when set
+	OutputTemplate OutputTemplate
+	// SummaryLines folds longer text output into its first and last this many
+	// lines, zero returns all of it
+	SummaryLines int
+	// MaxOutputLines cuts longer text output to its first this many lines,
+	// zero returns all of it
+	MaxOutputLines int
+	// Cache returns the output of an identical successful run instead of running
+	// the command again, when set
+	Cache *ResultCache
+	// Messages overrides the wording of the errors studio writes into results
+	Messages Messages
+	// OutputFile is a file the command writes its result to, returned after
+	// its output when the command succeeds. It can use {{name}} placeholders
+	// for the values of fields.
+	OutputFile string
+	// RemoveOutputFile deletes the OutputFile once the call is done, whether
+	// or not it succeeded, unless it was there before the call
+	RemoveOutputFile bool
+	// FileRoot is the directory the OutputFile must be inside, anywhere when empty
+	FileRoot string
+}
+
+// isSuccess reports whether a command that exited with code succeeded
+func (o Options) isSuccess(code int) bool {
+	if len(o.SuccessCodes) == 0 {
+		return code == 0
+	}
+	return slices.Contains(o.SuccessCodes, code)
+}
+
+// matchesSuccess reports whether a command that exited counts as a success,
+// letting ErrorIfMatch and SuccessIfMatch overrule the exit code for CLIs that
+// don't report failure through it
+func (o Options) matchesSuccess(result commandResult) bool {
+	switch {
+	case result.matches(o.ErrorIfMatch):
+		debug("Output matched --error-if-match %s", o.ErrorIfMatch)
+		return false
+	case result.matches(o.SuccessIfMatch):
+		debug("Output matched --success-if-match %s", o.SuccessIfMatch)
+		return true
+	}
+	return o.isSuccess(result.ExitCode)
+}
+
 var debugMode bool
 var logFile *os.File
 var logger *log.Logger
@@ -53,69 +204,553 @@ func IsDebugMode() bool {
 	return debugMode && os.Getenv("NODE_ENV") != "test"
 }
 
+// Debug logs a message to stderr, or the log file when set, if debug mode is enabled
+func Debug(format string, args ...interface{}) {
+	debug(format, args...)
+}
+
 // debug logs a message to stderr if debug mode is enabled
 func debug(format string, args ...interface{}) {
 	if IsDebugMode() {
+		message := redactSecrets(fmt.Sprintf(format, args...))
 		if logger != nil {
-			logger.Printf(format, args...)
+			logger.Print(message)
 		} else {
-			fmt.Fprintf(os.Stderr, "[Studio MCP] "+format+"\n", args...)
+			fmt.Fprintln(os.Stderr, Paint(colorMode, Cyan, "[Studio MCP]")+" "+message)
 		}
 	}
 }
 
+// commandResult holds the output of a finished command
+type commandResult struct {
+	Stdout []byte
+	Stderr []byte
+	// ExitCode is the exit code of the command, or -1 if it did not exit normally
+	ExitCode int
+	// Signal names the signal that killed the command, like segmentation fault,
+	// when it crashed or was killed by something other than studio
+	Signal string
+}
+
+// Output returns trimmed combined stdout+stderr
+func (r commandResult) Output() string {
+	return strings.TrimSpace(string(r.Stdout) + "\n" + string(r.Stderr))
+}
+
 // Execute runs a command and returns trimmed combined stdout+stderr or an error
 func Execute(command string, args ...string) (string, error) {
+	result, err := executeCommand(context.Background(), runOptions{}, command, args...)
+	return result.Output(), err
+}
+
+// runOptions changes how executeCommand runs a command
+type runOptions struct {
+	onOutput    func()        // called whenever the command writes output, when set
+	mergeOutput bool          // capture stdout and stderr as one stream, returned as stdout
+	env         []string      // NAME=value pairs added to the environment of the command
+	credential  *Credential   // user to run the command as, studio's own when nil
+	clearEnv    bool          // start from an empty environment instead of studio's
+	passthrough []string      // variables of studio's environment kept when clearEnv is set
+	killGrace   time.Duration // how long a stopped command gets to exit before it is killed
+	limits      Limits        // CPU time and memory the command can use, unlimited when zero
+}
+
+// baseEnv returns the environment the command starts from, before env is added
+func (r runOptions) baseEnv() []string {
+	if !r.clearEnv {
+		return os.Environ()
+	}
+	base := []string{}
+	for _, name := range r.passthrough {
+		if value, ok := os.LookupEnv(name); ok {
+			base = append(base, name+"="+value)
+		}
+	}
+	return base
+}
+
+// executeCommand runs a command and returns its captured output. The command
+// and every process it started are stopped when ctx is done, getting
+// run.killGrace to exit on their own first.
+func executeCommand(ctx context.Context, run runOptions, command string, args ...string) (commandResult, error) {
 	debug("Executing command: %s %s", command, strings.Join(args, " "))
 
-	cmd := exec.Command(command, args...)
+	cmd, err := newCommand(ctx, run, command, args...)
+	if err != nil {
+		return commandResult{ExitCode: -1}, err
+	}
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	var stdoutWriter, stderrWriter io.Writer = &stdout, &stderr
+	if run.onOutput != nil {
+		stdoutWriter = &activityWriter{w: &stdout, onWrite: run.onOutput}
+		stderrWriter = &activityWriter{w: &stderr, onWrite: run.onOutput}
+	}
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+	// The same writer for both makes exec give the command a single pipe, so
+	// the output keeps the order it was written in
+	if run.mergeOutput {
+		cmd.Stderr = stdoutWriter
+	}
 
-	err := cmd.Run()
+	err = cmd.Run()
 
-	// Always combine outputs for visibility
-	output := strings.TrimSpace(stdout.String() + "\n" + stderr.String())
+	result := commandResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), ExitCode: -1}
 
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			debug("Command completed with non-zero exit code: %d", exitErr.ExitCode())
-			debug("Final output length: %d chars", len(output))
-			return output, fmt.Errorf("command failed with exit code %d", exitErr.ExitCode())
+		if ctx.Err() != nil {
+			cause := context.Cause(ctx)
+			debug("Command stopped: %s", cause)
+			return result, fmt.Errorf("command stopped: %w", cause)
 		}
-		debug("Spawn error: %s", err.Error())
-		return output, fmt.Errorf("Studio error: %w", err)
+		return result, exitError(err, &result, run)
 	}
 
+	result.ExitCode = 0
 	debug("Command completed successfully with exit code 0")
-	debug("Final output length: %d chars", len(output))
+	debug("Final output length: %d bytes", len(result.Stdout)+len(result.Stderr))
+
+	return result, nil
+}
+
+// newCommand sets up a command to run the way run asks, stopped when ctx is done
+func newCommand(ctx context.Context, run runOptions, command string, args ...string) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	configureProcess(cmd, run.credential, run.killGrace)
+	if err := prepareCommand(cmd); err != nil {
+		debug("Refusing to run: %s", err)
+		return nil, fmt.Errorf("Studio error: %w", err)
+	}
+	// Commands that can't be found are reported by Run, not by sh
+	if !run.limits.IsZero() && cmd.Err == nil {
+		run.limits.wrap(cmd)
+	}
+	if len(run.env) > 0 || run.clearEnv {
+		cmd.Env = append(run.baseEnv(), run.env...)
+	}
+	// Don't wait forever on output pipes held open by orphaned grandchildren,
+	// but give a stopped command its grace period first
+	cmd.WaitDelay = run.killGrace + time.Second
+	return cmd, nil
+}
+
+// exitError returns the error for a command that failed to run or exited
+// unsuccessfully, recording how it exited on result
+func exitError(err error, result *commandResult, run runOptions) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Signal = status.Signal().String()
+			debug("Command killed by signal: %s", result.Signal)
+			if run.limits.CPUSeconds > 0 && isCPULimitSignal(status.Signal()) {
+				return fmt.Errorf("command used more than its limit of %ds of CPU time", run.limits.CPUSeconds)
+			}
+			return fmt.Errorf("command killed by signal: %s", result.Signal)
+		}
+		result.ExitCode = exitErr.ExitCode()
+		debug("Command completed with non-zero exit code: %d", exitErr.ExitCode())
+		debug("Final output length: %d bytes", len(result.Stdout)+len(result.Stderr))
+		return fmt.Errorf("command failed with exit code %d", exitErr.ExitCode())
+	}
+	debug("Spawn error: %s", err.Error())
+	return fmt.Errorf("Studio error: %w", err)
+}
+
+// matches reports whether stdout or stderr matches re, which may be nil
+func (r commandResult) matches(re *regexp.Regexp) bool {
+	return re != nil && (re.Match(r.Stdout) || re.Match(r.Stderr))
+}
 
-	return output, nil
+// addNote appends a message from studio itself to the end of stderr
+func (r *commandResult) addNote(note string) {
+	if len(r.Stderr) > 0 && !bytes.HasSuffix(r.Stderr, []byte("\n")) {
+		r.Stderr = append(r.Stderr, '\n')
+	}
+	r.Stderr = append(r.Stderr, note...)
+}
+
+// activityWriter calls onWrite every time output is written to w
+type activityWriter struct {
+	w       io.Writer
+	onWrite func()
+}
+
+func (a *activityWriter) Write(p []byte) (int, error) {
+	a.onWrite()
+	return a.w.Write(p)
 }
 
 // CreateToolFunction creates a tool handler for the given blueprint
 func CreateToolFunction(blueprint Blueprint) mcp.ToolHandlerFor[map[string]any, map[string]any] {
-	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[map[string]any], error) {
-		debug("Tool called with args: %v", params.Arguments)
+	return CreateToolFunctionWithOptions(blueprint, Options{})
+}
 
-		fullCommand, err := blueprint.BuildCommandArgs(params.Arguments)
+// CreateToolFunctionWithOptions creates a tool handler for the given blueprint with options
+func CreateToolFunctionWithOptions(blueprint Blueprint, opts Options) mcp.ToolHandlerFor[map[string]any, map[string]any] {
+	slots := newLimiter(opts.MaxConcurrency)
+	bucket := newRateLimiter(opts.RateLimit)
+
+	// run runs the command once with args and returns its result
+	run := func(ctx context.Context, params *mcp.CallToolParamsFor[map[string]any], args map[string]interface{}) *mcp.CallToolResultFor[map[string]any] {
+		fullCommand, stages, err := buildCommand(blueprint, args, opts)
+		if err != nil {
+			return createToolResult(opts.Messages.validationError(err), true)
+		}
+		env, err := blueprint.BuildEnv(args)
 		if err != nil {
-			return createToolResult(fmt.Sprintf("Validation error: %s", err.Error()), true), nil
+			return createToolResult(opts.Messages.validationError(err), true)
+		}
+		// The path is filled in before the command runs, while the fields
+		// still describe the files as they were when the call was made
+		var output *outputFile
+		if opts.OutputFile != "" {
+			if output, err = newOutputFile(blueprint, args, opts.OutputFile, opts.FileRoot); err != nil {
+				return createToolResult(opts.Messages.validationError(err), true)
+			}
+			// Cleaned up however the call ends, so failed runs don't leave files behind
+			if opts.RemoveOutputFile {
+				defer output.remove()
+			}
 		}
 
+		// Values read from secret files never show up in logs or the command shown to clients
+		hideSecrets(blueprint, args)
 		debug("Built command: %s", strings.Join(fullCommand, " "))
 
-		output, err := Execute(fullCommand[0], fullCommand[1:]...)
+		// Reject calls beyond the rate limit before anything runs
+		if ok, retryAfter := bucket.take(); !ok {
+			seconds := int((retryAfter + time.Second - 1) / time.Second)
+			debug("Rate limit of %s reached, retry after %ds", opts.RateLimit, seconds)
+			result := createToolResult(opts.Messages.format(messageRateLimited, "rate", opts.RateLimit.String(), "seconds", strconv.Itoa(seconds)), true)
+			setMeta(result, "retryAfter", seconds)
+			return result
+		}
+
+		// Identical calls get the output of a recent successful run
+		env = append(env, metaEnv(params.Meta, opts.MetaEnv)...)
+		key := cacheKey(fullCommand, env)
+		cached, hit := opts.Cache.get(key)
+		if hit {
+			debug("Returning cached output")
+		}
+
+		// Stop the command when the request is cancelled or the server shuts down
+		if opts.Shutdown != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+			defer context.AfterFunc(opts.Shutdown, cancel)()
+		}
+
+		// Wait for a free slot when the number of running commands is limited
+		if !hit {
+			if err := slots.acquire(ctx); err != nil {
+				debug("Gave up waiting to run command: %s", err)
+				return createToolResult(opts.Messages.format(messageGaveUpWaiting, "error", err.Error()), true)
+			}
+			defer slots.release()
+		}
+
+		// Stop the command when it goes quiet for too long
+		var onOutput func()
+		var inactive error
+		if opts.InactivityTimeout > 0 {
+			var cancel context.CancelCauseFunc
+			ctx, cancel = context.WithCancelCause(ctx)
+			defer cancel(nil)
+
+			inactive = fmt.Errorf("no output for %s", opts.InactivityTimeout)
+			timer := time.AfterFunc(opts.InactivityTimeout, func() { cancel(inactive) })
+			defer timer.Stop()
+			onOutput = func() { timer.Reset(opts.InactivityTimeout) }
+		}
+
+		run := runOptions{
+			onOutput:    onOutput,
+			mergeOutput: opts.MergeOutput,
+			env:         env,
+			credential:  opts.RunAs,
+			clearEnv:    opts.ClearEnv,
+			passthrough: opts.EnvPassthrough,
+			killGrace:   opts.KillGrace,
+			limits:      opts.Limits,
+		}
+		start := time.Now()
+		result, err := cached, error(nil)
+		var detached *detachedProcess
+		if !hit {
+			if opts.Detach > 0 {
+				result, detached, err = startDetached(ctx, run, opts.Detach, fullCommand[0], fullCommand[1:]...)
+			} else if len(stages) > 1 {
+				result, err = executePipeline(ctx, run, stages)
+			} else {
+				result, err = executeCommand(ctx, run, fullCommand[0], fullCommand[1:]...)
+			}
+			if opts.Audit != nil {
+				opts.Audit.record(opts.ToolName(blueprint), fullCommand, run.env, start, result, err)
+			}
+		}
+		duration := time.Since(start)
+		stdoutBytes, stderrBytes := len(result.Stdout), len(result.Stderr)
 		isError := err != nil
+		// Keep whatever a crashed command wrote, and say why it stopped
+		if inactive != nil && errors.Is(err, inactive) {
+			result.addNote(opts.Messages.format(messageInactivityTimeout, "duration", opts.InactivityTimeout.String()))
+		} else if result.Signal != "" {
+			result.addNote(fmt.Sprintf("Studio error: %s", err))
+		}
+		if result.ExitCode >= 0 {
+			isError = !opts.matchesSuccess(result)
+		}
+		// Commands out of memory fail in their own way, so only hint at it
+		if isError && err != nil && ctx.Err() == nil && opts.Limits.MemoryBytes > 0 {
+			result.addNote(opts.Limits.memoryNote())
+		}
+		// Nothing after this point, including the cache, sees redacted text
+		result = redactOutput(result, opts.Redact)
+		var summary string
+		if isError && opts.ErrorSummary {
+			summary = errorSummary(result, err)
+		}
+		if !hit && !isError && err == nil && result.ExitCode == 0 {
+			opts.Cache.put(key, result)
+		}
 
 		if isError {
-			debug("Execution error: %s", err)
+			debug("Execution error (exit code %d): %v", result.ExitCode, err)
+		} else if opts.Quiet && len(result.Stderr) > 0 {
+			debug("Discarding %d bytes of stderr from successful command", len(result.Stderr))
+			result.Stderr = nil
+		}
+		if detached != nil {
+			result.addNote(detached.note())
+		}
+
+		if opts.LastOutput != nil {
+			opts.LastOutput.Set(result.Output())
+		}
+
+		// Keep only the selected values, falling back to the full output
+		if !isError && !opts.Select.IsZero() {
+			if selected, err := opts.Select.Select(result.Stdout); err != nil {
+				debug("Returning full output, select failed: %s", err)
+				result.addNote(fmt.Sprintf("Studio warning: --select %s: %s, returning the full output", opts.Select, err))
+			} else {
+				result.Stdout = selected
+			}
+		}
+
+		// Output that doesn't fit the schema fails the call rather than
+		// reaching a client that relies on it
+		var structured map[string]any
+		if !isError && opts.OutputSchema != nil {
+			var structureErr error
+			if structured, structureErr = opts.OutputSchema.structure(result.Stdout); structureErr != nil {
+				debug("Output failed the output schema: %s", structureErr)
+				result.addNote(fmt.Sprintf("Studio error: %s", structureErr))
+				isError = true
+				if opts.ErrorSummary {
+					summary = fmt.Sprintf("Studio error: %s", structureErr)
+				}
+			}
+		}
+
+		// Commands that write their result to disk get it back as content
+		var fileContent mcp.Content
+		if !isError && output != nil {
+			var fileErr error
+			if fileContent, fileErr = output.read(opts.RunAs, opts.Redact); fileErr != nil {
+				debug("Output file not returned: %s", fileErr)
+				result.addNote(fmt.Sprintf("Studio error: %s", fileErr))
+				isError = true
+				if opts.ErrorSummary {
+					summary = fmt.Sprintf("Studio error: %s", fileErr)
+				}
+			}
+		}
+
+		toolResult := &mcp.CallToolResultFor[map[string]any]{
+			Content:           createContent(result, opts),
+			StructuredContent: structured,
+			IsError:           isError,
+		}
+		// Of the two line limits, only the one that cuts the output sooner applies
+		summaryLines, maxOutputLines := opts.SummaryLines, opts.MaxOutputLines
+		if summaryLines > 0 && maxOutputLines > 0 {
+			if 2*summaryLines < maxOutputLines {
+				maxOutputLines = 0
+			} else {
+				summaryLines = 0
+			}
+		}
+		pageSize := 0
+		if opts.Pages != nil {
+			pageSize = opts.PageSize
+		}
+		summarizeContent(toolResult.Content, summaryLines, opts.LastOutput)
+		var truncated bool
+		toolResult.Content, truncated = truncateContent(toolResult.Content, maxOutputLines, pageSize, opts.LastOutput)
+		opts.OutputTemplate.formatContent(toolResult.Content, redactCommand(fullCommand), result.ExitCode)
+
+		if opts.EchoCommand {
+			setMeta(toolResult, "command", redactCommand(fullCommand))
+		}
+
+		if len(opts.SuccessCodes) > 0 && result.ExitCode >= 0 {
+			setMeta(toolResult, "exitCode", result.ExitCode)
+		}
+
+		if opts.OutputSize {
+			setMeta(toolResult, "stdoutBytes", stdoutBytes)
+			setMeta(toolResult, "stderrBytes", stderrBytes)
+		}
+
+		if hit {
+			setMeta(toolResult, "cached", true)
+		}
+
+		if opts.ReportDuration {
+			setMeta(toolResult, "duration", formatDuration(duration))
+		}
+
+		if detached != nil {
+			setMeta(toolResult, "pid", detached.PID)
+			setMeta(toolResult, "logFile", detached.LogFile)
+		}
+
+		if pageSize > 0 && !truncated {
+			truncated = pageResult(toolResult, opts.Pages, pageSize)
+		}
+
+		// The byte counts are of the whole output, so say whether the content is all of it
+		if opts.OutputSize {
+			setMeta(toolResult, "truncated", truncated)
+		}
+
+		if fileContent != nil {
+			toolResult.Content = append(toolResult.Content, fileContent)
+		}
+
+		// The summary goes first so it's what the client reads before the full output
+		if summary != "" {
+			toolResult.Content = append([]mcp.Content{&mcp.TextContent{Text: summary}}, toolResult.Content...)
+			setMeta(toolResult, "errorSummary", summary)
+		}
+
+		return toolResult
+	}
+
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[map[string]any], error) {
+		debug("Tool called with args: %v", params.Arguments)
+
+		// Tools without fields are often called with no arguments at all
+		args := params.Arguments
+		if args == nil {
+			args = map[string]any{}
+		}
+		schema, _ := blueprint.GetInputSchema().(*jsonschema.Schema)
+		if unknown := unknownArgs(args, schema); len(unknown) > 0 {
+			if opts.StrictArgs {
+				return createToolResult(opts.Messages.format(messageUnknownArguments, "names", strings.Join(unknown, ", ")), true), nil
+			}
+			debug("Ignoring arguments that are not fields: %s", strings.Join(unknown, ", "))
+		}
+
+		args = applyMetaArgs(args, params.Meta, opts.MetaArgs)
+		if opts.FileLists {
+			if schema != nil {
+				var err error
+				if args, err = expandFileLists(args, schema); err != nil {
+					return createToolResult(opts.Messages.validationError(err), true), nil
+				}
+			}
+		}
+
+		// Fields written name:each run the command once per value
+		key, values, err := blueprint.EachValues(args)
+		if err != nil {
+			return createToolResult(opts.Messages.validationError(err), true), nil
+		}
+		if key != "" {
+			return runEach(ctx, key, values, args, opts.eachConcurrency(), func(ctx context.Context, args map[string]interface{}) *mcp.CallToolResultFor[map[string]any] {
+				return run(ctx, params, args)
+			}), nil
+		}
+
+		return run(ctx, params, args), nil
+	}
+}
+
+// unknownArgs returns the sorted names of args that aren't properties of
+// schema. They are ignored unless StrictArgs is set, whether or not the tool
+// takes any arguments, since clients sometimes send extra ones.
+func unknownArgs(args map[string]any, schema *jsonschema.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	var unknown []string
+	for name := range args {
+		if _, ok := schema.Properties[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// formatDuration rounds d for reading, to the millisecond under a second and
+// to hundredths of a second above, like 42ms or 1.23s
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
+
+// buildCommand builds the command to execute, wrapping it in sh -c when the
+// tool runs through a shell. Pipelines also return the command of each stage,
+// and their command has a | between the stages. Every command, and every
+// stage of a pipeline, starts with the ExecPrefix.
+func buildCommand(blueprint Blueprint, args map[string]interface{}, opts Options) ([]string, [][]string, error) {
+	if opts.Shell {
+		script, err := blueprint.BuildShellCommand(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		return opts.prefixed([]string{"sh", "-c", script}), nil, nil
+	}
+
+	pipeline, ok := blueprint.(Pipeline)
+	if !ok {
+		command, err := blueprint.BuildCommandArgs(args)
+		if err != nil {
+			return nil, nil, err
 		}
+		return opts.prefixed(command), nil, nil
+	}
+	stages, err := pipeline.BuildPipeline(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, stage := range stages {
+		stages[i] = opts.prefixed(stage)
+	}
+	if len(stages) == 1 {
+		return stages[0], nil, nil
+	}
+	command := slices.Clone(stages[0])
+	for _, stage := range stages[1:] {
+		command = append(append(command, "|"), stage...)
+	}
+	return command, stages, nil
+}
 
-		return createToolResult(output, isError), nil
+// prefixed returns command with the ExecPrefix in front
+func (o Options) prefixed(command []string) []string {
+	if len(o.ExecPrefix) == 0 {
+		return command
 	}
+	return append(slices.Clone(o.ExecPrefix), command...)
 }
 
 // GenerateToolName generates a tool name from a base command by replacing dashes with underscores
@@ -123,14 +758,39 @@ func GenerateToolName(baseCommand string) string {
 	return strings.ReplaceAll(baseCommand, "-", "_")
 }
 
+// ToolName returns the name of the tool created from blueprint with these options
+func (o Options) ToolName(blueprint Blueprint) string {
+	return o.NamePrefix + GenerateToolName(blueprint.GetBaseCommand())
+}
+
+// toolNamePattern matches the tool names MCP clients accept
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ValidToolName reports whether MCP clients accept name as a tool name
+func ValidToolName(name string) bool {
+	return toolNamePattern.MatchString(name)
+}
+
 // CreateServerTool creates a complete MCP server tool from a blueprint
 func CreateServerTool(blueprint Blueprint) *mcp.ServerTool {
+	return CreateServerToolWithOptions(blueprint, Options{})
+}
+
+// CreateServerToolWithOptions creates a complete MCP server tool from a blueprint with options
+func CreateServerToolWithOptions(blueprint Blueprint, opts Options) *mcp.ServerTool {
 	schema, ok := blueprint.GetInputSchema().(*jsonschema.Schema)
 	if !ok {
 		// This should never happen if the Blueprint interface is implemented correctly
 		panic("blueprint.GetInputSchema() must return *jsonschema.Schema")
 	}
 
+	if opts.FileLists {
+		schema = withFileLists(schema)
+	}
+	if opts.StrictArgs {
+		schema = withoutAdditionalProperties(schema)
+	}
+
 	// Debug logging
 	debug("CreateServerTool called")
 	debug("  Schema type: %s", schema.Type)
@@ -143,14 +803,32 @@ func CreateServerTool(blueprint Blueprint) *mcp.ServerTool {
 		debug("    required: %s", req)
 	}
 
+	if opts.OutputSchema != nil {
+		return withStructuredContent(&mcp.Tool{
+			Name:         opts.ToolName(blueprint),
+			Description:  GetToolDescription(blueprint),
+			InputSchema:  schema,
+			OutputSchema: opts.OutputSchema.schema,
+		}, CreateToolFunctionWithOptions(blueprint, opts))
+	}
+
 	return mcp.NewServerTool(
-		GenerateToolName(blueprint.GetBaseCommand()),
+		opts.ToolName(blueprint),
 		GetToolDescription(blueprint),
-		CreateToolFunction(blueprint),
+		CreateToolFunctionWithOptions(blueprint, opts),
 		mcp.Input(mcp.Schema(schema)),
 	)
 }
 
+// withoutAdditionalProperties returns a copy of schema that tells clients
+// arguments other than its properties are rejected. The SDK writes the false
+// schema as {"not": {}}, which validators treat the same as false.
+func withoutAdditionalProperties(schema *jsonschema.Schema) *jsonschema.Schema {
+	strict := *schema
+	strict.AdditionalProperties = &jsonschema.Schema{Not: &jsonschema.Schema{}}
+	return &strict
+}
+
 func createToolResult(output string, isError bool) *mcp.CallToolResultFor[map[string]any] {
 	return &mcp.CallToolResultFor[map[string]any]{
 		Content: []mcp.Content{
@@ -160,6 +838,14 @@ func createToolResult(output string, isError bool) *mcp.CallToolResultFor[map[st
 	}
 }
 
+// setMeta sets key of the _meta of result to value, creating _meta if needed
+func setMeta(result *mcp.CallToolResultFor[map[string]any], key string, value any) {
+	if result.Meta == nil {
+		result.Meta = mcp.Meta{}
+	}
+	result.Meta[key] = value
+}
+
 // GetToolDescription generates the tool description from a blueprint
 func GetToolDescription(blueprint Blueprint) string {
 	return "Run the shell command `" + blueprint.GetCommandFormat() + "`"
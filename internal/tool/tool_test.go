@@ -2,14 +2,19 @@ package tool
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-mcp/studio/internal/blueprint"
 )
 
 func TestTool_Execute(t *testing.T) {
@@ -175,6 +180,13 @@ func TestTool_CreateToolFunction(t *testing.T) {
 			expectContains: "error message",
 			expectIsError:  true,
 		},
+		{
+			name:          "keeps the output written before a failing exit",
+			blueprint:     &MockBlueprint{commandArgs: []string{"sh", "-c", "echo partial; echo 'went wrong' >&2; exit 2"}},
+			args:          map[string]any{},
+			expectText:    "partial\n\nwent wrong",
+			expectIsError: true,
+		},
 		{
 			name:           "handles blueprint validation errors",
 			blueprint:      &MockBlueprintWithError{err: fmt.Errorf("missing required parameter: name")},
@@ -222,6 +234,625 @@ func TestTool_CreateToolFunction(t *testing.T) {
 	}
 }
 
+func TestTool_CreateToolFunctionWithShell(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		params     map[string]any
+		expectText string
+	}{
+		{
+			name:       "runs pipes through the shell",
+			args:       []string{"printf 'b\\na\\n'", "|", "sort"},
+			params:     map[string]any{},
+			expectText: "a\nb",
+		},
+		{
+			name:       "passes values with shell syntax as literal words",
+			args:       []string{"echo", "{{text}}"},
+			params:     map[string]any{"text": "$(echo pwned); `id` | cat > /dev/null"},
+			expectText: "$(echo pwned); `id` | cat > /dev/null",
+		},
+		{
+			name:       "passes values with single quotes intact",
+			args:       []string{"echo", "{{text}}"},
+			params:     map[string]any{"text": "it's"},
+			expectText: "it's",
+		},
+		{
+			name:       "quotes each array item",
+			args:       []string{"printf '%s\\n'", "[items...]"},
+			params:     map[string]any{"items": []any{"a b", "c;d"}},
+			expectText: "a b\nc;d",
+		},
+		{
+			name:       "passes values inside double quotes as literal text",
+			args:       []string{`echo "got: {{text}}"`},
+			params:     map[string]any{"text": "\"; echo pwned; \" $(echo pwned)"},
+			expectText: "got: \"; echo pwned; \" $(echo pwned)",
+		},
+		{
+			name:       "passes values inside single quotes as literal text",
+			args:       []string{`echo 'got: {{text}}'`},
+			params:     map[string]any{"text": "'; echo pwned; '"},
+			expectText: "got: '; echo pwned; '",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := blueprint.FromArgs(tt.args)
+			require.NoError(t, err)
+
+			handler := CreateToolFunctionWithOptions(bp, Options{Shell: true})
+			result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: tt.params})
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(*mcp.TextContent)
+			require.True(t, ok, "Expected content to be TextContent")
+			assert.Equal(t, tt.expectText, textContent.Text)
+			assert.False(t, result.IsError)
+		})
+	}
+}
+
+func TestTool_CreateToolFunctionWithShellRejectsNUL(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "{{text}}"})
+	require.NoError(t, err)
+
+	handler := CreateToolFunctionWithOptions(bp, Options{Shell: true})
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: map[string]any{"text": "a\x00b"}})
+	require.NoError(t, err)
+
+	textContent, ok := result.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "Expected content to be TextContent")
+	assert.Equal(t, "Validation error: values can't contain NUL bytes in shell mode", textContent.Text)
+	assert.True(t, result.IsError)
+}
+
+func TestTool_CreateToolFunctionQuiet(t *testing.T) {
+	t.Run("leaves stderr out of successful results", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", "echo progress >&2; echo done"}}, Options{Quiet: true})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "done", textContent.Text)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("keeps stderr in failed results", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", "echo partial; echo broken >&2; exit 2"}}, Options{Quiet: true})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "partial\n\nbroken", textContent.Text)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestTool_CreateToolFunctionMergeOutput(t *testing.T) {
+	script := "echo 1; echo 2 >&2; echo 3; echo 4 >&2"
+
+	t.Run("keeps stdout and stderr in the order they were written", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{MergeOutput: true})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n2\n3\n4", textContent.Text)
+	})
+
+	t.Run("separates the streams by default", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n3\n\n2\n4", textContent.Text)
+	})
+
+	t.Run("merges with an inactivity timeout", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{MergeOutput: true, InactivityTimeout: time.Minute})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n2\n3\n4", textContent.Text)
+	})
+}
+
+func TestTool_CreateToolFunctionEnv(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"sh", "-c", `echo "token=$STUDIO_TEST_TOKEN args=$#"`, "{{name}}"})
+	require.NoError(t, err)
+	require.NoError(t, bp.AddEnv("STUDIO_TEST_TOKEN={{token}}"))
+	handler := CreateToolFunctionWithOptions(bp, Options{EchoCommand: true})
+
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+		Arguments: map[string]any{"name": "sh", "token": "s3cret"},
+	})
+	require.NoError(t, err)
+
+	textContent, ok := result.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "Expected content to be TextContent")
+	assert.Equal(t, "token=s3cret args=0", textContent.Text)
+	assert.NotContains(t, result.Meta["command"], "s3cret")
+}
+
+func TestTool_ExecPrefix(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		opts            Options
+		expectedContent string
+		expectedCommand []string
+	}{
+		{
+			name:            "runs the command through the prefix",
+			args:            []string{"rm", "-rf", "{{path}}"},
+			opts:            Options{ExecPrefix: []string{"echo", "would run:"}},
+			expectedContent: "would run: rm -rf build",
+			expectedCommand: []string{"echo", "would run:", "rm", "-rf", "build"},
+		},
+		{
+			name:            "goes in front of the shell",
+			args:            []string{"rm", "-rf", "{{path}}"},
+			opts:            Options{ExecPrefix: []string{"echo"}, Shell: true},
+			expectedContent: "sh -c rm -rf build",
+			expectedCommand: []string{"echo", "sh", "-c", "rm -rf build"},
+		},
+		{
+			name:            "goes in front of every command of a pipeline",
+			args:            []string{"printf", "{{path}}", "|", "wc", "-c"},
+			opts:            Options{ExecPrefix: []string{"env", "LC_ALL=C"}},
+			expectedContent: "5",
+			expectedCommand: []string{"env", "LC_ALL=C", "printf", "build", "|", "env", "LC_ALL=C", "wc", "-c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := blueprint.FromArgs(tt.args)
+			require.NoError(t, err)
+
+			tt.opts.EchoCommand = true
+			result, err := CreateToolFunctionWithOptions(bp, tt.opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+				Arguments: map[string]any{"path": "build"},
+			})
+			require.NoError(t, err)
+
+			assert.False(t, result.IsError)
+			assert.Equal(t, tt.expectedContent, strings.TrimSpace(result.Content[0].(*mcp.TextContent).Text))
+			assert.Equal(t, tt.expectedCommand, result.Meta["command"])
+		})
+	}
+
+	t.Run("the command keeps its environment", func(t *testing.T) {
+		bp, err := blueprint.FromArgs([]string{"sh", "-c", `echo "$STUDIO_TEST_TOKEN $STUDIO_TEST_WRAPPER"`})
+		require.NoError(t, err)
+		require.NoError(t, bp.AddEnv("STUDIO_TEST_TOKEN={{token}}"))
+
+		handler := CreateToolFunctionWithOptions(bp, Options{ExecPrefix: []string{"env", "STUDIO_TEST_WRAPPER=nice"}})
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"token": "s3cret"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret nice", result.Content[0].(*mcp.TextContent).Text)
+	})
+}
+
+func TestTool_CreateToolFunctionClearEnv(t *testing.T) {
+	t.Setenv("STUDIO_TEST_SECRET", "hunter2")
+	t.Setenv("STUDIO_TEST_KEPT", "kept")
+
+	bp, err := blueprint.FromArgs([]string{"env"})
+	require.NoError(t, err)
+	require.NoError(t, bp.AddEnv("STUDIO_TEST_TOKEN={{token}}"))
+
+	run := func(t *testing.T, opts Options) string {
+		result, err := CreateToolFunctionWithOptions(bp, opts)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+			Arguments: map[string]any{"token": "s3cret"},
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		return textContent.Text
+	}
+
+	t.Run("inherits studio's environment by default", func(t *testing.T) {
+		output := run(t, Options{})
+		assert.Contains(t, output, "STUDIO_TEST_SECRET=hunter2")
+		assert.Contains(t, output, "STUDIO_TEST_KEPT=kept")
+		assert.Contains(t, output, "STUDIO_TEST_TOKEN=s3cret")
+	})
+
+	t.Run("starts clean with only the variables that are set", func(t *testing.T) {
+		assert.Equal(t, "STUDIO_TEST_TOKEN=s3cret", run(t, Options{ClearEnv: true}))
+	})
+
+	t.Run("keeps the passthrough variables", func(t *testing.T) {
+		output := run(t, Options{ClearEnv: true, EnvPassthrough: []string{"STUDIO_TEST_KEPT", "STUDIO_TEST_UNSET"}})
+		assert.Equal(t, "STUDIO_TEST_KEPT=kept\nSTUDIO_TEST_TOKEN=s3cret", output)
+		assert.NotContains(t, output, "hunter2")
+	})
+}
+
+func TestTool_CreateToolFunctionMaxArrayItems(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "[args...]"})
+	require.NoError(t, err)
+	bp.MaxArrayItems = 100
+
+	args := make([]any, 5000)
+	for i := range args {
+		args[i] = "x"
+	}
+
+	result, err := CreateToolFunction(bp)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+		Arguments: map[string]any{"args": args},
+	})
+	require.NoError(t, err)
+
+	textContent, ok := result.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "Expected content to be TextContent")
+	assert.Equal(t, "Validation error: parameter 'args' has 5000 values, more than the limit of 100", textContent.Text)
+	assert.True(t, result.IsError)
+}
+
+func TestTool_CreateToolFunctionItemCount(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "{{paths...(2-3)}}"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		paths         []any
+		expectedText  string
+		expectedError bool
+	}{
+		{name: "below the minimum", paths: []any{"a"}, expectedText: "Validation error: parameter 'paths' needs at least 2 value(s)", expectedError: true},
+		{name: "in range", paths: []any{"a", "b"}, expectedText: "a b"},
+		{name: "above the maximum", paths: []any{"a", "b", "c", "d"}, expectedText: "Validation error: parameter 'paths' takes at most 3 value(s), got 4", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CreateToolFunction(bp)(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{
+				Arguments: map[string]any{"paths": tt.paths},
+			})
+			require.NoError(t, err)
+
+			textContent, ok := result.Content[0].(*mcp.TextContent)
+			require.True(t, ok, "Expected content to be TextContent")
+			assert.Equal(t, tt.expectedText, textContent.Text)
+			assert.Equal(t, tt.expectedError, result.IsError)
+		})
+	}
+}
+
+func TestTool_CreateToolFunctionEchoCommand(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "{{text}}", "[args...]"})
+	require.NoError(t, err)
+	params := &mcp.CallToolParamsFor[map[string]any]{
+		Arguments: map[string]any{"text": "hello world", "args": []any{"a", "b"}},
+	}
+
+	t.Run("includes the executed argv in the result metadata", func(t *testing.T) {
+		result, err := CreateToolFunctionWithOptions(bp, Options{EchoCommand: true})(context.Background(), nil, params)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"echo", "hello world", "a", "b"}, result.Meta["command"])
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "hello world a b", textContent.Text)
+	})
+
+	t.Run("includes the shell wrapper in shell mode", func(t *testing.T) {
+		result, err := CreateToolFunctionWithOptions(bp, Options{EchoCommand: true, Shell: true})(context.Background(), nil, params)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"sh", "-c", "echo 'hello world' a b"}, result.Meta["command"])
+	})
+
+	t.Run("leaves metadata out by default", func(t *testing.T) {
+		result, err := CreateToolFunction(bp)(context.Background(), nil, params)
+		require.NoError(t, err)
+
+		assert.Nil(t, result.Meta)
+	})
+}
+
+func TestTool_CreateToolFunctionReportDuration(t *testing.T) {
+	t.Run("adds how long the command ran to the result metadata", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sleep", "0.2"}}, Options{ReportDuration: true})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		duration, ok := result.Meta["duration"].(string)
+		require.True(t, ok, "Expected duration to be a string")
+		parsed, err := time.ParseDuration(duration)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, parsed, 200*time.Millisecond)
+	})
+
+	t.Run("leaves the metadata out by default", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"true"}}, Options{})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Nil(t, result.Meta)
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{42*time.Millisecond + 300*time.Microsecond, "42ms"},
+		{1234 * time.Millisecond, "1.23s"},
+		{83*time.Second + 456*time.Millisecond, "1m23.46s"},
+		{0, "0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatDuration(tt.duration))
+		})
+	}
+}
+
+func TestTool_CreateToolFunctionOutputSize(t *testing.T) {
+	script := `printf 'héllo\n'; printf 'warn' >&2`
+
+	t.Run("adds the bytes written to the result metadata", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{OutputSize: true})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 7, result.Meta["stdoutBytes"])
+		assert.Equal(t, 4, result.Meta["stderrBytes"])
+		assert.Equal(t, false, result.Meta["truncated"])
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "héllo\n\nwarn", textContent.Text)
+	})
+
+	t.Run("says when the content was cut to fewer lines", func(t *testing.T) {
+		blueprint := &MockBlueprint{commandArgs: []string{"sh", "-c", "seq 10"}}
+		handler := CreateToolFunctionWithOptions(blueprint, Options{OutputSize: true, MaxOutputLines: 3})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 21, result.Meta["stdoutBytes"])
+		assert.Equal(t, true, result.Meta["truncated"])
+	})
+
+	t.Run("says when the content was cut to a page", func(t *testing.T) {
+		blueprint := &MockBlueprint{commandArgs: []string{"sh", "-c", "seq 10"}}
+		handler := CreateToolFunctionWithOptions(blueprint, Options{OutputSize: true, PageSize: 8, Pages: NewPagedOutputs(10)})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Equal(t, true, result.Meta["truncated"])
+	})
+
+	t.Run("doesn't count content shorter than the limits as cut", func(t *testing.T) {
+		blueprint := &MockBlueprint{commandArgs: []string{"sh", "-c", "seq 3"}}
+		handler := CreateToolFunctionWithOptions(blueprint, Options{OutputSize: true, MaxOutputLines: 3, PageSize: 100, Pages: NewPagedOutputs(10)})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Equal(t, false, result.Meta["truncated"])
+	})
+
+	t.Run("counts stderr that quiet leaves out", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{OutputSize: true, Quiet: true})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, result.Meta["stderrBytes"])
+	})
+
+	t.Run("leaves the metadata out by default", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Nil(t, result.Meta)
+	})
+}
+
+func TestTool_CreateToolFunctionShutdown(t *testing.T) {
+	shutdown, stop := context.WithCancel(context.Background())
+	handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sleep", "30"}}, Options{Shutdown: shutdown})
+
+	time.AfterFunc(100*time.Millisecond, stop)
+
+	start := time.Now()
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), 5*time.Second, "command should stop on shutdown")
+	assert.True(t, result.IsError)
+
+	t.Run("gives the command its grace period", func(t *testing.T) {
+		shutdown, stop := context.WithCancel(context.Background())
+		script := `trap 'echo cleaned up; exit 0' TERM; while :; do sleep 0.05; done`
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{Shutdown: shutdown, KillGrace: 5 * time.Second})
+
+		time.AfterFunc(100*time.Millisecond, stop)
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "cleaned up")
+	})
+}
+
+func TestTool_CreateToolFunctionSuccessCodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		exitCode      int
+		successCodes  []int
+		expectedError bool
+		expectedMeta  mcp.Meta
+	}{
+		{name: "zero is success by default", exitCode: 0, expectedError: false},
+		{name: "non-zero is an error by default", exitCode: 1, expectedError: true},
+		{name: "listed non-zero code is success", exitCode: 1, successCodes: []int{0, 1}, expectedError: false, expectedMeta: mcp.Meta{"exitCode": 1}},
+		{name: "unlisted code is an error", exitCode: 2, successCodes: []int{0, 1}, expectedError: true, expectedMeta: mcp.Meta{"exitCode": 2}},
+		{name: "zero is an error when not listed", exitCode: 0, successCodes: []int{1}, expectedError: true, expectedMeta: mcp.Meta{"exitCode": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := fmt.Sprintf("echo output; exit %d", tt.exitCode)
+			handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{SuccessCodes: tt.successCodes})
+
+			result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedError, result.IsError)
+			assert.Equal(t, tt.expectedMeta, result.Meta)
+
+			textContent, ok := result.Content[0].(*mcp.TextContent)
+			require.True(t, ok, "Expected content to be TextContent")
+			assert.Equal(t, "output", textContent.Text)
+		})
+	}
+}
+
+func TestTool_CreateToolFunctionOutputMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		script         string
+		errorIfMatch   string
+		successIfMatch string
+		expectedError  bool
+	}{
+		{name: "error in stderr on a zero exit", script: "echo 'Error: bad token' >&2", errorIfMatch: `(?i)^error:`, expectedError: true},
+		{name: "error in stdout on a zero exit", script: "echo FAILED", errorIfMatch: `FAILED`, expectedError: true},
+		{name: "no error match keeps success", script: "echo ok", errorIfMatch: `FAILED`, expectedError: false},
+		{name: "no error match keeps failure", script: "echo ok; exit 1", errorIfMatch: `FAILED`, expectedError: true},
+		{name: "success match overrides a failing exit", script: "echo 'already up to date'; exit 1", successIfMatch: `up to date`, expectedError: false},
+		{name: "no success match keeps failure", script: "echo broken; exit 1", successIfMatch: `up to date`, expectedError: true},
+		{name: "error match wins over success match", script: "echo 'up to date'; echo FAILED >&2", errorIfMatch: `FAILED`, successIfMatch: `up to date`, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{}
+			if tt.errorIfMatch != "" {
+				opts.ErrorIfMatch = regexp.MustCompile(tt.errorIfMatch)
+			}
+			if tt.successIfMatch != "" {
+				opts.SuccessIfMatch = regexp.MustCompile(tt.successIfMatch)
+			}
+			handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", tt.script}}, opts)
+
+			result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedError, result.IsError)
+		})
+	}
+
+	t.Run("doesn't turn a stopped command into a success", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", "echo started; sleep 30"}}, Options{
+			SuccessIfMatch:    regexp.MustCompile(`started`),
+			InactivityTimeout: 200 * time.Millisecond,
+		})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestTool_CreateToolFunctionInactivityTimeout(t *testing.T) {
+	t.Run("stops commands that go quiet", func(t *testing.T) {
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", "echo started; sleep 30"}}, Options{InactivityTimeout: 200 * time.Millisecond})
+
+		start := time.Now()
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.Less(t, time.Since(start), 5*time.Second, "command should stop when quiet")
+		assert.True(t, result.IsError)
+
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "started\n\nStudio error: command stopped: no output for 200ms", textContent.Text)
+	})
+
+	t.Run("keeps commands running while they write output", func(t *testing.T) {
+		script := "for i in 1 2 3 4 5; do echo $i; sleep 0.1; done"
+		handler := CreateToolFunctionWithOptions(&MockBlueprint{commandArgs: []string{"sh", "-c", script}}, Options{InactivityTimeout: 300 * time.Millisecond})
+
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{})
+		require.NoError(t, err)
+
+		assert.False(t, result.IsError)
+		textContent, ok := result.Content[0].(*mcp.TextContent)
+		require.True(t, ok, "Expected content to be TextContent")
+		assert.Equal(t, "1\n2\n3\n4\n5", textContent.Text)
+	})
+}
+
+func TestTool_GetToolDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "fields",
+			args:     []string{"git", "log", "{{ref}}", "[paths...]"},
+			expected: "Run the shell command `git log {{ref}} [paths...]`",
+		},
+		{
+			name:     "defaults",
+			args:     []string{"git", "log", "{{ref=HEAD # where to start}}", "[limit=10]", "[paths...=.]"},
+			expected: "Run the shell command `git log {{ref=HEAD}} [limit=10] [paths...=.]`",
+		},
+		{
+			name:     "environment defaults",
+			args:     []string{"aws", "s3", "ls", "[profile=$AWS_PROFILE]"},
+			expected: "Run the shell command `aws s3 ls [profile]`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := blueprint.FromArgs(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, GetToolDescription(bp))
+		})
+	}
+}
+
 func TestTool_GenerateToolName(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -258,6 +889,22 @@ func TestTool_GenerateToolName(t *testing.T) {
 	}
 }
 
+func TestTool_ToolName(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"git-lfs", "[args...]"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "git_lfs", Options{}.ToolName(bp))
+	assert.Equal(t, "repo1_git_lfs", Options{NamePrefix: "repo1_"}.ToolName(bp))
+	assert.Equal(t, "repo1_git_lfs", CreateServerToolWithOptions(bp, Options{NamePrefix: "repo1_"}).Tool.Name)
+}
+
+func TestTool_ValidToolName(t *testing.T) {
+	assert.True(t, ValidToolName("repo1_git-lfs"))
+	assert.False(t, ValidToolName(""))
+	assert.False(t, ValidToolName("/usr/bin/git"))
+	assert.False(t, ValidToolName(strings.Repeat("a", 65)))
+}
+
 // MockBlueprint is a test helper that implements the Blueprint interface
 type MockBlueprint struct {
 	commandArgs []string
@@ -267,6 +914,18 @@ func (m *MockBlueprint) BuildCommandArgs(args map[string]interface{}) ([]string,
 	return m.commandArgs, nil
 }
 
+func (m *MockBlueprint) BuildShellCommand(args map[string]interface{}) (string, error) {
+	return strings.Join(m.commandArgs, " "), nil
+}
+
+func (m *MockBlueprint) BuildEnv(args map[string]interface{}) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockBlueprint) EachValues(args map[string]interface{}) (string, []interface{}, error) {
+	return "", nil, nil
+}
+
 func (m *MockBlueprint) GetBaseCommand() string {
 	return "mock-tool"
 }
@@ -291,6 +950,18 @@ func (m *MockBlueprintWithError) BuildCommandArgs(args map[string]interface{}) (
 	return nil, m.err
 }
 
+func (m *MockBlueprintWithError) BuildShellCommand(args map[string]interface{}) (string, error) {
+	return "", m.err
+}
+
+func (m *MockBlueprintWithError) BuildEnv(args map[string]interface{}) ([]string, error) {
+	return nil, m.err
+}
+
+func (m *MockBlueprintWithError) EachValues(args map[string]interface{}) (string, []interface{}, error) {
+	return "", nil, nil
+}
+
 func (m *MockBlueprintWithError) GetBaseCommand() string {
 	return "mock-error-tool"
 }
@@ -305,3 +976,73 @@ func (m *MockBlueprintWithError) GetInputSchema() interface{} {
 		Properties: make(map[string]*jsonschema.Schema),
 	}
 }
+
+func TestUnknownArgs(t *testing.T) {
+	schema := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"text": {Type: "string"}}}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		schema   *jsonschema.Schema
+		expected []string
+	}{
+		{name: "known", args: map[string]any{"text": "hi"}, schema: schema},
+		{name: "no arguments", args: map[string]any{}, schema: schema},
+		{name: "stray", args: map[string]any{"text": "hi", "verbose": true, "color": "red"}, schema: schema, expected: []string{"color", "verbose"}},
+		{name: "tool without fields", args: map[string]any{"stray": 1}, schema: &jsonschema.Schema{Type: "object"}, expected: []string{"stray"}},
+		{name: "no schema", args: map[string]any{"stray": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unknownArgs(tt.args, tt.schema))
+		})
+	}
+}
+
+func TestTool_StrictArgs(t *testing.T) {
+	bp, err := blueprint.FromArgs([]string{"echo", "{{text}}"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		strict          bool
+		args            map[string]any
+		expectedError   bool
+		expectedContent string
+	}{
+		{name: "lenient ignores unknown arguments", args: map[string]any{"text": "hi", "verbose": true}, expectedContent: "hi"},
+		{name: "strict without unknown arguments", strict: true, args: map[string]any{"text": "hi"}, expectedContent: "hi"},
+		{
+			name:            "strict names unknown arguments",
+			strict:          true,
+			args:            map[string]any{"text": "hi", "verbose": true, "color": "red"},
+			expectedError:   true,
+			expectedContent: "Validation error: unknown arguments: color, verbose",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CreateToolFunctionWithOptions(bp, Options{StrictArgs: tt.strict})
+			result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[map[string]any]{Arguments: tt.args})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedError, result.IsError)
+			assert.Equal(t, tt.expectedContent, result.Content[0].(*mcp.TextContent).Text)
+		})
+	}
+
+	t.Run("schema disallows additional properties", func(t *testing.T) {
+		assert.Nil(t, CreateServerToolWithOptions(bp, Options{}).Tool.InputSchema.AdditionalProperties)
+
+		schema := CreateServerToolWithOptions(bp, Options{StrictArgs: true}).Tool.InputSchema
+		require.NotNil(t, schema.AdditionalProperties)
+		assert.Equal(t, &jsonschema.Schema{Not: &jsonschema.Schema{}}, schema.AdditionalProperties)
+		assert.Nil(t, bp.GetInputSchema().(*jsonschema.Schema).AdditionalProperties)
+
+		data, err := json.Marshal(schema)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"additionalProperties":{"not":{}}`)
+	})
+}